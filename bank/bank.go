@@ -0,0 +1,112 @@
+// Package bank sends wallet withdrawals to bank accounts. Each payout
+// provider is an adapter behind PayoutProvider; a withdrawal keeps its funds
+// on hold until the provider reports the transfer settled or failed.
+package bank
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Statuses of a transfer at the provider. Settled and failed are final.
+const (
+	StatusPending = "pending"
+	StatusSettled = "settled"
+	StatusFailed  = "failed"
+)
+
+// Destination is the bank account a transfer pays into, identified either by
+// an IFSC and account number or by an IBAN.
+type Destination struct {
+	HolderName    string
+	AccountNumber string
+	IFSC          string
+	IBAN          string
+}
+
+// TransferRequest asks the provider to pay Amount into Destination.
+// Reference identifies the withdrawal on our side and is echoed back in
+// every Transfer.
+type TransferRequest struct {
+	Reference   string
+	Amount      float64
+	Destination Destination
+}
+
+// Transfer is what the provider reports about a transfer.
+type Transfer struct {
+	ID            string
+	Reference     string
+	Amount        float64
+	Status        string
+	FailureReason string
+}
+
+// PayoutProvider is implemented by every payout provider adapter.
+type PayoutProvider interface {
+	// Name identifies the provider on stored withdrawals.
+	Name() string
+	// Submit asks for a transfer. Submitting a Reference again returns the
+	// transfer made the first time instead of paying twice. It fails with
+	// errors.ErrTransferRejected when the provider turned the transfer down
+	// for good; after any other error it may or may not have been made.
+	Submit(context.Context, TransferRequest) (Transfer, error)
+	QueryStatus(ctx context.Context, transferID string) (Transfer, error)
+	// FindTransfer returns the transfer made for reference, or
+	// errors.ErrTransferNotFound when the provider never took one.
+	FindTransfer(ctx context.Context, reference string) (Transfer, error)
+}
+
+// New returns the payout provider named by kind. Only "fake" exists so far.
+func New(kind string) (PayoutProvider, error) {
+	switch kind {
+	case "fake":
+		return NewFakeProvider(), nil
+	}
+	return nil, fmt.Errorf("unknown payout provider %q", kind)
+}
+
+var (
+	ifscPattern          = regexp.MustCompile(`^[A-Z]{4}0[A-Z0-9]{6}$`)
+	accountNumberPattern = regexp.MustCompile(`^[0-9]{9,18}$`)
+	ibanPattern          = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+)
+
+// NormalizeIFSC uppercases an Indian Financial System Code and reports
+// whether it has the four letter bank code, the zero and the six character
+// branch code.
+func NormalizeIFSC(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return code, ifscPattern.MatchString(code)
+}
+
+// NormalizeAccountNumber strips spaces from an Indian bank account number
+// and reports whether it has 9 to 18 digits.
+func NormalizeAccountNumber(number string) (string, bool) {
+	number = strings.ReplaceAll(strings.TrimSpace(number), " ", "")
+	return number, accountNumberPattern.MatchString(number)
+}
+
+// NormalizeIBAN strips spaces from an IBAN, uppercases it and reports
+// whether its check digits are right.
+func NormalizeIBAN(iban string) (string, bool) {
+	iban = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(iban), " ", ""))
+	if !ibanPattern.MatchString(iban) {
+		return iban, false
+	}
+	// Move the country code and check digits to the end, turn letters into
+	// 10 to 35 and check the number modulo 97, as ISO 13616 describes
+	var digits strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(fmt.Sprint(c - 'A' + 10))
+		} else {
+			digits.WriteRune(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	return iban, new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
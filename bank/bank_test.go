@@ -0,0 +1,79 @@
+package bank
+
+import (
+	"context"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeIFSC(t *testing.T) {
+	code, ok := NormalizeIFSC(" sbin0001234 ")
+	require.True(t, ok)
+	require.Equal(t, "SBIN0001234", code)
+
+	for _, code := range []string{"SBIN1001234", "SBI0001234", "SBIN000123", "SBIN00012345", "1BIN0001234"} {
+		_, ok = NormalizeIFSC(code)
+		require.False(t, ok, code)
+	}
+}
+
+func TestNormalizeAccountNumber(t *testing.T) {
+	number, ok := NormalizeAccountNumber("1234 5678 9012")
+	require.True(t, ok)
+	require.Equal(t, "123456789012", number)
+
+	for _, number := range []string{"12345678", "1234567890123456789", "12345678A"} {
+		_, ok = NormalizeAccountNumber(number)
+		require.False(t, ok, number)
+	}
+}
+
+func TestNormalizeIBAN(t *testing.T) {
+	iban, ok := NormalizeIBAN("gb82 west 1234 5698 7654 32")
+	require.True(t, ok)
+	require.Equal(t, "GB82WEST12345698765432", iban)
+	_, ok = NormalizeIBAN("DE89370400440532013000")
+	require.True(t, ok)
+
+	for _, iban := range []string{"GB82WEST12345698765433", "GB00WEST12345698765432", "GB82WEST", "8282WEST12345698765432"} {
+		_, ok = NormalizeIBAN(iban)
+		require.False(t, ok, iban)
+	}
+}
+
+func TestFakeProvider(t *testing.T) {
+	p, err := New("fake")
+	require.NoError(t, err)
+	fake := p.(*FakeProvider)
+
+	transfer, err := fake.Submit(context.Background(), TransferRequest{Reference: "7", Amount: 50, Destination: Destination{AccountNumber: "123456789012", IFSC: "SBIN0001234"}})
+	require.NoError(t, err)
+	require.Equal(t, StatusPending, transfer.Status)
+	got, err := fake.QueryStatus(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, Transfer{ID: transfer.ID, Reference: "7", Amount: 50, Status: StatusSettled}, got)
+
+	// Submitting a reference again does not pay twice
+	again, err := fake.Submit(context.Background(), TransferRequest{Reference: "7", Amount: 50, Destination: Destination{AccountNumber: "123456789012", IFSC: "SBIN0001234"}})
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, again.ID)
+	found, err := fake.FindTransfer(context.Background(), "7")
+	require.NoError(t, err)
+	require.Equal(t, got, found)
+	_, err = fake.FindTransfer(context.Background(), "70")
+	require.Equal(t, errors.ErrTransferNotFound, err)
+
+	transfer, err = fake.Submit(context.Background(), TransferRequest{Reference: "8", Amount: 50, Destination: Destination{IBAN: "DE89370400440532010000"}})
+	require.NoError(t, err)
+	got, err = fake.QueryStatus(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, StatusFailed, got.Status)
+	require.NotEmpty(t, got.FailureReason)
+
+	_, err = fake.QueryStatus(context.Background(), "fake_unknown")
+	require.Equal(t, errors.ErrTransferNotFound, err)
+	_, err = New("")
+	require.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package bank
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"nickPay/wallet/internal/errors"
+	"strings"
+	"sync"
+)
+
+// FakeFailingSuffix ends the account numbers and IBANs the fake provider
+// fails transfers to, so failures and reversals can be tried out.
+const FakeFailingSuffix = "0000"
+
+// FakeProvider is an in-memory payout provider for development and tests.
+// A transfer stays pending until its status is first queried, when it
+// settles, or fails for accounts ending in FakeFailingSuffix.
+type FakeProvider struct {
+	mu         sync.Mutex
+	transfers  map[string]fakeTransfer
+	references map[string]string // transfer IDs by reference
+}
+
+type fakeTransfer struct {
+	Transfer
+	fails bool
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{transfers: map[string]fakeTransfer{}, references: map[string]string{}}
+}
+
+func (f *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakeProvider) Submit(ctx context.Context, request TransferRequest) (Transfer, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return Transfer{}, err
+	}
+	transfer := Transfer{ID: "fake_" + hex.EncodeToString(id), Reference: request.Reference, Amount: request.Amount, Status: StatusPending}
+	destination := request.Destination.AccountNumber + request.Destination.IBAN
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if transferID, ok := f.references[request.Reference]; ok {
+		return f.transfers[transferID].Transfer, nil
+	}
+	f.transfers[transfer.ID] = fakeTransfer{Transfer: transfer, fails: strings.HasSuffix(destination, FakeFailingSuffix)}
+	f.references[request.Reference] = transfer.ID
+	return transfer, nil
+}
+
+func (f *FakeProvider) QueryStatus(ctx context.Context, transferID string) (Transfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	transfer, ok := f.transfers[transferID]
+	if !ok {
+		return Transfer{}, errors.ErrTransferNotFound
+	}
+	if transfer.Status == StatusPending {
+		transfer.Status = StatusSettled
+		if transfer.fails {
+			transfer.Status, transfer.FailureReason = StatusFailed, "account closed"
+		}
+		f.transfers[transferID] = transfer
+	}
+	return transfer.Transfer, nil
+}
+
+func (f *FakeProvider) FindTransfer(ctx context.Context, reference string) (Transfer, error) {
+	f.mu.Lock()
+	transferID, ok := f.references[reference]
+	f.mu.Unlock()
+	if !ok {
+		return Transfer{}, errors.ErrTransferNotFound
+	}
+	return f.QueryStatus(ctx, transferID)
+}
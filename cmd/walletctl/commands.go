@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+)
+
+func runUser(ctx context.Context, NikPay service.WalletService, args []string) error {
+	flags := flag.NewFlagSet("user", flag.ExitOnError)
+	email := flags.String("email", "", "look the user up by email")
+	phone := flags.String("phone", "", "look the user up by phone number")
+	flags.Parse(args)
+
+	var profile domain.UserProfile
+	var err error
+	switch {
+	case *email != "" && *phone == "" && flags.NArg() == 0:
+		profile, err = NikPay.LookupUser(ctx, *email)
+	case *phone != "" && *email == "" && flags.NArg() == 0:
+		profile, err = NikPay.LookupUser(ctx, *phone)
+	case *email == "" && *phone == "" && flags.NArg() == 1:
+		var userID int64
+		userID, err = parseUserID(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		profile, err = NikPay.GetProfile(ctx, userID)
+	default:
+		return errUsage
+	}
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, profile)
+}
+
+func runWallet(ctx context.Context, NikPay service.WalletService, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	userID, err := parseUserID(args[0])
+	if err != nil {
+		return err
+	}
+	wallet, err := NikPay.GetWallet(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if wallet.ID == 0 {
+		return fmt.Errorf("user %d has no wallet", userID)
+	}
+	return printJSON(os.Stdout, wallet)
+}
+
+func runCredit(ctx context.Context, NikPay service.WalletService, args []string) error {
+	return adjust(ctx, NikPay, domain.TransactionCredit, args)
+}
+
+func runDebit(ctx context.Context, NikPay service.WalletService, args []string) error {
+	return adjust(ctx, NikPay, domain.TransactionDebit, args)
+}
+
+func adjust(ctx context.Context, NikPay service.WalletService, txnType string, args []string) error {
+	flags := flag.NewFlagSet(txnType, flag.ExitOnError)
+	reason := flags.String("reason", "", "why the balance is adjusted, required")
+	operator := operatorFlag(flags)
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		return errUsage
+	}
+	userID, err := parseUserID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	amount, err := strconv.ParseFloat(flags.Arg(1), 64)
+	if err != nil {
+		return fmt.Errorf("invalid amount %q", flags.Arg(1))
+	}
+
+	err = NikPay.AdjustWallet(ctx, domain.WalletAdjustment{
+		UserID:   userID,
+		Type:     txnType,
+		Amount:   amount,
+		Reason:   *reason,
+		Operator: *operator,
+	})
+	if err != nil {
+		return err
+	}
+	return showWallet(ctx, NikPay, userID)
+}
+
+func runFreeze(ctx context.Context, NikPay service.WalletService, args []string) error {
+	return changeStatus(ctx, NikPay, "freeze", domain.WalletStatusFrozen, args)
+}
+
+func runUnfreeze(ctx context.Context, NikPay service.WalletService, args []string) error {
+	return changeStatus(ctx, NikPay, "unfreeze", domain.WalletStatusActive, args)
+}
+
+func changeStatus(ctx context.Context, NikPay service.WalletService, name string, status string, args []string) error {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	reason := flags.String("reason", "", "why the wallet is changed, required")
+	operator := operatorFlag(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errUsage
+	}
+	userID, err := parseUserID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	err = NikPay.ChangeWalletStatus(ctx, domain.WalletStatusChange{
+		UserID:   userID,
+		Status:   status,
+		Reason:   *reason,
+		Operator: *operator,
+	})
+	if err != nil {
+		return err
+	}
+	return showWallet(ctx, NikPay, userID)
+}
+
+func runExport(ctx context.Context, NikPay service.WalletService, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", service.ExportFormatJSON, "json for everything, csv for the transactions only")
+	output := flags.String("output", "", "write to this file instead of stdout")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errUsage
+	}
+	userID, err := parseUserID(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	// Check the format before reading a possibly long history
+	if *format != service.ExportFormatJSON && *format != service.ExportFormatCSV {
+		return errors.ErrInvalidExportFormat
+	}
+
+	export, err := NikPay.ExportUserData(ctx, userID)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return service.WriteUserExport(out, export, *format)
+}
+
+// operatorFlag registers -operator, naming who runs the command in the logs.
+// It defaults to the login name.
+func operatorFlag(flags *flag.FlagSet) *string {
+	return flags.String("operator", os.Getenv("USER"), "who is making the change, for the logs")
+}
+
+func showWallet(ctx context.Context, NikPay service.WalletService, userID int64) error {
+	wallet, err := NikPay.GetWallet(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, wallet)
+}
+
+func parseUserID(arg string) (int64, error) {
+	userID, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, fmt.Errorf("invalid user ID %q", arg)
+	}
+	return userID, nil
+}
+
+func printJSON(out io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
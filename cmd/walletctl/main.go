@@ -0,0 +1,97 @@
+// Command walletctl runs operator tasks against wallets through the same
+// service layer as the API, so every change is validated, recorded on the
+// ledger and logged like any other.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"nickPay/wallet/internal/config"
+	"nickPay/wallet/internal/db"
+	"nickPay/wallet/internal/service"
+)
+
+const usage = `usage: walletctl <command> [flags] [args]
+
+commands:
+  user <user-id> | -email <email> | -phone <number>
+                  look up a user
+  wallet <user-id>
+                  show a user's wallet
+  credit -reason <text> <user-id> <amount>
+  debit -reason <text> <user-id> <amount>
+                  adjust a wallet balance, recorded on the ledger with the reason
+  freeze -reason <text> <user-id>
+  unfreeze -reason <text> <user-id>
+                  block or allow wallet operations
+  export [-format json|csv] [-output file] <user-id>
+                  export a user's profile, wallet and transactions
+  payout [-mode all_or_nothing|best_effort] [-format csv|json] [-report file] <file>
+  payout -resume <batch-id> [-report file]
+                  credit the users of a file of recipient (email or phone),
+                  amount and reference rows, or finish an interrupted batch
+
+Connection settings are read from the same environment as walletd.
+`
+
+// commandTimeout bounds a single command, unless timeouts sets another.
+const commandTimeout = time.Minute
+
+var timeouts = map[string]time.Duration{
+	"payout": payoutTimeout,
+}
+
+var errUsage = errors.New("invalid arguments, run walletctl without arguments for usage")
+
+type command func(ctx context.Context, NikPay service.WalletService, args []string) error
+
+var commands = map[string]command{
+	"user":     runUser,
+	"wallet":   runWallet,
+	"credit":   runCredit,
+	"debit":    runDebit,
+	"freeze":   runFreeze,
+	"unfreeze": runUnfreeze,
+	"export":   runExport,
+	"payout":   runPayout,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err := run(os.Args[1], os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(name string, args []string) error {
+	cfg := config.Load()
+	store, err := db.Init(cfg.DatabaseURI)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	NikPay := service.NewWalletService(store,
+		service.WithPhoneRegion(cfg.PhoneRegion),
+		service.WithMaxAmount(cfg.MaxAmount),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	timeout, ok := timeouts[name]
+	if !ok {
+		timeout = commandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return commands[name](ctx, NikPay, args)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+)
+
+// payoutTimeout bounds a payout run, which may pay thousands of rows. A run
+// cut short can be resumed.
+const payoutTimeout = 30 * time.Minute
+
+func runPayout(ctx context.Context, NikPay service.WalletService, args []string) error {
+	flags := flag.NewFlagSet("payout", flag.ExitOnError)
+	mode := flags.String("mode", domain.PayoutModeAllOrNothing, "all_or_nothing or best_effort")
+	format := flags.String("format", "", "csv or json, by default taken from the file extension")
+	report := flags.String("report", "", "write the per-row result to this file, csv unless it ends in .json")
+	resume := flags.Int64("resume", 0, "pay the rows left of this batch instead of uploading a file")
+	operator := operatorFlag(flags)
+	flags.Parse(args)
+
+	batchID := *resume
+	switch {
+	case batchID > 0 && flags.NArg() == 0:
+	case batchID == 0 && flags.NArg() == 1:
+		batch, err := createPayout(ctx, NikPay, flags.Arg(0), *format, domain.PayoutRequest{Mode: *mode, CreatedBy: *operator})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "batch %d created, paying %d rows\n", batch.ID, batch.TotalRows)
+		batchID = batch.ID
+	default:
+		return errUsage
+	}
+
+	batch, err := NikPay.ExecutePayoutBatch(ctx, batchID)
+	if err != nil {
+		return fmt.Errorf("batch %d: %w, run walletctl payout -resume %d to pay the rows left", batchID, err, batchID)
+	}
+	if *report != "" {
+		if err := writePayoutReport(ctx, NikPay, batchID, *report); err != nil {
+			return err
+		}
+	}
+	return printJSON(os.Stdout, batch)
+}
+
+func createPayout(ctx context.Context, NikPay service.WalletService, path string, format string, request domain.PayoutRequest) (domain.PayoutBatch, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return domain.PayoutBatch{}, err
+	}
+	defer file.Close()
+
+	request.Instructions, err = service.ParsePayoutFile(file, format)
+	if err == nil {
+		var batch domain.PayoutBatch
+		batch, err = NikPay.CreatePayoutBatch(ctx, request)
+		if err == nil {
+			return batch, nil
+		}
+	}
+	var fieldErrs errors.ValidationErrors
+	if stderrors.As(err, &fieldErrs) {
+		for _, fieldErr := range fieldErrs {
+			fmt.Fprintln(os.Stderr, fieldErr)
+		}
+		return domain.PayoutBatch{}, fmt.Errorf("%s: %d invalid fields, nothing was paid", path, len(fieldErrs))
+	}
+	return domain.PayoutBatch{}, err
+}
+
+func writePayoutReport(ctx context.Context, NikPay service.WalletService, batchID int64, path string) error {
+	rows, err := NikPay.GetPayoutReport(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	format := service.PayoutFormatCSV
+	if strings.EqualFold(filepath.Ext(path), "."+service.PayoutFormatJSON) {
+		format = service.PayoutFormatJSON
+	}
+	return service.WritePayoutReport(file, rows, format)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"nickPay/wallet/internal/config"
+	"nickPay/wallet/internal/db"
+	"nickPay/wallet/internal/service"
+)
+
+// errDriftFound makes a one-off run exit non-zero so cron jobs can alert on it.
+var errDriftFound = errors.New("wallet balances do not match the ledger")
+
+// reconcileTimeout bounds a single one-off reconciliation run.
+const reconcileTimeout = 10 * time.Minute
+
+func runReconcile(args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	freeze := flags.Bool("freeze", false, "freeze wallets whose balance does not match the ledger")
+	output := flags.String("output", "", "write the JSON report to this file instead of stdout")
+	interval := flags.Duration("interval", 0, "keep running and reconcile on this interval, e.g. 1h")
+	flags.Parse(args)
+
+	store, err := db.Init(config.Load().DatabaseURI)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	NikPay := service.NewWalletService(store)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *interval > 0 {
+		service.RunReconciliationJob(ctx, NikPay, *interval, *freeze)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reconcileTimeout)
+	defer cancel()
+	report, err := NikPay.Reconcile(ctx, *freeze)
+	if err != nil {
+		return err
+	}
+	if err = writeReport(report, *output); err != nil {
+		return err
+	}
+	if len(report.Mismatches) > 0 {
+		return errDriftFound
+	}
+	return nil
+}
+
+func writeReport(report interface{}, path string) error {
+	out := os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"nickPay/wallet/internal/bank"
+	"nickPay/wallet/internal/config"
+	"nickPay/wallet/internal/controller"
+	"nickPay/wallet/internal/db"
+	"nickPay/wallet/internal/events"
+	"nickPay/wallet/internal/gateway"
+	"nickPay/wallet/internal/grpcserver"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/notify"
+	"nickPay/wallet/internal/phone"
+	"nickPay/wallet/internal/ratelimit"
+	"nickPay/wallet/internal/service"
+	"nickPay/wallet/internal/tracing"
+
+	logger "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+func runServe(args []string) error {
+	cfg := config.Load()
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags.StringVar(&cfg.HTTPAddr, "addr", cfg.HTTPAddr, "address to listen on")
+	flags.StringVar(&cfg.GRPCAddr, "grpc-addr", cfg.GRPCAddr, "address to serve the gRPC API on, empty disables it")
+	flags.Parse(args)
+
+	logger.SetFormatter(&logger.JSONFormatter{})
+	logger.AddHook(logging.RedactHook{})
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.TracingExporter, cfg.OTLPEndpoint, "walletd")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.WithField("err", err.Error()).Error("Error while flushing traces")
+		}
+	}()
+
+	store, err := db.Init(cfg.DatabaseURI)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.WithField("err", err.Error()).Error("Error while closing database pool")
+		}
+	}()
+
+	// The log notifier delivers nothing, users would never get their codes
+	if cfg.Notifier == "log" && !cfg.DevMode {
+		return fmt.Errorf("the log notifier is for local development, set WALLET_DEV_MODE to use it")
+	}
+	notifier, err := notify.New(cfg.Notifier, cfg.NotifierFile)
+	if err != nil {
+		return fmt.Errorf("WALLET_NOTIFIER: %w", err)
+	}
+
+	if !phone.KnownRegion(cfg.PhoneRegion) {
+		return fmt.Errorf("unknown phone region %q", cfg.PhoneRegion)
+	}
+
+	opts := []service.Option{}
+	if cfg.Gateway != "" {
+		// The fake gateway confirms payments nobody made
+		if cfg.Gateway == "fake" && !cfg.DevMode {
+			return fmt.Errorf("the fake payment gateway is for local development, set WALLET_DEV_MODE to use it")
+		}
+		paymentGateway, err := gateway.New(cfg.Gateway, cfg.GatewaySecret)
+		if err != nil {
+			return fmt.Errorf("WALLET_GATEWAY: %w", err)
+		}
+		opts = append(opts, service.WithPaymentGateway(paymentGateway))
+	} else {
+		logger.Warn("No WALLET_GATEWAY set, wallet top-ups are disabled")
+	}
+	if cfg.PayoutProvider != "" {
+		// The fake provider pays nothing out and forgets its transfers on
+		// restart, while withdrawals still take the money from the wallets
+		if cfg.PayoutProvider == "fake" && !cfg.DevMode {
+			return fmt.Errorf("the fake payout provider is for local development, set WALLET_DEV_MODE to use it")
+		}
+		provider, err := bank.New(cfg.PayoutProvider)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, service.WithPayoutProvider(provider))
+	}
+	fees, err := service.ParseFeeSchedule(cfg.Fees)
+	if err != nil {
+		return err
+	}
+	if len(fees) > 0 {
+		if cfg.FeeAccount <= 0 {
+			return fmt.Errorf("WALLET_FEES needs WALLET_FEE_ACCOUNT, the user whose wallet collects the fees")
+		}
+		opts = append(opts, service.WithFees(fees, int64(cfg.FeeAccount)))
+	}
+
+	lockout := service.DefaultLockoutPolicy
+	lockout.MaxAccountFailures = cfg.LoginMaxFailures
+	lockout.MaxIPFailures = cfg.LoginMaxIPFailures
+	lockout.LockoutDuration = cfg.LoginLockout
+	opts = append(opts,
+		service.WithLockoutPolicy(lockout),
+		service.WithTOTPIssuer(cfg.TOTPIssuer),
+		service.WithStepUpThreshold(cfg.StepUpThreshold),
+		service.WithNotifier(notifier),
+		service.WithPhoneRegion(cfg.PhoneRegion),
+		service.WithMaxAmount(cfg.MaxAmount),
+	)
+	NikPay := service.NewWalletService(store, opts...)
+	deps := &controller.Dependencies{
+		NikPay: NikPay,
+	}
+
+	broker := events.NewBroker()
+	routerCfg := routerConfig(cfg)
+	routerCfg.Events = broker
+	srv := &http.Server{
+		Addr:         cfg.HTTPAddr,
+		Handler:      controller.InitRouter(deps, routerCfg),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+	// Event streams never finish on their own, end them so Shutdown can drain
+	srv.RegisterOnShutdown(broker.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := events.ListenPostgres(ctx, cfg.DatabaseURI, broker); err != nil {
+			logger.WithField("err", err.Error()).Error("Cannot listen for wallet events, /wallet/events streams stay idle")
+		}
+	}()
+
+	if cfg.ReconcileInterval > 0 {
+		go service.RunReconciliationJob(ctx, NikPay, cfg.ReconcileInterval, cfg.ReconcileFreeze)
+	}
+
+	if cfg.PayoutProvider != "" && cfg.SettleInterval > 0 {
+		go service.RunWithdrawalSettlementJob(ctx, NikPay, cfg.SettleInterval)
+	}
+
+	var grpcListener net.Listener
+	if cfg.GRPCAddr != "" {
+		grpcListener, err = net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			return err
+		}
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		logger.WithField("addr", cfg.HTTPAddr).Info("HTTP server listening")
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	var grpcSrv *grpc.Server
+	if grpcListener != nil {
+		grpcSrv = grpcserver.NewServer(NikPay)
+		go func() {
+			logger.WithField("addr", cfg.GRPCAddr).Info("gRPC server listening")
+			if err := grpcSrv.Serve(grpcListener); err != nil {
+				serveErr <- fmt.Errorf("grpc server: %w", err)
+			}
+		}()
+	}
+
+	select {
+	case err = <-serveErr:
+		if err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	logger.Info("Shutting down, draining in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if grpcSrv != nil {
+		go func() {
+			<-shutdownCtx.Done()
+			grpcSrv.Stop()
+		}()
+		grpcSrv.GracefulStop()
+		logger.Info("gRPC server stopped")
+	}
+	if err = srv.Shutdown(shutdownCtx); err != nil {
+		logger.WithField("err", err.Error()).Error("HTTP server did not drain before the shutdown timeout")
+		return err
+	}
+	logger.Info("HTTP server stopped")
+	return nil
+}
+
+func routerConfig(cfg config.Config) controller.RouterConfig {
+	return controller.RouterConfig{
+		RateLimitStore:    ratelimit.NewMemoryStore(),
+		AuthRateLimit:     ratelimit.PerMinute(cfg.AuthRateLimit, cfg.AuthRateLimit),
+		WalletRateLimit:   ratelimit.PerMinute(cfg.WalletRateLimit, cfg.WalletRateLimit),
+		TrustProxyHeaders: cfg.TrustProxyHeaders,
+		AdminToken:        cfg.AdminToken,
+		EventHeartbeat:    cfg.EventHeartbeat,
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		cfg := Load()
+		require.Equal(t, ":8080", cfg.HTTPAddr)
+		require.Empty(t, cfg.GRPCAddr)
+		require.Equal(t, 15*time.Second, cfg.EventHeartbeat)
+		require.Empty(t, cfg.Gateway)
+		require.Empty(t, cfg.GatewaySecret)
+		require.Empty(t, cfg.PayoutProvider)
+		require.Equal(t, 30*time.Second, cfg.SettleInterval)
+		require.Empty(t, cfg.Fees)
+		require.Zero(t, cfg.FeeAccount)
+		require.Empty(t, cfg.Notifier)
+		require.False(t, cfg.DevMode)
+		require.Equal(t, 10*time.Second, cfg.ReadTimeout)
+		require.Equal(t, time.Duration(0), cfg.ReconcileInterval)
+		require.False(t, cfg.ReconcileFreeze)
+		require.Equal(t, 10, cfg.AuthRateLimit)
+		require.False(t, cfg.TrustProxyHeaders)
+	})
+
+	t.Run("Environment overrides", func(t *testing.T) {
+		t.Setenv("WALLET_HTTP_ADDR", ":9090")
+		t.Setenv("WALLET_HTTP_WRITE_TIMEOUT", "45s")
+		t.Setenv("WALLET_RECONCILE_INTERVAL", "1h")
+		t.Setenv("WALLET_RECONCILE_FREEZE", "true")
+		t.Setenv("WALLET_WALLET_RATE_LIMIT", "30")
+
+		cfg := Load()
+		require.Equal(t, ":9090", cfg.HTTPAddr)
+		require.Equal(t, 45*time.Second, cfg.WriteTimeout)
+		require.Equal(t, time.Hour, cfg.ReconcileInterval)
+		require.True(t, cfg.ReconcileFreeze)
+		require.Equal(t, 30, cfg.WalletRateLimit)
+	})
+
+	t.Run("Invalid values fall back to defaults", func(t *testing.T) {
+		t.Setenv("WALLET_HTTP_IDLE_TIMEOUT", "forever")
+		t.Setenv("WALLET_RECONCILE_FREEZE", "maybe")
+		t.Setenv("WALLET_AUTH_RATE_LIMIT", "lots")
+
+		cfg := Load()
+		require.Equal(t, 120*time.Second, cfg.IdleTimeout)
+		require.False(t, cfg.ReconcileFreeze)
+		require.Equal(t, 10, cfg.AuthRateLimit)
+	})
+}
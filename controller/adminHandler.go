@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+)
+
+// UnlockLogin clears the failed login lock on an account, a client IP or
+// both, ahead of its automatic expiry.
+func UnlockLogin(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var request domain.UnlockLoginRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		err = NikPay.UnlockLogin(r.Context(), request)
+		if err == errors.ErrInvalidUnlockRequest {
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeMessage(rw, http.StatusOK, "login unlocked")
+	})
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUnlockLogin(t *testing.T) {
+	tests := []struct {
+		name       string
+		auth       string
+		body       string
+		prepare    func(*mocks.WalletService)
+		wantStatus int
+	}{
+		{
+			name:       "Missing admin token",
+			auth:       "",
+			body:       `{"email":"john@mail.com"}`,
+			prepare:    func(s *mocks.WalletService) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Wrong admin token",
+			auth:       "Bearer guess",
+			body:       `{"email":"john@mail.com"}`,
+			prepare:    func(s *mocks.WalletService) {},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Unlock account",
+			auth: "Bearer admin-token",
+			body: `{"email":"john@mail.com"}`,
+			prepare: func(s *mocks.WalletService) {
+				s.On("UnlockLogin", mock.Anything, domain.UnlockLoginRequest{Email: "john@mail.com"}).Return(nil).Once()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Nothing to unlock",
+			auth: "Bearer admin-token",
+			body: `{}`,
+			prepare: func(s *mocks.WalletService) {
+				s.On("UnlockLogin", mock.Anything, domain.UnlockLoginRequest{}).Return(errors.ErrInvalidUnlockRequest).Once()
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			NikPay := &mocks.WalletService{}
+			tt.prepare(NikPay)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/login/unlock", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rw := httptest.NewRecorder()
+			adminMiddleware("admin-token", UnlockLogin(NikPay))(rw, req)
+
+			assert.Equal(t, tt.wantStatus, rw.Code)
+			NikPay.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/service"
+)
+
+// CreateCampaign starts a cashback campaign. Debits or QR payments, as its
+// operation says, made while it runs earn rewards into the promo balance.
+func CreateCampaign(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var campaign domain.Campaign
+		err := decodeJSON(r, &campaign)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		campaign, err = NikPay.CreateCampaign(r.Context(), campaign)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusCreated, campaign)
+	})
+}
+
+// ListCampaigns serves every campaign with how much of its budget was spent.
+func ListCampaigns(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		campaigns, err := NikPay.ListCampaigns(r.Context())
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, campaigns)
+	})
+}
+
+// GetPromoBalance serves the signed in user's unexpired cashback.
+func GetPromoBalance(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		balance, err := NikPay.GetPromoBalance(r.Context(), userID)
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, balance)
+	})
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateCampaign(t *testing.T) {
+	startsAt := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	campaign := domain.Campaign{Name: "Week cashback", Operation: domain.CampaignOperationDebit, Percent: 5, MaxReward: 100, Budget: 5000, RewardValidityDays: 30, StartsAt: startsAt, EndsAt: startsAt.AddDate(0, 0, 7)}
+	NikPay := &mocks.WalletService{}
+	created := campaign
+	created.ID = 3
+	NikPay.On("CreateCampaign", mock.Anything, campaign).Return(created, nil).Once()
+	NikPay.On("CreateCampaign", mock.Anything, domain.Campaign{Name: "Week cashback"}).
+		Return(domain.Campaign{}, errors.ValidationErrors{{Field: "operation", Code: errors.CodeRequired, Err: errors.ErrInvalidCampaignOperation}}).Once()
+
+	body := `{"name":"Week cashback","operation":"debit","percent":5,"max_reward":100,"budget":5000,"reward_validity_days":30,"starts_at":"2024-05-01T00:00:00Z","ends_at":"2024-05-08T00:00:00Z"}`
+	rw := httptest.NewRecorder()
+	CreateCampaign(NikPay)(rw, httptest.NewRequest(http.MethodPost, "/v1/admin/campaigns", strings.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, rw.Code)
+	assert.Contains(t, rw.Body.String(), `"id":3`)
+
+	rw = httptest.NewRecorder()
+	CreateCampaign(NikPay)(rw, httptest.NewRequest(http.MethodPost, "/v1/admin/campaigns", strings.NewReader(`{"name":"Week cashback"}`)))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestGetPromoBalance(t *testing.T) {
+	expiresAt := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	NikPay := &mocks.WalletService{}
+	NikPay.On("GetPromoBalance", mock.Anything, int64(1)).Return(domain.PromoBalance{Balance: 50, Rewards: []domain.PromoReward{
+		{ID: 1, UserID: 1, CampaignID: 3, CampaignName: "Week cashback", SourceAmount: 1000, Amount: 50, ExpiresAt: expiresAt, CreatedAt: expiresAt.AddDate(0, 0, -30)},
+	}}, nil).Once()
+
+	rw := httptest.NewRecorder()
+	GetPromoBalance(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodGet, "/v1/wallet/promo", nil), 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"balance":50,"rewards":[{"id":1,"campaign_id":3,"campaign_name":"Week cashback","source_amount":1000,"amount":50,"expires_at":"2024-06-01T00:00:00Z","created_at":"2024-05-02T00:00:00Z"}]}`, rw.Body.String())
+	NikPay.AssertExpectations(t)
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/events"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/service"
+	"strconv"
+	"time"
+)
+
+// replayPageSize is how many missed transactions are read at a time when a
+// stream resumes.
+const replayPageSize = 100
+
+// StreamWalletEvents streams the user's wallet as server-sent events. A new
+// stream opens with a balance event holding the wallet, then every committed
+// transaction follows as a transaction event whose ID is the transaction ID.
+// Reconnecting with Last-Event-ID replays the transactions missed in between
+// instead. A comment is sent every heartbeat so proxies keep idle streams
+// open.
+func StreamWalletEvents(NikPay service.WalletService, broker *events.Broker, heartbeat time.Duration) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var lastID int64
+		if header := r.Header.Get("Last-Event-ID"); header != "" {
+			var err error
+			lastID, err = strconv.ParseInt(header, 10, 64)
+			if err != nil || lastID < 0 {
+				writeMessage(rw, http.StatusBadRequest, "invalid Last-Event-ID")
+				return
+			}
+		}
+
+		// Subscribe before reading the wallet or the missed transactions, so
+		// nothing committed in between is lost.
+		live, cancel := broker.Subscribe(userID)
+		defer cancel()
+
+		var wallet domain.Wallet
+		if lastID == 0 {
+			var err error
+			wallet, err = NikPay.GetWallet(r.Context(), userID)
+			if err != nil {
+				writeMessage(rw, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		rc := http.NewResponseController(rw)
+		// Streams outlive the server's write timeout
+		rc.SetWriteDeadline(time.Time{})
+		rw.Header().Set("Content-Type", "text/event-stream")
+		rw.Header().Set("Cache-Control", "no-cache")
+		rw.Header().Set("X-Accel-Buffering", "no")
+		rw.WriteHeader(http.StatusOK)
+
+		stream := eventStream{rw: rw}
+		if lastID == 0 {
+			stream.send("balance", "", domain.GetWalletResponse{
+				ID:           wallet.ID,
+				Balance:      wallet.Balance,
+				CreationDate: wallet.CreationDate,
+				LastUpdated:  wallet.LastUpdated,
+				Status:       wallet.Status,
+			})
+		}
+		for lastID > 0 {
+			missed, err := NikPay.ListTransactionsSince(r.Context(), userID, lastID, replayPageSize)
+			if err != nil {
+				logging.FromContext(r.Context()).WithField("err", err.Error()).Error("Cannot replay wallet events")
+				return
+			}
+			for _, txn := range missed {
+				stream.send("transaction", strconv.FormatInt(txn.ID, 10), txn)
+				lastID = txn.ID
+			}
+			if len(missed) < replayPageSize {
+				break
+			}
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			if stream.err != nil || rc.Flush() != nil {
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case txn, ok := <-live:
+				// Dropped by the broker, the client reconnects and resumes
+				if !ok {
+					return
+				}
+				if txn.ID <= lastID {
+					continue
+				}
+				stream.send("transaction", strconv.FormatInt(txn.ID, 10), txn)
+				lastID = txn.ID
+			case <-ticker.C:
+				stream.comment("heartbeat")
+			}
+		}
+	})
+}
+
+// eventStream writes server-sent events and remembers the first write error.
+type eventStream struct {
+	rw  http.ResponseWriter
+	err error
+}
+
+func (s *eventStream) send(event string, id string, data interface{}) {
+	if s.err != nil {
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		s.err = err
+		return
+	}
+	if id != "" {
+		_, s.err = fmt.Fprintf(s.rw, "event: %s\nid: %s\ndata: %s\n\n", event, id, payload)
+	} else {
+		_, s.err = fmt.Fprintf(s.rw, "event: %s\ndata: %s\n\n", event, payload)
+	}
+}
+
+func (s *eventStream) comment(text string) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.rw, ": %s\n\n", text)
+}
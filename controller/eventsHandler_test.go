@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/events"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// openStream serves StreamWalletEvents for user 1 and returns the response
+// to a request carrying lastEventID.
+func openStream(t *testing.T, NikPay *mocks.WalletService, broker *events.Broker, heartbeat time.Duration, lastEventID string) *http.Response {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		StreamWalletEvents(NikPay, broker, heartbeat)(rw, withUserID(r, 1))
+	}))
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// readEvent reads lines up to the blank line ending the next event.
+func readEvent(t *testing.T, body *bufio.Reader) string {
+	var event strings.Builder
+	for {
+		line, err := body.ReadString('\n')
+		require.NoError(t, err)
+		if line == "\n" {
+			return event.String()
+		}
+		event.WriteString(line)
+	}
+}
+
+func TestStreamWalletEvents(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 3, Balance: 100, Status: "active"}, nil).Once()
+	broker := events.NewBroker()
+
+	resp := openStream(t, NikPay, broker, time.Hour, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	body := bufio.NewReader(resp.Body)
+	event := readEvent(t, body)
+	assert.True(t, strings.HasPrefix(event, "event: balance\ndata: {"), event)
+	assert.Contains(t, event, `"balance":100`)
+
+	broker.Publish(domain.Transaction{ID: 9, WalletID: 3, UserID: 1, Type: domain.TransactionCredit, Amount: 20, BalanceAfter: 120})
+	broker.Publish(domain.Transaction{ID: 10, UserID: 2})
+	event = readEvent(t, body)
+	assert.True(t, strings.HasPrefix(event, "event: transaction\nid: 9\ndata: {"), event)
+	assert.Contains(t, event, `"balance_after":120`)
+
+	broker.Close()
+	_, err := body.ReadString('\n')
+	assert.Error(t, err, "the stream ends when the broker closes")
+	NikPay.AssertExpectations(t)
+}
+
+func TestStreamWalletEventsResume(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("ListTransactionsSince", mock.Anything, int64(1), int64(7), replayPageSize).Return([]domain.Transaction{{ID: 8, UserID: 1}, {ID: 9, UserID: 1}}, nil).Once()
+	broker := events.NewBroker()
+
+	resp := openStream(t, NikPay, broker, time.Hour, "7")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body := bufio.NewReader(resp.Body)
+	assert.Contains(t, readEvent(t, body), "id: 8\n")
+	assert.Contains(t, readEvent(t, body), "id: 9\n")
+
+	// Already replayed transactions are not sent twice
+	broker.Publish(domain.Transaction{ID: 9, UserID: 1})
+	broker.Publish(domain.Transaction{ID: 10, UserID: 1})
+	assert.Contains(t, readEvent(t, body), "id: 10\n")
+	broker.Close()
+	NikPay.AssertExpectations(t)
+}
+
+func TestStreamWalletEventsHeartbeat(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("ListTransactionsSince", mock.Anything, int64(1), int64(7), replayPageSize).Return(nil, nil).Once()
+	broker := events.NewBroker()
+
+	resp := openStream(t, NikPay, broker, 10*time.Millisecond, "7")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, ": heartbeat\n", readEvent(t, bufio.NewReader(resp.Body)))
+	broker.Close()
+}
+
+func TestStreamWalletEventsInvalidLastEventID(t *testing.T) {
+	for _, header := range []string{"abc", "-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/wallet/events", nil)
+		req.Header.Set("Last-Event-ID", header)
+		rw := httptest.NewRecorder()
+		StreamWalletEvents(&mocks.WalletService{}, events.NewBroker(), time.Hour)(rw, withUserID(req, 1))
+		assert.Equal(t, http.StatusBadRequest, rw.Code, header)
+		assert.Contains(t, rw.Body.String(), "invalid Last-Event-ID")
+	}
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/service"
+)
+
+// QuoteFee tells the signed in user what a debit or withdrawal would cost
+// before they make it.
+func QuoteFee(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var request domain.QuoteRequest
+		err := decodeJSON(r, &request)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		quote, err := NikPay.QuoteFee(r.Context(), request)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, quote)
+	})
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestQuoteFee(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("QuoteFee", mock.Anything, domain.QuoteRequest{Operation: domain.FeeOperationWithdrawal, Amount: 100}).
+		Return(domain.FeeQuote{Operation: domain.FeeOperationWithdrawal, Amount: 100, Fee: 1, Total: 101, Breakdown: []domain.FeeComponent{{Kind: domain.FeePercentage, Rate: 1, Amount: 1}}}, nil).Once()
+	NikPay.On("QuoteFee", mock.Anything, domain.QuoteRequest{Operation: "transfer", Amount: 100}).
+		Return(domain.FeeQuote{}, errors.ValidationErrors{{Field: "operation", Code: errors.CodeInvalid, Err: errors.ErrInvalidFeeOperation}}).Once()
+
+	rw := httptest.NewRecorder()
+	QuoteFee(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodPost, "/v1/wallet/quote", strings.NewReader(`{"operation":"withdrawal","amount":100}`)), 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"operation":"withdrawal","amount":100,"fee":1,"total":101,"breakdown":[{"kind":"percentage","rate":1,"amount":1}]}`, rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	QuoteFee(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodPost, "/v1/wallet/quote", strings.NewReader(`{"operation":"transfer","amount":100}`)), 1))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	NikPay.AssertExpectations(t)
+}
@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/service"
+	"time"
+)
+
+// readinessTimeout bounds the database ping made by /readyz.
+const readinessTimeout = 2 * time.Second
+
+// Healthz reports that the process is up. It never touches dependencies so
+// that a slow database does not get the process restarted.
+func Healthz() http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		writeMessage(rw, http.StatusOK, "ok")
+	})
+}
+
+// Readyz reports whether the service can take traffic, which requires
+// Postgres to be reachable.
+func Readyz(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		err := NikPay.Ping(ctx)
+		if err != nil {
+			logging.FromContext(r.Context()).WithField("err", err.Error()).Error("Readiness check failed")
+			writeMessage(rw, http.StatusServiceUnavailable, "database unavailable")
+			return
+		}
+		writeMessage(rw, http.StatusOK, "ready")
+	})
+}
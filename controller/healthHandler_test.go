@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/service/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHealthz(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rw := httptest.NewRecorder()
+
+	Healthz().ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"message":"ok"}`, rw.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	t.Run("Database reachable", func(t *testing.T) {
+		NikPay := mocks.NewWalletService(t)
+		NikPay.On("Ping", mock.Anything).Return(nil).Once()
+		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		rw := httptest.NewRecorder()
+
+		Readyz(NikPay).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.JSONEq(t, `{"message":"ready"}`, rw.Body.String())
+	})
+
+	t.Run("Database unreachable", func(t *testing.T) {
+		NikPay := mocks.NewWalletService(t)
+		NikPay.On("Ping", mock.Anything).Return(errors.New("connection refused")).Once()
+		req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		rw := httptest.NewRecorder()
+
+		Readyz(NikPay).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+		assert.JSONEq(t, `{"message":"database unavailable"}`, rw.Body.String())
+	})
+}
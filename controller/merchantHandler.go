@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RegisterMerchant opens a merchant account for the signed in user.
+func RegisterMerchant(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var merchant domain.Merchant
+		err := decodeJSON(r, &merchant)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		merchant, err = NikPay.RegisterMerchant(r.Context(), userID, merchant)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		switch err {
+		case nil:
+			writeJSON(rw, http.StatusCreated, merchant)
+		case errors.ErrUnverifiedUser:
+			writeMessage(rw, http.StatusForbidden, err.Error())
+		case errors.ErrMerchantExists:
+			writeMessage(rw, http.StatusConflict, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// GetMerchant serves the signed in user's merchant account.
+func GetMerchant(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		merchant, err := NikPay.GetMerchant(r.Context(), userID)
+		if err == errors.ErrMerchantNotFound {
+			writeMessage(rw, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, merchant)
+	})
+}
+
+// GetMerchantWallet returns the wallet the signed in user's merchant is paid
+// into.
+func GetMerchantWallet(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		wallet, err := NikPay.GetMerchantWallet(r.Context(), userID)
+		if err == errors.ErrMerchantNotFound || err == errors.ErrNoMerchantWallet {
+			writeMessage(rw, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, domain.GetWalletResponse{
+			ID:           wallet.ID,
+			Balance:      wallet.Balance,
+			CreationDate: wallet.CreationDate,
+			LastUpdated:  wallet.LastUpdated,
+			Status:       wallet.Status,
+		})
+	})
+}
+
+// GetStaticQR serves the static QR code of the signed in user's merchant in
+// the ?format= requested, json by default.
+func GetStaticQR(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		format, ok := qrFormat(rw, r)
+		if !ok {
+			return
+		}
+		qr, err := NikPay.GetStaticQR(r.Context(), userID)
+		writeQRCode(rw, r, qr, format, err)
+	})
+}
+
+// CreatePaymentQR creates a dynamic QR code for the signed in user's
+// merchant. Its image is served at the returned Location.
+func CreatePaymentQR(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var request domain.PaymentQRRequest
+		err := decodeJSON(r, &request)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		qr, err := NikPay.CreatePaymentQR(r.Context(), userID, request)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		switch err {
+		case nil:
+			rw.Header().Set("Location", fmt.Sprintf("%s/merchant/qr/%d", apiVersion, qr.ID))
+			writeJSON(rw, http.StatusCreated, qr)
+		case errors.ErrMerchantNotFound:
+			writeMessage(rw, http.StatusNotFound, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// GetPaymentQR serves one of the dynamic QR codes of the signed in user's
+// merchant in the ?format= requested, json by default.
+func GetPaymentQR(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		qrID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		format, ok := qrFormat(rw, r)
+		if !ok {
+			return
+		}
+		qr, err := NikPay.GetPaymentQR(r.Context(), userID, qrID)
+		writeQRCode(rw, r, qr, format, err)
+	})
+}
+
+// qrFormat returns the QR code format asked for with ?format=, answering
+// the request itself when the format is not supported.
+func qrFormat(rw http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.QRFormatJSON
+	}
+	if service.QRContentType(format) == "" {
+		writeMessage(rw, http.StatusBadRequest, errors.ErrInvalidQRFormat.Error())
+		return "", false
+	}
+	return format, true
+}
+
+// writeQRCode answers with qr rendered in format, or with err if fetching it
+// failed.
+func writeQRCode(rw http.ResponseWriter, r *http.Request, qr domain.PaymentQR, format string, err error) {
+	switch err {
+	case nil:
+	case errors.ErrMerchantNotFound, errors.ErrPaymentQRNotFound:
+		writeMessage(rw, http.StatusNotFound, err.Error())
+		return
+	default:
+		writeMessage(rw, http.StatusInternalServerError, err.Error())
+		return
+	}
+	rw.Header().Set("Content-Type", service.QRContentType(format))
+	rw.WriteHeader(http.StatusOK)
+	err = service.WriteQRCode(rw, qr, format)
+	if err != nil {
+		logging.FromContext(r.Context()).WithField("err", err.Error()).Error("Cannot render QR code")
+	}
+}
+
+// PayQR pays the merchant of a scanned QR code from the signed in user's
+// wallet.
+func PayQR(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var request domain.QRPaymentRequest
+		err := decodeJSON(r, &request)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		ctx := r.Context()
+		if code := r.Header.Get(stepUpHeader); code != "" {
+			ctx = service.WithStepUpCode(ctx, code)
+		}
+		payment, err := NikPay.PayQR(ctx, userID, request)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		switch err {
+		case nil:
+			writeJSON(rw, http.StatusOK, payment)
+		case errors.ErrStepUpRequired, errors.ErrInvalidMFACode, errors.ErrMFANotEnrolled, errors.ErrUnverifiedUser:
+			writeMessage(rw, http.StatusForbidden, err.Error())
+		case errors.ErrStepUpLocked:
+			writeMessage(rw, http.StatusTooManyRequests, err.Error())
+		case errors.ErrInsufficientBalance, errors.ErrWalletFrozen, errors.ErrNoWallet, errors.ErrSelfPayment, errors.ErrNoMerchantWallet:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrQRAlreadyPaid, errors.ErrQRExpired:
+			writeMessage(rw, http.StatusConflict, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// GetSettlementReport serves the daily totals of the payments the signed in
+// user's merchant received between ?from= and ?to=, both YYYY-MM-DD and
+// inclusive. They default to the first day of the current month and today.
+func GetSettlementReport(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		now := time.Now()
+		from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		for name, date := range map[string]*time.Time{"from": &from, "to": &to} {
+			value := r.URL.Query().Get(name)
+			if value == "" {
+				continue
+			}
+			parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				writeMessage(rw, http.StatusBadRequest, errors.ErrInvalidSettlementPeriod.Error())
+				return
+			}
+			*date = parsed
+		}
+
+		report, err := NikPay.GetSettlementReport(r.Context(), userID, from, to.AddDate(0, 0, 1))
+		switch err {
+		case nil:
+			writeJSON(rw, http.StatusOK, report)
+		case errors.ErrInvalidSettlementPeriod:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrMerchantNotFound:
+			writeMessage(rw, http.StatusNotFound, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
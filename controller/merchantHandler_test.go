@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetMerchantWallet(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("GetMerchantWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 5, Balance: 250, Status: domain.WalletStatusActive}, nil).Once()
+	NikPay.On("GetMerchantWallet", mock.Anything, int64(2)).Return(domain.Wallet{}, errors.ErrMerchantNotFound).Once()
+
+	rw := httptest.NewRecorder()
+	GetMerchantWallet(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodGet, "/v1/merchant/wallet", nil), 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"id":5,"balance":250,"creation_date":"","last_updated":"","status":"active"}`, rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	GetMerchantWallet(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodGet, "/v1/merchant/wallet", nil), 2))
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestGetStaticQR(t *testing.T) {
+	qr := domain.PaymentQR{MerchantID: 12, Kind: domain.PaymentQRStatic, Payload: "nikpay://pay?merchant=12"}
+	NikPay := &mocks.WalletService{}
+	NikPay.On("GetStaticQR", mock.Anything, int64(1)).Return(qr, nil).Twice()
+
+	rw := httptest.NewRecorder()
+	GetStaticQR(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodGet, "/v1/merchant/qr", nil), 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"merchant_id":12,"kind":"static","payload":"nikpay://pay?merchant=12"}`, rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	GetStaticQR(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodGet, "/v1/merchant/qr?format=svg", nil), 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "image/svg+xml", rw.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(rw.Body.String(), "<svg"))
+
+	rw = httptest.NewRecorder()
+	GetStaticQR(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodGet, "/v1/merchant/qr?format=gif", nil), 1))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestPayQR(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "paid", status: http.StatusOK},
+		{name: "already paid", err: errors.ErrQRAlreadyPaid, status: http.StatusConflict},
+		{name: "expired", err: errors.ErrQRExpired, status: http.StatusConflict},
+		{name: "insufficient balance", err: errors.ErrInsufficientBalance, status: http.StatusBadRequest},
+		{name: "paying oneself", err: errors.ErrSelfPayment, status: http.StatusBadRequest},
+		{name: "step-up required", err: errors.ErrStepUpRequired, status: http.StatusForbidden},
+		{name: "invalid payload", err: errors.ValidationErrors{{Field: "payload", Code: errors.CodeInvalid, Err: errors.ErrInvalidQRPayload}}, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			NikPay := &mocks.WalletService{}
+			NikPay.On("PayQR", mock.Anything, int64(1), domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12", Amount: 20}).
+				Return(domain.MerchantPayment{ID: 56, MerchantID: 12, MerchantName: "Corner Cafe", Amount: 20}, tt.err).Once()
+
+			rw := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/v1/pay/qr", strings.NewReader(`{"payload":"nikpay://pay?merchant=12","amount":20}`))
+			PayQR(NikPay)(rw, withUserID(req, 1))
+			assert.Equal(t, tt.status, rw.Code)
+			NikPay.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetSettlementReport(t *testing.T) {
+	from := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.Local)
+	NikPay := &mocks.WalletService{}
+	NikPay.On("GetSettlementReport", mock.Anything, int64(1), from, from.AddDate(0, 1, 0)).
+		Return(domain.SettlementReport{MerchantID: 12, From: from, To: from.AddDate(0, 1, 0), Days: []domain.SettlementDay{}}, nil).Once()
+
+	rw := httptest.NewRecorder()
+	GetSettlementReport(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodGet, "/v1/merchant/settlements?from=2024-05-01&to=2024-05-31", nil), 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	rw = httptest.NewRecorder()
+	GetSettlementReport(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodGet, "/v1/merchant/settlements?from=May", nil), 1))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.Contains(t, rw.Body.String(), errors.ErrInvalidSettlementPeriod.Error())
+	NikPay.AssertExpectations(t)
+}
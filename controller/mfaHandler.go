@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+)
+
+// stepUpHeader carries the TOTP code for operations above the step-up
+// threshold.
+const stepUpHeader = "X-MFA-Code"
+
+// EnrollTOTP starts TOTP enrollment and returns the secret and otpauth URI
+// for the user's authenticator app.
+func EnrollTOTP(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		enrollment, err := NikPay.EnrollTOTP(r.Context(), userID)
+		if err == errors.ErrMFAAlreadyEnabled {
+			writeMessage(rw, http.StatusConflict, err.Error())
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, enrollment)
+	})
+}
+
+// ConfirmTOTP enables two-factor authentication given a code from the newly
+// enrolled authenticator, and returns the recovery codes.
+func ConfirmTOTP(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var request domain.MFACodeRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		codes, err := NikPay.ConfirmTOTP(r.Context(), userID, request.Code)
+		switch err {
+		case nil:
+			writeJSON(rw, http.StatusOK, domain.RecoveryCodesResponse{RecoveryCodes: codes})
+		case errors.ErrInvalidMFACode, errors.ErrMFANotEnrolled:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrMFAAlreadyEnabled:
+			writeMessage(rw, http.StatusConflict, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// VerifyLoginMFA exchanges the MFA challenge token from /login and a TOTP or
+// recovery code for an access token.
+func VerifyLoginMFA(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var request domain.MFALoginRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		request.ClientIP = requestClientIP(r)
+
+		token, err := NikPay.VerifyLoginMFA(r.Context(), request)
+		switch err {
+		case nil:
+			writeJSON(rw, http.StatusOK, domain.LoginUserResponse{Message: "User Logged In Successfully", Token: token})
+		case errors.ErrLoginLocked:
+			writeMessage(rw, http.StatusTooManyRequests, err.Error())
+		case errors.ErrInvalidMFACode, errors.ErrInvalidMFAToken:
+			writeMessage(rw, http.StatusUnauthorized, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
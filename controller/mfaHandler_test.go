@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func withUserID(req *http.Request, userID int64) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), "id", userID))
+}
+
+func TestEnrollTOTP(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("EnrollTOTP", mock.Anything, int64(1)).Return(domain.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/NikPay:john"}, nil).Once()
+	NikPay.On("EnrollTOTP", mock.Anything, int64(2)).Return(domain.TOTPEnrollment{}, errors.ErrMFAAlreadyEnabled).Once()
+
+	rw := httptest.NewRecorder()
+	EnrollTOTP(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodPost, "/mfa/totp/enroll", nil), 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"secret":"SECRET","otpauth_uri":"otpauth://totp/NikPay:john"}`, rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	EnrollTOTP(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodPost, "/mfa/totp/enroll", nil), 2))
+	assert.Equal(t, http.StatusConflict, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestConfirmTOTP(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("ConfirmTOTP", mock.Anything, int64(1), "123456").Return([]string{"abcde-fghij"}, nil).Once()
+	NikPay.On("ConfirmTOTP", mock.Anything, int64(1), "000000").Return(nil, errors.ErrInvalidMFACode).Once()
+
+	rw := httptest.NewRecorder()
+	ConfirmTOTP(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodPost, "/mfa/totp/confirm", strings.NewReader(`{"code":"123456"}`)), 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"recovery_codes":["abcde-fghij"]}`, rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	ConfirmTOTP(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodPost, "/mfa/totp/confirm", strings.NewReader(`{"code":"000000"}`)), 1))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestVerifyLoginMFA(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("VerifyLoginMFA", mock.Anything, domain.MFALoginRequest{MFAToken: "challenge", Code: "123456", ClientIP: "192.0.2.1"}).Return("token", nil).Once()
+	NikPay.On("VerifyLoginMFA", mock.Anything, domain.MFALoginRequest{MFAToken: "challenge", Code: "000000", ClientIP: "192.0.2.1"}).Return("", errors.ErrInvalidMFACode).Once()
+
+	rw := httptest.NewRecorder()
+	VerifyLoginMFA(NikPay)(rw, httptest.NewRequest(http.MethodPost, "/login/mfa", strings.NewReader(`{"mfa_token":"challenge","code":"123456"}`)))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"message":"User Logged In Successfully","token":"token"}`, rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	VerifyLoginMFA(NikPay)(rw, httptest.NewRequest(http.MethodPost, "/login/mfa", strings.NewReader(`{"mfa_token":"challenge","code":"000000"}`)))
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestDebitWalletStepUp(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("DebitWallet", mock.Anything, int64(1), 5000.0).Return(domain.FeeQuote{}, errors.ErrStepUpRequired).Once()
+
+	rw := httptest.NewRecorder()
+	DebitWallet(NikPay)(rw, withUserID(httptest.NewRequest(http.MethodPost, "/wallet/debit", strings.NewReader(`{"amount":5000}`)), 1))
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+	assert.JSONEq(t, `{"message":"two-factor code required for this amount"}`, rw.Body.String())
+	NikPay.AssertExpectations(t)
+}
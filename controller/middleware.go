@@ -2,13 +2,34 @@ package controller
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"net/http"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/metrics"
+	"nickPay/wallet/internal/service"
+	"nickPay/wallet/internal/tracing"
+	"regexp"
 	"strings"
+	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	logger "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+var tracer = otel.Tracer("nickPay/wallet/internal/controller")
+
+// authMiddleware accepts access tokens whose session version is still
+// current, so changing the password signs out every earlier session.
+func authMiddleware(NikPay service.WalletService, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		header := req.Header.Get("Authorization")
 
@@ -19,38 +40,204 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Parse the JWT token from the header
-		token, err := jwt.Parse(strings.TrimPrefix(header, "Bearer "), func(token *jwt.Token) (interface{}, error) {
-			// Check the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-
-			// Set the secret key for the token
-			return []byte("secret@987"), nil
-		})
-
-		// Check if there was an error parsing the token
+		_, span := tracer.Start(req.Context(), "authMiddleware.ParseJWT")
+		userID, sessionVersion, err := service.ParseAccessToken(strings.TrimPrefix(header, "Bearer "))
+		tracing.End(span, &err)
 		if err != nil {
 			http.Error(rw, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
 
-		// Check if the token is valid and has not expired
-		if !token.Valid {
+		err = NikPay.ValidateSession(req.Context(), userID, sessionVersion)
+		if err == errors.ErrSessionExpired {
 			http.Error(rw, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
-		// Get the claims from the token
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		if err != nil {
+			http.Error(rw, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 
-		ctx := context.WithValue(context.Background(), "id", claims["user_id"])
+		// Derive from the request context so handlers see client disconnects
+		// and server shutdown
+		ctx := context.WithValue(req.Context(), "id", userID)
+		logging.AddFields(ctx, logger.Fields{"user_id": userID})
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("enduser.id", userID))
 		req = req.WithContext(ctx)
 
 		// Call the next handler in the chain
 		next.ServeHTTP(rw, req)
 	})
-}
\ No newline at end of file
+}
+
+// adminMiddleware only lets through requests bearing the configured admin
+// token.
+func adminMiddleware(adminToken string, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		header := req.Header.Get("Authorization")
+		token := strings.TrimPrefix(header, "Bearer ")
+		if !strings.HasPrefix(header, "Bearer ") || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		logging.AddFields(req.Context(), logger.Fields{"admin": true})
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// deprecated flags responses of routes superseded by the same path under
+// prefix with a Deprecation header and a Link to their successor.
+func deprecated(prefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("Deprecation", "true")
+			rw.Header().Set("Link", "<"+prefix+req.URL.Path+`>; rel="successor-version"`)
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID limits what we accept from clients so an arbitrary header
+// value can not be used to forge or bloat log lines.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// routeTemplate returns the mux path template that matched req, falling back
+// to the raw path. Templates keep log and metric labels low-cardinality.
+func routeTemplate(req *http.Request) string {
+	if current := mux.CurrentRoute(req); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return req.URL.Path
+}
+
+// statusRecorder captures the status code and body size written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// streaming handlers need to flush and lift the write deadline.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// requestLogger assigns every request an ID, taken from X-Request-ID when the
+// caller supplies a sane one, echoes it back, and stores a logger carrying it
+// in the request context. Once the handler returns it writes one access log
+// line with the route, status and latency. Bodies, query strings and headers
+// are never logged since they may carry passwords or tokens.
+func requestLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+
+		requestID := req.Header.Get(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		rw.Header().Set(requestIDHeader, requestID)
+
+		route := routeTemplate(req)
+
+		ctx := logging.WithLogger(req.Context(), logger.WithFields(logger.Fields{
+			"request_id": requestID,
+			"method":     req.Method,
+			"route":      route,
+		}))
+		recorder := &statusRecorder{ResponseWriter: rw}
+		next.ServeHTTP(recorder, req.WithContext(ctx))
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		entry := logging.FromContext(ctx).WithFields(logger.Fields{
+			"status":     recorder.status,
+			"bytes":      recorder.bytes,
+			"latency_ms": time.Since(start).Milliseconds(),
+		})
+		switch {
+		case recorder.status >= http.StatusInternalServerError:
+			entry.Error("Request failed")
+		case recorder.status >= http.StatusBadRequest:
+			entry.Warn("Request rejected")
+		default:
+			entry.Info("Request completed")
+		}
+	})
+}
+
+// metricsMiddleware counts requests and observes their latency per route
+// template, method and status code.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: rw}
+		next.ServeHTTP(recorder, req)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		metrics.ObserveHTTPRequest(routeTemplate(req), req.Method, recorder.status, time.Since(start))
+	})
+}
+
+// tracingMiddleware continues the trace described by the incoming W3C
+// traceparent header, or starts a new one, and wraps the request in a server
+// span named after its route template. The trace ID is added to the request
+// logger so log lines can be matched with traces.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		route := routeTemplate(req)
+		ctx := otel.GetTextMapPropagator().Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := tracer.Start(ctx, req.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.HTTPRoute(route),
+			),
+		)
+		defer span.End()
+
+		if span.SpanContext().HasTraceID() {
+			logging.AddFields(ctx, logger.Fields{"trace_id": span.SpanContext().TraceID().String()})
+		}
+
+		recorder := &statusRecorder{ResponseWriter: rw}
+		next.ServeHTTP(recorder, req.WithContext(ctx))
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
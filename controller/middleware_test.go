@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/service/mocks"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	logger "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func signedToken(t *testing.T, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret@987"))
+	assert.NoError(t, err)
+	return token
+}
+
+// currentSessions is a WalletService that accepts every session.
+func currentSessions() *mocks.WalletService {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("ValidateSession", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	return NikPay
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	NikPay := currentSessions()
+	var gotID interface{}
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		gotID = r.Context().Value("id")
+		rw.WriteHeader(http.StatusOK)
+	}
+
+	t.Run("Valid token puts int64 user ID in context", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		req.Header.Set("Authorization", "Bearer "+signedToken(t, jwt.MapClaims{"user_id": 42, "exp": time.Now().Add(time.Minute).Unix()}))
+		rw := httptest.NewRecorder()
+
+		authMiddleware(NikPay, next).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, int64(42), gotID)
+	})
+
+	t.Run("Missing header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		rw := httptest.NewRecorder()
+
+		authMiddleware(NikPay, next).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		req.Header.Set("Authorization", "Bearer "+signedToken(t, jwt.MapClaims{"user_id": 42, "exp": time.Now().Add(-time.Minute).Unix()}))
+		rw := httptest.NewRecorder()
+
+		authMiddleware(NikPay, next).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	})
+
+	t.Run("Token from before a password change", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("ValidateSession", mock.Anything, int64(42), int64(1)).Return(errors.ErrSessionExpired).Once()
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		req.Header.Set("Authorization", "Bearer "+signedToken(t, jwt.MapClaims{"user_id": 42, "sv": 1, "exp": time.Now().Add(time.Minute).Unix()}))
+		rw := httptest.NewRecorder()
+
+		authMiddleware(NikPay, next).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		NikPay.AssertExpectations(t)
+	})
+}
+
+func TestRequestLogger(t *testing.T) {
+	var entryData logger.Fields
+	next := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		entryData = logging.FromContext(r.Context()).Data
+		rw.WriteHeader(http.StatusTeapot)
+	})
+
+	t.Run("Accepts caller supplied request ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		req.Header.Set("X-Request-ID", "abc-123")
+		rw := httptest.NewRecorder()
+
+		requestLogger(next).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusTeapot, rw.Code)
+		assert.Equal(t, "abc-123", rw.Header().Get("X-Request-ID"))
+		assert.Equal(t, "abc-123", entryData["request_id"])
+		assert.Equal(t, "/wallet", entryData["route"])
+	})
+
+	t.Run("Replaces malformed request ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		req.Header.Set("X-Request-ID", "bad id\nwith newline")
+		rw := httptest.NewRecorder()
+
+		requestLogger(next).ServeHTTP(rw, req)
+		got := rw.Header().Get("X-Request-ID")
+		assert.Len(t, got, 32)
+		assert.Equal(t, got, entryData["request_id"])
+	})
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(metricsMiddleware)
+	router.HandleFunc("/wallet/{id}", func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	req := httptest.NewRequest(http.MethodPost, "/wallet/7", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rw.Body.String(), `wallet_http_requests_total{method="POST",route="/wallet/{id}",status="201"} 1`)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	router := mux.NewRouter()
+	router.Use(tracingMiddleware)
+	router.HandleFunc("/wallet", authMiddleware(currentSessions(), func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})).Methods("GET")
+
+	req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("Authorization", "Bearer "+signedToken(t, jwt.MapClaims{"user_id": 42, "exp": time.Now().Add(time.Minute).Unix()}))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	names := []string{spans[0].Name(), spans[1].Name()}
+	assert.ElementsMatch(t, []string{"authMiddleware.ParseJWT", "GET /wallet"}, names)
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	}
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec documents every route under apiVersion. Keep it in step with
+// registerAPIRoutes, TestOpenAPISpec checks handler responses against it.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// OpenAPISpec serves the OpenAPI 3 description of the API.
+func OpenAPISpec() http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusOK)
+		rw.Write(openAPISpec)
+	})
+}
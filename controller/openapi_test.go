@@ -0,0 +1,637 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/events"
+	"nickPay/wallet/internal/service/mocks"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPIDoc is the part of an OpenAPI 3 document the tests check responses
+// against.
+type openAPIDoc struct {
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Responses map[string]openAPIResponse `json:"responses"`
+		Schemas   map[string]*jsonSchema     `json:"schemas"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	Responses map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIResponse struct {
+	Ref     string `json:"$ref"`
+	Content map[string]struct {
+		Schema *jsonSchema `json:"schema"`
+	} `json:"content"`
+}
+
+// jsonSchema is the subset of OpenAPI schema objects used by openapi.json.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Format               string                 `json:"format"`
+	Enum                 []interface{}          `json:"enum"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	OneOf                []*jsonSchema          `json:"oneOf"`
+	Nullable             bool                   `json:"nullable"`
+}
+
+func loadOpenAPIDoc(t *testing.T) openAPIDoc {
+	var doc openAPIDoc
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	return doc
+}
+
+func (doc openAPIDoc) response(ref openAPIResponse) openAPIResponse {
+	if ref.Ref == "" {
+		return ref
+	}
+	return doc.Components.Responses[strings.TrimPrefix(ref.Ref, "#/components/responses/")]
+}
+
+// validate returns an error describing the first way value does not match s.
+func (doc openAPIDoc) validate(s *jsonSchema, value interface{}, at string) error {
+	if s.Ref != "" {
+		resolved, ok := doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", at, s.Ref)
+		}
+		return doc.validate(resolved, value, at)
+	}
+	if value == nil {
+		if s.Nullable {
+			return nil
+		}
+		return fmt.Errorf("%s: is null", at)
+	}
+	if len(s.OneOf) > 0 {
+		matches := 0
+		for _, option := range s.OneOf {
+			if doc.validate(option, value, at) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: matches %d of the oneOf schemas", at, matches)
+		}
+		return nil
+	}
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			found = found || allowed == value
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", at, value, s.Enum)
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %v is not an object", at, value)
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return fmt.Errorf("%s: missing required property %s", at, name)
+			}
+		}
+		for name, property := range object {
+			propertySchema, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %s", at, name)
+				}
+				continue
+			}
+			if err := doc.validate(propertySchema, property, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %v is not an array", at, value)
+		}
+		for i, item := range array {
+			if err := doc.validate(s.Items, item, at+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: %v is not a string", at, value)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", at, str)
+			}
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			return fmt.Errorf("%s: %v is not an integer", at, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: %v is not a number", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: %v is not a boolean", at, value)
+		}
+	}
+	return nil
+}
+
+var routeVariablePattern = regexp.MustCompile(`\{([^}:]+):[^}]+\}`)
+
+// specPath turns a mux path template into its OpenAPI form by dropping the
+// patterns of route variables.
+func specPath(template string) string {
+	return routeVariablePattern.ReplaceAllString(template, "{$1}")
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	doc := loadOpenAPIDoc(t)
+	userToken := signedToken(t, jwt.MapClaims{"user_id": 42, "sv": 1, "exp": time.Now().Add(time.Minute).Unix()})
+	periodStart := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	statement := domain.Statement{
+		WalletID:       7,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodStart.AddDate(0, 1, 0),
+		OpeningBalance: 100,
+		TotalCredits:   50,
+		ClosingBalance: 150,
+		Entries: []domain.StatementEntry{
+			{TransactionID: 1, Date: periodStart.Add(time.Hour), Type: domain.TransactionCredit, Description: "Wallet credit", Credit: 50, Balance: 150},
+		},
+	}
+	profile := domain.UserProfile{ID: 42, Name: "John", Email: "john@mail.com", PhoneNumber: "+918123467890", EmailVerified: true, PendingEmail: "new@mail.com"}
+	pendingTopUp := domain.TopUp{ID: 3, UserID: 42, Amount: 50, Status: domain.TopUpStatusPending, CheckoutURL: "https://checkout.fake.invalid/pay/fake_1", CreatedAt: periodStart}
+	completedAt := periodStart.Add(time.Minute)
+	bankAccount := domain.BankAccount{ID: 5, UserID: 42, HolderName: "John Doe", AccountNumber: "123456789012", IFSC: "SBIN0001234", CreatedAt: periodStart}
+	withdrawalFee := []domain.FeeComponent{{Kind: domain.FeeFlat, Amount: 1}}
+	pendingWithdrawal := domain.Withdrawal{ID: 9, UserID: 42, BankAccountID: 5, Amount: 50, Fee: 1, Status: domain.WithdrawalStatusPending, CreatedAt: periodStart, FeeBreakdown: withdrawalFee}
+	debitQuote := domain.FeeQuote{Operation: domain.FeeOperationDebit, Amount: 50, Fee: 1.75, Total: 51.75, Breakdown: []domain.FeeComponent{
+		{Kind: domain.FeeFlat, Amount: 0.25},
+		{Kind: domain.FeePercentage, Rate: 3, Amount: 1.5},
+	}}
+	campaign := domain.Campaign{ID: 3, Name: "Week cashback", Operation: domain.CampaignOperationDebit, Percent: 5, MaxReward: 100, Budget: 5000, Spent: 250, RewardValidityDays: 30, StartsAt: periodStart, EndsAt: periodStart.AddDate(0, 0, 7), CreatedAt: periodStart}
+	promoBalance := domain.PromoBalance{Balance: 50, Rewards: []domain.PromoReward{{ID: 1, UserID: 42, CampaignID: 3, CampaignName: "Week cashback", SourceAmount: 1000, Amount: 50, ExpiresAt: periodStart.AddDate(0, 1, 0), CreatedAt: periodStart}}}
+	merchant := domain.Merchant{ID: 12, UserID: 42, Name: "Corner Cafe", CreatedAt: periodStart}
+	staticQR := domain.PaymentQR{MerchantID: 12, Kind: domain.PaymentQRStatic, Payload: "nikpay://pay?merchant=12"}
+	qrExpiresAt := periodStart.Add(15 * time.Minute)
+	paymentQR := domain.PaymentQR{ID: 34, MerchantID: 12, Kind: domain.PaymentQRDynamic, Amount: 60, Reference: "INV-1", Status: domain.PaymentQRStatusPending, ExpiresAt: &qrExpiresAt,
+		Payload: "nikpay://pay?amount=60.00&expires=1714523400&merchant=12&qr=34&ref=INV-1"}
+	merchantPayment := domain.MerchantPayment{ID: 56, MerchantID: 12, MerchantName: "Corner Cafe", PayerID: 43, QRID: 34, Amount: 60, Reference: "INV-1", CreatedAt: periodStart}
+	settlementReport := domain.SettlementReport{MerchantID: 12, From: periodStart, To: periodStart.AddDate(0, 1, 0), Payments: 1, Amount: 60, Days: []domain.SettlementDay{{Date: "2024-05-01", Payments: 1, Amount: 60}}}
+	payoutBatch := domain.PayoutBatch{ID: 3, Mode: domain.PayoutModeAllOrNothing, Status: domain.PayoutStatusPending, CreatedBy: "admin", CreatedAt: periodStart, TotalRows: 1, TotalAmount: 10, PendingRows: 1}
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		token   string
+		prepare func(*mocks.WalletService)
+		status  int
+	}{
+		{name: "healthz", method: "GET", path: "/healthz", status: http.StatusOK},
+		{name: "readyz", method: "GET", path: "/readyz", status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("Ping", mock.Anything).Return(nil)
+		}},
+		{name: "readyz without database", method: "GET", path: "/readyz", status: http.StatusServiceUnavailable, prepare: func(m *mocks.WalletService) {
+			m.On("Ping", mock.Anything).Return(errors.ErrFetchingWallet)
+		}},
+		{name: "openapi", method: "GET", path: "/v1/openapi.json", status: http.StatusOK},
+		{name: "register", method: "POST", path: "/v1/register", body: `{"name":"John","email":"john@mail.com","phone_number":"8123467890","password":"pass"}`, status: http.StatusCreated, prepare: func(m *mocks.WalletService) {
+			m.On("RegisterUser", mock.Anything, mock.Anything).Return(nil)
+		}},
+		{name: "register with invalid fields", method: "POST", path: "/v1/register", body: `{"name":"John"}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("RegisterUser", mock.Anything, mock.Anything).Return(errors.ValidationErrors{{Field: "email", Code: errors.CodeRequired, Err: errors.ErrInvalidEmail}})
+		}},
+		{name: "register with wrong type", method: "POST", path: "/v1/register", body: `{"name":1}`, status: http.StatusBadRequest},
+		{name: "login", method: "POST", path: "/v1/login", body: `{"email":"john@mail.com","password":"pass"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("LoginUser", mock.Anything, mock.Anything).Return("token", nil)
+		}},
+		{name: "login requiring mfa", method: "POST", path: "/v1/login", body: `{"email":"john@mail.com","password":"pass"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("LoginUser", mock.Anything, mock.Anything).Return("mfa-token", errors.ErrMFARequired)
+		}},
+		{name: "login with wrong password", method: "POST", path: "/v1/login", body: `{"email":"john@mail.com","password":"wrong"}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("LoginUser", mock.Anything, mock.Anything).Return("", errors.ErrInvalidPassword)
+		}},
+		{name: "login with malformed body", method: "POST", path: "/v1/login", body: `{`, status: http.StatusBadRequest},
+		{name: "login locked", method: "POST", path: "/v1/login", body: `{"email":"john@mail.com","password":"pass"}`, status: http.StatusTooManyRequests, prepare: func(m *mocks.WalletService) {
+			m.On("LoginUser", mock.Anything, mock.Anything).Return("", errors.ErrLoginLocked)
+		}},
+		{name: "login mfa", method: "POST", path: "/v1/login/mfa", body: `{"mfa_token":"t","code":"123456"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("VerifyLoginMFA", mock.Anything, mock.Anything).Return("token", nil)
+		}},
+		{name: "login mfa with wrong code", method: "POST", path: "/v1/login/mfa", body: `{"mfa_token":"t","code":"000000"}`, status: http.StatusUnauthorized, prepare: func(m *mocks.WalletService) {
+			m.On("VerifyLoginMFA", mock.Anything, mock.Anything).Return("", errors.ErrInvalidMFACode)
+		}},
+		{name: "forgot password", method: "POST", path: "/v1/password/forgot", body: `{"email":"john@mail.com"}`, status: http.StatusAccepted, prepare: func(m *mocks.WalletService) {
+			m.On("ForgotPassword", mock.Anything, "john@mail.com").Return(nil)
+		}},
+		{name: "reset password", method: "POST", path: "/v1/password/reset", body: `{"token":"t","new_password":"new"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("ResetPassword", mock.Anything, mock.Anything).Return(nil)
+		}},
+		{name: "reset password with invalid token", method: "POST", path: "/v1/password/reset", body: `{"token":"t","new_password":"new"}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("ResetPassword", mock.Anything, mock.Anything).Return(errors.ErrInvalidResetToken)
+		}},
+		{name: "change password", method: "POST", path: "/v1/password/change", token: userToken, body: `{"current_password":"old","new_password":"new"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("ChangePassword", mock.Anything, int64(42), mock.Anything).Return(nil)
+		}},
+		{name: "change password with wrong password", method: "POST", path: "/v1/password/change", token: userToken, body: `{"current_password":"bad","new_password":"new"}`, status: http.StatusForbidden, prepare: func(m *mocks.WalletService) {
+			m.On("ChangePassword", mock.Anything, int64(42), mock.Anything).Return(errors.ErrIncorrectPassword)
+		}},
+		{name: "change password signed out", method: "POST", path: "/v1/password/change", body: `{}`, status: http.StatusUnauthorized},
+		{name: "get profile", method: "GET", path: "/v1/me", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetProfile", mock.Anything, int64(42)).Return(profile, nil)
+		}},
+		{name: "update profile", method: "PATCH", path: "/v1/me", token: userToken, body: `{"name":"Johnny"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("UpdateProfile", mock.Anything, int64(42), mock.Anything).Return(profile, nil)
+		}},
+		{name: "update profile to taken email", method: "PATCH", path: "/v1/me", token: userToken, body: `{"email":"taken@mail.com"}`, status: http.StatusConflict, prepare: func(m *mocks.WalletService) {
+			m.On("UpdateProfile", mock.Anything, int64(42), mock.Anything).Return(domain.UserProfile{}, errors.ErrEmailTaken)
+		}},
+		{name: "delete account", method: "DELETE", path: "/v1/me", token: userToken, status: http.StatusNoContent, prepare: func(m *mocks.WalletService) {
+			m.On("DeleteAccount", mock.Anything, int64(42)).Return(nil)
+		}},
+		{name: "delete account with balance", method: "DELETE", path: "/v1/me", token: userToken, status: http.StatusConflict, prepare: func(m *mocks.WalletService) {
+			m.On("DeleteAccount", mock.Anything, int64(42)).Return(errors.ErrNonZeroBalance)
+		}},
+		{name: "confirm email change", method: "POST", path: "/v1/me/email/confirm", token: userToken, body: `{"code":"123456"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("ConfirmEmailChange", mock.Anything, int64(42), "123456").Return(nil)
+		}},
+		{name: "enroll totp", method: "POST", path: "/v1/mfa/totp/enroll", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("EnrollTOTP", mock.Anything, int64(42)).Return(domain.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/NikPay:john@mail.com?secret=SECRET"}, nil)
+		}},
+		{name: "confirm totp", method: "POST", path: "/v1/mfa/totp/confirm", token: userToken, body: `{"code":"123456"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("ConfirmTOTP", mock.Anything, int64(42), "123456").Return([]string{"abcd-efgh"}, nil)
+		}},
+		{name: "verify email", method: "POST", path: "/v1/verify/email", token: userToken, body: `{"code":"123456"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("VerifyContact", mock.Anything, int64(42), "email", "123456").Return(nil)
+		}},
+		{name: "resend phone code", method: "POST", path: "/v1/verify/phone/resend", token: userToken, status: http.StatusAccepted, prepare: func(m *mocks.WalletService) {
+			m.On("ResendVerification", mock.Anything, int64(42), "phone").Return(nil)
+		}},
+		{name: "resend code when verified", method: "POST", path: "/v1/verify/phone/resend", token: userToken, status: http.StatusConflict, prepare: func(m *mocks.WalletService) {
+			m.On("ResendVerification", mock.Anything, int64(42), "phone").Return(errors.ErrAlreadyVerified)
+		}},
+		{name: "resend code after too many wrong ones", method: "POST", path: "/v1/verify/phone/resend", token: userToken, status: http.StatusTooManyRequests, prepare: func(m *mocks.WalletService) {
+			m.On("ResendVerification", mock.Anything, int64(42), "phone").Return(errors.ErrTooManyVerificationAttempts)
+		}},
+		{name: "get wallet", method: "GET", path: "/v1/wallet", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetWallet", mock.Anything, int64(42)).Return(domain.Wallet{ID: 7, UserID: 42, Balance: 150, CreationDate: "2024-05-01 10:00:00", LastUpdated: "2024-05-01 11:00:00", Status: domain.WalletStatusActive}, nil)
+		}},
+		{name: "credit wallet", method: "POST", path: "/v1/wallet/credit", token: userToken, body: `{"amount":50}`, status: http.StatusAccepted, prepare: func(m *mocks.WalletService) {
+			m.On("CreditWallet", mock.Anything, int64(42), float64(50)).Return(pendingTopUp, nil)
+		}},
+		{name: "credit wallet with invalid amount", method: "POST", path: "/v1/wallet/credit", token: userToken, body: `{"amount":-1}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("CreditWallet", mock.Anything, int64(42), float64(-1)).Return(domain.TopUp{}, errors.ValidationErrors{{Field: "amount", Code: errors.CodeNotPositive, Err: errors.ErrAmountNotPositive}})
+		}},
+		{name: "credit unverified wallet", method: "POST", path: "/v1/wallet/credit", token: userToken, body: `{"amount":50}`, status: http.StatusForbidden, prepare: func(m *mocks.WalletService) {
+			m.On("CreditWallet", mock.Anything, int64(42), float64(50)).Return(domain.TopUp{}, errors.ErrUnverifiedUser)
+		}},
+		{name: "credit wallet without payment gateway", method: "POST", path: "/v1/wallet/credit", token: userToken, body: `{"amount":50}`, status: http.StatusServiceUnavailable, prepare: func(m *mocks.WalletService) {
+			m.On("CreditWallet", mock.Anything, int64(42), float64(50)).Return(domain.TopUp{}, errors.ErrNoPaymentGateway)
+		}},
+		{name: "get top-up", method: "GET", path: "/v1/wallet/topups/3", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			settled := pendingTopUp
+			settled.Status, settled.CompletedAt = domain.TopUpStatusSucceeded, &completedAt
+			m.On("GetTopUp", mock.Anything, int64(42), int64(3)).Return(settled, nil)
+		}},
+		{name: "get top-up without token", method: "GET", path: "/v1/wallet/topups/3", status: http.StatusUnauthorized},
+		{name: "get unknown top-up", method: "GET", path: "/v1/wallet/topups/3", token: userToken, status: http.StatusNotFound, prepare: func(m *mocks.WalletService) {
+			m.On("GetTopUp", mock.Anything, int64(42), int64(3)).Return(domain.TopUp{}, errors.ErrTopUpNotFound)
+		}},
+		{name: "get top-up failing", method: "GET", path: "/v1/wallet/topups/3", token: userToken, status: http.StatusInternalServerError, prepare: func(m *mocks.WalletService) {
+			m.On("GetTopUp", mock.Anything, int64(42), int64(3)).Return(domain.TopUp{}, errors.ErrFetchingTopUp)
+		}},
+		{name: "gateway callback", method: "POST", path: "/v1/gateway/callback", body: `{"id":"fake_1","reference":"3","amount":50,"status":"succeeded"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("HandleTopUpCallback", mock.Anything, mock.Anything, mock.Anything).Return(pendingTopUp, nil)
+		}},
+		{name: "gateway callback with bad signature", method: "POST", path: "/v1/gateway/callback", body: `{}`, status: http.StatusUnauthorized, prepare: func(m *mocks.WalletService) {
+			m.On("HandleTopUpCallback", mock.Anything, mock.Anything, mock.Anything).Return(domain.TopUp{}, errors.ErrInvalidGatewaySignature)
+		}},
+		{name: "gateway callback for unknown top-up", method: "POST", path: "/v1/gateway/callback", body: `{}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("HandleTopUpCallback", mock.Anything, mock.Anything, mock.Anything).Return(domain.TopUp{}, errors.ErrTopUpNotFound)
+		}},
+		{name: "gateway callback failing", method: "POST", path: "/v1/gateway/callback", body: `{}`, status: http.StatusInternalServerError, prepare: func(m *mocks.WalletService) {
+			m.On("HandleTopUpCallback", mock.Anything, mock.Anything, mock.Anything).Return(domain.TopUp{}, errors.ErrCompletingTopUp)
+		}},
+		{name: "gateway callback without payment gateway", method: "POST", path: "/v1/gateway/callback", body: `{}`, status: http.StatusServiceUnavailable, prepare: func(m *mocks.WalletService) {
+			m.On("HandleTopUpCallback", mock.Anything, mock.Anything, mock.Anything).Return(domain.TopUp{}, errors.ErrNoPaymentGateway)
+		}},
+		{name: "list bank accounts", method: "GET", path: "/v1/me/bank-accounts", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("ListBankAccounts", mock.Anything, int64(42)).Return([]domain.BankAccount{bankAccount}, nil)
+		}},
+		{name: "list bank accounts without token", method: "GET", path: "/v1/me/bank-accounts", status: http.StatusUnauthorized},
+		{name: "list bank accounts failing", method: "GET", path: "/v1/me/bank-accounts", token: userToken, status: http.StatusInternalServerError, prepare: func(m *mocks.WalletService) {
+			m.On("ListBankAccounts", mock.Anything, int64(42)).Return(nil, errors.ErrFetchingBankAccounts)
+		}},
+		{name: "link bank account", method: "POST", path: "/v1/me/bank-accounts", token: userToken, body: `{"holder_name":"John Doe","ifsc":"SBIN0001234","account_number":"123456789012"}`, status: http.StatusCreated, prepare: func(m *mocks.WalletService) {
+			m.On("LinkBankAccount", mock.Anything, int64(42), mock.Anything).Return(bankAccount, nil)
+		}},
+		{name: "link invalid bank account", method: "POST", path: "/v1/me/bank-accounts", token: userToken, body: `{"holder_name":"John Doe","iban":"GB00"}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("LinkBankAccount", mock.Anything, int64(42), mock.Anything).Return(domain.BankAccount{}, errors.ValidationErrors{{Field: "iban", Code: errors.CodeInvalid, Err: errors.ErrInvalidIBAN}})
+		}},
+		{name: "link bank account unverified", method: "POST", path: "/v1/me/bank-accounts", token: userToken, body: `{"holder_name":"John Doe","iban":"GB82WEST12345698765432"}`, status: http.StatusForbidden, prepare: func(m *mocks.WalletService) {
+			m.On("LinkBankAccount", mock.Anything, int64(42), mock.Anything).Return(domain.BankAccount{}, errors.ErrUnverifiedUser)
+		}},
+		{name: "link bank account twice", method: "POST", path: "/v1/me/bank-accounts", token: userToken, body: `{"holder_name":"John Doe","iban":"GB82WEST12345698765432"}`, status: http.StatusConflict, prepare: func(m *mocks.WalletService) {
+			m.On("LinkBankAccount", mock.Anything, int64(42), mock.Anything).Return(domain.BankAccount{}, errors.ErrBankAccountExists)
+		}},
+		{name: "link bank account failing", method: "POST", path: "/v1/me/bank-accounts", token: userToken, body: `{"holder_name":"John Doe","iban":"GB82WEST12345698765432"}`, status: http.StatusInternalServerError, prepare: func(m *mocks.WalletService) {
+			m.On("LinkBankAccount", mock.Anything, int64(42), mock.Anything).Return(domain.BankAccount{}, errors.ErrLinkingBankAccount)
+		}},
+		{name: "withdraw", method: "POST", path: "/v1/wallet/withdrawals", token: userToken, body: `{"bank_account_id":5,"amount":50}`, status: http.StatusAccepted, prepare: func(m *mocks.WalletService) {
+			m.On("Withdraw", mock.Anything, int64(42), domain.WithdrawalRequest{BankAccountID: 5, Amount: 50}).Return(pendingWithdrawal, nil)
+		}},
+		{name: "withdraw to unknown account", method: "POST", path: "/v1/wallet/withdrawals", token: userToken, body: `{"bank_account_id":6,"amount":50}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("Withdraw", mock.Anything, int64(42), mock.Anything).Return(domain.Withdrawal{}, errors.ValidationErrors{{Field: "bank_account_id", Code: errors.CodeNotFound, Err: errors.ErrBankAccountNotFound}})
+		}},
+		{name: "withdraw without step-up", method: "POST", path: "/v1/wallet/withdrawals", token: userToken, body: `{"bank_account_id":5,"amount":50000}`, status: http.StatusForbidden, prepare: func(m *mocks.WalletService) {
+			m.On("Withdraw", mock.Anything, int64(42), mock.Anything).Return(domain.Withdrawal{}, errors.ErrStepUpRequired)
+		}},
+		{name: "withdraw after too many wrong step-up codes", method: "POST", path: "/v1/wallet/withdrawals", token: userToken, body: `{"bank_account_id":5,"amount":50000}`, status: http.StatusTooManyRequests, prepare: func(m *mocks.WalletService) {
+			m.On("Withdraw", mock.Anything, int64(42), mock.Anything).Return(domain.Withdrawal{}, errors.ErrStepUpLocked)
+		}},
+		{name: "withdraw failing", method: "POST", path: "/v1/wallet/withdrawals", token: userToken, body: `{"bank_account_id":5,"amount":50}`, status: http.StatusInternalServerError, prepare: func(m *mocks.WalletService) {
+			m.On("Withdraw", mock.Anything, int64(42), mock.Anything).Return(domain.Withdrawal{}, errors.ErrCreatingWithdrawal)
+		}},
+		{name: "withdraw without payout provider", method: "POST", path: "/v1/wallet/withdrawals", token: userToken, body: `{"bank_account_id":5,"amount":50}`, status: http.StatusServiceUnavailable, prepare: func(m *mocks.WalletService) {
+			m.On("Withdraw", mock.Anything, int64(42), mock.Anything).Return(domain.Withdrawal{}, errors.ErrNoPayoutProvider)
+		}},
+		{name: "withdraw without token", method: "POST", path: "/v1/wallet/withdrawals", body: `{"bank_account_id":5,"amount":50}`, status: http.StatusUnauthorized},
+		{name: "get withdrawal", method: "GET", path: "/v1/wallet/withdrawals/9", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			failed := pendingWithdrawal
+			failed.Status, failed.FailureReason, failed.CompletedAt = domain.WithdrawalStatusFailed, "account closed", &completedAt
+			m.On("GetWithdrawal", mock.Anything, int64(42), int64(9)).Return(failed, nil)
+		}},
+		{name: "get withdrawal without token", method: "GET", path: "/v1/wallet/withdrawals/9", status: http.StatusUnauthorized},
+		{name: "get unknown withdrawal", method: "GET", path: "/v1/wallet/withdrawals/9", token: userToken, status: http.StatusNotFound, prepare: func(m *mocks.WalletService) {
+			m.On("GetWithdrawal", mock.Anything, int64(42), int64(9)).Return(domain.Withdrawal{}, errors.ErrWithdrawalNotFound)
+		}},
+		{name: "get withdrawal failing", method: "GET", path: "/v1/wallet/withdrawals/9", token: userToken, status: http.StatusInternalServerError, prepare: func(m *mocks.WalletService) {
+			m.On("GetWithdrawal", mock.Anything, int64(42), int64(9)).Return(domain.Withdrawal{}, errors.ErrFetchingWithdrawal)
+		}},
+		{name: "debit wallet", method: "POST", path: "/v1/wallet/debit", token: userToken, body: `{"amount":50}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("DebitWallet", mock.Anything, int64(42), float64(50)).Return(debitQuote, nil)
+		}},
+		{name: "debit wallet with insufficient balance", method: "POST", path: "/v1/wallet/debit", token: userToken, body: `{"amount":50}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("DebitWallet", mock.Anything, int64(42), float64(50)).Return(domain.FeeQuote{}, errors.ErrInsufficientBalance)
+		}},
+		{name: "debit wallet without step-up", method: "POST", path: "/v1/wallet/debit", token: userToken, body: `{"amount":50}`, status: http.StatusForbidden, prepare: func(m *mocks.WalletService) {
+			m.On("DebitWallet", mock.Anything, int64(42), float64(50)).Return(domain.FeeQuote{}, errors.ErrStepUpRequired)
+		}},
+		{name: "debit wallet after too many wrong step-up codes", method: "POST", path: "/v1/wallet/debit", token: userToken, body: `{"amount":50}`, status: http.StatusTooManyRequests, prepare: func(m *mocks.WalletService) {
+			m.On("DebitWallet", mock.Anything, int64(42), float64(50)).Return(domain.FeeQuote{}, errors.ErrStepUpLocked)
+		}},
+		{name: "quote fee", method: "POST", path: "/v1/wallet/quote", token: userToken, body: `{"operation":"debit","amount":50}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("QuoteFee", mock.Anything, domain.QuoteRequest{Operation: domain.FeeOperationDebit, Amount: 50}).Return(debitQuote, nil)
+		}},
+		{name: "quote fee of unknown operation", method: "POST", path: "/v1/wallet/quote", token: userToken, body: `{"operation":"transfer","amount":50}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("QuoteFee", mock.Anything, mock.Anything).Return(domain.FeeQuote{}, errors.ValidationErrors{{Field: "operation", Code: errors.CodeInvalid, Err: errors.ErrInvalidFeeOperation}})
+		}},
+		{name: "quote fee without token", method: "POST", path: "/v1/wallet/quote", body: `{"operation":"debit","amount":50}`, status: http.StatusUnauthorized},
+		{name: "promo balance", method: "GET", path: "/v1/wallet/promo", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetPromoBalance", mock.Anything, int64(42)).Return(promoBalance, nil)
+		}},
+		{name: "promo balance failing", method: "GET", path: "/v1/wallet/promo", token: userToken, status: http.StatusInternalServerError, prepare: func(m *mocks.WalletService) {
+			m.On("GetPromoBalance", mock.Anything, int64(42)).Return(domain.PromoBalance{}, errors.ErrFetchingPromoBalance)
+		}},
+		{name: "promo balance without token", method: "GET", path: "/v1/wallet/promo", status: http.StatusUnauthorized},
+		{name: "register merchant", method: "POST", path: "/v1/merchant", token: userToken, body: `{"name":"Corner Cafe"}`, status: http.StatusCreated, prepare: func(m *mocks.WalletService) {
+			m.On("RegisterMerchant", mock.Anything, int64(42), domain.Merchant{Name: "Corner Cafe"}).Return(merchant, nil)
+		}},
+		{name: "register merchant twice", method: "POST", path: "/v1/merchant", token: userToken, body: `{"name":"Corner Cafe"}`, status: http.StatusConflict, prepare: func(m *mocks.WalletService) {
+			m.On("RegisterMerchant", mock.Anything, int64(42), mock.Anything).Return(domain.Merchant{}, errors.ErrMerchantExists)
+		}},
+		{name: "register merchant without name", method: "POST", path: "/v1/merchant", token: userToken, body: `{}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("RegisterMerchant", mock.Anything, int64(42), mock.Anything).Return(domain.Merchant{}, errors.ValidationErrors{{Field: "name", Code: errors.CodeRequired, Err: errors.ErrMerchantNameRequired}})
+		}},
+		{name: "get merchant", method: "GET", path: "/v1/merchant", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetMerchant", mock.Anything, int64(42)).Return(merchant, nil)
+		}},
+		{name: "get missing merchant", method: "GET", path: "/v1/merchant", token: userToken, status: http.StatusNotFound, prepare: func(m *mocks.WalletService) {
+			m.On("GetMerchant", mock.Anything, int64(42)).Return(domain.Merchant{}, errors.ErrMerchantNotFound)
+		}},
+		{name: "merchant wallet", method: "GET", path: "/v1/merchant/wallet", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetMerchantWallet", mock.Anything, int64(42)).Return(domain.Wallet{ID: 5, Balance: 60, CreationDate: "2024-05-01", LastUpdated: "2024-05-01 10:00:00", Status: domain.WalletStatusActive}, nil)
+		}},
+		{name: "merchant wallet without merchant", method: "GET", path: "/v1/merchant/wallet", token: userToken, status: http.StatusNotFound, prepare: func(m *mocks.WalletService) {
+			m.On("GetMerchantWallet", mock.Anything, int64(42)).Return(domain.Wallet{}, errors.ErrMerchantNotFound)
+		}},
+		{name: "static qr", method: "GET", path: "/v1/merchant/qr", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetStaticQR", mock.Anything, int64(42)).Return(staticQR, nil)
+		}},
+		{name: "static qr as png", method: "GET", path: "/v1/merchant/qr?format=png", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetStaticQR", mock.Anything, int64(42)).Return(staticQR, nil)
+		}},
+		{name: "static qr in unknown format", method: "GET", path: "/v1/merchant/qr?format=gif", token: userToken, status: http.StatusBadRequest},
+		{name: "create payment qr", method: "POST", path: "/v1/merchant/qr", token: userToken, body: `{"amount":60,"reference":"INV-1"}`, status: http.StatusCreated, prepare: func(m *mocks.WalletService) {
+			m.On("CreatePaymentQR", mock.Anything, int64(42), domain.PaymentQRRequest{Amount: 60, Reference: "INV-1"}).Return(paymentQR, nil)
+		}},
+		{name: "create payment qr without merchant", method: "POST", path: "/v1/merchant/qr", token: userToken, body: `{"amount":60}`, status: http.StatusNotFound, prepare: func(m *mocks.WalletService) {
+			m.On("CreatePaymentQR", mock.Anything, int64(42), mock.Anything).Return(domain.PaymentQR{}, errors.ErrMerchantNotFound)
+		}},
+		{name: "get payment qr", method: "GET", path: "/v1/merchant/qr/34", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetPaymentQR", mock.Anything, int64(42), int64(34)).Return(paymentQR, nil)
+		}},
+		{name: "get payment qr as svg", method: "GET", path: "/v1/merchant/qr/34?format=svg", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetPaymentQR", mock.Anything, int64(42), int64(34)).Return(paymentQR, nil)
+		}},
+		{name: "get unknown payment qr", method: "GET", path: "/v1/merchant/qr/35", token: userToken, status: http.StatusNotFound, prepare: func(m *mocks.WalletService) {
+			m.On("GetPaymentQR", mock.Anything, int64(42), int64(35)).Return(domain.PaymentQR{}, errors.ErrPaymentQRNotFound)
+		}},
+		{name: "settlement report", method: "GET", path: "/v1/merchant/settlements?from=2024-05-01&to=2024-05-31", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetSettlementReport", mock.Anything, int64(42), mock.Anything, mock.Anything).Return(settlementReport, nil)
+		}},
+		{name: "settlement report for invalid period", method: "GET", path: "/v1/merchant/settlements?to=May", token: userToken, status: http.StatusBadRequest},
+		{name: "pay qr", method: "POST", path: "/v1/pay/qr", token: userToken, body: `{"payload":"nikpay://pay?amount=60.00&expires=1714523400&merchant=12&qr=34&ref=INV-1"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("PayQR", mock.Anything, int64(42), mock.Anything).Return(merchantPayment, nil)
+		}},
+		{name: "pay qr already paid", method: "POST", path: "/v1/pay/qr", token: userToken, body: `{"payload":"nikpay://pay?amount=60.00&expires=1714523400&merchant=12&qr=34&ref=INV-1"}`, status: http.StatusConflict, prepare: func(m *mocks.WalletService) {
+			m.On("PayQR", mock.Anything, int64(42), mock.Anything).Return(domain.MerchantPayment{}, errors.ErrQRAlreadyPaid)
+		}},
+		{name: "pay invalid qr", method: "POST", path: "/v1/pay/qr", token: userToken, body: `{"payload":"nikpay://pay"}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("PayQR", mock.Anything, int64(42), mock.Anything).Return(domain.MerchantPayment{}, errors.ValidationErrors{{Field: "payload", Code: errors.CodeInvalid, Err: errors.ErrInvalidQRPayload}})
+		}},
+		{name: "pay qr without step-up", method: "POST", path: "/v1/pay/qr", token: userToken, body: `{"payload":"nikpay://pay?merchant=12","amount":5000}`, status: http.StatusForbidden, prepare: func(m *mocks.WalletService) {
+			m.On("PayQR", mock.Anything, int64(42), mock.Anything).Return(domain.MerchantPayment{}, errors.ErrStepUpRequired)
+		}},
+		{name: "pay qr after too many wrong step-up codes", method: "POST", path: "/v1/pay/qr", token: userToken, body: `{"payload":"nikpay://pay?merchant=12","amount":5000}`, status: http.StatusTooManyRequests, prepare: func(m *mocks.WalletService) {
+			m.On("PayQR", mock.Anything, int64(42), mock.Anything).Return(domain.MerchantPayment{}, errors.ErrStepUpLocked)
+		}},
+		{name: "pay qr without token", method: "POST", path: "/v1/pay/qr", body: `{"payload":"nikpay://pay?merchant=12","amount":20}`, status: http.StatusUnauthorized},
+		{name: "json statement", method: "GET", path: "/v1/wallet/statements?month=2024-05", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GenerateStatement", mock.Anything, int64(42), mock.Anything).Return(statement, nil)
+		}},
+		{name: "csv statement", method: "GET", path: "/v1/wallet/statements?month=2024-05&format=csv", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GenerateStatement", mock.Anything, int64(42), mock.Anything).Return(statement, nil)
+		}},
+		{name: "statement for invalid month", method: "GET", path: "/v1/wallet/statements?month=May", token: userToken, status: http.StatusBadRequest},
+		{name: "graphql", method: "POST", path: "/v1/graphql", token: userToken, body: `{"query":"{ me { name } wallet { balance } activity(first: 1) { nodes { id } } }"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetProfile", mock.Anything, int64(42)).Return(profile, nil)
+			m.On("GetWallet", mock.Anything, int64(42)).Return(domain.Wallet{ID: 7, UserID: 42, Balance: 150}, nil)
+			m.On("ListActivity", mock.Anything, int64(42), 1, "").Return(domain.ActivityPage{}, errors.ErrFetchingTransactions)
+		}},
+		{name: "wallet events", method: "GET", path: "/v1/wallet/events", token: userToken, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetWallet", mock.Anything, int64(42)).Return(domain.Wallet{ID: 7, Balance: 150, Status: domain.WalletStatusActive}, nil)
+		}},
+		{name: "wallet events without wallet", method: "GET", path: "/v1/wallet/events", token: userToken, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("GetWallet", mock.Anything, int64(42)).Return(domain.Wallet{}, errors.ErrFetchingWallet)
+		}},
+		{name: "graphql with invalid query", method: "POST", path: "/v1/graphql", token: userToken, body: `{"query":"{ me { password } }"}`, status: http.StatusBadRequest},
+		{name: "unlock login", method: "POST", path: "/v1/admin/login/unlock", token: "admin-token", body: `{"email":"john@mail.com"}`, status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("UnlockLogin", mock.Anything, domain.UnlockLoginRequest{Email: "john@mail.com"}).Return(nil)
+		}},
+		{name: "unlock login without admin token", method: "POST", path: "/v1/admin/login/unlock", token: userToken, body: `{}`, status: http.StatusUnauthorized},
+		{name: "create payout batch", method: "POST", path: "/v1/admin/payouts", token: "admin-token", body: `[{"recipient":"john@mail.com","amount":10,"reference":"may-1"}]`, status: http.StatusAccepted, prepare: func(m *mocks.WalletService) {
+			m.On("CreatePayoutBatch", mock.Anything, mock.Anything).Return(payoutBatch, nil)
+			m.On("ExecutePayoutBatch", mock.Anything, int64(3)).Return(payoutBatch, nil).Maybe()
+		}},
+		{name: "create payout batch with invalid rows", method: "POST", path: "/v1/admin/payouts", token: "admin-token", body: `[{"recipient":"nobody@mail.com","amount":10,"reference":"may-1"}]`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("CreatePayoutBatch", mock.Anything, mock.Anything).Return(domain.PayoutBatch{}, errors.ValidationErrors{{Field: "rows[1].recipient", Code: errors.CodeNotFound, Err: errors.ErrUnknownRecipient}})
+		}},
+		{name: "create campaign", method: "POST", path: "/v1/admin/campaigns", token: "admin-token", body: `{"name":"Week cashback","operation":"debit","percent":5,"max_reward":100,"budget":5000,"reward_validity_days":30,"starts_at":"2024-05-01T00:00:00Z","ends_at":"2024-05-08T00:00:00Z"}`, status: http.StatusCreated, prepare: func(m *mocks.WalletService) {
+			m.On("CreateCampaign", mock.Anything, mock.Anything).Return(campaign, nil)
+		}},
+		{name: "create invalid campaign", method: "POST", path: "/v1/admin/campaigns", token: "admin-token", body: `{"name":"Week cashback"}`, status: http.StatusBadRequest, prepare: func(m *mocks.WalletService) {
+			m.On("CreateCampaign", mock.Anything, mock.Anything).Return(domain.Campaign{}, errors.ValidationErrors{{Field: "percent", Code: errors.CodeInvalid, Err: errors.ErrInvalidCashbackPercent}})
+		}},
+		{name: "create campaign failing", method: "POST", path: "/v1/admin/campaigns", token: "admin-token", body: `{"name":"Week cashback"}`, status: http.StatusInternalServerError, prepare: func(m *mocks.WalletService) {
+			m.On("CreateCampaign", mock.Anything, mock.Anything).Return(domain.Campaign{}, errors.ErrCreatingCampaign)
+		}},
+		{name: "create campaign without admin token", method: "POST", path: "/v1/admin/campaigns", token: userToken, body: `{}`, status: http.StatusUnauthorized},
+		{name: "list campaigns", method: "GET", path: "/v1/admin/campaigns", token: "admin-token", status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("ListCampaigns", mock.Anything).Return([]domain.Campaign{campaign}, nil)
+		}},
+		{name: "get payout batch", method: "GET", path: "/v1/admin/payouts/3", token: "admin-token", status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetPayoutBatch", mock.Anything, int64(3)).Return(payoutBatch, nil)
+		}},
+		{name: "get unknown payout batch", method: "GET", path: "/v1/admin/payouts/4", token: "admin-token", status: http.StatusNotFound, prepare: func(m *mocks.WalletService) {
+			m.On("GetPayoutBatch", mock.Anything, int64(4)).Return(domain.PayoutBatch{}, errors.ErrPayoutBatchNotFound)
+		}},
+		{name: "json payout report", method: "GET", path: "/v1/admin/payouts/3/report?format=json", token: "admin-token", status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetPayoutReport", mock.Anything, int64(3)).Return([]domain.PayoutRow{
+				{Line: 1, Recipient: "john@mail.com", UserID: 42, Amount: 10, Reference: "may-1", Status: domain.PayoutStatusPaid},
+				{Line: 2, Recipient: "jane@mail.com", UserID: 43, Amount: 5, Reference: "may-2", Status: domain.PayoutStatusFailed, Error: errors.ErrWalletFrozen.Error()},
+			}, nil)
+		}},
+		{name: "csv payout report", method: "GET", path: "/v1/admin/payouts/3/report", token: "admin-token", status: http.StatusOK, prepare: func(m *mocks.WalletService) {
+			m.On("GetPayoutReport", mock.Anything, int64(3)).Return([]domain.PayoutRow{}, nil)
+		}},
+	}
+
+	// A closed broker ends event streams right after they open
+	broker := events.NewBroker()
+	broker.Close()
+	exercised := map[string]bool{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			NikPay := &mocks.WalletService{}
+			NikPay.On("ValidateSession", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+			if test.prepare != nil {
+				test.prepare(NikPay)
+			}
+			router := InitRouter(&Dependencies{NikPay: NikPay}, RouterConfig{AdminToken: "admin-token", Events: broker})
+
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			var match mux.RouteMatch
+			require.True(t, router.Match(req, &match))
+			template, err := match.Route.GetPathTemplate()
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			require.Equal(t, test.status, rr.Code, rr.Body.String())
+			NikPay.AssertExpectations(t)
+
+			path := specPath(template)
+			operation, ok := doc.Paths[path][strings.ToLower(test.method)]
+			require.True(t, ok, "%s %s is not documented", test.method, path)
+			exercised[test.method+" "+path] = true
+			response, ok := operation.Responses[strconv.Itoa(rr.Code)]
+			require.True(t, ok, "%d is not documented for %s %s", rr.Code, test.method, path)
+			response = doc.response(response)
+
+			body, err := io.ReadAll(rr.Body)
+			require.NoError(t, err)
+			if len(response.Content) == 0 {
+				assert.Empty(t, body)
+				return
+			}
+			mediaType, _, err := mime.ParseMediaType(rr.Header().Get("Content-Type"))
+			require.NoError(t, err)
+			content, ok := response.Content[mediaType]
+			require.True(t, ok, "%s is not documented for %d", mediaType, rr.Code)
+			if mediaType != "application/json" {
+				return
+			}
+			var value interface{}
+			require.NoError(t, json.Unmarshal(body, &value))
+			assert.NoError(t, doc.validate(content.Schema, value, "body"))
+		})
+	}
+
+	for path, operations := range doc.Paths {
+		for method := range operations {
+			assert.True(t, exercised[strings.ToUpper(method)+" "+path], "no response of %s %s was checked", strings.ToUpper(method), path)
+		}
+	}
+}
+
+// TestOpenAPISpecCoversRoutes checks that every versioned route is documented.
+func TestOpenAPISpecCoversRoutes(t *testing.T) {
+	doc := loadOpenAPIDoc(t)
+	router := InitRouter(&Dependencies{NikPay: &mocks.WalletService{}}, RouterConfig{AdminToken: "admin-token", Events: events.NewBroker()})
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(template, apiVersion+"/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			_, ok := doc.Paths[specPath(template)][strings.ToLower(method)]
+			assert.True(t, ok, "%s %s is not documented", method, template)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
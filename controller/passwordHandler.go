@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+)
+
+// ForgotPassword emails a password reset token. It answers the same whether
+// or not the email is registered.
+func ForgotPassword(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var request domain.ForgotPasswordRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		err = NikPay.ForgotPassword(r.Context(), request.Email)
+		switch err {
+		case nil:
+			writeMessage(rw, http.StatusAccepted, "if the email is registered, a password reset token has been sent to it")
+		case errors.ErrInvalidEmail:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// ResetPassword sets a new password using a token sent by ForgotPassword.
+func ResetPassword(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		var request domain.ResetPasswordRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		err = NikPay.ResetPassword(r.Context(), request)
+		switch err {
+		case nil:
+			writeMessage(rw, http.StatusOK, "password reset, please log in again")
+		case errors.ErrInvalidResetToken, errors.ErrInvalidPassword, errors.ErrPasswordTooLong:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// ChangePassword replaces the password of the signed in user, who must give
+// the current one. All of the user's sessions end, including this one.
+func ChangePassword(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var request domain.ChangePasswordRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		err = NikPay.ChangePassword(r.Context(), userID, request)
+		switch err {
+		case nil:
+			writeMessage(rw, http.StatusOK, "password changed, please log in again")
+		case errors.ErrInvalidPassword, errors.ErrPasswordTooLong:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrIncorrectPassword:
+			writeMessage(rw, http.StatusForbidden, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestForgotPassword(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("ForgotPassword", mock.Anything, "john@mail.com").Return(nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/password/forgot", strings.NewReader(`{"email":"john@mail.com"}`))
+	rw := httptest.NewRecorder()
+	ForgotPassword(NikPay)(rw, req)
+	assert.Equal(t, http.StatusAccepted, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestResetPassword(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("ResetPassword", mock.Anything, domain.ResetPasswordRequest{Token: "good", NewPassword: "new password"}).Return(nil).Once()
+	NikPay.On("ResetPassword", mock.Anything, domain.ResetPasswordRequest{Token: "spent", NewPassword: "new password"}).Return(errors.ErrInvalidResetToken).Once()
+
+	for token, want := range map[string]int{"good": http.StatusOK, "spent": http.StatusBadRequest} {
+		req := httptest.NewRequest(http.MethodPost, "/password/reset", strings.NewReader(`{"token":"`+token+`","new_password":"new password"}`))
+		rw := httptest.NewRecorder()
+		ResetPassword(NikPay)(rw, req)
+		assert.Equal(t, want, rw.Code)
+	}
+	NikPay.AssertExpectations(t)
+}
+
+func TestChangePassword(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("ChangePassword", mock.Anything, int64(1), domain.ChangePasswordRequest{CurrentPassword: "12345678", NewPassword: "new password"}).Return(nil).Once()
+	NikPay.On("ChangePassword", mock.Anything, int64(1), domain.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new password"}).Return(errors.ErrIncorrectPassword).Once()
+
+	for current, want := range map[string]int{"12345678": http.StatusOK, "wrong": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, "/password/change", strings.NewReader(`{"current_password":"`+current+`","new_password":"new password"}`))
+		rw := httptest.NewRecorder()
+		ChangePassword(NikPay)(rw, withUserID(req, 1))
+		assert.Equal(t, want, rw.Code)
+	}
+	NikPay.AssertExpectations(t)
+}
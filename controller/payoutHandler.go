@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/service"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// maxPayoutFileSize caps uploaded payout files.
+const maxPayoutFileSize = 5 << 20
+
+// operatorHeader names who uploads a payout batch, for the logs.
+const operatorHeader = "X-Operator"
+
+// CreatePayoutBatch validates an uploaded payout file, CSV when sent as
+// text/csv and JSON otherwise, and stores it as a batch paid in the
+// background. ?mode= is all_or_nothing by default.
+func CreatePayoutBatch(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		format := service.PayoutFormatJSON
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+			format = service.PayoutFormatCSV
+		}
+		mode := r.URL.Query().Get("mode")
+		if mode == "" {
+			mode = domain.PayoutModeAllOrNothing
+		}
+		operator := r.Header.Get(operatorHeader)
+		if operator == "" {
+			operator = "admin"
+		}
+
+		instructions, err := service.ParsePayoutFile(http.MaxBytesReader(rw, r.Body, maxPayoutFileSize), format)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		batch, err := NikPay.CreatePayoutBatch(r.Context(), domain.PayoutRequest{Mode: mode, CreatedBy: operator, Instructions: instructions})
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		switch err {
+		case nil:
+		case errors.ErrInvalidPayoutMode, errors.ErrEmptyPayout, errors.ErrTooManyPayoutRows:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+			return
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// The batch outlives the request; a failed run is logged and can be
+		// resumed with walletctl payout -resume.
+		ctx := context.WithoutCancel(r.Context())
+		go func() {
+			_, err := NikPay.ExecutePayoutBatch(ctx, batch.ID)
+			if err != nil {
+				logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrPayingOut.Error())
+			}
+		}()
+
+		rw.Header().Set("Location", fmt.Sprintf("%s/admin/payouts/%d", apiVersion, batch.ID))
+		writeJSON(rw, http.StatusAccepted, batch)
+	})
+}
+
+// GetPayoutBatch serves a payout batch with its progress.
+func GetPayoutBatch(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		batchID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		batch, err := NikPay.GetPayoutBatch(r.Context(), batchID)
+		if err == errors.ErrPayoutBatchNotFound {
+			writeMessage(rw, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, batch)
+	})
+}
+
+// GetPayoutReport serves every row of a payout batch with its status as a
+// download in the ?format= requested, csv by default.
+func GetPayoutReport(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		batchID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = service.PayoutFormatCSV
+		}
+		contentType := service.PayoutContentType(format)
+		if contentType == "" {
+			writeMessage(rw, http.StatusBadRequest, errors.ErrInvalidExportFormat.Error())
+			return
+		}
+
+		rows, err := NikPay.GetPayoutReport(r.Context(), batchID)
+		if err == errors.ErrPayoutBatchNotFound {
+			writeMessage(rw, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		rw.Header().Set("Content-Type", contentType)
+		rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="payout-%d.%s"`, batchID, format))
+		rw.WriteHeader(http.StatusOK)
+		err = service.WritePayoutReport(rw, rows, format)
+		if err != nil {
+			logging.FromContext(r.Context()).WithField("err", err.Error()).Error(errors.ErrFetchingPayoutBatch.Error())
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePayoutBatch(t *testing.T) {
+	batch := domain.PayoutBatch{ID: 7, Mode: domain.PayoutModeBestEffort, Status: domain.PayoutStatusPending, TotalRows: 1, TotalAmount: 10, PendingRows: 1}
+
+	t.Run("Stores a CSV batch and pays it in the background", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("CreatePayoutBatch", mock.Anything, domain.PayoutRequest{
+			Mode:         domain.PayoutModeBestEffort,
+			CreatedBy:    "finance",
+			Instructions: []domain.PayoutInstruction{{Recipient: "john@mail.com", Amount: 10, Reference: "may-1"}},
+		}).Return(batch, nil).Once()
+		executed := make(chan struct{})
+		NikPay.On("ExecutePayoutBatch", mock.Anything, int64(7)).Return(batch, nil).Once().Run(func(mock.Arguments) { close(executed) })
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/payouts?mode=best_effort", strings.NewReader("recipient,amount,reference\njohn@mail.com,10,may-1\n"))
+		req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+		req.Header.Set(operatorHeader, "finance")
+		rw := httptest.NewRecorder()
+		CreatePayoutBatch(NikPay)(rw, req)
+
+		assert.Equal(t, http.StatusAccepted, rw.Code)
+		assert.Equal(t, "/v1/admin/payouts/7", rw.Header().Get("Location"))
+		select {
+		case <-executed:
+		case <-time.After(time.Second):
+			t.Fatal("batch was not executed")
+		}
+		NikPay.AssertExpectations(t)
+	})
+
+	t.Run("Defaults to an all-or-nothing JSON batch", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("CreatePayoutBatch", mock.Anything, domain.PayoutRequest{
+			Mode:         domain.PayoutModeAllOrNothing,
+			CreatedBy:    "admin",
+			Instructions: []domain.PayoutInstruction{{Recipient: "john@mail.com", Amount: 10, Reference: "may-1"}},
+		}).Return(domain.PayoutBatch{}, errors.ValidationErrors{{Field: "rows[1].recipient", Code: errors.CodeNotFound, Err: errors.ErrUnknownRecipient}}).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/payouts", strings.NewReader(`[{"recipient":"john@mail.com","amount":10,"reference":"may-1"}]`))
+		rw := httptest.NewRecorder()
+		CreatePayoutBatch(NikPay)(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Contains(t, rw.Body.String(), `"field":"rows[1].recipient"`)
+		NikPay.AssertExpectations(t)
+	})
+
+	t.Run("Rejects malformed files", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		req := httptest.NewRequest(http.MethodPost, "/v1/admin/payouts", strings.NewReader(`{`))
+		rw := httptest.NewRecorder()
+		CreatePayoutBatch(NikPay)(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Contains(t, rw.Body.String(), errors.ErrInvalidPayoutFile.Error())
+		NikPay.AssertExpectations(t)
+	})
+}
+
+func TestGetPayoutBatch(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("GetPayoutBatch", mock.Anything, int64(7)).Return(domain.PayoutBatch{ID: 7, PaidRows: 3}, nil).Once()
+	NikPay.On("GetPayoutBatch", mock.Anything, int64(8)).Return(domain.PayoutBatch{}, errors.ErrPayoutBatchNotFound).Once()
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/admin/payouts/{id:[0-9]+}", GetPayoutBatch(NikPay))
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/admin/payouts/7", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Contains(t, rw.Body.String(), `"paid_rows":3`)
+
+	rw = httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/admin/payouts/8", nil))
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestGetPayoutReport(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	rows := []domain.PayoutRow{{Line: 1, Recipient: "john@mail.com", UserID: 1, Amount: 10, Reference: "may-1", Status: domain.PayoutStatusPaid}}
+	NikPay.On("GetPayoutReport", mock.Anything, int64(7)).Return(rows, nil).Once()
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/admin/payouts/{id:[0-9]+}/report", GetPayoutReport(NikPay))
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/admin/payouts/7/report", nil))
+	require.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "text/csv", rw.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="payout-7.csv"`, rw.Header().Get("Content-Disposition"))
+	assert.Equal(t, "line,recipient,user_id,amount,reference,status,error\n1,john@mail.com,1,10.00,may-1,paid,\n", rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/admin/payouts/7/report?format=pdf", nil))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	NikPay.AssertExpectations(t)
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+)
+
+func GetProfile(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+
+		profile, err := NikPay.GetProfile(r.Context(), userID)
+		switch err {
+		case nil:
+			writeJSON(rw, http.StatusOK, profile)
+		case errors.ErrUserNotFound:
+			writeMessage(rw, http.StatusNotFound, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// UpdateProfile changes the name, phone number or email given in the body.
+// Fields left out are unchanged.
+func UpdateProfile(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var request domain.UpdateProfileRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		profile, err := NikPay.UpdateProfile(r.Context(), userID, request)
+		switch err {
+		case nil:
+			writeJSON(rw, http.StatusOK, profile)
+		case errors.ErrEmptyProfileUpdate, errors.ErrInvalidName, errors.ErrInvalidEmail, errors.ErrInvalidPhoneNumber:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrEmailTaken:
+			writeMessage(rw, http.StatusConflict, err.Error())
+		case errors.ErrUserNotFound:
+			writeMessage(rw, http.StatusNotFound, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// ConfirmEmailChange checks the code sent to the pending email and makes it
+// the user's email.
+func ConfirmEmailChange(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var request domain.VerificationRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		err = NikPay.ConfirmEmailChange(r.Context(), userID, request.Code)
+		switch err {
+		case nil:
+			writeMessage(rw, http.StatusOK, "email changed")
+		case errors.ErrInvalidVerificationCode, errors.ErrNoPendingEmail:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrEmailTaken:
+			writeMessage(rw, http.StatusConflict, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// DeleteAccount closes the account of the signed in user, whose wallet must
+// be empty.
+func DeleteAccount(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+
+		err := NikPay.DeleteAccount(r.Context(), userID)
+		switch err {
+		case nil:
+			rw.WriteHeader(http.StatusNoContent)
+		case errors.ErrNonZeroBalance:
+			writeMessage(rw, http.StatusConflict, err.Error())
+		case errors.ErrUserNotFound:
+			writeMessage(rw, http.StatusNotFound, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
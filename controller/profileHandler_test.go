@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetProfile(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("GetProfile", mock.Anything, int64(1)).Return(domain.UserProfile{ID: 1, Name: "John Doe", Email: "john@mail.com", PhoneNumber: "8123467890", EmailVerified: true}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	rw := httptest.NewRecorder()
+	GetProfile(NikPay)(rw, withUserID(req, 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"id":1,"name":"John Doe","email":"john@mail.com","phone_number":"8123467890","email_verified":true,"phone_verified":false}`, rw.Body.String())
+	assert.NotContains(t, rw.Body.String(), "password")
+	NikPay.AssertExpectations(t)
+}
+
+func TestUpdateProfile(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	name := "Jane Doe"
+	NikPay.On("UpdateProfile", mock.Anything, int64(1), domain.UpdateProfileRequest{Name: &name}).Return(domain.UserProfile{ID: 1, Name: name}, nil).Once()
+	NikPay.On("UpdateProfile", mock.Anything, int64(1), domain.UpdateProfileRequest{}).Return(domain.UserProfile{}, errors.ErrEmptyProfileUpdate).Once()
+
+	for body, want := range map[string]int{`{"name":"Jane Doe"}`: http.StatusOK, `{}`: http.StatusBadRequest, `not json`: http.StatusBadRequest} {
+		req := httptest.NewRequest(http.MethodPatch, "/me", strings.NewReader(body))
+		rw := httptest.NewRecorder()
+		UpdateProfile(NikPay)(rw, withUserID(req, 1))
+		assert.Equal(t, want, rw.Code, body)
+	}
+	NikPay.AssertExpectations(t)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("DeleteAccount", mock.Anything, int64(1)).Return(nil).Once()
+	NikPay.On("DeleteAccount", mock.Anything, int64(2)).Return(errors.ErrNonZeroBalance).Once()
+
+	for userID, want := range map[int64]int{1: http.StatusNoContent, 2: http.StatusConflict} {
+		req := httptest.NewRequest(http.MethodDelete, "/me", nil)
+		rw := httptest.NewRecorder()
+		DeleteAccount(NikPay)(rw, withUserID(req, userID))
+		assert.Equal(t, want, rw.Code)
+	}
+	NikPay.AssertExpectations(t)
+}
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rateLimiter applies one token bucket limit from a shared store. A zero
+// Burst disables it.
+type rateLimiter struct {
+	store ratelimit.Store
+	limit ratelimit.Limit
+	name  string // namespaces keys so limits sharing a store do not collide
+}
+
+// byIP limits next per client IP, for routes that run before authentication.
+func (l rateLimiter) byIP(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		l.serve(rw, req, "ip:"+requestClientIP(req), next)
+	}
+}
+
+// byUser limits next per authenticated user. It must be wrapped by
+// authMiddleware so the user ID is in the request context.
+func (l rateLimiter) byUser(next http.HandlerFunc) http.HandlerFunc {
+	return func(rw http.ResponseWriter, req *http.Request) {
+		userID, _ := req.Context().Value("id").(int64)
+		l.serve(rw, req, "user:"+strconv.FormatInt(userID, 10), next)
+	}
+}
+
+func (l rateLimiter) serve(rw http.ResponseWriter, req *http.Request, key string, next http.HandlerFunc) {
+	if l.limit.Burst <= 0 {
+		next.ServeHTTP(rw, req)
+		return
+	}
+
+	result, err := l.store.Take(req.Context(), l.name+":"+key, l.limit)
+	if err != nil {
+		// Fail open, an unavailable store should not take the API down with it
+		logging.FromContext(req.Context()).WithField("err", err.Error()).Error("Error while checking rate limit")
+		next.ServeHTTP(rw, req)
+		return
+	}
+
+	header := rw.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	if !result.Allowed {
+		header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		logging.FromContext(req.Context()).WithField("limit", l.name).Warn("Rate limit exceeded")
+		writeMessage(rw, http.StatusTooManyRequests, "too many requests")
+		return
+	}
+	next.ServeHTTP(rw, req)
+}
+
+type clientIPKey struct{}
+
+// clientIPMiddleware stores the client IP in the request context for the rate
+// limiter and login lockout. X-Forwarded-For is only honoured when trustProxy
+// is set, since otherwise clients could pick their own address.
+func clientIPMiddleware(trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			ctx := context.WithValue(req.Context(), clientIPKey{}, clientIP(req, trustProxy))
+			next.ServeHTTP(rw, req.WithContext(ctx))
+		})
+	}
+}
+
+// requestClientIP returns the IP stored by clientIPMiddleware, falling back to
+// the peer address.
+func requestClientIP(req *http.Request) string {
+	if ip, ok := req.Context().Value(clientIPKey{}).(string); ok {
+		return ip
+	}
+	return clientIP(req, false)
+}
+
+// clientIP returns the address of the client that sent req. Behind a trusted
+// proxy that is the last X-Forwarded-For entry, the one the proxy appended.
+func clientIP(req *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+			hops := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/ratelimit"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func okHandler(rw http.ResponseWriter, r *http.Request) {
+	rw.WriteHeader(http.StatusOK)
+}
+
+func TestRateLimiterByIP(t *testing.T) {
+	limiter := rateLimiter{store: ratelimit.NewMemoryStore(), limit: ratelimit.PerMinute(2, 2), name: "auth"}
+	handler := limiter.byIP(okHandler)
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = remoteAddr
+		rw := httptest.NewRecorder()
+		handler(rw, req)
+		return rw
+	}
+
+	rw := send("10.0.0.1:1234")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "2", rw.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rw.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rw.Header().Get("RateLimit-Reset"))
+
+	// A different port is the same client
+	assert.Equal(t, http.StatusOK, send("10.0.0.1:5678").Code)
+
+	rw = send("10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.Equal(t, "0", rw.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", rw.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"message":"too many requests"}`, rw.Body.String())
+
+	assert.Equal(t, http.StatusOK, send("10.0.0.2:1234").Code)
+}
+
+func TestRateLimiterByUser(t *testing.T) {
+	limiter := rateLimiter{store: ratelimit.NewMemoryStore(), limit: ratelimit.PerMinute(1, 1), name: "wallet"}
+	handler := limiter.byUser(okHandler)
+
+	send := func(userID int64) int {
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "id", userID))
+		rw := httptest.NewRecorder()
+		handler(rw, req)
+		return rw.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send(1))
+	assert.Equal(t, http.StatusTooManyRequests, send(1))
+	assert.Equal(t, http.StatusOK, send(2))
+}
+
+func TestRateLimiterPassThrough(t *testing.T) {
+	t.Run("Zero limit disables limiting", func(t *testing.T) {
+		limiter := rateLimiter{store: failingStore{}, name: "auth"}
+		rw := httptest.NewRecorder()
+		limiter.byIP(okHandler)(rw, httptest.NewRequest(http.MethodPost, "/login", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Empty(t, rw.Header().Get("RateLimit-Limit"))
+	})
+
+	t.Run("Store errors fail open", func(t *testing.T) {
+		limiter := rateLimiter{store: failingStore{}, limit: ratelimit.PerMinute(1, 1), name: "auth"}
+		rw := httptest.NewRecorder()
+		limiter.byIP(okHandler)(rw, httptest.NewRequest(http.MethodPost, "/login", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 203.0.113.7")
+
+	assert.Equal(t, "10.0.0.1", clientIP(req, false))
+	assert.Equal(t, "203.0.113.7", clientIP(req, true))
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	var got string
+	handler := clientIPMiddleware(true)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = requestClientIP(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "203.0.113.7", got)
+}
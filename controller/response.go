@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+)
+
+// writeJSON marshals body and writes it with the given status code.
+func writeJSON(rw http.ResponseWriter, status int, body interface{}) {
+	resp, err := json.Marshal(body)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	rw.Write(resp)
+}
+
+// writeMessage writes a domain.Message carrying message with the given status code.
+func writeMessage(rw http.ResponseWriter, status int, message string) {
+	writeJSON(rw, status, domain.Message{Message: message})
+}
+
+// writeValidationErrors answers 400 listing every invalid field when err
+// holds errors.ValidationErrors, and reports whether it did.
+func writeValidationErrors(rw http.ResponseWriter, err error) bool {
+	var errs errors.ValidationErrors
+	if !stderrors.As(err, &errs) {
+		return false
+	}
+	body := domain.ValidationErrorResponse{
+		Message: "invalid request",
+		Errors:  make([]domain.FieldErrorResponse, len(errs)),
+	}
+	for i, fieldErr := range errs {
+		body.Errors[i] = domain.FieldErrorResponse{Field: fieldErr.Field, Code: fieldErr.Code, Message: fieldErr.Err.Error()}
+	}
+	writeJSON(rw, http.StatusBadRequest, body)
+	return true
+}
+
+// decodeJSON decodes the request body into v. A value of the wrong JSON type
+// is reported as errors.ValidationErrors naming the field.
+func decodeJSON(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) {
+		return errors.ValidationErrors{{Field: typeErr.Field, Code: errors.CodeInvalidType, Err: errors.ErrInvalidFieldType}}
+	}
+	return err
+}
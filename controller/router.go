@@ -1,17 +1,115 @@
 package controller
 
 import (
-	server "nickPay/wallet/server"
+	"nickPay/wallet/internal/events"
+	"nickPay/wallet/internal/graphqlapi"
+	"nickPay/wallet/internal/ratelimit"
+	"nickPay/wallet/internal/service"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func InitRouter(deps *server.Dependencies) (router *mux.Router) {
+// Dependencies are the services the routes are served from.
+type Dependencies struct {
+	NikPay service.WalletService
+}
+
+// RouterConfig tunes the middleware InitRouter installs.
+type RouterConfig struct {
+	RateLimitStore    ratelimit.Store // defaults to an in-memory store
+	AuthRateLimit     ratelimit.Limit // per client IP on /register, /login and /password, zero Burst disables
+	WalletRateLimit   ratelimit.Limit // per user on /me, /wallet and /graphql routes, zero Burst disables
+	TrustProxyHeaders bool            // take the client IP from X-Forwarded-For
+	AdminToken        string          // bearer token for /admin routes, empty disables them
+	Events            *events.Broker  // feeds /wallet/events, nil disables the route
+	EventHeartbeat    time.Duration   // interval of /wallet/events keep-alive comments, defaults to 15s
+}
+
+// apiVersion prefixes every API route. The unversioned paths served before
+// it still answer, flagged as deprecated, until clients have moved over.
+const apiVersion = "/v1"
+
+func InitRouter(deps *Dependencies, cfg RouterConfig) (router *mux.Router) {
+	if cfg.RateLimitStore == nil {
+		cfg.RateLimitStore = ratelimit.NewMemoryStore()
+	}
+	if cfg.EventHeartbeat <= 0 {
+		cfg.EventHeartbeat = 15 * time.Second
+	}
+
 	router = mux.NewRouter()
+	router.Use(requestLogger, tracingMiddleware, metricsMiddleware, clientIPMiddleware(cfg.TrustProxyHeaders))
 
-	router.HandleFunc("/register", RegisterUser(deps.NikPay)).Methods("POST")
-	router.HandleFunc("/login", LoginUser(deps.NikPay)).Methods("POST")
-	router.HandleFunc("/wallet", authMiddleware(GetWallet(deps.NikPay))).Methods("GET")
-	router.HandleFunc("/wallet/credit", authMiddleware(CreditWallet(deps.NikPay))).Methods("POST")
+	router.HandleFunc("/healthz", Healthz()).Methods("GET")
+	router.HandleFunc("/readyz", Readyz(deps.NikPay)).Methods("GET")
+	router.Handle("/metrics", promhttp.Handler()).Methods("GET")
+
+	v1 := router.PathPrefix(apiVersion).Subrouter()
+	v1.HandleFunc("/openapi.json", OpenAPISpec()).Methods("GET")
+	registerAPIRoutes(v1, deps, cfg)
+	// Routes added after versioning have no unversioned alias.
+	walletLimiter := rateLimiter{store: cfg.RateLimitStore, limit: cfg.WalletRateLimit, name: "wallet"}
+	v1.HandleFunc("/graphql", authMiddleware(deps.NikPay, walletLimiter.byUser(graphqlapi.Handler(deps.NikPay)))).Methods("POST")
+	if cfg.Events != nil {
+		v1.HandleFunc("/wallet/events", authMiddleware(deps.NikPay, walletLimiter.byUser(StreamWalletEvents(deps.NikPay, cfg.Events, cfg.EventHeartbeat)))).Methods("GET")
+	}
+	v1.HandleFunc("/wallet/topups/{id:[0-9]+}", authMiddleware(deps.NikPay, walletLimiter.byUser(GetTopUp(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/gateway/callback", GatewayCallback(deps.NikPay)).Methods("POST")
+	v1.HandleFunc("/me/bank-accounts", authMiddleware(deps.NikPay, walletLimiter.byUser(LinkBankAccount(deps.NikPay)))).Methods("POST")
+	v1.HandleFunc("/me/bank-accounts", authMiddleware(deps.NikPay, walletLimiter.byUser(ListBankAccounts(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/wallet/withdrawals", authMiddleware(deps.NikPay, walletLimiter.byUser(Withdraw(deps.NikPay)))).Methods("POST")
+	v1.HandleFunc("/wallet/withdrawals/{id:[0-9]+}", authMiddleware(deps.NikPay, walletLimiter.byUser(GetWithdrawal(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/wallet/quote", authMiddleware(deps.NikPay, walletLimiter.byUser(QuoteFee(deps.NikPay)))).Methods("POST")
+	v1.HandleFunc("/wallet/promo", authMiddleware(deps.NikPay, walletLimiter.byUser(GetPromoBalance(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/merchant", authMiddleware(deps.NikPay, walletLimiter.byUser(RegisterMerchant(deps.NikPay)))).Methods("POST")
+	v1.HandleFunc("/merchant", authMiddleware(deps.NikPay, walletLimiter.byUser(GetMerchant(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/merchant/wallet", authMiddleware(deps.NikPay, walletLimiter.byUser(GetMerchantWallet(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/merchant/qr", authMiddleware(deps.NikPay, walletLimiter.byUser(GetStaticQR(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/merchant/qr", authMiddleware(deps.NikPay, walletLimiter.byUser(CreatePaymentQR(deps.NikPay)))).Methods("POST")
+	v1.HandleFunc("/merchant/qr/{id:[0-9]+}", authMiddleware(deps.NikPay, walletLimiter.byUser(GetPaymentQR(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/merchant/settlements", authMiddleware(deps.NikPay, walletLimiter.byUser(GetSettlementReport(deps.NikPay)))).Methods("GET")
+	v1.HandleFunc("/pay/qr", authMiddleware(deps.NikPay, walletLimiter.byUser(PayQR(deps.NikPay)))).Methods("POST")
+	if cfg.AdminToken != "" {
+		v1.HandleFunc("/admin/payouts", adminMiddleware(cfg.AdminToken, CreatePayoutBatch(deps.NikPay))).Methods("POST")
+		v1.HandleFunc("/admin/payouts/{id:[0-9]+}", adminMiddleware(cfg.AdminToken, GetPayoutBatch(deps.NikPay))).Methods("GET")
+		v1.HandleFunc("/admin/payouts/{id:[0-9]+}/report", adminMiddleware(cfg.AdminToken, GetPayoutReport(deps.NikPay))).Methods("GET")
+		v1.HandleFunc("/admin/campaigns", adminMiddleware(cfg.AdminToken, CreateCampaign(deps.NikPay))).Methods("POST")
+		v1.HandleFunc("/admin/campaigns", adminMiddleware(cfg.AdminToken, ListCampaigns(deps.NikPay))).Methods("GET")
+	}
+
+	legacy := router.NewRoute().Subrouter()
+	legacy.Use(deprecated(apiVersion))
+	registerAPIRoutes(legacy, deps, cfg)
 	return
 }
+
+// registerAPIRoutes adds the API routes to router. Each call gets its own
+// rate limiters, which share cfg.RateLimitStore and so their buckets.
+func registerAPIRoutes(router *mux.Router, deps *Dependencies, cfg RouterConfig) {
+	authLimiter := rateLimiter{store: cfg.RateLimitStore, limit: cfg.AuthRateLimit, name: "auth"}
+	walletLimiter := rateLimiter{store: cfg.RateLimitStore, limit: cfg.WalletRateLimit, name: "wallet"}
+
+	router.HandleFunc("/register", authLimiter.byIP(RegisterUser(deps.NikPay))).Methods("POST")
+	router.HandleFunc("/login", authLimiter.byIP(LoginUser(deps.NikPay))).Methods("POST")
+	router.HandleFunc("/login/mfa", authLimiter.byIP(VerifyLoginMFA(deps.NikPay))).Methods("POST")
+	router.HandleFunc("/password/forgot", authLimiter.byIP(ForgotPassword(deps.NikPay))).Methods("POST")
+	router.HandleFunc("/password/reset", authLimiter.byIP(ResetPassword(deps.NikPay))).Methods("POST")
+	router.HandleFunc("/password/change", authMiddleware(deps.NikPay, authLimiter.byUser(ChangePassword(deps.NikPay)))).Methods("POST")
+	router.HandleFunc("/me", authMiddleware(deps.NikPay, walletLimiter.byUser(GetProfile(deps.NikPay)))).Methods("GET")
+	router.HandleFunc("/me", authMiddleware(deps.NikPay, walletLimiter.byUser(UpdateProfile(deps.NikPay)))).Methods("PATCH")
+	router.HandleFunc("/me", authMiddleware(deps.NikPay, walletLimiter.byUser(DeleteAccount(deps.NikPay)))).Methods("DELETE")
+	router.HandleFunc("/me/email/confirm", authMiddleware(deps.NikPay, walletLimiter.byUser(ConfirmEmailChange(deps.NikPay)))).Methods("POST")
+	router.HandleFunc("/mfa/totp/enroll", authMiddleware(deps.NikPay, walletLimiter.byUser(EnrollTOTP(deps.NikPay)))).Methods("POST")
+	router.HandleFunc("/mfa/totp/confirm", authMiddleware(deps.NikPay, walletLimiter.byUser(ConfirmTOTP(deps.NikPay)))).Methods("POST")
+	router.HandleFunc("/verify/{channel:email|phone}", authMiddleware(deps.NikPay, walletLimiter.byUser(VerifyContact(deps.NikPay)))).Methods("POST")
+	router.HandleFunc("/verify/{channel:email|phone}/resend", authMiddleware(deps.NikPay, authLimiter.byUser(ResendVerification(deps.NikPay)))).Methods("POST")
+	router.HandleFunc("/wallet", authMiddleware(deps.NikPay, walletLimiter.byUser(GetWallet(deps.NikPay)))).Methods("GET")
+	router.HandleFunc("/wallet/credit", authMiddleware(deps.NikPay, walletLimiter.byUser(CreditWallet(deps.NikPay)))).Methods("POST")
+	router.HandleFunc("/wallet/debit", authMiddleware(deps.NikPay, walletLimiter.byUser(DebitWallet(deps.NikPay)))).Methods("POST")
+	router.HandleFunc("/wallet/statements", authMiddleware(deps.NikPay, walletLimiter.byUser(GetStatement(deps.NikPay)))).Methods("GET")
+	if cfg.AdminToken != "" {
+		router.HandleFunc("/admin/login/unlock", adminMiddleware(cfg.AdminToken, UnlockLogin(deps.NikPay))).Methods("POST")
+	}
+}
@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInitRouterVersioning(t *testing.T) {
+	NikPay := currentSessions()
+	NikPay.On("GetWallet", mock.Anything, int64(42)).Return(domain.Wallet{ID: 7, Status: domain.WalletStatusActive}, nil)
+	router := InitRouter(&Dependencies{NikPay: NikPay}, RouterConfig{})
+	token := signedToken(t, jwt.MapClaims{"user_id": 42, "sv": 1, "exp": time.Now().Add(time.Minute).Unix()})
+
+	tests := []struct {
+		name        string
+		path        string
+		status      int
+		deprecation string
+		link        string
+	}{
+		{name: "versioned route", path: "/v1/wallet", status: http.StatusOK},
+		{name: "deprecated alias", path: "/wallet", status: http.StatusOK, deprecation: "true", link: `</v1/wallet>; rel="successor-version"`},
+		{name: "unversioned probe", path: "/healthz", status: http.StatusOK},
+		{name: "openapi is only versioned", path: "/openapi.json", status: http.StatusNotFound},
+		{name: "graphql is only versioned", path: "/graphql", status: http.StatusNotFound},
+		{name: "unknown version", path: "/v2/wallet", status: http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, test.status, rr.Code)
+			assert.Equal(t, test.deprecation, rr.Header().Get("Deprecation"))
+			assert.Equal(t, test.link, rr.Header().Get("Link"))
+		})
+	}
+}
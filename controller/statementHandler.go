@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/service"
+	"time"
+)
+
+// GetStatement serves the wallet statement for the UTC month ?month=YYYY-MM
+// (defaulting to the current month) in the ?format= requested, json by
+// default.
+func GetStatement(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		userID := r.Context().Value("id").(int64)
+
+		month := time.Now().UTC()
+		if value := r.URL.Query().Get("month"); value != "" {
+			parsed, err := time.ParseInLocation("2006-01", value, time.UTC)
+			if err != nil {
+				writeMessage(rw, http.StatusBadRequest, errors.ErrInvalidStatementPeriod.Error())
+				return
+			}
+			month = parsed
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = service.StatementFormatJSON
+		}
+		contentType := service.StatementContentType(format)
+		if contentType == "" {
+			writeMessage(rw, http.StatusBadRequest, errors.ErrInvalidStatementFormat.Error())
+			return
+		}
+
+		statement, err := NikPay.GenerateStatement(r.Context(), userID, month)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		rw.Header().Set("Content-Type", contentType)
+		if format != service.StatementFormatJSON {
+			rw.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s.%s"`, statement.PeriodStart.Format("2006-01"), format))
+		}
+		rw.WriteHeader(http.StatusOK)
+		err = service.WriteStatement(rw, statement, format)
+		if err != nil {
+			logging.FromContext(r.Context()).WithField("err", err.Error()).Error(errors.ErrGeneratingStatement.Error())
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/service/mocks"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type StatementHandlerSuite struct {
+	suite.Suite
+	service *mocks.WalletService
+}
+
+func TestStatementHandlerTestSuite(t *testing.T) {
+	suite.Run(t, new(StatementHandlerSuite))
+}
+
+func (suite *StatementHandlerSuite) SetupTest() {
+	suite.service = &mocks.WalletService{}
+}
+
+func (suite *StatementHandlerSuite) TearDownTest() {
+	suite.service.AssertExpectations(suite.T())
+}
+
+func (suite *StatementHandlerSuite) TestGetStatement() {
+	t := suite.T()
+	start := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	statement := domain.Statement{
+		WalletID:       1,
+		PeriodStart:    start,
+		PeriodEnd:      start.AddDate(0, 1, 0),
+		OpeningBalance: 100,
+		ClosingBalance: 100,
+		Entries:        []domain.StatementEntry{},
+	}
+
+	t.Run("CSV statement for a month", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet/statements?month=2023-05&format=csv", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "id", int64(1)))
+		rw := httptest.NewRecorder()
+
+		suite.service.On("GenerateStatement", mock.Anything, int64(1), start).Return(statement, nil).Once()
+
+		GetStatement(suite.service).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "text/csv", rw.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="statement-2023-05.csv"`, rw.Header().Get("Content-Disposition"))
+		assert.Contains(t, rw.Body.String(), "Opening balance")
+	})
+
+	t.Run("Invalid month", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet/statements?month=05-2023", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "id", int64(1)))
+		rw := httptest.NewRecorder()
+
+		GetStatement(suite.service).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.JSONEq(t, `{"message":"invalid statement period, expected YYYY-MM"}`, rw.Body.String())
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet/statements?month=2023-05&format=xml", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "id", int64(1)))
+		rw := httptest.NewRecorder()
+
+		GetStatement(suite.service).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+
+	t.Run("Error while generating statement", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/wallet/statements?month=2023-05", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "id", int64(1)))
+		rw := httptest.NewRecorder()
+
+		suite.service.On("GenerateStatement", mock.Anything, int64(1), start).Return(domain.Statement{}, errors.New("error generating statement")).Once()
+
+		GetStatement(suite.service).ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.JSONEq(t, `{"message":"error generating statement"}`, rw.Body.String())
+	})
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// maxCallbackSize caps gateway callback bodies.
+const maxCallbackSize = 64 << 10
+
+// signatureHeader carries the signature of a gateway callback body.
+const signatureHeader = "X-Signature"
+
+// GetTopUp serves one of the signed in user's top-ups.
+func GetTopUp(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		topUpID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		topUp, err := NikPay.GetTopUp(r.Context(), userID, topUpID)
+		if err == errors.ErrTopUpNotFound {
+			writeMessage(rw, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, topUp)
+	})
+}
+
+// GatewayCallback receives payment updates from the payment gateway. The
+// body is passed on as received, since the signature covers its exact bytes.
+func GatewayCallback(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxCallbackSize))
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		_, err = NikPay.HandleTopUpCallback(r.Context(), payload, r.Header.Get(signatureHeader))
+		switch err {
+		case nil:
+			writeMessage(rw, http.StatusOK, "callback processed")
+		case errors.ErrInvalidGatewaySignature:
+			writeMessage(rw, http.StatusUnauthorized, err.Error())
+		case errors.ErrInvalidGatewayCallback, errors.ErrTopUpNotFound, errors.ErrTopUpMismatch:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrWalletFrozen, errors.ErrWalletClosed:
+			// The top-up stays pending, a later retry credits the wallet
+			// once it is active again
+			writeMessage(rw, http.StatusConflict, err.Error())
+		case errors.ErrNoPaymentGateway:
+			writeMessage(rw, http.StatusServiceUnavailable, err.Error())
+		default:
+			// The gateway retries callbacks that did not succeed
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
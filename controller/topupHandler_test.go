@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreditWalletStartsTopUp(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("CreditWallet", mock.Anything, int64(1), 50.0).Return(domain.TopUp{ID: 7, UserID: 1, Amount: 50, Status: domain.TopUpStatusPending, CheckoutURL: "https://checkout.fake.invalid/pay/fake_1"}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/wallet/credit", strings.NewReader(`{"amount": 50}`))
+	rw := httptest.NewRecorder()
+	CreditWallet(NikPay)(rw, withUserID(req, 1))
+	assert.Equal(t, http.StatusAccepted, rw.Code)
+	assert.Equal(t, "/v1/wallet/topups/7", rw.Header().Get("Location"))
+	assert.JSONEq(t, `{"id":7,"amount":50,"status":"pending","checkout_url":"https://checkout.fake.invalid/pay/fake_1","created_at":"0001-01-01T00:00:00Z","completed_at":null}`, rw.Body.String())
+	NikPay.AssertExpectations(t)
+}
+
+func TestGetTopUp(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("GetTopUp", mock.Anything, int64(1), int64(7)).Return(domain.TopUp{ID: 7, Amount: 50, Status: domain.TopUpStatusSucceeded}, nil).Once()
+	NikPay.On("GetTopUp", mock.Anything, int64(1), int64(8)).Return(domain.TopUp{}, errors.ErrTopUpNotFound).Once()
+
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/v1/wallet/topups/7", nil), map[string]string{"id": "7"})
+	rw := httptest.NewRecorder()
+	GetTopUp(NikPay)(rw, withUserID(req, 1))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Contains(t, rw.Body.String(), `"status":"succeeded"`)
+
+	req = mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/v1/wallet/topups/8", nil), map[string]string{"id": "8"})
+	rw = httptest.NewRecorder()
+	GetTopUp(NikPay)(rw, withUserID(req, 1))
+	assert.Equal(t, http.StatusNotFound, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestGatewayCallback(t *testing.T) {
+	const payload = `{"id":"fake_1","reference":"7","amount":50,"status":"succeeded"}`
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "applied", status: http.StatusOK},
+		{name: "bad signature", err: errors.ErrInvalidGatewaySignature, status: http.StatusUnauthorized},
+		{name: "mismatch", err: errors.ErrTopUpMismatch, status: http.StatusBadRequest},
+		{name: "frozen wallet", err: errors.ErrWalletFrozen, status: http.StatusConflict},
+		{name: "store failure", err: errors.ErrCompletingTopUp, status: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			NikPay := &mocks.WalletService{}
+			NikPay.On("HandleTopUpCallback", mock.Anything, []byte(payload), "abc123").Return(domain.TopUp{}, test.err).Once()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/gateway/callback", strings.NewReader(payload))
+			req.Header.Set(signatureHeader, "abc123")
+			rw := httptest.NewRecorder()
+			GatewayCallback(NikPay)(rw, req)
+			assert.Equal(t, test.status, rw.Code)
+			NikPay.AssertExpectations(t)
+		})
+	}
+}
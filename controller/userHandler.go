@@ -1,10 +1,10 @@
 package controller
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
 	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
 	service "nickPay/wallet/internal/service"
 )
 
@@ -15,79 +15,57 @@ func RegisterUser(NikPay service.WalletService) http.HandlerFunc {
 			return
 		}
 		var user domain.User
-		err := json.NewDecoder(r.Body).Decode(&user)
-		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
+		err := decodeJSON(r, &user)
+		if writeValidationErrors(rw, err) {
 			return
 		}
-		err = NikPay.RegisterUser(r.Context(), user)
-
 		if err != nil {
-			message := domain.RegisterUserResponse{
-				Message: err.Error(),
-			}
-			rw.WriteHeader(http.StatusBadRequest)
-			resp, err := json.Marshal(message)
-			if err != nil {
-				rw.WriteHeader(http.StatusInternalServerError)
-				http.Error(rw, err.Error(), http.StatusInternalServerError)
-			}
-			rw.WriteHeader(http.StatusBadRequest)
-			rw.Header().Set("Content-Type", "application/json")
-			rw.Write(resp)
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
 			return
 		}
-		message := domain.RegisterUserResponse{
-			Message: "User Registered Successfully",
+		err = NikPay.RegisterUser(r.Context(), user)
+		if writeValidationErrors(rw, err) {
+			return
 		}
-		resp, err := json.Marshal(message)
+
 		if err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			writeJSON(rw, http.StatusBadRequest, domain.RegisterUserResponse{Message: err.Error()})
+			return
 		}
-		rw.WriteHeader(http.StatusCreated)
-		rw.Header().Set("Content-Type", "application/json")
-		rw.Write(resp)
+		writeJSON(rw, http.StatusCreated, domain.RegisterUserResponse{Message: "User Registered Successfully"})
 	})
 }
 
 func LoginUser(NikPay service.WalletService) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		var loginRequest domain.LoginUserRequest
-		err := json.NewDecoder(r.Body).Decode(&loginRequest)
+		err := decodeJSON(r, &loginRequest)
+		if writeValidationErrors(rw, err) {
+			return
+		}
 		if err != nil {
-			fmt.Println(err)
-			rw.WriteHeader(http.StatusBadRequest)
+			logging.FromContext(r.Context()).WithField("err", err.Error()).Warn("Invalid login request body")
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
 			return
 		}
+		loginRequest.ClientIP = requestClientIP(r)
 		token, err := NikPay.LoginUser(r.Context(), loginRequest)
+		if writeValidationErrors(rw, err) {
+			return
+		}
 
-		if err != nil {
-			message := domain.LoginUserResponse{
-				Message: err.Error(),
-				Token:   "",
-			}
-			rw.WriteHeader(http.StatusBadRequest)
-			resp, err := json.Marshal(message)
-			if err != nil {
-				rw.WriteHeader(http.StatusInternalServerError)
-				http.Error(rw, err.Error(), http.StatusInternalServerError)
-			}
-			rw.WriteHeader(http.StatusBadRequest)
-			rw.Header().Set("Content-Type", "application/json")
-			rw.Write(resp)
+		if err == errors.ErrLoginLocked {
+			writeJSON(rw, http.StatusTooManyRequests, domain.LoginUserResponse{Message: err.Error()})
 			return
 		}
-		message := domain.LoginUserResponse{
-			Message: "User Logged In Successfully",
-			Token:   token,
+		if err == errors.ErrMFARequired {
+			writeJSON(rw, http.StatusOK, domain.LoginUserResponse{Message: err.Error(), MFAToken: token})
+			return
 		}
-		resp, err := json.Marshal(message)
 		if err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			writeJSON(rw, http.StatusBadRequest, domain.LoginUserResponse{Message: err.Error()})
+			return
 		}
-		rw.Header().Set("Content-Type", "application/json")
-		rw.Write(resp)
+		writeJSON(rw, http.StatusOK, domain.LoginUserResponse{Message: "User Logged In Successfully", Token: token})
 	})
 }
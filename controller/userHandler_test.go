@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"net/http"
 	"nickPay/wallet/internal/domain"
-	"nickPay/wallet/server"
 	"strings"
 
 	"net/http/httptest"
@@ -66,7 +65,7 @@ func (suite *UserHandlerTestSuite) TestRegisterUserHandler() {
 
 		// Act
 		suite.service.On("RegisterUser", req.Context(), user).Return(nil).Once()
-		deps := server.Dependencies{
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 		// Assert
@@ -103,13 +102,13 @@ func (suite *UserHandlerTestSuite) TestRegisterUserHandler() {
 		user := domain.User{
 			Name:        "John Doe",
 			Email:       "john1mail.com",
-			PhoneNumber: "8123467890",
+			PhoneNumber: "9993679833",
 			Password:    "12345678",
 		}
 
 		// Act
 		suite.service.On("RegisterUser", req.Context(), user).Return(errors.ErrInvalidEmail).Once()
-		deps := server.Dependencies{
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 		// Assert
@@ -151,7 +150,7 @@ func (suite *UserHandlerTestSuite) TestRegisterUserHandler() {
 
 		// Act
 		suite.service.On("RegisterUser", req.Context(), user).Return(errors.ErrInvalidPhoneNumber).Once()
-		deps := server.Dependencies{
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 		// Assert
@@ -189,11 +188,12 @@ func (suite *UserHandlerTestSuite) TestLoginUserHandler() {
 		loginRequest := domain.LoginUserRequest{
 			Email:    "john1@gmail.com",
 			Password: "12345678",
+			ClientIP: "192.0.2.1",
 		}
 
 		// Act
 		suite.service.On("LoginUser", req.Context(), loginRequest).Return("token", nil).Once()
-		deps := server.Dependencies{
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 		// Assert
@@ -227,11 +227,12 @@ func (suite *UserHandlerTestSuite) TestLoginUserHandler() {
 		loginRequest := domain.LoginUserRequest{
 			Email:    "john1mail.com",
 			Password: "12345678",
+			ClientIP: "192.0.2.1",
 		}
 
 		// Act
 		suite.service.On("LoginUser", req.Context(), loginRequest).Return("", errors.ErrInvalidEmail).Once()
-		deps := server.Dependencies{
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 
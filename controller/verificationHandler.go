@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// VerifyContact checks the code sent to the email address or phone number
+// named by the {channel} route variable.
+func VerifyContact(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		channel := mux.Vars(r)["channel"]
+		var request domain.VerificationRequest
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		err = NikPay.VerifyContact(r.Context(), userID, channel, request.Code)
+		switch err {
+		case nil:
+			writeMessage(rw, http.StatusOK, channel+" verified")
+		case errors.ErrInvalidVerificationCode, errors.ErrInvalidVerificationChannel:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// ResendVerification sends a new code to the email address or phone number
+// named by the {channel} route variable.
+func ResendVerification(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		channel := mux.Vars(r)["channel"]
+
+		err := NikPay.ResendVerification(r.Context(), userID, channel)
+		switch err {
+		case nil:
+			writeMessage(rw, http.StatusAccepted, "verification code sent")
+		case errors.ErrInvalidVerificationChannel:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrAlreadyVerified:
+			writeMessage(rw, http.StatusConflict, err.Error())
+		case errors.ErrTooManyVerificationAttempts:
+			writeMessage(rw, http.StatusTooManyRequests, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVerifyContact(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("VerifyContact", mock.Anything, int64(1), "email", "123456").Return(nil).Once()
+	NikPay.On("VerifyContact", mock.Anything, int64(1), "phone", "000000").Return(errors.ErrInvalidVerificationCode).Once()
+
+	send := func(channel string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/verify/"+channel, strings.NewReader(body))
+		req = mux.SetURLVars(withUserID(req, 1), map[string]string{"channel": channel})
+		rw := httptest.NewRecorder()
+		VerifyContact(NikPay)(rw, req)
+		return rw
+	}
+
+	rw := send("email", `{"code":"123456"}`)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"message":"email verified"}`, rw.Body.String())
+
+	rw = send("phone", `{"code":"000000"}`)
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	NikPay.AssertExpectations(t)
+}
+
+func TestResendVerification(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("ResendVerification", mock.Anything, int64(1), "phone").Return(nil).Once()
+	NikPay.On("ResendVerification", mock.Anything, int64(1), "email").Return(errors.ErrAlreadyVerified).Once()
+
+	for channel, want := range map[string]int{"phone": http.StatusAccepted, "email": http.StatusConflict} {
+		req := httptest.NewRequest(http.MethodPost, "/verify/"+channel+"/resend", nil)
+		req = mux.SetURLVars(withUserID(req, 1), map[string]string{"channel": channel})
+		rw := httptest.NewRecorder()
+		ResendVerification(NikPay)(rw, req)
+		assert.Equal(t, want, rw.Code)
+	}
+	NikPay.AssertExpectations(t)
+}
@@ -1,9 +1,10 @@
 package controller
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
 	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
 	"nickPay/wallet/internal/service"
 )
 
@@ -17,18 +18,7 @@ func GetWallet(NikPay service.WalletService) http.HandlerFunc {
 		var wallet domain.Wallet
 		wallet, err := NikPay.GetWallet(r.Context(), userID)
 		if err != nil {
-			message := domain.Message{
-				Message: err.Error(),
-			}
-			rw.WriteHeader(http.StatusBadRequest)
-			resp, err := json.Marshal(message)
-			if err != nil {
-				rw.WriteHeader(http.StatusInternalServerError)
-				http.Error(rw, err.Error(), http.StatusInternalServerError)
-			}
-			rw.WriteHeader(http.StatusUnauthorized)
-			rw.Header().Set("Content-Type", "application/json")
-			rw.Write(resp)
+			writeMessage(rw, http.StatusBadRequest, err.Error())
 			return
 		}
 		message := domain.GetWalletResponse{
@@ -38,17 +28,12 @@ func GetWallet(NikPay service.WalletService) http.HandlerFunc {
 			LastUpdated:  wallet.LastUpdated,
 			Status:       wallet.Status,
 		}
-		resp, err := json.Marshal(message)
-		if err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
-		}
-		rw.WriteHeader(http.StatusOK)
-		rw.Header().Set("Content-Type", "application/json")
-		rw.Write(resp)
+		writeJSON(rw, http.StatusOK, message)
 	})
 }
 
+// CreditWallet starts a top-up through the payment gateway. The wallet is
+// credited once the user has paid at the checkout URL of the top-up.
 func CreditWallet(NikPay service.WalletService) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
@@ -57,39 +42,32 @@ func CreditWallet(NikPay service.WalletService) http.HandlerFunc {
 		}
 		userID := r.Context().Value("id").(int64)
 		var credit domain.Credit
-		err := json.NewDecoder(r.Body).Decode(&credit)
-		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+		err := decodeJSON(r, &credit)
+		if writeValidationErrors(rw, err) {
 			return
 		}
-		err = NikPay.CreditWallet(r.Context(), userID, credit.Amount)
 		if err != nil {
-			message := domain.Message{
-				Message: err.Error(),
-			}
-			rw.WriteHeader(http.StatusBadRequest)
-			resp, err := json.Marshal(message)
-			if err != nil {
-				rw.WriteHeader(http.StatusInternalServerError)
-				http.Error(rw, err.Error(), http.StatusInternalServerError)
-			}
-			rw.WriteHeader(http.StatusUnauthorized)
-			rw.Header().Set("Content-Type", "application/json")
-			rw.Write(resp)
-			return
-		}
-		message := domain.Message{
-			Message: "Wallet credited successfully",
-		}
-		resp, err := json.Marshal(message)
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		topUp, err := NikPay.CreditWallet(r.Context(), userID, credit.Amount)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err == errors.ErrUnverifiedUser {
+			writeMessage(rw, http.StatusForbidden, err.Error())
+			return
+		}
+		if err == errors.ErrNoPaymentGateway {
+			writeMessage(rw, http.StatusServiceUnavailable, err.Error())
+			return
+		}
 		if err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+			return
 		}
-		rw.WriteHeader(http.StatusOK)
-		rw.Header().Set("Content-Type", "application/json")
-		rw.Write(resp)
+		rw.Header().Set("Location", fmt.Sprintf("%s/wallet/topups/%d", apiVersion, topUp.ID))
+		writeJSON(rw, http.StatusAccepted, topUp)
 	})
 }
 
@@ -101,38 +79,38 @@ func DebitWallet(NikPay service.WalletService) http.HandlerFunc {
 		}
 		userID := r.Context().Value("id").(int64)
 		var debit domain.Debit
-		err := json.NewDecoder(r.Body).Decode(&debit)
-		if err != nil {
-			rw.WriteHeader(http.StatusBadRequest)
-			http.Error(rw, err.Error(), http.StatusBadRequest)
+		err := decodeJSON(r, &debit)
+		if writeValidationErrors(rw, err) {
 			return
 		}
-		err = NikPay.DebitWallet(r.Context(), userID, debit.Amount)
 		if err != nil {
-			message := domain.Message{
-				Message: err.Error(),
-			}
-			rw.WriteHeader(http.StatusBadRequest)
-			resp, err := json.Marshal(message)
-			if err != nil {
-				rw.WriteHeader(http.StatusInternalServerError)
-				http.Error(rw, err.Error(), http.StatusInternalServerError)
-			}
-			rw.WriteHeader(http.StatusUnauthorized)
-			rw.Header().Set("Content-Type", "application/json")
-			rw.Write(resp)
-			return
-		}
-		message := domain.Message{
-			Message: "Wallet debited successfully",
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		ctx := r.Context()
+		if code := r.Header.Get(stepUpHeader); code != "" {
+			ctx = service.WithStepUpCode(ctx, code)
+		}
+		quote, err := NikPay.DebitWallet(ctx, userID, debit.Amount)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err == errors.ErrStepUpRequired || err == errors.ErrInvalidMFACode || err == errors.ErrMFANotEnrolled || err == errors.ErrUnverifiedUser {
+			writeMessage(rw, http.StatusForbidden, err.Error())
+			return
+		}
+		if err == errors.ErrStepUpLocked {
+			writeMessage(rw, http.StatusTooManyRequests, err.Error())
+			return
 		}
-		resp, err := json.Marshal(message)
 		if err != nil {
-			rw.WriteHeader(http.StatusInternalServerError)
-			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+			return
+		}
+		result := domain.DebitResult{
+			Message: "Wallet debited successfully",
+			Quote:   quote,
 		}
-		rw.WriteHeader(http.StatusOK)
-		rw.Header().Set("Content-Type", "application/json")
-		rw.Write(resp)
+		writeJSON(rw, http.StatusOK, result)
 	})
 }
\ No newline at end of file
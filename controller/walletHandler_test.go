@@ -7,12 +7,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"nickPay/wallet/internal/domain"
+	apperrors "nickPay/wallet/internal/errors"
 	"nickPay/wallet/internal/service/mocks"
-	"nickPay/wallet/server"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -29,7 +30,7 @@ func (suite *WalletHandlerSuite) SetupTest() {
 	suite.service = &mocks.WalletService{}
 }
 
-func (suite *WalletHandlerSuite) TeardownTest() {
+func (suite *WalletHandlerSuite) TearDownTest() {
 	suite.service.AssertExpectations(suite.T())
 }
 
@@ -40,7 +41,7 @@ func (suite *WalletHandlerSuite) TestWallet_GetWallet() {
 		req := httptest.NewRequest(http.MethodGet, "/user/wallet", nil)
 		rw := httptest.NewRecorder()
 		ctx := req.Context()
-		ctx = context.WithValue(ctx, "id", 1)
+		ctx = context.WithValue(ctx, "id", int64(1))
 		req = req.WithContext(ctx)
 		expectedResponse := domain.Wallet{
 			ID:           1,
@@ -56,8 +57,8 @@ func (suite *WalletHandlerSuite) TestWallet_GetWallet() {
 		}
 
 		// Act
-		suite.service.On("GetWallet", ctx, 1).Return(expectedResponse, nil).Once()
-		deps := server.Dependencies{
+		suite.service.On("GetWallet", ctx, int64(1)).Return(expectedResponse, nil).Once()
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 
@@ -73,7 +74,7 @@ func (suite *WalletHandlerSuite) TestWallet_GetWallet() {
 		req := httptest.NewRequest(http.MethodGet, "/user/wallet", nil)
 		rw := httptest.NewRecorder()
 		ctx := req.Context()
-		ctx = context.WithValue(ctx, "id", 1)
+		ctx = context.WithValue(ctx, "id", int64(1))
 		req = req.WithContext(ctx)
 		expectedResponse := domain.Message{
 			Message: "invalid request",
@@ -84,8 +85,8 @@ func (suite *WalletHandlerSuite) TestWallet_GetWallet() {
 		}
 
 		// Act
-		suite.service.On("GetWallet", ctx, 1).Return(domain.Wallet{}, errors.New("invalid request")).Once()
-		deps := server.Dependencies{
+		suite.service.On("GetWallet", ctx, int64(1)).Return(domain.Wallet{}, errors.New("invalid request")).Once()
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 
@@ -104,10 +105,13 @@ func (suite *WalletHandlerSuite) TestWallet_CreditWallet() {
 		req := httptest.NewRequest(http.MethodPost, "/user/wallet/credit", strings.NewReader(`{"amount": 1000}`))
 		rw := httptest.NewRecorder()
 		ctx := req.Context()
-		ctx = context.WithValue(ctx, "id", 1)
+		ctx = context.WithValue(ctx, "id", int64(1))
 		req = req.WithContext(ctx)
-		expectedResponse := domain.Message{
-			Message: "Amount credited to the wallet successfully.",
+		expectedResponse := domain.TopUp{
+			ID:          1,
+			Amount:      1000,
+			Status:      domain.TopUpStatusPending,
+			CheckoutURL: "https://checkout.fake.invalid/pay/fake_1",
 		}
 		exp, err := json.Marshal(expectedResponse)
 		if err != nil {
@@ -115,15 +119,15 @@ func (suite *WalletHandlerSuite) TestWallet_CreditWallet() {
 		}
 
 		// Act
-		suite.service.On("CreditWallet", ctx, 1, 1000).Return(expectedResponse, nil).Once()
-		deps := server.Dependencies{
+		suite.service.On("CreditWallet", ctx, int64(1), 1000.0).Return(expectedResponse, nil).Once()
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 
 		// Assert
 		got := CreditWallet(deps.NikPay)
 		got.ServeHTTP(rw, req)
-		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, http.StatusAccepted, rw.Code)
 		assert.Equal(t, string(exp), rw.Body.String())
 	})
 
@@ -132,7 +136,7 @@ func (suite *WalletHandlerSuite) TestWallet_CreditWallet() {
 		req := httptest.NewRequest(http.MethodPost, "/user/wallet/credit", strings.NewReader(`{"amount": -1000}`))
 		rw := httptest.NewRecorder()
 		ctx := req.Context()
-		ctx = context.WithValue(ctx, "id", 1)
+		ctx = context.WithValue(ctx, "id", int64(1))
 		req = req.WithContext(ctx)
 		expectedResponse := domain.Message{
 			Message: "invalid request amount cannot be negative",
@@ -143,8 +147,8 @@ func (suite *WalletHandlerSuite) TestWallet_CreditWallet() {
 		}
 
 		// Act
-		suite.service.On("CreditWallet", ctx, 1, -1000).Return(domain.Wallet{}, errors.New("mocked error")).Once()
-		deps := server.Dependencies{
+		suite.service.On("CreditWallet", ctx, int64(1), -1000.0).Return(domain.TopUp{}, errors.New("invalid request amount cannot be negative")).Once()
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 
@@ -164,10 +168,17 @@ func (suite *WalletHandlerSuite) TestWallet_DebitWallet(){
 		req := httptest.NewRequest(http.MethodPost, "/user/wallet/debit", strings.NewReader(`{"amount": 1000}`))
 		rw := httptest.NewRecorder()
 		ctx := req.Context()
-		ctx = context.WithValue(ctx, "id", 1)
+		ctx = context.WithValue(ctx, "id", int64(1))
 		req = req.WithContext(ctx)
-		expectedResponse := domain.Message{
-			Message: "Amount debited from the wallet successfully.",
+		quote := domain.FeeQuote{
+			Operation: domain.FeeOperationDebit,
+			Amount:    1000,
+			Total:     1000,
+			Breakdown: []domain.FeeComponent{},
+		}
+		expectedResponse := domain.DebitResult{
+			Message: "Wallet debited successfully",
+			Quote:   quote,
 		}
 		exp, err := json.Marshal(expectedResponse)
 		if err != nil {
@@ -175,8 +186,8 @@ func (suite *WalletHandlerSuite) TestWallet_DebitWallet(){
 		}
 
 		// Act
-		suite.service.On("DebitWallet", ctx, 1, 1000).Return(expectedResponse, nil).Once()
-		deps := server.Dependencies{
+		suite.service.On("DebitWallet", ctx, int64(1), 1000.0).Return(quote, nil).Once()
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 
@@ -192,7 +203,7 @@ func (suite *WalletHandlerSuite) TestWallet_DebitWallet(){
 		req := httptest.NewRequest(http.MethodPost, "/user/wallet/debit", strings.NewReader(`{"amount": -1000}`))
 		rw := httptest.NewRecorder()
 		ctx := req.Context()
-		ctx = context.WithValue(ctx, "id", 1)
+		ctx = context.WithValue(ctx, "id", int64(1))
 		req = req.WithContext(ctx)
 		expectedResponse := domain.Message{
 			Message: "invalid request amount cannot be negative",
@@ -203,8 +214,8 @@ func (suite *WalletHandlerSuite) TestWallet_DebitWallet(){
 		}
 
 		// Act
-		suite.service.On("DebitWallet", ctx, 1, -1000).Return(domain.Wallet{}, errors.New("mocked error")).Once()
-		deps := server.Dependencies{
+		suite.service.On("DebitWallet", ctx, int64(1), -1000.0).Return(domain.FeeQuote{}, errors.New("invalid request amount cannot be negative")).Once()
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 
@@ -220,7 +231,7 @@ func (suite *WalletHandlerSuite) TestWallet_DebitWallet(){
 		req := httptest.NewRequest(http.MethodPost, "/user/wallet/debit", strings.NewReader(`{"amount": 1000}`))
 		rw := httptest.NewRecorder()
 		ctx := req.Context()
-		ctx = context.WithValue(ctx, "id", 1)
+		ctx = context.WithValue(ctx, "id", int64(1))
 		req = req.WithContext(ctx)
 		expectedResponse := domain.Message{
 			Message: "insufficient balance in wallet",
@@ -231,8 +242,8 @@ func (suite *WalletHandlerSuite) TestWallet_DebitWallet(){
 		}
 
 		// Act
-		suite.service.On("DebitWallet", ctx, 1, 1000).Return(domain.Message{}, errors.New("insufficient balance in wallet")).Once()
-		deps := server.Dependencies{
+		suite.service.On("DebitWallet", ctx, int64(1), 1000.0).Return(domain.FeeQuote{}, errors.New("insufficient balance in wallet")).Once()
+		deps := Dependencies{
 			NikPay: suite.service,
 		}
 
@@ -242,4 +253,23 @@ func (suite *WalletHandlerSuite) TestWallet_DebitWallet(){
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
 		assert.Equal(t, string(exp), rw.Body.String())
 	})
-}
\ No newline at end of file
+}
+func TestCreditWalletValidationErrors(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("CreditWallet", mock.Anything, int64(1), -1000.0).Return(domain.TopUp{}, apperrors.ValidationErrors{
+		{Field: "amount", Code: apperrors.CodeNotPositive, Err: apperrors.ErrAmountNotPositive},
+	}).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/wallet/credit", strings.NewReader(`{"amount": -1000}`))
+	rw := httptest.NewRecorder()
+	CreditWallet(NikPay)(rw, withUserID(req, 1))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.JSONEq(t, `{"message":"invalid request","errors":[{"field":"amount","code":"not_positive","message":"amount must be greater than zero"}]}`, rw.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/wallet/credit", strings.NewReader(`{"amount": "ten"}`))
+	rw = httptest.NewRecorder()
+	CreditWallet(NikPay)(rw, withUserID(req, 1))
+	assert.Equal(t, http.StatusBadRequest, rw.Code)
+	assert.JSONEq(t, `{"message":"invalid request","errors":[{"field":"amount","code":"invalid_type","message":"value has the wrong type"}]}`, rw.Body.String())
+	NikPay.AssertExpectations(t)
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// LinkBankAccount adds a bank account the signed in user can withdraw to.
+func LinkBankAccount(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var account domain.BankAccount
+		err := decodeJSON(r, &account)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		account, err = NikPay.LinkBankAccount(r.Context(), userID, account)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		switch err {
+		case nil:
+			writeJSON(rw, http.StatusCreated, account)
+		case errors.ErrUnverifiedUser:
+			writeMessage(rw, http.StatusForbidden, err.Error())
+		case errors.ErrBankAccountExists:
+			writeMessage(rw, http.StatusConflict, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+func ListBankAccounts(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		accounts, err := NikPay.ListBankAccounts(r.Context(), userID)
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, accounts)
+	})
+}
+
+// Withdraw starts a withdrawal to one of the user's bank accounts. The
+// amount is held at once and the withdrawal settles in the background.
+func Withdraw(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		var request domain.WithdrawalRequest
+		err := decodeJSON(r, &request)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		ctx := r.Context()
+		if code := r.Header.Get(stepUpHeader); code != "" {
+			ctx = service.WithStepUpCode(ctx, code)
+		}
+		withdrawal, err := NikPay.Withdraw(ctx, userID, request)
+		if writeValidationErrors(rw, err) {
+			return
+		}
+		switch err {
+		case nil:
+			rw.Header().Set("Location", fmt.Sprintf("%s/wallet/withdrawals/%d", apiVersion, withdrawal.ID))
+			writeJSON(rw, http.StatusAccepted, withdrawal)
+		case errors.ErrStepUpRequired, errors.ErrInvalidMFACode, errors.ErrMFANotEnrolled, errors.ErrUnverifiedUser:
+			writeMessage(rw, http.StatusForbidden, err.Error())
+		case errors.ErrStepUpLocked:
+			writeMessage(rw, http.StatusTooManyRequests, err.Error())
+		case errors.ErrInsufficientBalance, errors.ErrWalletFrozen, errors.ErrNoWallet, errors.ErrTransferRefused:
+			writeMessage(rw, http.StatusBadRequest, err.Error())
+		case errors.ErrNoPayoutProvider:
+			writeMessage(rw, http.StatusServiceUnavailable, err.Error())
+		default:
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+		}
+	})
+}
+
+// GetWithdrawal serves one of the signed in user's withdrawals.
+func GetWithdrawal(NikPay service.WalletService) http.HandlerFunc {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		userID := r.Context().Value("id").(int64)
+		withdrawalID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		withdrawal, err := NikPay.GetWithdrawal(r.Context(), userID, withdrawalID)
+		if err == errors.ErrWithdrawalNotFound {
+			writeMessage(rw, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			writeMessage(rw, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(rw, http.StatusOK, withdrawal)
+	})
+}
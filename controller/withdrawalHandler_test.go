@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLinkBankAccount(t *testing.T) {
+	NikPay := &mocks.WalletService{}
+	NikPay.On("LinkBankAccount", mock.Anything, int64(1), domain.BankAccount{HolderName: "John Doe", IFSC: "sbin0001234", AccountNumber: "123456789012"}).
+		Return(domain.BankAccount{ID: 5, UserID: 1, HolderName: "John Doe", IFSC: "SBIN0001234", AccountNumber: "123456789012"}, nil).Once()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/me/bank-accounts", strings.NewReader(`{"holder_name":"John Doe","ifsc":"sbin0001234","account_number":"123456789012"}`))
+	rw := httptest.NewRecorder()
+	LinkBankAccount(NikPay)(rw, withUserID(req, 1))
+	assert.Equal(t, http.StatusCreated, rw.Code)
+	assert.JSONEq(t, `{"id":5,"holder_name":"John Doe","ifsc":"SBIN0001234","account_number":"123456789012","created_at":"0001-01-01T00:00:00Z"}`, rw.Body.String())
+	NikPay.AssertExpectations(t)
+}
+
+func TestWithdraw(t *testing.T) {
+	t.Run("Holds the amount and points at the withdrawal", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("Withdraw", mock.Anything, int64(1), domain.WithdrawalRequest{BankAccountID: 5, Amount: 50}).Return(domain.Withdrawal{ID: 9, BankAccountID: 5, Amount: 50, Status: domain.WithdrawalStatusPending}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/wallet/withdrawals", strings.NewReader(`{"bank_account_id":5,"amount":50}`))
+		rw := httptest.NewRecorder()
+		Withdraw(NikPay)(rw, withUserID(req, 1))
+		assert.Equal(t, http.StatusAccepted, rw.Code)
+		assert.Equal(t, "/v1/wallet/withdrawals/9", rw.Header().Get("Location"))
+		NikPay.AssertExpectations(t)
+	})
+
+	t.Run("Reports a refused transfer", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("Withdraw", mock.Anything, int64(1), mock.Anything).Return(domain.Withdrawal{}, errors.ErrTransferRefused).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/wallet/withdrawals", strings.NewReader(`{"bank_account_id":5,"amount":50}`))
+		rw := httptest.NewRecorder()
+		Withdraw(NikPay)(rw, withUserID(req, 1))
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.JSONEq(t, `{"message":"payout provider refused the withdrawal, the funds were returned to the wallet"}`, rw.Body.String())
+	})
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	campaignColumns = `id, name, operation, percent, min_amount, max_reward, user_limit, budget, spent, reward_validity_days, starts_at, ends_at, created_at`
+
+	// lockCampaignQuery locks the campaign while a reward is granted, so
+	// concurrent grants can not overrun its budget or a user's limit.
+	lockCampaignQuery  = `SELECT budget - spent, user_limit FROM "campaign" WHERE id = $1 FOR UPDATE`
+	userRewardsQuery   = `SELECT COALESCE(SUM(amount), 0) FROM "promo_reward" WHERE user_id = $1 AND campaign_id = $2`
+	spendCampaignQuery = `UPDATE "campaign" SET spent = spent + $1 WHERE id = $2`
+	insertRewardQuery  = `INSERT INTO "promo_reward" (user_id, campaign_id, source_amount, amount, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	grantRewardQueries = lockCampaignQuery + "; " + userRewardsQuery + "; " + spendCampaignQuery + "; " + insertRewardQuery
+)
+
+// CreateCampaign stores a cashback campaign and returns its ID.
+func (s *pgStore) CreateCampaign(ctx context.Context, campaign domain.Campaign) (campaignID int64, err error) {
+	const query = `INSERT INTO "campaign" (name, operation, percent, min_amount, max_reward, user_limit, budget, reward_validity_days, starts_at, ends_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
+	ctx, finish := startQuery(ctx, "CreateCampaign", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, campaign.Name, campaign.Operation, campaign.Percent, campaign.MinAmount, campaign.MaxReward,
+		campaign.UserLimit, campaign.Budget, campaign.RewardValidityDays, campaign.StartsAt, campaign.EndsAt).Scan(&campaignID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingCampaign.Error())
+		return 0, errors.ErrCreatingCampaign
+	}
+	setRowCount(ctx, 1)
+	return campaignID, nil
+}
+
+func (s *pgStore) GetCampaign(ctx context.Context, campaignID int64) (campaign domain.Campaign, err error) {
+	const query = `SELECT ` + campaignColumns + ` FROM "campaign" WHERE id = $1`
+	ctx, finish := startQuery(ctx, "GetCampaign", query)
+	defer finish(&err)
+	campaign, err = scanCampaign(s.db.QueryRowxContext(ctx, query, campaignID))
+	if err == sql.ErrNoRows {
+		return domain.Campaign{}, errors.ErrCampaignNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingCampaign.Error())
+		return domain.Campaign{}, errors.ErrFetchingCampaign
+	}
+	setRowCount(ctx, 1)
+	return campaign, nil
+}
+
+// ListCampaigns returns every campaign, oldest first.
+func (s *pgStore) ListCampaigns(ctx context.Context) (campaigns []domain.Campaign, err error) {
+	const query = `SELECT ` + campaignColumns + ` FROM "campaign" ORDER BY id`
+	ctx, finish := startQuery(ctx, "ListCampaigns", query)
+	defer finish(&err)
+	return s.queryCampaigns(ctx, query)
+}
+
+// ListActiveCampaigns returns the campaigns rewarding operation at the given
+// time that have budget left.
+func (s *pgStore) ListActiveCampaigns(ctx context.Context, operation string, at time.Time) (campaigns []domain.Campaign, err error) {
+	const query = `SELECT ` + campaignColumns + ` FROM "campaign" WHERE operation = $1 AND starts_at <= $2 AND ends_at > $2 AND spent < budget ORDER BY id`
+	ctx, finish := startQuery(ctx, "ListActiveCampaigns", query)
+	defer finish(&err)
+	return s.queryCampaigns(ctx, query, operation, at)
+}
+
+func (s *pgStore) queryCampaigns(ctx context.Context, query string, args ...interface{}) ([]domain.Campaign, error) {
+	campaigns := []domain.Campaign{}
+	rows, err := s.db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingCampaign.Error())
+		return campaigns, errors.ErrFetchingCampaign
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingCampaign.Error())
+			return []domain.Campaign{}, errors.ErrFetchingCampaign
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	setRowCount(ctx, int64(len(campaigns)))
+	return campaigns, nil
+}
+
+// GrantReward credits up to reward.Amount to the user's promo balance. The
+// amount is cut down to what is left of the campaign budget and of the
+// user's limit, and the reduced amount, zero when nothing was left, is
+// returned.
+func (s *pgStore) GrantReward(ctx context.Context, reward domain.PromoReward) (granted float64, err error) {
+	ctx, finish := startQuery(ctx, "GrantReward", grantRewardQueries)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		var budgetLeft, userLimit float64
+		err := tx.QueryRowxContext(ctx, lockCampaignQuery, reward.CampaignID).Scan(&budgetLeft, &userLimit)
+		if err == sql.ErrNoRows {
+			return errors.ErrCampaignNotFound
+		}
+		if err != nil {
+			return err
+		}
+		granted = math.Min(reward.Amount, budgetLeft)
+		if userLimit > 0 {
+			var rewarded float64
+			err = tx.QueryRowxContext(ctx, userRewardsQuery, reward.UserID, reward.CampaignID).Scan(&rewarded)
+			if err != nil {
+				return err
+			}
+			granted = math.Min(granted, userLimit-rewarded)
+		}
+		granted = math.Round(granted*100) / 100
+		if granted <= 0 {
+			granted = 0
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, spendCampaignQuery, granted, reward.CampaignID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, insertRewardQuery, reward.UserID, reward.CampaignID, reward.SourceAmount, granted, reward.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		setRowCount(ctx, 2)
+		return nil
+	})
+	if err == errors.ErrCampaignNotFound {
+		return 0, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrGrantingReward.Error())
+		return 0, errors.ErrGrantingReward
+	}
+	return granted, nil
+}
+
+// ListPromoRewards returns the user's rewards that have not expired at the
+// given time, those expiring first first.
+func (s *pgStore) ListPromoRewards(ctx context.Context, userID int64, at time.Time) (rewards []domain.PromoReward, err error) {
+	const query = `SELECT r.id, r.user_id, r.campaign_id, c.name, r.source_amount, r.amount, r.expires_at, r.created_at
+		FROM "promo_reward" r JOIN "campaign" c ON c.id = r.campaign_id
+		WHERE r.user_id = $1 AND r.expires_at > $2 ORDER BY r.expires_at, r.id`
+	ctx, finish := startQuery(ctx, "ListPromoRewards", query)
+	defer finish(&err)
+	rewards = []domain.PromoReward{}
+	rows, err := s.db.QueryContext(ctx, query, userID, at)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingPromoBalance.Error())
+		return rewards, errors.ErrFetchingPromoBalance
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reward domain.PromoReward
+		err = rows.Scan(&reward.ID, &reward.UserID, &reward.CampaignID, &reward.CampaignName, &reward.SourceAmount, &reward.Amount, &reward.ExpiresAt, &reward.CreatedAt)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingPromoBalance.Error())
+			return []domain.PromoReward{}, errors.ErrFetchingPromoBalance
+		}
+		rewards = append(rewards, reward)
+	}
+	setRowCount(ctx, int64(len(rewards)))
+	return rewards, nil
+}
+
+func scanCampaign(row interface{ Scan(...interface{}) error }) (campaign domain.Campaign, err error) {
+	err = row.Scan(&campaign.ID, &campaign.Name, &campaign.Operation, &campaign.Percent, &campaign.MinAmount, &campaign.MaxReward, &campaign.UserLimit,
+		&campaign.Budget, &campaign.Spent, &campaign.RewardValidityDays, &campaign.StartsAt, &campaign.EndsAt, &campaign.CreatedAt)
+	return campaign, err
+}
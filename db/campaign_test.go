@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_Campaigns() {
+	t := suite.T()
+	startsAt := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := startsAt.AddDate(0, 0, 7)
+	campaign := domain.Campaign{Name: "May cashback", Operation: domain.CampaignOperationDebit, Percent: 5, MaxReward: 100, Budget: 10000, RewardValidityDays: 30, StartsAt: startsAt, EndsAt: endsAt}
+
+	suite.mock.ExpectQuery(`INSERT INTO "campaign"`).WithArgs("May cashback", domain.CampaignOperationDebit, 5.0, 0.0, 100.0, 0.0, 10000.0, 30, startsAt, endsAt).
+		WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(3))
+	campaignID, err := suite.repo.CreateCampaign(context.Background(), campaign)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), campaignID)
+
+	columns := []string{"id", "name", "operation", "percent", "min_amount", "max_reward", "user_limit", "budget", "spent", "reward_validity_days", "starts_at", "ends_at", "created_at"}
+	now := startsAt.Add(time.Hour)
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "campaign" WHERE operation = \$1 AND starts_at <= \$2 AND ends_at > \$2 AND spent < budget`).WithArgs(domain.CampaignOperationDebit, now).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(3, "May cashback", domain.CampaignOperationDebit, 5.0, 0.0, 100.0, 0.0, 10000.0, 250.0, 30, startsAt, endsAt, startsAt))
+	active, err := suite.repo.ListActiveCampaigns(context.Background(), domain.CampaignOperationDebit, now)
+	require.NoError(t, err)
+	campaign.ID, campaign.Spent, campaign.CreatedAt = 3, 250, startsAt
+	require.Equal(t, []domain.Campaign{campaign}, active)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "campaign" WHERE id = \$1`).WithArgs(int64(4)).WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetCampaign(context.Background(), 4)
+	require.Equal(t, errors.ErrCampaignNotFound, err)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_GrantReward() {
+	t := suite.T()
+	expiresAt := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	reward := domain.PromoReward{UserID: 42, CampaignID: 3, SourceAmount: 1000, Amount: 50, ExpiresAt: expiresAt}
+	lock := func() *sqlxmock.ExpectedQuery {
+		return suite.mock.ExpectQuery(`SELECT budget - spent, user_limit FROM "campaign" WHERE id = \$1 FOR UPDATE`).WithArgs(int64(3))
+	}
+
+	t.Run("cut down to the user's limit", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		lock().WillReturnRows(sqlxmock.NewRows([]string{"budget_left", "user_limit"}).AddRow(500.0, 100.0))
+		suite.mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM "promo_reward"`).WithArgs(int64(42), int64(3)).
+			WillReturnRows(sqlxmock.NewRows([]string{"sum"}).AddRow(80.0))
+		suite.mock.ExpectExec(`UPDATE "campaign" SET spent = spent \+ \$1`).WithArgs(20.0, int64(3)).WillReturnResult(sqlxmock.NewResult(0, 1))
+		suite.mock.ExpectExec(`INSERT INTO "promo_reward"`).WithArgs(int64(42), int64(3), 1000.0, 20.0, expiresAt).WillReturnResult(sqlxmock.NewResult(1, 1))
+		suite.mock.ExpectCommit()
+
+		granted, err := suite.repo.GrantReward(context.Background(), reward)
+		require.NoError(t, err)
+		require.Equal(t, 20.0, granted)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing left of the budget", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		lock().WillReturnRows(sqlxmock.NewRows([]string{"budget_left", "user_limit"}).AddRow(0.0, 0.0))
+		suite.mock.ExpectCommit()
+
+		granted, err := suite.repo.GrantReward(context.Background(), reward)
+		require.NoError(t, err)
+		require.Zero(t, granted)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back on errors", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		lock().WillReturnRows(sqlxmock.NewRows([]string{"budget_left", "user_limit"}).AddRow(500.0, 0.0))
+		suite.mock.ExpectExec(`UPDATE "campaign"`).WillReturnError(sql.ErrConnDone)
+		suite.mock.ExpectRollback()
+
+		_, err := suite.repo.GrantReward(context.Background(), reward)
+		require.Equal(t, errors.ErrGrantingReward, err)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ListPromoRewards() {
+	t := suite.T()
+	now := time.Date(2024, time.May, 10, 0, 0, 0, 0, time.UTC)
+	expiresAt := now.AddDate(0, 0, 20)
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "promo_reward" r JOIN "campaign" c`).WithArgs(int64(42), now).
+		WillReturnRows(sqlxmock.NewRows([]string{"id", "user_id", "campaign_id", "name", "source_amount", "amount", "expires_at", "created_at"}).
+			AddRow(1, 42, 3, "May cashback", 1000.0, 50.0, expiresAt, now))
+
+	rewards, err := suite.repo.ListPromoRewards(context.Background(), 42, now)
+	require.NoError(t, err)
+	require.Equal(t, []domain.PromoReward{{ID: 1, UserID: 42, CampaignID: 3, CampaignName: "May cashback", SourceAmount: 1000, Amount: 50, ExpiresAt: expiresAt, CreatedAt: now}}, rewards)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
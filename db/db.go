@@ -3,13 +3,79 @@ package db
 import (
 	"context"
 	"nickPay/wallet/internal/domain"
+	"time"
 )
 
 type Storer interface {
-	RegisterUser(context.Context, domain.User) error
+	Ping(context.Context) error
+	Close() error
+	RegisterUser(context.Context, domain.User) (int64, error)
 	LoginUser(context.Context, string) (domain.LoginDbResponse, error)
+	GetUser(context.Context, int64) (domain.User, error)
+	FindUser(context.Context, string) (domain.User, error)
 	CreateWallet(context.Context, int64) error
 	GetWallet(context.Context, int64) (domain.Wallet, error)
-	CreditWallet(context.Context, int64, float64) error
-	DebitWallet(context.Context, int64, float64) error
+	DebitWallet(context.Context, int64, float64, domain.FeeCharge) error
+	AdjustWallet(context.Context, int64, float64, string) error
+	ListTransactions(context.Context, int64, time.Time, time.Time) ([]domain.Transaction, error)
+	ListRecentTransactions(context.Context, int64, int64, int) ([]domain.Transaction, error)
+	ListTransactionsSince(context.Context, int64, int64, int) ([]domain.Transaction, error)
+	GetWalletsByID(context.Context, int64, []int64) ([]domain.Wallet, error)
+	GetBalanceAt(context.Context, int64, time.Time) (float64, error)
+	ListWalletBalances(context.Context) ([]domain.WalletLedgerBalance, error)
+	SetWalletStatus(context.Context, int64, string) error
+	GetLoginAttempt(context.Context, string) (domain.LoginAttempt, error)
+	RecordLoginFailure(context.Context, string, time.Time) (domain.LoginAttempt, error)
+	LockLogin(context.Context, string, time.Time) error
+	ClearLoginAttempts(context.Context, string) error
+	GetUserMFA(context.Context, int64) (domain.UserMFA, error)
+	SaveTOTPSecret(context.Context, int64, string) error
+	EnableMFA(context.Context, int64, []string) error
+	MarkTOTPStepUsed(context.Context, int64, int64) (bool, error)
+	UseRecoveryCode(context.Context, int64, string) (bool, error)
+	SaveVerificationCode(context.Context, domain.VerificationCode) error
+	GetVerificationCode(context.Context, int64, string) (domain.VerificationCode, error)
+	IncrementVerificationAttempts(context.Context, int64, string) error
+	MarkVerified(context.Context, int64, string) error
+	GetUserCredentials(context.Context, int64) (domain.LoginDbResponse, error)
+	GetSessionVersion(context.Context, int64) (int64, error)
+	SavePasswordResetToken(context.Context, domain.PasswordResetToken) error
+	ResetPassword(context.Context, string, string) (int64, error)
+	ChangePassword(context.Context, int64, string) error
+	UpgradePasswordHash(context.Context, int64, string, string) error
+	UpdateUser(context.Context, int64, domain.UserUpdate) error
+	ConfirmEmailChange(context.Context, int64) error
+	DeleteUser(context.Context, int64) error
+	FindPayoutRecipients(context.Context, []string) ([]domain.PayoutRecipient, error)
+	CreatePayoutBatch(context.Context, domain.PayoutBatch, []domain.PayoutRow) (int64, error)
+	GetPayoutBatch(context.Context, int64) (domain.PayoutBatch, error)
+	ListPayoutRows(context.Context, int64) ([]domain.PayoutRow, error)
+	SetPayoutBatchStatus(context.Context, int64, string) error
+	PayPayoutRows(context.Context, int64, []domain.PayoutRow, bool) error
+	CreateTopUp(context.Context, domain.TopUp) (int64, error)
+	SetTopUpPayment(context.Context, int64, string, string) error
+	GetTopUp(context.Context, int64) (domain.TopUp, error)
+	CompleteTopUp(context.Context, int64, string) (bool, error)
+	CreateBankAccount(context.Context, domain.BankAccount) (int64, error)
+	ListBankAccounts(context.Context, int64) ([]domain.BankAccount, error)
+	GetBankAccount(context.Context, int64) (domain.BankAccount, error)
+	CreateWithdrawal(context.Context, domain.Withdrawal, domain.FeeCharge) (int64, error)
+	SetWithdrawalTransfer(context.Context, int64, string) error
+	GetWithdrawal(context.Context, int64) (domain.Withdrawal, error)
+	ListPendingWithdrawals(context.Context, int, time.Duration) ([]domain.Withdrawal, error)
+	CompleteWithdrawal(context.Context, int64, string, string) (bool, error)
+	CreateCampaign(context.Context, domain.Campaign) (int64, error)
+	GetCampaign(context.Context, int64) (domain.Campaign, error)
+	ListCampaigns(context.Context) ([]domain.Campaign, error)
+	ListActiveCampaigns(context.Context, string, time.Time) ([]domain.Campaign, error)
+	GrantReward(context.Context, domain.PromoReward) (float64, error)
+	ListPromoRewards(context.Context, int64, time.Time) ([]domain.PromoReward, error)
+	CreateMerchant(context.Context, domain.Merchant) (int64, error)
+	GetMerchant(context.Context, int64) (domain.Merchant, error)
+	GetMerchantByUser(context.Context, int64) (domain.Merchant, error)
+	GetMerchantWallet(context.Context, int64) (domain.Wallet, error)
+	CreatePaymentQR(context.Context, domain.PaymentQR) (int64, error)
+	GetPaymentQR(context.Context, int64) (domain.PaymentQR, error)
+	PayMerchant(context.Context, domain.Merchant, domain.MerchantPayment, domain.FeeCharge) (domain.MerchantPayment, error)
+	ListSettlementDays(context.Context, int64, time.Time, time.Time) ([]domain.SettlementDay, error)
 }
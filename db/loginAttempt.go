@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+)
+
+// GetLoginAttempt returns the failed login record for key, or a zero record
+// when there have been no recent failures.
+func (s *pgStore) GetLoginAttempt(ctx context.Context, key string) (attempt domain.LoginAttempt, err error) {
+	const query = `SELECT key, failures, last_failure_at, locked_until FROM "login_attempt" WHERE key = $1`
+	ctx, finish := startQuery(ctx, "GetLoginAttempt", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, key).StructScan(&attempt)
+	if err == sql.ErrNoRows {
+		return domain.LoginAttempt{Key: key}, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrTrackingLogin.Error())
+		return domain.LoginAttempt{}, errors.ErrTrackingLogin
+	}
+	setRowCount(ctx, 1)
+	return attempt, nil
+}
+
+// RecordLoginFailure counts a failed login against key. Failures recorded
+// before since are forgotten and the count starts again from one.
+func (s *pgStore) RecordLoginFailure(ctx context.Context, key string, since time.Time) (attempt domain.LoginAttempt, err error) {
+	const query = `INSERT INTO "login_attempt" (key, failures, last_failure_at, locked_until) VALUES ($1, 1, $2, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN "login_attempt".last_failure_at < $3 THEN 1 ELSE "login_attempt".failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at, locked_until`
+	ctx, finish := startQuery(ctx, "RecordLoginFailure", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, key, time.Now(), since).StructScan(&attempt)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrTrackingLogin.Error())
+		return domain.LoginAttempt{}, errors.ErrTrackingLogin
+	}
+	setRowCount(ctx, 1)
+	return attempt, nil
+}
+
+// LockLogin rejects logins for key until the given instant.
+func (s *pgStore) LockLogin(ctx context.Context, key string, until time.Time) (err error) {
+	const query = `UPDATE "login_attempt" SET locked_until = $1 WHERE key = $2`
+	ctx, finish := startQuery(ctx, "LockLogin", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, until, key)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrTrackingLogin.Error())
+		return errors.ErrTrackingLogin
+	}
+	rowsAffected, _ := result.RowsAffected()
+	setRowCount(ctx, rowsAffected)
+	return nil
+}
+
+// ClearLoginAttempts forgets the failures and any lock recorded for key.
+func (s *pgStore) ClearLoginAttempts(ctx context.Context, key string) (err error) {
+	const query = `DELETE FROM "login_attempt" WHERE key = $1`
+	ctx, finish := startQuery(ctx, "ClearLoginAttempts", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, key)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrTrackingLogin.Error())
+		return errors.ErrTrackingLogin
+	}
+	rowsAffected, _ := result.RowsAffected()
+	setRowCount(ctx, rowsAffected)
+	return nil
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"nickPay/wallet/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_GetLoginAttempt() {
+	t := suite.T()
+	lockedUntil := time.Date(2023, time.May, 1, 12, 15, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		key     string
+		rows    *sqlxmock.Rows
+		want    domain.LoginAttempt
+		wantErr bool
+	}{
+		{
+			name:    "Locked account",
+			key:     "account:john@mail.com",
+			rows:    sqlxmock.NewRows([]string{"key", "failures", "last_failure_at", "locked_until"}).AddRow("account:john@mail.com", 5, lockedUntil.Add(-15*time.Minute), lockedUntil),
+			want:    domain.LoginAttempt{Key: "account:john@mail.com", Failures: 5, LastFailureAt: lockedUntil.Add(-15 * time.Minute), LockedUntil: lockedUntil},
+			wantErr: false,
+		},
+		{
+			name:    "No recent failures",
+			key:     "ip:10.0.0.1",
+			rows:    sqlxmock.NewRows([]string{"key", "failures", "last_failure_at", "locked_until"}),
+			want:    domain.LoginAttempt{Key: "ip:10.0.0.1"},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite.mock.ExpectQuery(`SELECT (.+) FROM "login_attempt"`).WithArgs(tt.key).WillReturnRows(tt.rows)
+
+			got, err := suite.repo.GetLoginAttempt(context.Background(), tt.key)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func (suite *StoreTestSuite) Test_pgStore_RecordLoginFailure() {
+	t := suite.T()
+	since := time.Date(2023, time.May, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{
+			name:    "Count failure",
+			wantErr: false,
+		},
+		{
+			name:    "Error while counting failure",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := suite.mock.ExpectQuery(`INSERT INTO "login_attempt"`).WithArgs("account:john@mail.com", sqlxmock.AnyArg(), since)
+			if tt.wantErr {
+				query.WillReturnError(errors.New("mocked error"))
+			} else {
+				query.WillReturnRows(sqlxmock.NewRows([]string{"key", "failures", "last_failure_at", "locked_until"}).AddRow("account:john@mail.com", 3, since, since))
+			}
+
+			got, err := suite.repo.RecordLoginFailure(context.Background(), "account:john@mail.com", since)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, 3, got.Failures)
+			}
+		})
+	}
+}
+
+func (suite *StoreTestSuite) Test_pgStore_LockAndClearLogin() {
+	t := suite.T()
+	until := time.Date(2023, time.May, 1, 12, 15, 0, 0, time.UTC)
+
+	suite.mock.ExpectExec(`UPDATE "login_attempt" SET locked_until = \$1`).WithArgs(until, "ip:10.0.0.1").WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.LockLogin(context.Background(), "ip:10.0.0.1", until))
+
+	suite.mock.ExpectExec(`DELETE FROM "login_attempt"`).WithArgs("ip:10.0.0.1").WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.ClearLoginAttempts(context.Background(), "ip:10.0.0.1"))
+
+	suite.mock.ExpectExec(`DELETE FROM "login_attempt"`).WithArgs("ip:10.0.0.2").WillReturnError(errors.New("mocked error"))
+	require.Error(t, suite.repo.ClearLoginAttempts(context.Background(), "ip:10.0.0.2"))
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
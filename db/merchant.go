@@ -0,0 +1,250 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// merchantUserUnique limits users to one merchant account.
+	merchantUserUnique = "merchant_user_unique"
+
+	merchantColumns = `id, user_id, name, created_at`
+
+	insertMerchantQuery       = `INSERT INTO "merchant" (user_id, name) VALUES ($1, $2) RETURNING id`
+	insertMerchantWalletQuery = `INSERT INTO "wallet" (merchant_id, balance, creation_date, last_updated, status) VALUES ($1, 0, $2, $3, $4)`
+
+	// creditMerchantQuery pays into the wallet of a merchant, unless deleting
+	// the account of its user closed it.
+	creditMerchantQuery = `UPDATE "wallet" SET balance = balance + $1, last_updated = $2 WHERE merchant_id = $3 AND status <> 'closed' RETURNING id, balance`
+
+	// claimPaymentQRQuery marks a dynamic code paid if it still is pending
+	// and has not expired, which also locks it, so it is paid once.
+	claimPaymentQRQuery        = `UPDATE "payment_qr" SET status = 'paid', paid_at = NOW() WHERE id = $1 AND status = 'pending' AND expires_at > NOW()`
+	paymentQRStatusQuery       = `SELECT status FROM "payment_qr" WHERE id = $1`
+	insertMerchantPaymentQuery = `INSERT INTO "merchant_payment" (merchant_id, payer_id, qr_id, amount, reference) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+	transferFeeDescription     = "QR payment fee"
+	payMerchantQueries         = claimPaymentQRQuery + "; " + movementQueries + "; " + creditMerchantQuery + "; " + insertMerchantPaymentQuery
+)
+
+// CreateMerchant links a merchant to its user, opens the wallet it is paid
+// into and returns its ID.
+func (s *pgStore) CreateMerchant(ctx context.Context, merchant domain.Merchant) (merchantID int64, err error) {
+	ctx, finish := startQuery(ctx, "CreateMerchant", insertMerchantQuery+"; "+insertMerchantWalletQuery)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, insertMerchantQuery, merchant.UserID, merchant.Name).Scan(&merchantID)
+		if err != nil {
+			return err
+		}
+		now := time.Now().Local()
+		_, err = tx.ExecContext(ctx, insertMerchantWalletQuery, merchantID, now.Format("2006-01-02"), now.Format("2006-01-02 15:04:05"), domain.WalletStatusActive)
+		return err
+	})
+	if isUniqueViolation(err, merchantUserUnique) {
+		return 0, errors.ErrMerchantExists
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingMerchant.Error())
+		return 0, errors.ErrCreatingMerchant
+	}
+	setRowCount(ctx, 2)
+	return merchantID, nil
+}
+
+func (s *pgStore) GetMerchant(ctx context.Context, merchantID int64) (merchant domain.Merchant, err error) {
+	const query = `SELECT ` + merchantColumns + ` FROM "merchant" WHERE id = $1`
+	ctx, finish := startQuery(ctx, "GetMerchant", query)
+	defer finish(&err)
+	return s.getMerchant(ctx, query, merchantID)
+}
+
+// GetMerchantByUser returns the merchant linked to the user.
+func (s *pgStore) GetMerchantByUser(ctx context.Context, userID int64) (merchant domain.Merchant, err error) {
+	const query = `SELECT ` + merchantColumns + ` FROM "merchant" WHERE user_id = $1`
+	ctx, finish := startQuery(ctx, "GetMerchantByUser", query)
+	defer finish(&err)
+	return s.getMerchant(ctx, query, userID)
+}
+
+func (s *pgStore) getMerchant(ctx context.Context, query string, id int64) (merchant domain.Merchant, err error) {
+	err = s.db.QueryRowxContext(ctx, query, id).Scan(&merchant.ID, &merchant.UserID, &merchant.Name, &merchant.CreatedAt)
+	if err == sql.ErrNoRows {
+		return domain.Merchant{}, errors.ErrMerchantNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingMerchant.Error())
+		return domain.Merchant{}, errors.ErrFetchingMerchant
+	}
+	setRowCount(ctx, 1)
+	return merchant, nil
+}
+
+// GetMerchantWallet returns the wallet the merchant is paid into.
+func (s *pgStore) GetMerchantWallet(ctx context.Context, merchantID int64) (wallet domain.Wallet, err error) {
+	const query = `SELECT id, balance, creation_date, last_updated, status FROM "wallet" WHERE merchant_id = $1`
+	ctx, finish := startQuery(ctx, "GetMerchantWallet", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, merchantID).Scan(&wallet.ID, &wallet.Balance, &wallet.CreationDate, &wallet.LastUpdated, &wallet.Status)
+	if err == sql.ErrNoRows {
+		return domain.Wallet{}, errors.ErrNoMerchantWallet
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
+		return domain.Wallet{}, errors.ErrFetchingWallet
+	}
+	setRowCount(ctx, 1)
+	return wallet, nil
+}
+
+// CreatePaymentQR stores a pending dynamic payment QR code and returns its
+// ID.
+func (s *pgStore) CreatePaymentQR(ctx context.Context, qr domain.PaymentQR) (qrID int64, err error) {
+	const query = `INSERT INTO "payment_qr" (merchant_id, amount, reference, status, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	ctx, finish := startQuery(ctx, "CreatePaymentQR", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, qr.MerchantID, qr.Amount, qr.Reference, domain.PaymentQRStatusPending, *qr.ExpiresAt).Scan(&qrID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingPaymentQR.Error())
+		return 0, errors.ErrCreatingPaymentQR
+	}
+	setRowCount(ctx, 1)
+	return qrID, nil
+}
+
+func (s *pgStore) GetPaymentQR(ctx context.Context, qrID int64) (qr domain.PaymentQR, err error) {
+	const query = `SELECT id, merchant_id, amount, reference, status, expires_at FROM "payment_qr" WHERE id = $1`
+	ctx, finish := startQuery(ctx, "GetPaymentQR", query)
+	defer finish(&err)
+	var expiresAt time.Time
+	err = s.db.QueryRowxContext(ctx, query, qrID).Scan(&qr.ID, &qr.MerchantID, &qr.Amount, &qr.Reference, &qr.Status, &expiresAt)
+	if err == sql.ErrNoRows {
+		return domain.PaymentQR{}, errors.ErrPaymentQRNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingPaymentQR.Error())
+		return domain.PaymentQR{}, errors.ErrFetchingPaymentQR
+	}
+	qr.Kind, qr.ExpiresAt = domain.PaymentQRDynamic, &expiresAt
+	setRowCount(ctx, 1)
+	return qr, nil
+}
+
+// PayMerchant moves payment.Amount from the payer's wallet to the wallet of
+// the merchant, and fee to the house revenue wallet, and records the payment,
+// in one transaction. A payment through a
+// dynamic code also marks the code paid, and fails with ErrQRAlreadyPaid if
+// another payment got to it first or ErrQRExpired if it ran out meanwhile.
+// It fails with ErrInsufficientBalance when the balance no longer covers
+// the amount and fee.
+func (s *pgStore) PayMerchant(ctx context.Context, merchant domain.Merchant, payment domain.MerchantPayment, fee domain.FeeCharge) (paid domain.MerchantPayment, err error) {
+	ctx, finish := startQuery(ctx, "PayMerchant", payMerchantQueries)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		if payment.QRID != 0 {
+			result, err := tx.ExecContext(ctx, claimPaymentQRQuery, payment.QRID)
+			if err != nil {
+				return err
+			}
+			claimed, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if claimed == 0 {
+				return claimFailure(ctx, tx, payment.QRID)
+			}
+		}
+		description := "QR payment to " + merchant.Name
+		if payment.Reference != "" {
+			description += fmt.Sprintf(" (%s)", payment.Reference)
+		}
+		err := applyMovement(ctx, tx, payment.PayerID, domain.TransactionDebit, -payment.Amount, description)
+		if err != nil {
+			return err
+		}
+		err = chargeFee(ctx, tx, payment.PayerID, fee, transferFeeDescription)
+		if err != nil {
+			return err
+		}
+		err = creditMerchant(ctx, tx, merchant.ID, payment.Amount, fmt.Sprintf("QR payment from user %d", payment.PayerID))
+		if err != nil {
+			return err
+		}
+		qrID := sql.NullInt64{Int64: payment.QRID, Valid: payment.QRID != 0}
+		return tx.QueryRowxContext(ctx, insertMerchantPaymentQuery, merchant.ID, payment.PayerID, qrID, payment.Amount, payment.Reference).Scan(&payment.ID, &payment.CreatedAt)
+	})
+	switch err {
+	case nil:
+		payment.MerchantID, payment.MerchantName = merchant.ID, merchant.Name
+		return payment, nil
+	case errors.ErrQRAlreadyPaid, errors.ErrQRExpired, errors.ErrNoWallet, errors.ErrNoMerchantWallet, errors.ErrInsufficientBalance:
+		return domain.MerchantPayment{}, err
+	}
+	logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrPayingMerchant.Error())
+	return domain.MerchantPayment{}, errors.ErrPayingMerchant
+}
+
+// creditMerchant pays amount into the wallet of merchantID and records it in
+// the ledger of that wallet, which has no user. Like applyMovement it must run
+// inside tx.
+func creditMerchant(ctx context.Context, tx *sqlx.Tx, merchantID int64, amount float64, description string) error {
+	var walletID int64
+	var balance float64
+	err := tx.QueryRowxContext(ctx, creditMerchantQuery, amount, time.Now().Local().Format("2006-01-02 15:04:05"), merchantID).Scan(&walletID, &balance)
+	if err == sql.ErrNoRows {
+		return errors.ErrNoMerchantWallet
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, insertTransactionQuery, walletID, nil, domain.TransactionCredit, amount, balance, description)
+	return err
+}
+
+// claimFailure tells why claimPaymentQRQuery left the code qrID alone.
+func claimFailure(ctx context.Context, tx *sqlx.Tx, qrID int64) error {
+	var status string
+	err := tx.QueryRowxContext(ctx, paymentQRStatusQuery, qrID).Scan(&status)
+	if err != nil {
+		return err
+	}
+	if status == domain.PaymentQRStatusPending {
+		return errors.ErrQRExpired
+	}
+	return errors.ErrQRAlreadyPaid
+}
+
+// ListSettlementDays totals the merchant's payments from from up to, but
+// excluding, to by day, leaving out days without any.
+func (s *pgStore) ListSettlementDays(ctx context.Context, merchantID int64, from time.Time, to time.Time) (days []domain.SettlementDay, err error) {
+	const query = `SELECT TO_CHAR(created_at, 'YYYY-MM-DD') AS day, COUNT(*), SUM(amount) FROM "merchant_payment"
+		WHERE merchant_id = $1 AND created_at >= $2 AND created_at < $3 GROUP BY day ORDER BY day`
+	ctx, finish := startQuery(ctx, "ListSettlementDays", query)
+	defer finish(&err)
+	days = []domain.SettlementDay{}
+	rows, err := s.db.QueryContext(ctx, query, merchantID, from, to)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingSettlements.Error())
+		return days, errors.ErrFetchingSettlements
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day domain.SettlementDay
+		err = rows.Scan(&day.Date, &day.Payments, &day.Amount)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingSettlements.Error())
+			return []domain.SettlementDay{}, errors.ErrFetchingSettlements
+		}
+		days = append(days, day)
+	}
+	setRowCount(ctx, int64(len(days)))
+	return days, nil
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_Merchants() {
+	t := suite.T()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "merchant"`).WithArgs(int64(42), "Corner Cafe").
+		WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(12))
+	suite.mock.ExpectExec(`INSERT INTO "wallet" \(merchant_id, balance, creation_date, last_updated, status\)`).
+		WithArgs(int64(12), sqlxmock.AnyArg(), sqlxmock.AnyArg(), domain.WalletStatusActive).WillReturnResult(sqlxmock.NewResult(5, 1))
+	suite.mock.ExpectCommit()
+	merchantID, err := suite.repo.CreateMerchant(context.Background(), domain.Merchant{UserID: 42, Name: "Corner Cafe"})
+	require.NoError(t, err)
+	require.Equal(t, int64(12), merchantID)
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "merchant"`).WillReturnError(&pq.Error{Code: "23505", Constraint: merchantUserUnique})
+	suite.mock.ExpectRollback()
+	_, err = suite.repo.CreateMerchant(context.Background(), domain.Merchant{UserID: 42, Name: "Corner Cafe"})
+	require.Equal(t, errors.ErrMerchantExists, err)
+
+	createdAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "name", "created_at"}
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "merchant" WHERE user_id = \$1`).WithArgs(int64(42)).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(12, 42, "Corner Cafe", createdAt))
+	merchant, err := suite.repo.GetMerchantByUser(context.Background(), 42)
+	require.NoError(t, err)
+	require.Equal(t, domain.Merchant{ID: 12, UserID: 42, Name: "Corner Cafe", CreatedAt: createdAt}, merchant)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "merchant" WHERE id = \$1`).WithArgs(int64(13)).WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetMerchant(context.Background(), 13)
+	require.Equal(t, errors.ErrMerchantNotFound, err)
+
+	walletColumns := []string{"id", "balance", "creation_date", "last_updated", "status"}
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "wallet" WHERE merchant_id = \$1`).WithArgs(int64(12)).
+		WillReturnRows(sqlxmock.NewRows(walletColumns).AddRow(5, 250.0, "2024-05-01", "2024-05-01 10:05:00", domain.WalletStatusActive))
+	wallet, err := suite.repo.GetMerchantWallet(context.Background(), 12)
+	require.NoError(t, err)
+	require.Equal(t, domain.Wallet{ID: 5, Balance: 250, CreationDate: "2024-05-01", LastUpdated: "2024-05-01 10:05:00", Status: domain.WalletStatusActive}, wallet)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "wallet" WHERE merchant_id = \$1`).WithArgs(int64(13)).WillReturnRows(sqlxmock.NewRows(walletColumns))
+	_, err = suite.repo.GetMerchantWallet(context.Background(), 13)
+	require.Equal(t, errors.ErrNoMerchantWallet, err)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_PaymentQR() {
+	t := suite.T()
+	expiresAt := time.Date(2024, time.May, 1, 10, 15, 0, 0, time.UTC)
+	suite.mock.ExpectQuery(`INSERT INTO "payment_qr"`).WithArgs(int64(12), 250.0, "INV-1", domain.PaymentQRStatusPending, expiresAt).
+		WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(34))
+	qrID, err := suite.repo.CreatePaymentQR(context.Background(), domain.PaymentQR{MerchantID: 12, Amount: 250, Reference: "INV-1", ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+	require.Equal(t, int64(34), qrID)
+
+	columns := []string{"id", "merchant_id", "amount", "reference", "status", "expires_at"}
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "payment_qr" WHERE id = \$1`).WithArgs(int64(34)).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(34, 12, 250.0, "INV-1", domain.PaymentQRStatusPending, expiresAt))
+	qr, err := suite.repo.GetPaymentQR(context.Background(), 34)
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentQR{ID: 34, MerchantID: 12, Kind: domain.PaymentQRDynamic, Amount: 250, Reference: "INV-1", Status: domain.PaymentQRStatusPending, ExpiresAt: &expiresAt}, qr)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "payment_qr" WHERE id = \$1`).WithArgs(int64(35)).WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetPaymentQR(context.Background(), 35)
+	require.Equal(t, errors.ErrPaymentQRNotFound, err)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_PayMerchant() {
+	t := suite.T()
+	merchant := domain.Merchant{ID: 12, UserID: 7, Name: "Corner Cafe"}
+	paidAt := time.Date(2024, time.May, 1, 10, 5, 0, 0, time.UTC)
+
+	t.Run("moves the amount and fee and claims the code", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectExec(`UPDATE "payment_qr" SET status = 'paid'`).WithArgs(int64(34)).WillReturnResult(sqlxmock.NewResult(0, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-250.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 750.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -250.0, 750.0, "QR payment to Corner Cafe (INV-1)").
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-0.5, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 749.5))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -0.5, 749.5, "QR payment fee").
+			WillReturnResult(sqlxmock.NewResult(2, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(0.5, sqlxmock.AnyArg(), int64(1)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(1, 10.5))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(1, int64(1), domain.TransactionCredit, 0.5, 10.5, "QR payment fee from user 42").
+			WillReturnResult(sqlxmock.NewResult(3, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1, last_updated = \$2 WHERE merchant_id = \$3 AND status <> 'closed'`).WithArgs(250.0, sqlxmock.AnyArg(), int64(12)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(5, 250.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(5, nil, domain.TransactionCredit, 250.0, 250.0, "QR payment from user 42").
+			WillReturnResult(sqlxmock.NewResult(4, 1))
+		suite.mock.ExpectQuery(`INSERT INTO "merchant_payment"`).WithArgs(int64(12), int64(42), int64(34), 250.0, "INV-1").
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "created_at"}).AddRow(56, paidAt))
+		suite.mock.ExpectCommit()
+
+		payment, err := suite.repo.PayMerchant(context.Background(), merchant, domain.MerchantPayment{PayerID: 42, QRID: 34, Amount: 250, Reference: "INV-1"}, domain.FeeCharge{Amount: 0.5, AccountID: 1})
+		require.NoError(t, err)
+		require.Equal(t, domain.MerchantPayment{ID: 56, MerchantID: 12, MerchantName: "Corner Cafe", PayerID: 42, QRID: 34, Amount: 250, Reference: "INV-1", CreatedAt: paidAt}, payment)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("pays a code once", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectExec(`UPDATE "payment_qr" SET status = 'paid'`).WithArgs(int64(34)).WillReturnResult(sqlxmock.NewResult(0, 0))
+		suite.mock.ExpectQuery(`SELECT status FROM "payment_qr" WHERE id = \$1`).WithArgs(int64(34)).
+			WillReturnRows(sqlxmock.NewRows([]string{"status"}).AddRow(domain.PaymentQRStatusPaid))
+		suite.mock.ExpectRollback()
+
+		_, err := suite.repo.PayMerchant(context.Background(), merchant, domain.MerchantPayment{PayerID: 42, QRID: 34, Amount: 250}, domain.FeeCharge{})
+		require.Equal(t, errors.ErrQRAlreadyPaid, err)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses an expired code", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectExec(`UPDATE "payment_qr" SET status = 'paid', paid_at = NOW\(\) WHERE id = \$1 AND status = 'pending' AND expires_at > NOW\(\)`).WithArgs(int64(34)).
+			WillReturnResult(sqlxmock.NewResult(0, 0))
+		suite.mock.ExpectQuery(`SELECT status FROM "payment_qr" WHERE id = \$1`).WithArgs(int64(34)).
+			WillReturnRows(sqlxmock.NewRows([]string{"status"}).AddRow(domain.PaymentQRStatusPending))
+		suite.mock.ExpectRollback()
+
+		_, err := suite.repo.PayMerchant(context.Background(), merchant, domain.MerchantPayment{PayerID: 42, QRID: 34, Amount: 250}, domain.FeeCharge{})
+		require.Equal(t, errors.ErrQRExpired, err)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses to overdraw the payer", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-20.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}))
+		suite.mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int64(42)).WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(true))
+		suite.mock.ExpectRollback()
+
+		_, err := suite.repo.PayMerchant(context.Background(), merchant, domain.MerchantPayment{PayerID: 42, Amount: 20}, domain.FeeCharge{})
+		require.Equal(t, errors.ErrInsufficientBalance, err)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back without a merchant wallet", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-20.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 980.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -20.0, 980.0, "QR payment to Corner Cafe").
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1, last_updated = \$2 WHERE merchant_id = \$3`).WithArgs(20.0, sqlxmock.AnyArg(), int64(12)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}))
+		suite.mock.ExpectRollback()
+
+		_, err := suite.repo.PayMerchant(context.Background(), merchant, domain.MerchantPayment{PayerID: 42, Amount: 20}, domain.FeeCharge{})
+		require.Equal(t, errors.ErrNoMerchantWallet, err)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ListSettlementDays() {
+	t := suite.T()
+	from := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	suite.mock.ExpectQuery(`SELECT TO_CHAR\(created_at, 'YYYY-MM-DD'\) AS day, COUNT\(\*\), SUM\(amount\) FROM "merchant_payment"`).WithArgs(int64(12), from, to).
+		WillReturnRows(sqlxmock.NewRows([]string{"day", "count", "sum"}).AddRow("2024-05-01", 2, 270.0).AddRow("2024-05-03", 1, 15.5))
+
+	days, err := suite.repo.ListSettlementDays(context.Background(), 12, from, to)
+	require.NoError(t, err)
+	require.Equal(t, []domain.SettlementDay{{Date: "2024-05-01", Payments: 2, Amount: 270}, {Date: "2024-05-03", Payments: 1, Amount: 15.5}}, days)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetUserMFA returns the two-factor settings of a user. Users who never
+// enrolled get a zero, disabled record.
+func (s *pgStore) GetUserMFA(ctx context.Context, userID int64) (mfa domain.UserMFA, err error) {
+	const query = `SELECT user_id, secret, enabled, last_used_step FROM "user_mfa" WHERE user_id = $1`
+	ctx, finish := startQuery(ctx, "GetUserMFA", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, userID).StructScan(&mfa)
+	if err == sql.ErrNoRows {
+		return domain.UserMFA{UserID: userID}, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingMFA.Error())
+		return domain.UserMFA{}, errors.ErrFetchingMFA
+	}
+	setRowCount(ctx, 1)
+	return mfa, nil
+}
+
+// SaveTOTPSecret stores a pending TOTP secret for the user, replacing any
+// earlier pending one. It returns ErrMFAAlreadyEnabled once two-factor
+// authentication has been confirmed.
+func (s *pgStore) SaveTOTPSecret(ctx context.Context, userID int64, secret string) (err error) {
+	const query = `INSERT INTO "user_mfa" (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE "user_mfa".enabled = FALSE`
+	ctx, finish := startQuery(ctx, "SaveTOTPSecret", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return errors.ErrUpdatingMFA
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return errors.ErrUpdatingMFA
+	}
+	setRowCount(ctx, rowsAffected)
+	if rowsAffected == 0 {
+		return errors.ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableMFA turns on two-factor authentication for the user and replaces
+// their recovery codes with codeHashes.
+func (s *pgStore) EnableMFA(ctx context.Context, userID int64, codeHashes []string) (err error) {
+	const (
+		enableQuery = `UPDATE "user_mfa" SET enabled = TRUE, enabled_at = $1 WHERE user_id = $2`
+		deleteQuery = `DELETE FROM "user_recovery_code" WHERE user_id = $1`
+		insertQuery = `INSERT INTO "user_recovery_code" (user_id, code_hash) VALUES ($1, $2)`
+	)
+	ctx, finish := startQuery(ctx, "EnableMFA", enableQuery+"; "+deleteQuery+"; "+insertQuery)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, enableQuery, time.Now(), userID)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.ErrMFANotEnrolled
+		}
+		_, err = tx.ExecContext(ctx, deleteQuery, userID)
+		if err != nil {
+			return err
+		}
+		for _, hash := range codeHashes {
+			_, err = tx.ExecContext(ctx, insertQuery, userID, hash)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == errors.ErrMFANotEnrolled {
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return errors.ErrUpdatingMFA
+	}
+	setRowCount(ctx, int64(len(codeHashes)+1))
+	return nil
+}
+
+// MarkTOTPStepUsed records that the code for step was accepted. It reports
+// false when that step, or a later one, was already used.
+func (s *pgStore) MarkTOTPStepUsed(ctx context.Context, userID int64, step int64) (accepted bool, err error) {
+	const query = `UPDATE "user_mfa" SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $1`
+	ctx, finish := startQuery(ctx, "MarkTOTPStepUsed", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, step, userID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return false, errors.ErrUpdatingMFA
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return false, errors.ErrUpdatingMFA
+	}
+	setRowCount(ctx, rowsAffected)
+	return rowsAffected == 1, nil
+}
+
+// UseRecoveryCode spends the unused recovery code with the given hash. It
+// reports false when there is no such unused code.
+func (s *pgStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (used bool, err error) {
+	const query = `UPDATE "user_recovery_code" SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`
+	ctx, finish := startQuery(ctx, "UseRecoveryCode", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, time.Now(), userID, codeHash)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return false, errors.ErrUpdatingMFA
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return false, errors.ErrUpdatingMFA
+	}
+	setRowCount(ctx, rowsAffected)
+	return rowsAffected > 0, nil
+}
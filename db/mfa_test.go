@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_GetUserMFA() {
+	t := suite.T()
+	columns := []string{"user_id", "secret", "enabled", "last_used_step"}
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "user_mfa"`).WithArgs(int64(1)).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(1, "SECRET", true, 42))
+	got, err := suite.repo.GetUserMFA(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, domain.UserMFA{UserID: 1, Secret: "SECRET", Enabled: true, LastUsedStep: 42}, got)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "user_mfa"`).WithArgs(int64(2)).
+		WillReturnRows(sqlxmock.NewRows(columns))
+	got, err = suite.repo.GetUserMFA(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, domain.UserMFA{UserID: 2}, got)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_SaveTOTPSecret() {
+	t := suite.T()
+	tests := []struct {
+		name         string
+		rowsAffected int64
+		wantErr      error
+	}{
+		{
+			name:         "Pending enrollment",
+			rowsAffected: 1,
+			wantErr:      nil,
+		},
+		{
+			name:         "Already enabled",
+			rowsAffected: 0,
+			wantErr:      errors.ErrMFAAlreadyEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite.mock.ExpectExec(`INSERT INTO "user_mfa"`).WithArgs(int64(1), "SECRET").
+				WillReturnResult(sqlxmock.NewResult(0, tt.rowsAffected))
+
+			err := suite.repo.SaveTOTPSecret(context.Background(), 1, "SECRET")
+			require.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func (suite *StoreTestSuite) Test_pgStore_EnableMFA() {
+	t := suite.T()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "user_mfa" SET enabled = TRUE`).WithArgs(sqlxmock.AnyArg(), int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`DELETE FROM "user_recovery_code"`).WithArgs(int64(1)).WillReturnResult(sqlxmock.NewResult(0, 3))
+	suite.mock.ExpectExec(`INSERT INTO "user_recovery_code"`).WithArgs(int64(1), "hash-1").WillReturnResult(sqlxmock.NewResult(1, 1))
+	suite.mock.ExpectExec(`INSERT INTO "user_recovery_code"`).WithArgs(int64(1), "hash-2").WillReturnResult(sqlxmock.NewResult(2, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.EnableMFA(context.Background(), 1, []string{"hash-1", "hash-2"})
+	require.NoError(t, err)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_MarkTOTPStepUsed() {
+	t := suite.T()
+	suite.mock.ExpectExec(`UPDATE "user_mfa" SET last_used_step`).WithArgs(int64(100), int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	accepted, err := suite.repo.MarkTOTPStepUsed(context.Background(), 1, 100)
+	require.NoError(t, err)
+	require.True(t, accepted)
+
+	suite.mock.ExpectExec(`UPDATE "user_mfa" SET last_used_step`).WithArgs(int64(100), int64(1)).WillReturnResult(sqlxmock.NewResult(0, 0))
+	accepted, err = suite.repo.MarkTOTPStepUsed(context.Background(), 1, 100)
+	require.NoError(t, err)
+	require.False(t, accepted)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_UseRecoveryCode() {
+	t := suite.T()
+	suite.mock.ExpectExec(`UPDATE "user_recovery_code" SET used_at`).WithArgs(sqlxmock.AnyArg(), int64(1), "hash-1").WillReturnResult(sqlxmock.NewResult(0, 1))
+	used, err := suite.repo.UseRecoveryCode(context.Background(), 1, "hash-1")
+	require.NoError(t, err)
+	require.True(t, used)
+}
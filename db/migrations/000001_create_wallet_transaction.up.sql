@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "wallet_transaction" (
+    id            BIGSERIAL PRIMARY KEY,
+    wallet_id     BIGINT NOT NULL REFERENCES "wallet" (id),
+    user_id       BIGINT NOT NULL,
+    type          VARCHAR(32) NOT NULL,
+    amount        NUMERIC(18, 2) NOT NULL,
+    balance_after NUMERIC(18, 2) NOT NULL,
+    description   TEXT NOT NULL DEFAULT '',
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS wallet_transaction_user_created_idx
+    ON "wallet_transaction" (user_id, created_at, id);
+
+-- Wallets that already hold money get an opening entry so that the ledger
+-- sums to the current balance.
+INSERT INTO "wallet_transaction" (wallet_id, user_id, type, amount, balance_after, description)
+SELECT id, user_id, 'credit', balance, balance, 'Opening balance'
+FROM "wallet"
+WHERE balance <> 0;
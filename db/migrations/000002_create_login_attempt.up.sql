@@ -0,0 +1,9 @@
+-- Failed login attempts, keyed by "account:<email>" or "ip:<address>". Keys
+-- are tracked whether or not an account exists so lockouts do not reveal
+-- which emails are registered.
+CREATE TABLE IF NOT EXISTS "login_attempt" (
+    key             VARCHAR(320) PRIMARY KEY,
+    failures        INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until    TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "user_mfa" (
+    user_id        BIGINT PRIMARY KEY REFERENCES "user" (id),
+    secret         TEXT NOT NULL,
+    enabled        BOOLEAN NOT NULL DEFAULT FALSE,
+    -- last TOTP time step accepted, so a code can not be replayed
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    enabled_at     TIMESTAMP
+);
+
+-- Recovery codes are stored as SHA-256 hashes and can each be used once.
+CREATE TABLE IF NOT EXISTS "user_recovery_code" (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES "user" (id),
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS user_recovery_code_user_idx
+    ON "user_recovery_code" (user_id);
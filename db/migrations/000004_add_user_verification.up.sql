@@ -0,0 +1,16 @@
+ALTER TABLE "user"
+    ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMP;
+
+-- Accounts created before verification existed keep working.
+UPDATE "user" SET email_verified_at = NOW(), phone_verified_at = NOW();
+
+-- One pending code per user and channel, stored as a SHA-256 hash.
+CREATE TABLE IF NOT EXISTS "verification_code" (
+    user_id    BIGINT NOT NULL REFERENCES "user" (id),
+    channel    VARCHAR(16) NOT NULL,
+    code_hash  VARCHAR(64) NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, channel)
+);
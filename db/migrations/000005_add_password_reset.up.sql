@@ -0,0 +1,15 @@
+-- Bumped whenever the password changes; access tokens carry the version they
+-- were issued for, so bumping it signs every existing session out.
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS session_version BIGINT NOT NULL DEFAULT 0;
+
+-- Reset tokens are stored as SHA-256 hashes and can each be used once.
+CREATE TABLE IF NOT EXISTS "password_reset_token" (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES "user" (id),
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_token_user_idx
+    ON "password_reset_token" (user_id);
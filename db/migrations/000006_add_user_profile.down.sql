@@ -0,0 +1,3 @@
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS pending_email;
@@ -0,0 +1,4 @@
+-- An email change only takes effect once the new address is confirmed.
+ALTER TABLE "user"
+    ADD COLUMN IF NOT EXISTS pending_email VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
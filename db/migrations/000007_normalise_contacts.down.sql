@@ -0,0 +1,2 @@
+-- Normalised numbers and emails are left as they are, both forms work.
+DROP INDEX IF EXISTS user_email_lower_idx;
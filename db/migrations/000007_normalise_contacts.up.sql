@@ -0,0 +1,12 @@
+-- Phone numbers are stored in E.164 form, at most 15 digits after the +.
+ALTER TABLE "user" ALTER COLUMN number TYPE VARCHAR(16);
+
+-- Numbers used to be stored as ten national digits, all of them Indian.
+UPDATE "user" SET number = '+91' || number WHERE number ~ '^[0-9]{10}$';
+
+-- Emails are stored lowercased and compared case-insensitively. Deleted
+-- accounts release their email.
+UPDATE "user" SET email = LOWER(TRIM(email)), pending_email = LOWER(TRIM(pending_email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_email_lower_idx
+    ON "user" (LOWER(email)) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS wallet_transaction_user_id_idx;
+DROP TRIGGER IF EXISTS wallet_transaction_notify ON "wallet_transaction";
+DROP FUNCTION IF EXISTS notify_wallet_transaction();
@@ -0,0 +1,29 @@
+-- Every ledger entry is announced on the wallet_events channel once its
+-- transaction commits. Each walletd replica listens on it and streams the
+-- entry to its owner's open /v1/wallet/events connections. created_at holds
+-- the wall clock of the session time zone, so it is converted to UTC before
+-- it is labelled as such.
+CREATE OR REPLACE FUNCTION notify_wallet_transaction() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('wallet_events', json_build_object(
+        'id', NEW.id,
+        'wallet_id', NEW.wallet_id,
+        'user_id', NEW.user_id,
+        'type', NEW.type,
+        'amount', NEW.amount,
+        'balance_after', NEW.balance_after,
+        'description', NEW.description,
+        'created_at', to_char(NEW.created_at AT TIME ZONE current_setting('TimeZone') AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wallet_transaction_notify ON "wallet_transaction";
+CREATE TRIGGER wallet_transaction_notify
+    AFTER INSERT ON "wallet_transaction"
+    FOR EACH ROW EXECUTE FUNCTION notify_wallet_transaction();
+
+-- Streams resume from the last transaction ID a client saw.
+CREATE INDEX IF NOT EXISTS wallet_transaction_user_id_idx
+    ON "wallet_transaction" (user_id, id);
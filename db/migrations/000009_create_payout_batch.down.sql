@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "payout_batch_row";
+DROP TABLE IF EXISTS "payout_batch";
@@ -0,0 +1,24 @@
+-- A payout batch credits many users at once. Rows are only ever paid in the
+-- same database transaction that marks them paid, so an interrupted batch
+-- can be resumed without paying anyone twice.
+CREATE TABLE IF NOT EXISTS "payout_batch" (
+    id          BIGSERIAL PRIMARY KEY,
+    mode        VARCHAR(16) NOT NULL,
+    status      VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_by  TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS "payout_batch_row" (
+    batch_id  BIGINT NOT NULL REFERENCES "payout_batch" (id),
+    line      INTEGER NOT NULL,
+    recipient TEXT NOT NULL,
+    user_id   BIGINT NOT NULL REFERENCES "user" (id),
+    amount    NUMERIC(18, 2) NOT NULL,
+    reference TEXT NOT NULL,
+    status    VARCHAR(16) NOT NULL DEFAULT 'pending',
+    error     TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (batch_id, line),
+    UNIQUE (batch_id, reference)
+);
@@ -0,0 +1,15 @@
+-- A top-up funds a wallet through a payment gateway. The wallet is credited
+-- in the same database transaction that moves the top-up out of pending, so
+-- a callback delivered twice credits it once.
+CREATE TABLE IF NOT EXISTS "top_up" (
+    id           BIGSERIAL PRIMARY KEY,
+    user_id      BIGINT NOT NULL REFERENCES "user" (id),
+    amount       NUMERIC(18, 2) NOT NULL,
+    status       VARCHAR(16) NOT NULL DEFAULT 'pending',
+    gateway      VARCHAR(32) NOT NULL,
+    payment_id   TEXT,
+    checkout_url TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    UNIQUE (gateway, payment_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "withdrawal";
+DROP TABLE IF EXISTS "bank_account";
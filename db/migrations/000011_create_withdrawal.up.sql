@@ -0,0 +1,33 @@
+-- Bank accounts users withdraw to, identified either by an IFSC and account
+-- number or by an IBAN.
+CREATE TABLE IF NOT EXISTS "bank_account" (
+    id             BIGSERIAL PRIMARY KEY,
+    user_id        BIGINT NOT NULL REFERENCES "user" (id),
+    holder_name    TEXT NOT NULL,
+    account_number VARCHAR(18) NOT NULL DEFAULT '',
+    ifsc           VARCHAR(11) NOT NULL DEFAULT '',
+    iban           VARCHAR(34) NOT NULL DEFAULT '',
+    created_at     TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT bank_account_unique UNIQUE (user_id, account_number, ifsc, iban)
+);
+
+-- A withdrawal debits the wallet when it is requested, holding the funds
+-- until the payout provider settles the transfer. A failed transfer is
+-- reversed in the same database transaction that moves the withdrawal out of
+-- pending, so it is refunded once.
+CREATE TABLE IF NOT EXISTS "withdrawal" (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES "user" (id),
+    bank_account_id BIGINT NOT NULL REFERENCES "bank_account" (id),
+    amount          NUMERIC(18, 2) NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    provider        VARCHAR(32) NOT NULL,
+    transfer_id     TEXT,
+    failure_reason  TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at    TIMESTAMP,
+    UNIQUE (provider, transfer_id)
+);
+
+CREATE INDEX IF NOT EXISTS withdrawal_pending_idx
+    ON "withdrawal" (id) WHERE status = 'pending';
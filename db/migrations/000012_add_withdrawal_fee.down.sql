@@ -0,0 +1,3 @@
+ALTER TABLE "withdrawal"
+    DROP COLUMN IF EXISTS fee_user_id,
+    DROP COLUMN IF EXISTS fee;
@@ -0,0 +1,5 @@
+-- The fee charged with a withdrawal and the user whose wallet collected it,
+-- so a failed withdrawal can refund the fee as well.
+ALTER TABLE "withdrawal"
+    ADD COLUMN IF NOT EXISTS fee         NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS fee_user_id BIGINT REFERENCES "user" (id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "promo_reward";
+DROP TABLE IF EXISTS "campaign";
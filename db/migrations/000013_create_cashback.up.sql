@@ -0,0 +1,35 @@
+-- A cashback campaign pays back a share of eligible operations into the
+-- promo balance. spent is only raised in the transaction that grants a
+-- reward, with the campaign row locked, so the budget is never overrun.
+CREATE TABLE IF NOT EXISTS "campaign" (
+    id                   BIGSERIAL PRIMARY KEY,
+    name                 TEXT NOT NULL,
+    operation            VARCHAR(16) NOT NULL,
+    percent              NUMERIC(5, 2) NOT NULL,
+    min_amount           NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    max_reward           NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    user_limit           NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    budget               NUMERIC(18, 2) NOT NULL,
+    spent                NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    reward_validity_days INTEGER NOT NULL,
+    starts_at            TIMESTAMP NOT NULL,
+    ends_at              TIMESTAMP NOT NULL,
+    created_at           TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (spent <= budget)
+);
+
+CREATE INDEX IF NOT EXISTS campaign_active_idx ON "campaign" (operation, starts_at, ends_at);
+
+-- The promo balance of a user is the sum of their unexpired rewards. It is
+-- kept apart from the wallet and its ledger and can not be withdrawn.
+CREATE TABLE IF NOT EXISTS "promo_reward" (
+    id            BIGSERIAL PRIMARY KEY,
+    user_id       BIGINT NOT NULL REFERENCES "user" (id),
+    campaign_id   BIGINT NOT NULL REFERENCES "campaign" (id),
+    source_amount NUMERIC(18, 2) NOT NULL,
+    amount        NUMERIC(18, 2) NOT NULL,
+    expires_at    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS promo_reward_user_idx ON "promo_reward" (user_id, campaign_id);
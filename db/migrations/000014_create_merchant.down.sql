@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "merchant_payment";
+DROP TABLE IF EXISTS "payment_qr";
+DROP TABLE IF EXISTS "merchant";
@@ -0,0 +1,36 @@
+-- A merchant lets a user accept wallet payments by QR code. Payments settle
+-- into the wallet of the user it is linked to, so each user has at most one.
+CREATE TABLE IF NOT EXISTS "merchant" (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT NOT NULL REFERENCES "user" (id),
+    name       TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT merchant_user_unique UNIQUE (user_id)
+);
+
+-- A dynamic payment QR code charges a set amount. It is moved out of pending
+-- in the same database transaction that pays it, so it is paid once.
+CREATE TABLE IF NOT EXISTS "payment_qr" (
+    id          BIGSERIAL PRIMARY KEY,
+    merchant_id BIGINT NOT NULL REFERENCES "merchant" (id),
+    amount      NUMERIC(18, 2) NOT NULL,
+    reference   TEXT NOT NULL DEFAULT '',
+    status      VARCHAR(16) NOT NULL DEFAULT 'pending',
+    expires_at  TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+    paid_at     TIMESTAMP
+);
+
+-- Every payment a merchant received, leaving qr_id empty when it came
+-- through the static code. Settlement reports total them by day.
+CREATE TABLE IF NOT EXISTS "merchant_payment" (
+    id          BIGSERIAL PRIMARY KEY,
+    merchant_id BIGINT NOT NULL REFERENCES "merchant" (id),
+    payer_id    BIGINT NOT NULL REFERENCES "user" (id),
+    qr_id       BIGINT REFERENCES "payment_qr" (id),
+    amount      NUMERIC(18, 2) NOT NULL,
+    reference   TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS merchant_payment_merchant_idx ON "merchant_payment" (merchant_id, created_at);
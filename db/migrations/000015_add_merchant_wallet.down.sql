@@ -0,0 +1,13 @@
+-- Merchant wallets and their ledgers are dropped along with whatever they
+-- hold, so settle them before going back.
+DELETE FROM "wallet_transaction" WHERE user_id IS NULL;
+DELETE FROM "wallet" WHERE merchant_id IS NOT NULL;
+
+ALTER TABLE "wallet_transaction" ALTER COLUMN user_id SET NOT NULL;
+
+DROP INDEX IF EXISTS wallet_merchant_unique;
+
+ALTER TABLE "wallet"
+    DROP CONSTRAINT IF EXISTS wallet_owner_check,
+    DROP COLUMN IF EXISTS merchant_id,
+    ALTER COLUMN user_id SET NOT NULL;
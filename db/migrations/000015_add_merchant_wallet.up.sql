@@ -0,0 +1,17 @@
+-- A merchant settles into a wallet of its own, found by merchant_id, so its
+-- takings never mix with the personal balance of the user it is linked to.
+-- Such a wallet belongs to no user, and neither do its ledger entries.
+ALTER TABLE "wallet"
+    ALTER COLUMN user_id DROP NOT NULL,
+    ADD COLUMN IF NOT EXISTS merchant_id BIGINT REFERENCES "merchant" (id),
+    ADD CONSTRAINT wallet_owner_check CHECK ((user_id IS NULL) <> (merchant_id IS NULL));
+
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_merchant_unique ON "wallet" (merchant_id);
+
+ALTER TABLE "wallet_transaction" ALTER COLUMN user_id DROP NOT NULL;
+
+-- Merchants registered earlier start with an empty wallet. What they took
+-- before stays in the wallet of their user.
+INSERT INTO "wallet" (merchant_id, balance, creation_date, last_updated, status)
+SELECT id, 0, NOW(), NOW(), 'active'
+FROM "merchant";
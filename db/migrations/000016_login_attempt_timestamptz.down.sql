@@ -0,0 +1,3 @@
+ALTER TABLE "login_attempt"
+    ALTER COLUMN last_failure_at TYPE TIMESTAMP,
+    ALTER COLUMN locked_until TYPE TIMESTAMP;
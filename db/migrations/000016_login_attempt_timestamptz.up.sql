@@ -0,0 +1,6 @@
+-- Lockout windows are compared against instants written by the service, so
+-- they are stored with their time zone. Existing rows are read in the zone of
+-- the migrating session.
+ALTER TABLE "login_attempt"
+    ALTER COLUMN last_failure_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN locked_until TYPE TIMESTAMPTZ;
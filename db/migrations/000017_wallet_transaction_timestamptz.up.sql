@@ -0,0 +1,21 @@
+-- Statements select ledger entries by UTC month, so entries are stored with
+-- their time zone. Existing entries are read in the zone of the migrating
+-- session, the one they were written in.
+ALTER TABLE "wallet_transaction" ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+-- created_at now names an instant, so it converts to UTC directly.
+CREATE OR REPLACE FUNCTION notify_wallet_transaction() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('wallet_events', json_build_object(
+        'id', NEW.id,
+        'wallet_id', NEW.wallet_id,
+        'user_id', NEW.user_id,
+        'type', NEW.type,
+        'amount', NEW.amount,
+        'balance_after', NEW.balance_after,
+        'description', NEW.description,
+        'created_at', to_char(NEW.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
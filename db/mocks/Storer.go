@@ -8,6 +8,8 @@ import (
 	domain "nickPay/wallet/internal/domain"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Storer is an autogenerated mock type for the Storer type
@@ -15,6 +17,268 @@ type Storer struct {
 	mock.Mock
 }
 
+// AdjustWallet provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) AdjustWallet(_a0 context.Context, _a1 int64, _a2 float64, _a3 string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, float64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ChangePassword provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ChangePassword(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClearLoginAttempts provides a mock function with given fields: _a0, _a1
+func (_m *Storer) ClearLoginAttempts(_a0 context.Context, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Close provides a mock function with given fields:
+func (_m *Storer) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CompleteTopUp provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) CompleteTopUp(_a0 context.Context, _a1 int64, _a2 string) (bool, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (bool, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteWithdrawal provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) CompleteWithdrawal(_a0 context.Context, _a1 int64, _a2 string, _a3 string) (bool, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) (bool, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) bool); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ConfirmEmailChange provides a mock function with given fields: _a0, _a1
+func (_m *Storer) ConfirmEmailChange(_a0 context.Context, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateBankAccount provides a mock function with given fields: _a0, _a1
+func (_m *Storer) CreateBankAccount(_a0 context.Context, _a1 domain.BankAccount) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.BankAccount) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.BankAccount) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.BankAccount) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCampaign provides a mock function with given fields: _a0, _a1
+func (_m *Storer) CreateCampaign(_a0 context.Context, _a1 domain.Campaign) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Campaign) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Campaign) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Campaign) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateMerchant provides a mock function with given fields: _a0, _a1
+func (_m *Storer) CreateMerchant(_a0 context.Context, _a1 domain.Merchant) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Merchant) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Merchant) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Merchant) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePaymentQR provides a mock function with given fields: _a0, _a1
+func (_m *Storer) CreatePaymentQR(_a0 context.Context, _a1 domain.PaymentQR) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PaymentQR) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PaymentQR) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.PaymentQR) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePayoutBatch provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) CreatePayoutBatch(_a0 context.Context, _a1 domain.PayoutBatch, _a2 []domain.PayoutRow) (int64, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PayoutBatch, []domain.PayoutRow) (int64, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PayoutBatch, []domain.PayoutRow) int64); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.PayoutBatch, []domain.PayoutRow) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateTopUp provides a mock function with given fields: _a0, _a1
+func (_m *Storer) CreateTopUp(_a0 context.Context, _a1 domain.TopUp) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.TopUp) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.TopUp) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.TopUp) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateWallet provides a mock function with given fields: _a0, _a1
 func (_m *Storer) CreateWallet(_a0 context.Context, _a1 int64) error {
 	ret := _m.Called(_a0, _a1)
@@ -29,13 +293,51 @@ func (_m *Storer) CreateWallet(_a0 context.Context, _a1 int64) error {
 	return r0
 }
 
-// CreditWallet provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Storer) CreditWallet(_a0 context.Context, _a1 int64, _a2 float64) error {
+// CreateWithdrawal provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) CreateWithdrawal(_a0 context.Context, _a1 domain.Withdrawal, _a2 domain.FeeCharge) (int64, error) {
 	ret := _m.Called(_a0, _a1, _a2)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) error); ok {
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Withdrawal, domain.FeeCharge) (int64, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Withdrawal, domain.FeeCharge) int64); ok {
 		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Withdrawal, domain.FeeCharge) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DebitWallet provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) DebitWallet(_a0 context.Context, _a1 int64, _a2 float64, _a3 domain.FeeCharge) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, float64, domain.FeeCharge) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteUser provides a mock function with given fields: _a0, _a1
+func (_m *Storer) DeleteUser(_a0 context.Context, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -43,12 +345,12 @@ func (_m *Storer) CreditWallet(_a0 context.Context, _a1 int64, _a2 float64) erro
 	return r0
 }
 
-// DebitWallet provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Storer) DebitWallet(_a0 context.Context, _a1 int64, _a2 float64) error {
+// EnableMFA provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) EnableMFA(_a0 context.Context, _a1 int64, _a2 []string) error {
 	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []string) error); ok {
 		r0 = rf(_a0, _a1, _a2)
 	} else {
 		r0 = ret.Error(0)
@@ -57,22 +359,24 @@ func (_m *Storer) DebitWallet(_a0 context.Context, _a1 int64, _a2 float64) error
 	return r0
 }
 
-// GetWallet provides a mock function with given fields: _a0, _a1
-func (_m *Storer) GetWallet(_a0 context.Context, _a1 int64) (domain.Wallet, error) {
+// FindPayoutRecipients provides a mock function with given fields: _a0, _a1
+func (_m *Storer) FindPayoutRecipients(_a0 context.Context, _a1 []string) ([]domain.PayoutRecipient, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 domain.Wallet
+	var r0 []domain.PayoutRecipient
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Wallet, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]domain.PayoutRecipient, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Wallet); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []domain.PayoutRecipient); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(domain.Wallet)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PayoutRecipient)
+		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -81,19 +385,19 @@ func (_m *Storer) GetWallet(_a0 context.Context, _a1 int64) (domain.Wallet, erro
 	return r0, r1
 }
 
-// LoginUser provides a mock function with given fields: _a0, _a1
-func (_m *Storer) LoginUser(_a0 context.Context, _a1 string) (domain.LoginDbResponse, error) {
+// FindUser provides a mock function with given fields: _a0, _a1
+func (_m *Storer) FindUser(_a0 context.Context, _a1 string) (domain.User, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 domain.LoginDbResponse
+	var r0 domain.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.LoginDbResponse, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.User, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) domain.LoginDbResponse); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.User); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(domain.LoginDbResponse)
+		r0 = ret.Get(0).(domain.User)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
@@ -105,18 +409,1112 @@ func (_m *Storer) LoginUser(_a0 context.Context, _a1 string) (domain.LoginDbResp
 	return r0, r1
 }
 
-// RegisterUser provides a mock function with given fields: _a0, _a1
-func (_m *Storer) RegisterUser(_a0 context.Context, _a1 domain.User) error {
+// GetBalanceAt provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetBalanceAt(_a0 context.Context, _a1 int64, _a2 time.Time) (float64, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) (float64, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) float64); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBankAccount provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetBankAccount(_a0 context.Context, _a1 int64) (domain.BankAccount, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.User) error); ok {
+	var r0 domain.BankAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.BankAccount, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.BankAccount); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(domain.BankAccount)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCampaign provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetCampaign(_a0 context.Context, _a1 int64) (domain.Campaign, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Campaign
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Campaign, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Campaign); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Campaign)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLoginAttempt provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetLoginAttempt(_a0 context.Context, _a1 string) (domain.LoginAttempt, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.LoginAttempt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.LoginAttempt, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.LoginAttempt); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.LoginAttempt)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMerchant provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetMerchant(_a0 context.Context, _a1 int64) (domain.Merchant, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Merchant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Merchant, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Merchant); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Merchant)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMerchantByUser provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetMerchantByUser(_a0 context.Context, _a1 int64) (domain.Merchant, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Merchant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Merchant, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Merchant); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Merchant)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMerchantWallet provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetMerchantWallet(_a0 context.Context, _a1 int64) (domain.Wallet, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Wallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Wallet, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Wallet); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Wallet)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPaymentQR provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPaymentQR(_a0 context.Context, _a1 int64) (domain.PaymentQR, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PaymentQR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.PaymentQR, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.PaymentQR); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PaymentQR)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPayoutBatch provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetPayoutBatch(_a0 context.Context, _a1 int64) (domain.PayoutBatch, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PayoutBatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.PayoutBatch, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.PayoutBatch); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PayoutBatch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSessionVersion provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetSessionVersion(_a0 context.Context, _a1 int64) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTopUp provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetTopUp(_a0 context.Context, _a1 int64) (domain.TopUp, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.TopUp, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.TopUp); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.TopUp)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetUser(_a0 context.Context, _a1 int64) (domain.User, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.User, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.User); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserCredentials provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetUserCredentials(_a0 context.Context, _a1 int64) (domain.LoginDbResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.LoginDbResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.LoginDbResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.LoginDbResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.LoginDbResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserMFA provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetUserMFA(_a0 context.Context, _a1 int64) (domain.UserMFA, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.UserMFA
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.UserMFA, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.UserMFA); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.UserMFA)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVerificationCode provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetVerificationCode(_a0 context.Context, _a1 int64, _a2 string) (domain.VerificationCode, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.VerificationCode
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (domain.VerificationCode, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) domain.VerificationCode); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.VerificationCode)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWallet provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetWallet(_a0 context.Context, _a1 int64) (domain.Wallet, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Wallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Wallet, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Wallet); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Wallet)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletsByID provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) GetWalletsByID(_a0 context.Context, _a1 int64, _a2 []int64) ([]domain.Wallet, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.Wallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []int64) ([]domain.Wallet, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []int64) []domain.Wallet); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Wallet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, []int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWithdrawal provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GetWithdrawal(_a0 context.Context, _a1 int64) (domain.Withdrawal, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Withdrawal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Withdrawal, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Withdrawal); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Withdrawal)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GrantReward provides a mock function with given fields: _a0, _a1
+func (_m *Storer) GrantReward(_a0 context.Context, _a1 domain.PromoReward) (float64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PromoReward) (float64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PromoReward) float64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.PromoReward) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementVerificationAttempts provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) IncrementVerificationAttempts(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListActiveCampaigns provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ListActiveCampaigns(_a0 context.Context, _a1 string, _a2 time.Time) ([]domain.Campaign, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.Campaign
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) ([]domain.Campaign, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) []domain.Campaign); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Campaign)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBankAccounts provides a mock function with given fields: _a0, _a1
+func (_m *Storer) ListBankAccounts(_a0 context.Context, _a1 int64) ([]domain.BankAccount, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.BankAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]domain.BankAccount, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []domain.BankAccount); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BankAccount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCampaigns provides a mock function with given fields: _a0
+func (_m *Storer) ListCampaigns(_a0 context.Context) ([]domain.Campaign, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.Campaign
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Campaign, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Campaign); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Campaign)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPayoutRows provides a mock function with given fields: _a0, _a1
+func (_m *Storer) ListPayoutRows(_a0 context.Context, _a1 int64) ([]domain.PayoutRow, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.PayoutRow
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]domain.PayoutRow, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []domain.PayoutRow); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PayoutRow)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPendingWithdrawals provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ListPendingWithdrawals(_a0 context.Context, _a1 int, _a2 time.Duration) ([]domain.Withdrawal, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.Withdrawal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) ([]domain.Withdrawal, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Duration) []domain.Withdrawal); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Withdrawal)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Duration) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListPromoRewards provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ListPromoRewards(_a0 context.Context, _a1 int64, _a2 time.Time) ([]domain.PromoReward, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.PromoReward
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) ([]domain.PromoReward, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) []domain.PromoReward); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PromoReward)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRecentTransactions provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) ListRecentTransactions(_a0 context.Context, _a1 int64, _a2 int64, _a3 int) ([]domain.Transaction, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []domain.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) ([]domain.Transaction, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) []domain.Transaction); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSettlementDays provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) ListSettlementDays(_a0 context.Context, _a1 int64, _a2 time.Time, _a3 time.Time) ([]domain.SettlementDay, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []domain.SettlementDay
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time, time.Time) ([]domain.SettlementDay, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time, time.Time) []domain.SettlementDay); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.SettlementDay)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTransactions provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) ListTransactions(_a0 context.Context, _a1 int64, _a2 time.Time, _a3 time.Time) ([]domain.Transaction, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []domain.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time, time.Time) ([]domain.Transaction, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time, time.Time) []domain.Transaction); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTransactionsSince provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) ListTransactionsSince(_a0 context.Context, _a1 int64, _a2 int64, _a3 int) ([]domain.Transaction, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []domain.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) ([]domain.Transaction, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) []domain.Transaction); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWalletBalances provides a mock function with given fields: _a0
+func (_m *Storer) ListWalletBalances(_a0 context.Context) ([]domain.WalletLedgerBalance, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.WalletLedgerBalance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.WalletLedgerBalance, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.WalletLedgerBalance); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.WalletLedgerBalance)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LockLogin provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) LockLogin(_a0 context.Context, _a1 string, _a2 time.Time) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LoginUser provides a mock function with given fields: _a0, _a1
+func (_m *Storer) LoginUser(_a0 context.Context, _a1 string) (domain.LoginDbResponse, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.LoginDbResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.LoginDbResponse, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.LoginDbResponse); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.LoginDbResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkTOTPStepUsed provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) MarkTOTPStepUsed(_a0 context.Context, _a1 int64, _a2 int64) (bool, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (bool, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) bool); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkVerified provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) MarkVerified(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PayMerchant provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) PayMerchant(_a0 context.Context, _a1 domain.Merchant, _a2 domain.MerchantPayment, _a3 domain.FeeCharge) (domain.MerchantPayment, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.MerchantPayment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Merchant, domain.MerchantPayment, domain.FeeCharge) (domain.MerchantPayment, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Merchant, domain.MerchantPayment, domain.FeeCharge) domain.MerchantPayment); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.MerchantPayment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Merchant, domain.MerchantPayment, domain.FeeCharge) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PayPayoutRows provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) PayPayoutRows(_a0 context.Context, _a1 int64, _a2 []domain.PayoutRow, _a3 bool) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []domain.PayoutRow, bool) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Ping provides a mock function with given fields: _a0
+func (_m *Storer) Ping(_a0 context.Context) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordLoginFailure provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) RecordLoginFailure(_a0 context.Context, _a1 string, _a2 time.Time) (domain.LoginAttempt, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.LoginAttempt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) (domain.LoginAttempt, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) domain.LoginAttempt); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.LoginAttempt)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegisterUser provides a mock function with given fields: _a0, _a1
+func (_m *Storer) RegisterUser(_a0 context.Context, _a1 domain.User) (int64, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User) (int64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User) int64); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.User) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetPassword provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) ResetPassword(_a0 context.Context, _a1 string, _a2 string) (int64, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (int64, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SavePasswordResetToken provides a mock function with given fields: _a0, _a1
+func (_m *Storer) SavePasswordResetToken(_a0 context.Context, _a1 domain.PasswordResetToken) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PasswordResetToken) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveTOTPSecret provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) SaveTOTPSecret(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveVerificationCode provides a mock function with given fields: _a0, _a1
+func (_m *Storer) SaveVerificationCode(_a0 context.Context, _a1 domain.VerificationCode) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.VerificationCode) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPayoutBatchStatus provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) SetPayoutBatchStatus(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetTopUpPayment provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) SetTopUpPayment(_a0 context.Context, _a1 int64, _a2 string, _a3 string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetWalletStatus provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) SetWalletStatus(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetWithdrawalTransfer provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) SetWithdrawalTransfer(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUser provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) UpdateUser(_a0 context.Context, _a1 int64, _a2 domain.UserUpdate) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.UserUpdate) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpgradePasswordHash provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Storer) UpgradePasswordHash(_a0 context.Context, _a1 int64, _a2 string, _a3 string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UseRecoveryCode provides a mock function with given fields: _a0, _a1, _a2
+func (_m *Storer) UseRecoveryCode(_a0 context.Context, _a1 int64, _a2 string) (bool, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (bool, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) bool); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 type mockConstructorTestingTNewStorer interface {
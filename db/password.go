@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	updatePasswordQuery    = `UPDATE "user" SET password = $1, session_version = session_version + 1 WHERE id = $2`
+	deleteResetTokensQuery = `DELETE FROM "password_reset_token" WHERE user_id = $1 AND used_at IS NULL`
+	consumeResetTokenQuery = `UPDATE "password_reset_token" SET used_at = $1 WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1 RETURNING user_id`
+	passwordChangeQueries  = updatePasswordQuery + "; " + deleteResetTokensQuery
+	passwordResetQueries   = consumeResetTokenQuery + "; " + passwordChangeQueries
+)
+
+// updatePassword stores a new password hash, which also signs the user out
+// everywhere and voids any outstanding reset tokens. It must run inside tx.
+func updatePassword(ctx context.Context, tx *sqlx.Tx, userID int64, passwordHash string) error {
+	result, err := tx.ExecContext(ctx, updatePasswordQuery, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.ErrUserNotFound
+	}
+	_, err = tx.ExecContext(ctx, deleteResetTokensQuery, userID)
+	return err
+}
+
+// GetUserCredentials returns the password hash and session version of a user.
+func (s *pgStore) GetUserCredentials(ctx context.Context, userID int64) (credentials domain.LoginDbResponse, err error) {
+	const query = `SELECT id, password, session_version FROM "user" WHERE id = $1 AND deleted_at IS NULL`
+	ctx, finish := startQuery(ctx, "GetUserCredentials", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, userID).StructScan(&credentials)
+	if err == sql.ErrNoRows {
+		return domain.LoginDbResponse{}, errors.ErrUserNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingUser.Error())
+		return domain.LoginDbResponse{}, errors.ErrFetchingUser
+	}
+	setRowCount(ctx, 1)
+	return credentials, nil
+}
+
+// GetSessionVersion returns the session version access tokens for the user
+// must carry to be accepted.
+func (s *pgStore) GetSessionVersion(ctx context.Context, userID int64) (version int64, err error) {
+	const query = `SELECT session_version FROM "user" WHERE id = $1 AND deleted_at IS NULL`
+	ctx, finish := startQuery(ctx, "GetSessionVersion", query)
+	defer finish(&err)
+	err = s.db.QueryRowContext(ctx, query, userID).Scan(&version)
+	if err == sql.ErrNoRows {
+		return 0, errors.ErrUserNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingUser.Error())
+		return 0, errors.ErrFetchingUser
+	}
+	setRowCount(ctx, 1)
+	return version, nil
+}
+
+func (s *pgStore) SavePasswordResetToken(ctx context.Context, token domain.PasswordResetToken) (err error) {
+	const query = `INSERT INTO "password_reset_token" (token_hash, user_id, expires_at) VALUES ($1, $2, $3)`
+	ctx, finish := startQuery(ctx, "SavePasswordResetToken", query)
+	defer finish(&err)
+	_, err = s.db.ExecContext(ctx, query, token.TokenHash, token.UserID, token.ExpiresAt)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrRequestingPasswordReset.Error())
+		return errors.ErrRequestingPasswordReset
+	}
+	setRowCount(ctx, 1)
+	return nil
+}
+
+// ResetPassword spends the unexpired, unused reset token with the given hash
+// and sets the password of its user, returning the user's ID. It returns
+// ErrInvalidResetToken when there is no such token.
+func (s *pgStore) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (userID int64, err error) {
+	ctx, finish := startQuery(ctx, "ResetPassword", passwordResetQueries)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, consumeResetTokenQuery, time.Now(), tokenHash).Scan(&userID)
+		if err == sql.ErrNoRows {
+			return errors.ErrInvalidResetToken
+		}
+		if err != nil {
+			return err
+		}
+		return updatePassword(ctx, tx, userID, passwordHash)
+	})
+	if err == errors.ErrInvalidResetToken {
+		return 0, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingPassword.Error())
+		return 0, errors.ErrUpdatingPassword
+	}
+	setRowCount(ctx, 2)
+	return userID, nil
+}
+
+func (s *pgStore) ChangePassword(ctx context.Context, userID int64, passwordHash string) (err error) {
+	ctx, finish := startQuery(ctx, "ChangePassword", passwordChangeQueries)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		return updatePassword(ctx, tx, userID, passwordHash)
+	})
+	if err == errors.ErrUserNotFound {
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingPassword.Error())
+		return errors.ErrUpdatingPassword
+	}
+	setRowCount(ctx, 1)
+	return nil
+}
+
+// UpgradePasswordHash replaces the stored hash of the same password with a
+// stronger one. Sessions are left alone, and nothing is stored if the hash
+// is no longer oldHash because the password changed in the meantime.
+func (s *pgStore) UpgradePasswordHash(ctx context.Context, userID int64, oldHash string, newHash string) (err error) {
+	const query = `UPDATE "user" SET password = $1 WHERE id = $2 AND password = $3`
+	ctx, finish := startQuery(ctx, "UpgradePasswordHash", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, newHash, userID, oldHash)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingPassword.Error())
+		return errors.ErrUpdatingPassword
+	}
+	rowsAffected, _ := result.RowsAffected()
+	setRowCount(ctx, rowsAffected)
+	return nil
+}
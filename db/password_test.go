@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_SavePasswordResetToken() {
+	t := suite.T()
+	token := domain.PasswordResetToken{TokenHash: "hash", UserID: 1, ExpiresAt: time.Date(2023, time.May, 1, 12, 30, 0, 0, time.UTC)}
+
+	suite.mock.ExpectExec(`INSERT INTO "password_reset_token"`).WithArgs(token.TokenHash, token.UserID, token.ExpiresAt).WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.SavePasswordResetToken(context.Background(), token))
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ResetPassword() {
+	t := suite.T()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`UPDATE "password_reset_token" SET used_at = \$1`).WithArgs(sqlxmock.AnyArg(), "hash").
+		WillReturnRows(sqlxmock.NewRows([]string{"user_id"}).AddRow(1))
+	suite.mock.ExpectExec(`UPDATE "user" SET password = \$1, session_version = session_version \+ 1`).WithArgs("newhash", int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`DELETE FROM "password_reset_token"`).WithArgs(int64(1)).WillReturnResult(sqlxmock.NewResult(0, 2))
+	suite.mock.ExpectCommit()
+
+	userID, err := suite.repo.ResetPassword(context.Background(), "hash", "newhash")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), userID)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`UPDATE "password_reset_token"`).WithArgs(sqlxmock.AnyArg(), "spent").
+		WillReturnRows(sqlxmock.NewRows([]string{"user_id"}))
+	suite.mock.ExpectRollback()
+
+	_, err = suite.repo.ResetPassword(context.Background(), "spent", "newhash")
+	require.Equal(t, errors.ErrInvalidResetToken, err)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ChangePassword() {
+	t := suite.T()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "user" SET password = \$1`).WithArgs("newhash", int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`DELETE FROM "password_reset_token"`).WithArgs(int64(1)).WillReturnResult(sqlxmock.NewResult(0, 0))
+	suite.mock.ExpectCommit()
+
+	require.NoError(t, suite.repo.ChangePassword(context.Background(), 1, "newhash"))
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_UpgradePasswordHash() {
+	t := suite.T()
+	suite.mock.ExpectExec(`UPDATE "user" SET password = \$1 WHERE id = \$2 AND password = \$3`).WithArgs("newhash", int64(1), "oldhash").WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.UpgradePasswordHash(context.Background(), 1, "oldhash", "newhash"))
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_GetSessionVersion() {
+	t := suite.T()
+	suite.mock.ExpectQuery(`SELECT session_version FROM "user"`).WithArgs(int64(1)).
+		WillReturnRows(sqlxmock.NewRows([]string{"session_version"}).AddRow(3))
+	version, err := suite.repo.GetSessionVersion(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), version)
+
+	suite.mock.ExpectQuery(`SELECT session_version FROM "user"`).WithArgs(int64(2)).
+		WillReturnRows(sqlxmock.NewRows([]string{"session_version"}))
+	_, err = suite.repo.GetSessionVersion(context.Background(), 2)
+	require.Equal(t, errors.ErrUserNotFound, err)
+}
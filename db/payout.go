@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const (
+	insertPayoutBatchQuery = `INSERT INTO "payout_batch" (mode, status, created_by) VALUES ($1, $2, $3) RETURNING id`
+	insertPayoutRowQuery   = `INSERT INTO "payout_batch_row" (batch_id, line, recipient, user_id, amount, reference) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	// claimPayoutRowQuery marks a row paid if it is still pending, which also
+	// locks it, so concurrent runs of a batch never pay a row twice.
+	claimPayoutRowQuery     = `UPDATE "payout_batch_row" SET status = 'paid', error = '' WHERE batch_id = $1 AND line = $2 AND status = 'pending'`
+	failPayoutRowQuery      = `UPDATE "payout_batch_row" SET status = $1, error = $2 WHERE batch_id = $3 AND line = $4 AND status = 'pending'`
+	lockWalletQuery         = `SELECT status FROM "wallet" WHERE user_id = $1 FOR UPDATE`
+	payoutRowSavepoint      = `SAVEPOINT payout_row`
+	payoutRowRollback       = `ROLLBACK TO SAVEPOINT payout_row`
+	payoutRowRelease        = `RELEASE SAVEPOINT payout_row`
+	payoutDescriptionPrefix = "Payout: "
+
+	// payoutQueries is what paying a row runs, for tracing.
+	payoutQueries = claimPayoutRowQuery + "; " + lockWalletQuery + "; " + movementQueries
+)
+
+// CreatePayoutBatch stores a pending batch with its rows and returns its ID.
+func (s *pgStore) CreatePayoutBatch(ctx context.Context, batch domain.PayoutBatch, rows []domain.PayoutRow) (batchID int64, err error) {
+	ctx, finish := startQuery(ctx, "CreatePayoutBatch", insertPayoutBatchQuery+"; "+insertPayoutRowQuery)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, insertPayoutBatchQuery, batch.Mode, domain.PayoutStatusPending, batch.CreatedBy).Scan(&batchID)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			_, err = tx.ExecContext(ctx, insertPayoutRowQuery, batchID, row.Line, row.Recipient, row.UserID, row.Amount, row.Reference)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingPayoutBatch.Error())
+		return 0, errors.ErrCreatingPayoutBatch
+	}
+	setRowCount(ctx, int64(len(rows)+1))
+	return batchID, nil
+}
+
+// GetPayoutBatch returns the batch with row counts and amounts by status.
+func (s *pgStore) GetPayoutBatch(ctx context.Context, batchID int64) (batch domain.PayoutBatch, err error) {
+	const query = `SELECT b.id, b.mode, b.status, b.created_by, b.created_at, b.finished_at,
+		COUNT(r.line), COALESCE(SUM(r.amount), 0),
+		COUNT(r.line) FILTER (WHERE r.status = 'paid'), COALESCE(SUM(r.amount) FILTER (WHERE r.status = 'paid'), 0),
+		COUNT(r.line) FILTER (WHERE r.status = 'failed'),
+		COUNT(r.line) FILTER (WHERE r.status = 'cancelled'),
+		COUNT(r.line) FILTER (WHERE r.status = 'pending')
+		FROM "payout_batch" b LEFT JOIN "payout_batch_row" r ON r.batch_id = b.id
+		WHERE b.id = $1 GROUP BY b.id`
+	ctx, finish := startQuery(ctx, "GetPayoutBatch", query)
+	defer finish(&err)
+	var finishedAt sql.NullTime
+	err = s.db.QueryRowxContext(ctx, query, batchID).Scan(&batch.ID, &batch.Mode, &batch.Status, &batch.CreatedBy, &batch.CreatedAt, &finishedAt,
+		&batch.TotalRows, &batch.TotalAmount, &batch.PaidRows, &batch.PaidAmount, &batch.FailedRows, &batch.CancelledRows, &batch.PendingRows)
+	if err == sql.ErrNoRows {
+		return domain.PayoutBatch{}, errors.ErrPayoutBatchNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingPayoutBatch.Error())
+		return domain.PayoutBatch{}, errors.ErrFetchingPayoutBatch
+	}
+	if finishedAt.Valid {
+		batch.FinishedAt = &finishedAt.Time
+	}
+	setRowCount(ctx, 1)
+	return batch, nil
+}
+
+// ListPayoutRows returns the rows of a batch in file order.
+func (s *pgStore) ListPayoutRows(ctx context.Context, batchID int64) (rows []domain.PayoutRow, err error) {
+	const query = `SELECT line, recipient, user_id, amount, reference, status, error FROM "payout_batch_row" WHERE batch_id = $1 ORDER BY line`
+	ctx, finish := startQuery(ctx, "ListPayoutRows", query)
+	defer finish(&err)
+	rows = []domain.PayoutRow{}
+	result, err := s.db.QueryContext(ctx, query, batchID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingPayoutBatch.Error())
+		return rows, errors.ErrFetchingPayoutBatch
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var row domain.PayoutRow
+		err = result.Scan(&row.Line, &row.Recipient, &row.UserID, &row.Amount, &row.Reference, &row.Status, &row.Error)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingPayoutBatch.Error())
+			return []domain.PayoutRow{}, errors.ErrFetchingPayoutBatch
+		}
+		rows = append(rows, row)
+	}
+	setRowCount(ctx, int64(len(rows)))
+	return rows, nil
+}
+
+// SetPayoutBatchStatus moves a batch to status, stamping when it finished.
+func (s *pgStore) SetPayoutBatchStatus(ctx context.Context, batchID int64, status string) (err error) {
+	const query = `UPDATE "payout_batch" SET status = $1, finished_at = CASE WHEN $2 THEN NOW() END WHERE id = $3`
+	ctx, finish := startQuery(ctx, "SetPayoutBatchStatus", query)
+	defer finish(&err)
+	finished := status == domain.PayoutStatusCompleted || status == domain.PayoutStatusFailed
+	result, err := s.db.ExecContext(ctx, query, status, finished, batchID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrPayingOut.Error())
+		return errors.ErrPayingOut
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrPayingOut.Error())
+		return errors.ErrPayingOut
+	}
+	setRowCount(ctx, rowsAffected)
+	if rowsAffected == 0 {
+		return errors.ErrPayoutBatchNotFound
+	}
+	return nil
+}
+
+// FindPayoutRecipients returns the active users whose email or phone number
+// is among contacts, which must be normalised, with their wallets.
+func (s *pgStore) FindPayoutRecipients(ctx context.Context, contacts []string) (recipients []domain.PayoutRecipient, err error) {
+	const query = `SELECT u.id, u.email, u.number, COALESCE(w.id, 0), COALESCE(w.status, '')
+		FROM "user" u LEFT JOIN "wallet" w ON w.user_id = u.id
+		WHERE (LOWER(u.email) = ANY($1) OR u.number = ANY($1)) AND u.deleted_at IS NULL`
+	ctx, finish := startQuery(ctx, "FindPayoutRecipients", query)
+	defer finish(&err)
+	recipients = []domain.PayoutRecipient{}
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(contacts))
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingUser.Error())
+		return recipients, errors.ErrFetchingUser
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recipient domain.PayoutRecipient
+		err = rows.Scan(&recipient.UserID, &recipient.Email, &recipient.PhoneNumber, &recipient.WalletID, &recipient.WalletStatus)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingUser.Error())
+			return []domain.PayoutRecipient{}, errors.ErrFetchingUser
+		}
+		recipients = append(recipients, recipient)
+	}
+	setRowCount(ctx, int64(len(recipients)))
+	return recipients, nil
+}
+
+// PayPayoutRows credits the pending rows among rows in one database
+// transaction, marking each paid together with its ledger entry. Rows no
+// longer pending are skipped.
+//
+// When atomic, the first row that cannot be paid rolls every credit back;
+// that row is then marked failed and the others cancelled. Otherwise each
+// row that cannot be paid is marked failed on its own and the rest are paid.
+// An error is only returned when the outcome could not be recorded.
+func (s *pgStore) PayPayoutRows(ctx context.Context, batchID int64, rows []domain.PayoutRow, atomic bool) (err error) {
+	ctx, finish := startQuery(ctx, "PayPayoutRows", payoutQueries)
+	defer finish(&err)
+	var failed *payoutRowError
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		for _, row := range rows {
+			if !atomic {
+				if _, err := tx.ExecContext(ctx, payoutRowSavepoint); err != nil {
+					return err
+				}
+			}
+			err := payRow(ctx, tx, batchID, row)
+			if err == nil {
+				if !atomic {
+					if _, err := tx.ExecContext(ctx, payoutRowRelease); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if atomic {
+				return &payoutRowError{line: row.Line, err: err}
+			}
+			if _, rollbackErr := tx.ExecContext(ctx, payoutRowRollback); rollbackErr != nil {
+				return rollbackErr
+			}
+			_, err = tx.ExecContext(ctx, failPayoutRowQuery, domain.PayoutStatusFailed, payoutErrorMessage(ctx, err), batchID, row.Line)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if stderrors.As(err, &failed) {
+		err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+			for _, row := range rows {
+				status, message := domain.PayoutStatusCancelled, ""
+				if row.Line == failed.line {
+					status, message = domain.PayoutStatusFailed, payoutErrorMessage(ctx, failed.err)
+				}
+				if _, err := tx.ExecContext(ctx, failPayoutRowQuery, status, message, batchID, row.Line); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrPayingOut.Error())
+		return errors.ErrPayingOut
+	}
+	setRowCount(ctx, int64(len(rows)))
+	return nil
+}
+
+// payRow claims and credits one row, doing nothing if it is no longer
+// pending.
+func payRow(ctx context.Context, tx *sqlx.Tx, batchID int64, row domain.PayoutRow) error {
+	result, err := tx.ExecContext(ctx, claimPayoutRowQuery, batchID, row.Line)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return err
+	}
+	var status string
+	err = tx.QueryRowxContext(ctx, lockWalletQuery, row.UserID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return errors.ErrNoWallet
+	}
+	if err != nil {
+		return err
+	}
+	switch status {
+	case domain.WalletStatusFrozen:
+		return errors.ErrWalletFrozen
+	case domain.WalletStatusClosed:
+		return errors.ErrWalletClosed
+	}
+	return applyMovement(ctx, tx, row.UserID, domain.TransactionCredit, row.Amount, payoutDescriptionPrefix+row.Reference)
+}
+
+// payoutRowError is the row an atomic payout stopped at.
+type payoutRowError struct {
+	line int
+	err  error
+}
+
+func (e *payoutRowError) Error() string {
+	return e.err.Error()
+}
+
+// payoutErrorMessage is the reason recorded on a row that could not be paid.
+// Unexpected errors are logged and recorded as errors.ErrPayingOut.
+func payoutErrorMessage(ctx context.Context, err error) string {
+	switch err {
+	case errors.ErrNoWallet, errors.ErrWalletFrozen, errors.ErrWalletClosed:
+		return err.Error()
+	}
+	logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrPayingOut.Error())
+	return errors.ErrPayingOut.Error()
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_CreatePayoutBatch() {
+	t := suite.T()
+	rows := []domain.PayoutRow{
+		{Line: 1, Recipient: "john@mail.com", UserID: 1, Amount: 10, Reference: "may-1"},
+		{Line: 2, Recipient: "+918123467890", UserID: 2, Amount: 20.5, Reference: "may-2"},
+	}
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "payout_batch"`).WithArgs(domain.PayoutModeBestEffort, domain.PayoutStatusPending, "finance").
+		WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(7))
+	for _, row := range rows {
+		suite.mock.ExpectExec(`INSERT INTO "payout_batch_row"`).WithArgs(int64(7), row.Line, row.Recipient, row.UserID, row.Amount, row.Reference).
+			WillReturnResult(sqlxmock.NewResult(0, 1))
+	}
+	suite.mock.ExpectCommit()
+
+	batchID, err := suite.repo.CreatePayoutBatch(context.Background(), domain.PayoutBatch{Mode: domain.PayoutModeBestEffort, CreatedBy: "finance"}, rows)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), batchID)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`INSERT INTO "payout_batch"`).WillReturnError(sql.ErrConnDone)
+	suite.mock.ExpectRollback()
+	_, err = suite.repo.CreatePayoutBatch(context.Background(), domain.PayoutBatch{Mode: domain.PayoutModeBestEffort}, rows)
+	require.Equal(t, errors.ErrCreatingPayoutBatch, err)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_GetPayoutBatch() {
+	t := suite.T()
+	createdAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	columns := []string{"id", "mode", "status", "created_by", "created_at", "finished_at", "total_rows", "total_amount", "paid_rows", "paid_amount", "failed_rows", "cancelled_rows", "pending_rows"}
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "payout_batch" b LEFT JOIN "payout_batch_row" r`).WithArgs(int64(7)).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(7, domain.PayoutModeBestEffort, domain.PayoutStatusProcessing, "finance", createdAt, nil, 3, 60.0, 1, 10.0, 1, 0, 1))
+	got, err := suite.repo.GetPayoutBatch(context.Background(), 7)
+	require.NoError(t, err)
+	require.Equal(t, domain.PayoutBatch{
+		ID:          7,
+		Mode:        domain.PayoutModeBestEffort,
+		Status:      domain.PayoutStatusProcessing,
+		CreatedBy:   "finance",
+		CreatedAt:   createdAt,
+		TotalRows:   3,
+		TotalAmount: 60,
+		PaidRows:    1,
+		PaidAmount:  10,
+		FailedRows:  1,
+		PendingRows: 1,
+	}, got)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "payout_batch"`).WithArgs(int64(8)).WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetPayoutBatch(context.Background(), 8)
+	require.Equal(t, errors.ErrPayoutBatchNotFound, err)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ListPayoutRows() {
+	t := suite.T()
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "payout_batch_row" WHERE batch_id = \$1 ORDER BY line`).WithArgs(int64(7)).
+		WillReturnRows(sqlxmock.NewRows([]string{"line", "recipient", "user_id", "amount", "reference", "status", "error"}).
+			AddRow(1, "john@mail.com", 1, 10.0, "may-1", domain.PayoutStatusPaid, "").
+			AddRow(2, "+918123467890", 2, 20.5, "may-2", domain.PayoutStatusFailed, "wallet is frozen"))
+
+	got, err := suite.repo.ListPayoutRows(context.Background(), 7)
+	require.NoError(t, err)
+	require.Equal(t, []domain.PayoutRow{
+		{Line: 1, Recipient: "john@mail.com", UserID: 1, Amount: 10, Reference: "may-1", Status: domain.PayoutStatusPaid},
+		{Line: 2, Recipient: "+918123467890", UserID: 2, Amount: 20.5, Reference: "may-2", Status: domain.PayoutStatusFailed, Error: "wallet is frozen"},
+	}, got)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_SetPayoutBatchStatus() {
+	t := suite.T()
+	suite.mock.ExpectExec(`UPDATE "payout_batch" SET status = \$1`).WithArgs(domain.PayoutStatusProcessing, false, int64(7)).
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.SetPayoutBatchStatus(context.Background(), 7, domain.PayoutStatusProcessing))
+
+	suite.mock.ExpectExec(`UPDATE "payout_batch" SET status = \$1`).WithArgs(domain.PayoutStatusCompleted, true, int64(8)).
+		WillReturnResult(sqlxmock.NewResult(0, 0))
+	require.Equal(t, errors.ErrPayoutBatchNotFound, suite.repo.SetPayoutBatchStatus(context.Background(), 8, domain.PayoutStatusCompleted))
+}
+
+func (suite *StoreTestSuite) Test_pgStore_FindPayoutRecipients() {
+	t := suite.T()
+	contacts := []string{"john@mail.com", "+918123467890"}
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "user" u LEFT JOIN "wallet" w`).WithArgs(pq.Array(contacts)).
+		WillReturnRows(sqlxmock.NewRows([]string{"id", "email", "number", "wallet_id", "wallet_status"}).
+			AddRow(1, "john@mail.com", "+918123467891", 11, domain.WalletStatusActive).
+			AddRow(2, "jane@mail.com", "+918123467890", 0, ""))
+
+	got, err := suite.repo.FindPayoutRecipients(context.Background(), contacts)
+	require.NoError(t, err)
+	require.Equal(t, []domain.PayoutRecipient{
+		{UserID: 1, Email: "john@mail.com", PhoneNumber: "+918123467891", WalletID: 11, WalletStatus: domain.WalletStatusActive},
+		{UserID: 2, Email: "jane@mail.com", PhoneNumber: "+918123467890"},
+	}, got)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_PayPayoutRows() {
+	t := suite.T()
+	rows := []domain.PayoutRow{
+		{Line: 1, UserID: 1, Amount: 10, Reference: "may-1"},
+		{Line: 2, UserID: 2, Amount: 20, Reference: "may-2"},
+		{Line: 3, UserID: 3, Amount: 30, Reference: "may-3"},
+	}
+	expectPaid := func(row domain.PayoutRow) {
+		suite.mock.ExpectExec(`UPDATE "payout_batch_row" SET status = 'paid'`).WithArgs(int64(7), row.Line).WillReturnResult(sqlxmock.NewResult(0, 1))
+		suite.mock.ExpectQuery(`SELECT status FROM "wallet" WHERE user_id = \$1 FOR UPDATE`).WithArgs(row.UserID).
+			WillReturnRows(sqlxmock.NewRows([]string{"status"}).AddRow(domain.WalletStatusActive))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(row.Amount, sqlxmock.AnyArg(), row.UserID).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(10+row.UserID, row.Amount))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).
+			WithArgs(10+row.UserID, row.UserID, domain.TransactionCredit, row.Amount, row.Amount, "Payout: "+row.Reference).
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+	}
+	expectFrozen := func(row domain.PayoutRow) {
+		suite.mock.ExpectExec(`UPDATE "payout_batch_row" SET status = 'paid'`).WithArgs(int64(7), row.Line).WillReturnResult(sqlxmock.NewResult(0, 1))
+		suite.mock.ExpectQuery(`SELECT status FROM "wallet"`).WithArgs(row.UserID).
+			WillReturnRows(sqlxmock.NewRows([]string{"status"}).AddRow(domain.WalletStatusFrozen))
+	}
+	expectMarked := func(row domain.PayoutRow, status string, message string) {
+		suite.mock.ExpectExec(`UPDATE "payout_batch_row" SET status = \$1, error = \$2`).WithArgs(status, message, int64(7), row.Line).
+			WillReturnResult(sqlxmock.NewResult(0, 1))
+	}
+
+	t.Run("best effort fails rows on their own", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectExec(`SAVEPOINT payout_row`).WillReturnResult(sqlxmock.NewResult(0, 0))
+		expectPaid(rows[0])
+		suite.mock.ExpectExec(`RELEASE SAVEPOINT payout_row`).WillReturnResult(sqlxmock.NewResult(0, 0))
+		suite.mock.ExpectExec(`SAVEPOINT payout_row`).WillReturnResult(sqlxmock.NewResult(0, 0))
+		expectFrozen(rows[1])
+		suite.mock.ExpectExec(`ROLLBACK TO SAVEPOINT payout_row`).WillReturnResult(sqlxmock.NewResult(0, 0))
+		expectMarked(rows[1], domain.PayoutStatusFailed, errors.ErrWalletFrozen.Error())
+		// Already paid by another run
+		suite.mock.ExpectExec(`SAVEPOINT payout_row`).WillReturnResult(sqlxmock.NewResult(0, 0))
+		suite.mock.ExpectExec(`UPDATE "payout_batch_row" SET status = 'paid'`).WithArgs(int64(7), 3).WillReturnResult(sqlxmock.NewResult(0, 0))
+		suite.mock.ExpectExec(`RELEASE SAVEPOINT payout_row`).WillReturnResult(sqlxmock.NewResult(0, 0))
+		suite.mock.ExpectCommit()
+
+		require.NoError(t, suite.repo.PayPayoutRows(context.Background(), 7, rows, false))
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("all or nothing rolls every row back", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		expectPaid(rows[0])
+		expectFrozen(rows[1])
+		suite.mock.ExpectRollback()
+		suite.mock.ExpectBegin()
+		expectMarked(rows[0], domain.PayoutStatusCancelled, "")
+		expectMarked(rows[1], domain.PayoutStatusFailed, errors.ErrWalletFrozen.Error())
+		expectMarked(rows[2], domain.PayoutStatusCancelled, "")
+		suite.mock.ExpectCommit()
+
+		require.NoError(t, suite.repo.PayPayoutRows(context.Background(), 7, rows, true))
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("unrecorded outcome", func(t *testing.T) {
+		suite.mock.ExpectBegin().WillReturnError(sql.ErrConnDone)
+		require.Equal(t, errors.ErrPayingOut, suite.repo.PayPayoutRows(context.Background(), 7, rows, false))
+	})
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// UpdateUser writes the editable profile columns of an active user. A changed
+// phone number is marked unverified in the same statement.
+func (s *pgStore) UpdateUser(ctx context.Context, userID int64, update domain.UserUpdate) (err error) {
+	const query = `UPDATE "user" SET name = $1, number = $2, pending_email = NULLIF($3, ''),
+		phone_verified_at = CASE WHEN $4 THEN NULL ELSE phone_verified_at END
+		WHERE id = $5 AND deleted_at IS NULL`
+	ctx, finish := startQuery(ctx, "UpdateUser", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, update.Name, update.PhoneNumber, update.PendingEmail, update.ResetPhoneVerification, userID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingUser.Error())
+		return errors.ErrUpdatingUser
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingUser.Error())
+		return errors.ErrUpdatingUser
+	}
+	setRowCount(ctx, rowsAffected)
+	if rowsAffected == 0 {
+		return errors.ErrUserNotFound
+	}
+	return nil
+}
+
+// ConfirmEmailChange makes the pending email of the user their verified
+// email and discards the code that confirmed it.
+func (s *pgStore) ConfirmEmailChange(ctx context.Context, userID int64) (err error) {
+	const updateQuery = `UPDATE "user" SET email = pending_email, pending_email = NULL, email_verified_at = $1 WHERE id = $2 AND pending_email IS NOT NULL AND deleted_at IS NULL`
+	const deleteQuery = `DELETE FROM "verification_code" WHERE user_id = $1 AND channel = $2`
+	ctx, finish := startQuery(ctx, "ConfirmEmailChange", updateQuery+"; "+deleteQuery)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, updateQuery, time.Now(), userID)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.ErrNoPendingEmail
+		}
+		_, err = tx.ExecContext(ctx, deleteQuery, userID, domain.VerificationEmailChange)
+		return err
+	})
+	if err == errors.ErrNoPendingEmail {
+		return
+	}
+	if isUniqueViolation(err, emailUniqueIndex) {
+		return errors.ErrEmailTaken
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingUser.Error())
+		return errors.ErrUpdatingUser
+	}
+	setRowCount(ctx, 2)
+	return nil
+}
+
+// DeleteUser soft-deletes the user and closes their wallet and the wallet of
+// their merchant, which must both be empty. Deleted users can not log in and
+// their sessions end at once.
+func (s *pgStore) DeleteUser(ctx context.Context, userID int64) (err error) {
+	const balanceQuery = `SELECT balance FROM "wallet" WHERE user_id = $1 FOR UPDATE`
+	const closeQuery = `UPDATE "wallet" SET status = $1, last_updated = $2 WHERE user_id = $3`
+	const merchantBalanceQuery = `SELECT w.balance FROM "wallet" w JOIN "merchant" m ON m.id = w.merchant_id WHERE m.user_id = $1 FOR UPDATE OF w`
+	const closeMerchantQuery = `UPDATE "wallet" SET status = $1, last_updated = $2 WHERE merchant_id = (SELECT id FROM "merchant" WHERE user_id = $3)`
+	const deleteQuery = `UPDATE "user" SET deleted_at = $1, pending_email = NULL, session_version = session_version + 1 WHERE id = $2 AND deleted_at IS NULL`
+	ctx, finish := startQuery(ctx, "DeleteUser", balanceQuery+"; "+closeQuery+"; "+merchantBalanceQuery+"; "+closeMerchantQuery+"; "+deleteQuery)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := closeEmptyWallet(ctx, tx, balanceQuery, closeQuery, userID)
+		if err != nil {
+			return err
+		}
+		err = closeEmptyWallet(ctx, tx, merchantBalanceQuery, closeMerchantQuery, userID)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.ExecContext(ctx, deleteQuery, time.Now(), userID)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.ErrUserNotFound
+		}
+		return nil
+	})
+	if err == errors.ErrNonZeroBalance || err == errors.ErrUserNotFound {
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrDeletingUser.Error())
+		return errors.ErrDeletingUser
+	}
+	setRowCount(ctx, 1)
+	return nil
+}
+
+// closeEmptyWallet locks the wallet balanceQuery finds for userID and closes
+// it with closeQuery, unless it still holds money. A missing wallet has
+// nothing to close.
+func closeEmptyWallet(ctx context.Context, tx *sqlx.Tx, balanceQuery string, closeQuery string, userID int64) error {
+	var balance float64
+	err := tx.QueryRowxContext(ctx, balanceQuery, userID).Scan(&balance)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil
+	case err != nil:
+		return err
+	case balance != 0:
+		return errors.ErrNonZeroBalance
+	}
+	_, err = tx.ExecContext(ctx, closeQuery, domain.WalletStatusClosed, time.Now().Local().Format("2006-01-02 15:04:05"), userID)
+	return err
+}
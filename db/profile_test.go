@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_GetUser() {
+	t := suite.T()
+	columns := []string{"id", "name", "email", "number", "email_verified", "phone_verified", "pending_email"}
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "user" WHERE id = \$1 AND deleted_at IS NULL`).WithArgs(int64(1)).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(1, "John Doe", "john@mail.com", "8123467890", true, false, "new@mail.com"))
+	got, err := suite.repo.GetUser(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, domain.User{ID: 1, Name: "John Doe", Email: "john@mail.com", PhoneNumber: "8123467890", EmailVerified: true, PendingEmail: "new@mail.com"}, got)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "user"`).WithArgs(int64(2)).WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetUser(context.Background(), 2)
+	require.Equal(t, errors.ErrUserNotFound, err)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_FindUser() {
+	t := suite.T()
+	columns := []string{"id", "name", "email", "number", "email_verified", "phone_verified", "pending_email"}
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "user" WHERE \(LOWER\(email\) = LOWER\(\$1\) OR number = \$1\) AND deleted_at IS NULL`).WithArgs("+918123467890").
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(1, "John Doe", "john@mail.com", "+918123467890", true, true, ""))
+	got, err := suite.repo.FindUser(context.Background(), "+918123467890")
+	require.NoError(t, err)
+	require.Equal(t, domain.User{ID: 1, Name: "John Doe", Email: "john@mail.com", PhoneNumber: "+918123467890", EmailVerified: true, PhoneVerified: true}, got)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "user"`).WithArgs("nobody@mail.com").WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.FindUser(context.Background(), "nobody@mail.com")
+	require.Equal(t, errors.ErrUserNotFound, err)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_UpdateUser() {
+	t := suite.T()
+	update := domain.UserUpdate{Name: "Jane Doe", PhoneNumber: "8123467891", ResetPhoneVerification: true}
+
+	suite.mock.ExpectExec(`UPDATE "user" SET name = \$1`).WithArgs(update.Name, update.PhoneNumber, "", true, int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.UpdateUser(context.Background(), 1, update))
+
+	suite.mock.ExpectExec(`UPDATE "user" SET name = \$1`).WithArgs(update.Name, update.PhoneNumber, "", true, int64(2)).WillReturnResult(sqlxmock.NewResult(0, 0))
+	require.Equal(t, errors.ErrUserNotFound, suite.repo.UpdateUser(context.Background(), 2, update))
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ConfirmEmailChange() {
+	t := suite.T()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "user" SET email = pending_email`).WithArgs(sqlxmock.AnyArg(), int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`DELETE FROM "verification_code"`).WithArgs(int64(1), domain.VerificationEmailChange).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	require.NoError(t, suite.repo.ConfirmEmailChange(context.Background(), 1))
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "user" SET email = pending_email`).WithArgs(sqlxmock.AnyArg(), int64(2)).WillReturnResult(sqlxmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+	require.Equal(t, errors.ErrNoPendingEmail, suite.repo.ConfirmEmailChange(context.Background(), 2))
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_DeleteUser() {
+	t := suite.T()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`SELECT balance FROM "wallet"`).WithArgs(int64(1)).WillReturnRows(sqlxmock.NewRows([]string{"balance"}).AddRow(0))
+	suite.mock.ExpectExec(`UPDATE "wallet" SET status = \$1, last_updated = \$2 WHERE user_id = \$3`).WithArgs(domain.WalletStatusClosed, sqlxmock.AnyArg(), int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectQuery(`SELECT w.balance FROM "wallet" w JOIN "merchant" m`).WithArgs(int64(1)).WillReturnRows(sqlxmock.NewRows([]string{"balance"}).AddRow(0))
+	suite.mock.ExpectExec(`UPDATE "wallet" SET status = \$1, last_updated = \$2 WHERE merchant_id`).WithArgs(domain.WalletStatusClosed, sqlxmock.AnyArg(), int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`UPDATE "user" SET deleted_at = \$1`).WithArgs(sqlxmock.AnyArg(), int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+	require.NoError(t, suite.repo.DeleteUser(context.Background(), 1))
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`SELECT balance FROM "wallet"`).WithArgs(int64(2)).WillReturnRows(sqlxmock.NewRows([]string{"balance"}).AddRow(10.5))
+	suite.mock.ExpectRollback()
+	require.Equal(t, errors.ErrNonZeroBalance, suite.repo.DeleteUser(context.Background(), 2))
+
+	// Nor while their merchant still holds takings
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery(`SELECT balance FROM "wallet"`).WithArgs(int64(3)).WillReturnRows(sqlxmock.NewRows([]string{"balance"}))
+	suite.mock.ExpectQuery(`SELECT w.balance FROM "wallet" w JOIN "merchant" m`).WithArgs(int64(3)).WillReturnRows(sqlxmock.NewRows([]string{"balance"}).AddRow(40.0))
+	suite.mock.ExpectRollback()
+	require.Equal(t, errors.ErrNonZeroBalance, suite.repo.DeleteUser(context.Background(), 3))
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
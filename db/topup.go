@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// claimTopUpQuery settles a top-up if it is still pending, which also
+	// locks it, so a callback delivered twice credits the wallet once.
+	claimTopUpQuery      = `UPDATE "top_up" SET status = $1, completed_at = NOW() WHERE id = $2 AND status = 'pending' RETURNING user_id, amount`
+	topUpDescription     = "Wallet top-up"
+	completeTopUpQueries = claimTopUpQuery + "; " + lockWalletQuery + "; " + movementQueries
+)
+
+// CreateTopUp stores a pending top-up and returns its ID.
+func (s *pgStore) CreateTopUp(ctx context.Context, topUp domain.TopUp) (topUpID int64, err error) {
+	const query = `INSERT INTO "top_up" (user_id, amount, status, gateway) VALUES ($1, $2, $3, $4) RETURNING id`
+	ctx, finish := startQuery(ctx, "CreateTopUp", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, topUp.UserID, topUp.Amount, domain.TopUpStatusPending, topUp.Gateway).Scan(&topUpID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingTopUp.Error())
+		return 0, errors.ErrCreatingTopUp
+	}
+	setRowCount(ctx, 1)
+	return topUpID, nil
+}
+
+// SetTopUpPayment records the gateway payment opened for a top-up.
+func (s *pgStore) SetTopUpPayment(ctx context.Context, topUpID int64, paymentID string, checkoutURL string) (err error) {
+	const query = `UPDATE "top_up" SET payment_id = $1, checkout_url = $2 WHERE id = $3`
+	ctx, finish := startQuery(ctx, "SetTopUpPayment", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, paymentID, checkoutURL, topUpID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingTopUp.Error())
+		return errors.ErrCreatingTopUp
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingTopUp.Error())
+		return errors.ErrCreatingTopUp
+	}
+	setRowCount(ctx, rowsAffected)
+	if rowsAffected == 0 {
+		return errors.ErrTopUpNotFound
+	}
+	return nil
+}
+
+func (s *pgStore) GetTopUp(ctx context.Context, topUpID int64) (topUp domain.TopUp, err error) {
+	const query = `SELECT id, user_id, amount, status, gateway, COALESCE(payment_id, ''), checkout_url, created_at, completed_at FROM "top_up" WHERE id = $1`
+	ctx, finish := startQuery(ctx, "GetTopUp", query)
+	defer finish(&err)
+	var completedAt sql.NullTime
+	err = s.db.QueryRowxContext(ctx, query, topUpID).Scan(&topUp.ID, &topUp.UserID, &topUp.Amount, &topUp.Status, &topUp.Gateway, &topUp.PaymentID, &topUp.CheckoutURL, &topUp.CreatedAt, &completedAt)
+	if err == sql.ErrNoRows {
+		return domain.TopUp{}, errors.ErrTopUpNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTopUp.Error())
+		return domain.TopUp{}, errors.ErrFetchingTopUp
+	}
+	if completedAt.Valid {
+		topUp.CompletedAt = &completedAt.Time
+	}
+	setRowCount(ctx, 1)
+	return topUp, nil
+}
+
+// CompleteTopUp settles a pending top-up as succeeded or failed, crediting
+// the wallet in the same transaction when it succeeded. It reports false,
+// and changes nothing, when the top-up was already settled. A top-up for a
+// frozen or closed wallet stays pending, so it is credited once the wallet
+// is active again.
+func (s *pgStore) CompleteTopUp(ctx context.Context, topUpID int64, status string) (completed bool, err error) {
+	ctx, finish := startQuery(ctx, "CompleteTopUp", completeTopUpQueries)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		var userID int64
+		var amount float64
+		err := tx.QueryRowxContext(ctx, claimTopUpQuery, status, topUpID).Scan(&userID, &amount)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		completed = true
+		if status != domain.TopUpStatusSucceeded {
+			return nil
+		}
+		var walletStatus string
+		err = tx.QueryRowxContext(ctx, lockWalletQuery, userID).Scan(&walletStatus)
+		if err == sql.ErrNoRows {
+			return errors.ErrNoWallet
+		}
+		if err != nil {
+			return err
+		}
+		switch walletStatus {
+		case domain.WalletStatusFrozen:
+			return errors.ErrWalletFrozen
+		case domain.WalletStatusClosed:
+			return errors.ErrWalletClosed
+		}
+		return applyMovement(ctx, tx, userID, domain.TransactionCredit, amount, topUpDescription)
+	})
+	if err == errors.ErrNoWallet || err == errors.ErrWalletFrozen || err == errors.ErrWalletClosed {
+		return false, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCompletingTopUp.Error())
+		return false, errors.ErrCompletingTopUp
+	}
+	return completed, nil
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_CreateTopUp() {
+	t := suite.T()
+	suite.mock.ExpectQuery(`INSERT INTO "top_up"`).WithArgs(int64(42), 50.0, domain.TopUpStatusPending, "fake").
+		WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(7))
+	topUpID, err := suite.repo.CreateTopUp(context.Background(), domain.TopUp{UserID: 42, Amount: 50, Gateway: "fake"})
+	require.NoError(t, err)
+	require.Equal(t, int64(7), topUpID)
+
+	suite.mock.ExpectExec(`UPDATE "top_up" SET payment_id = \$1, checkout_url = \$2`).WithArgs("fake_1", "https://pay/fake_1", int64(7)).
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.SetTopUpPayment(context.Background(), 7, "fake_1", "https://pay/fake_1"))
+
+	suite.mock.ExpectExec(`UPDATE "top_up" SET payment_id`).WithArgs("fake_1", "https://pay/fake_1", int64(8)).
+		WillReturnResult(sqlxmock.NewResult(0, 0))
+	require.Equal(t, errors.ErrTopUpNotFound, suite.repo.SetTopUpPayment(context.Background(), 8, "fake_1", "https://pay/fake_1"))
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_GetTopUp() {
+	t := suite.T()
+	createdAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "amount", "status", "gateway", "payment_id", "checkout_url", "created_at", "completed_at"}
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "top_up" WHERE id = \$1`).WithArgs(int64(7)).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(7, 42, 50.0, domain.TopUpStatusSucceeded, "fake", "fake_1", "https://pay/fake_1", createdAt, createdAt.Add(time.Minute)))
+
+	got, err := suite.repo.GetTopUp(context.Background(), 7)
+	require.NoError(t, err)
+	completedAt := createdAt.Add(time.Minute)
+	require.Equal(t, domain.TopUp{
+		ID:          7,
+		UserID:      42,
+		Amount:      50,
+		Status:      domain.TopUpStatusSucceeded,
+		Gateway:     "fake",
+		PaymentID:   "fake_1",
+		CheckoutURL: "https://pay/fake_1",
+		CreatedAt:   createdAt,
+		CompletedAt: &completedAt,
+	}, got)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "top_up"`).WithArgs(int64(8)).WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetTopUp(context.Background(), 8)
+	require.Equal(t, errors.ErrTopUpNotFound, err)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_CompleteTopUp() {
+	t := suite.T()
+	claim := func(status string) *sqlxmock.ExpectedQuery {
+		return suite.mock.ExpectQuery(`UPDATE "top_up" SET status = \$1, completed_at = NOW\(\) WHERE id = \$2 AND status = 'pending'`).WithArgs(status, int64(7))
+	}
+	lock := func() *sqlxmock.ExpectedQuery {
+		return suite.mock.ExpectQuery(`SELECT status FROM "wallet" WHERE user_id = \$1 FOR UPDATE`).WithArgs(int64(42))
+	}
+
+	t.Run("credits succeeded top-ups", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		claim(domain.TopUpStatusSucceeded).WillReturnRows(sqlxmock.NewRows([]string{"user_id", "amount"}).AddRow(42, 50.0))
+		lock().WillReturnRows(sqlxmock.NewRows([]string{"status"}).AddRow(domain.WalletStatusActive))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(50.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 150.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionCredit, 50.0, 150.0, "Wallet top-up").
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+		suite.mock.ExpectCommit()
+
+		completed, err := suite.repo.CompleteTopUp(context.Background(), 7, domain.TopUpStatusSucceeded)
+		require.NoError(t, err)
+		require.True(t, completed)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("keeps top-ups for frozen or closed wallets pending", func(t *testing.T) {
+		for status, want := range map[string]error{
+			domain.WalletStatusFrozen: errors.ErrWalletFrozen,
+			domain.WalletStatusClosed: errors.ErrWalletClosed,
+		} {
+			suite.mock.ExpectBegin()
+			claim(domain.TopUpStatusSucceeded).WillReturnRows(sqlxmock.NewRows([]string{"user_id", "amount"}).AddRow(42, 50.0))
+			lock().WillReturnRows(sqlxmock.NewRows([]string{"status"}).AddRow(status))
+			suite.mock.ExpectRollback()
+
+			completed, err := suite.repo.CompleteTopUp(context.Background(), 7, domain.TopUpStatusSucceeded)
+			require.Equal(t, want, err)
+			require.False(t, completed)
+			require.NoError(t, suite.mock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("only marks failed top-ups", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		claim(domain.TopUpStatusFailed).WillReturnRows(sqlxmock.NewRows([]string{"user_id", "amount"}).AddRow(42, 50.0))
+		suite.mock.ExpectCommit()
+
+		completed, err := suite.repo.CompleteTopUp(context.Background(), 7, domain.TopUpStatusFailed)
+		require.NoError(t, err)
+		require.True(t, completed)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("leaves settled top-ups alone", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		claim(domain.TopUpStatusSucceeded).WillReturnRows(sqlxmock.NewRows([]string{"user_id", "amount"}))
+		suite.mock.ExpectCommit()
+
+		completed, err := suite.repo.CompleteTopUp(context.Background(), 7, domain.TopUpStatusSucceeded)
+		require.NoError(t, err)
+		require.False(t, completed)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back on errors", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		claim(domain.TopUpStatusSucceeded).WillReturnError(sql.ErrConnDone)
+		suite.mock.ExpectRollback()
+
+		completed, err := suite.repo.CompleteTopUp(context.Background(), 7, domain.TopUpStatusSucceeded)
+		require.Equal(t, errors.ErrCompletingTopUp, err)
+		require.False(t, completed)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+}
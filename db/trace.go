@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"nickPay/wallet/internal/metrics"
+	"nickPay/wallet/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("nickPay/wallet/internal/db")
+
+// startQuery opens a span for a Storer call, tagged with the SQL it runs, and
+// starts its latency timer. The returned function stops both and is meant to
+// be deferred with a pointer to the call's error.
+func startQuery(ctx context.Context, name string, statement string) (context.Context, func(*error)) {
+	observe := metrics.TimeQuery(name)
+	ctx, span := tracer.Start(ctx, "Storer."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", name),
+			attribute.String("db.statement", statement),
+		),
+	)
+	return ctx, func(err *error) {
+		observe()
+		tracing.End(span, err)
+	}
+}
+
+// setRowCount records on the current query span how many rows were read or
+// written.
+func setRowCount(ctx context.Context, rows int64) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("db.rows", rows))
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_QuerySpans() {
+	t := suite.T()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	rows := sqlxmock.NewRows([]string{"id", "user_id", "balance", "creation_date", "last_updated", "status"}).
+		AddRow(1, 1, 1000.0, "2023-05-01", "2023-05-01 10:00:00", "active")
+	suite.mock.ExpectQuery(`SELECT \* FROM wallet`).WithArgs(1).WillReturnRows(rows)
+
+	_, err := suite.repo.GetWallet(context.Background(), 1)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "Storer.GetWallet", spans[0].Name())
+	require.Contains(t, spans[0].Attributes(), attribute.String("db.statement", "SELECT * FROM wallet where user_id = $1"))
+	require.Contains(t, spans[0].Attributes(), attribute.Int64("db.rows", 1))
+}
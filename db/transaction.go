@@ -0,0 +1,227 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// updateBalanceQuery leaves the wallet alone when a debit would take it
+	// below zero, so concurrent debits cannot overdraw it between the
+	// balance check of the service and the update.
+	updateBalanceQuery     = `UPDATE "wallet" SET balance = balance + $1, last_updated = $2 WHERE user_id = $3 AND ($1 >= 0 OR balance + $1 >= 0) RETURNING id, balance`
+	walletExistsQuery      = `SELECT EXISTS (SELECT 1 FROM "wallet" WHERE user_id = $1)`
+	insertTransactionQuery = `INSERT INTO "wallet_transaction" (wallet_id, user_id, type, amount, balance_after, description) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	// overdrawBalanceQuery is updateBalanceQuery without the guard, for the
+	// house revenue wallet refunding fees it may already have paid out.
+	overdrawBalanceQuery = `UPDATE "wallet" SET balance = balance + $1, last_updated = $2 WHERE user_id = $3 RETURNING id, balance`
+
+	// movementQueries is what a balance movement runs, for tracing.
+	movementQueries = updateBalanceQuery + "; " + insertTransactionQuery
+)
+
+// applyMovement adjusts the wallet balance of userID by amount and records the
+// movement in the wallet_transaction ledger. It must run inside tx so that the
+// balance and its ledger entry are always written together. A debit larger
+// than the balance fails with ErrInsufficientBalance, which rolls back tx.
+func applyMovement(ctx context.Context, tx *sqlx.Tx, userID int64, txnType string, amount float64, description string) error {
+	return moveBalance(ctx, tx, updateBalanceQuery, userID, txnType, amount, description)
+}
+
+// overdrawMovement is applyMovement for the house revenue wallet, which may
+// go below zero rather than hold up what it owes a user.
+func overdrawMovement(ctx context.Context, tx *sqlx.Tx, userID int64, txnType string, amount float64, description string) error {
+	return moveBalance(ctx, tx, overdrawBalanceQuery, userID, txnType, amount, description)
+}
+
+func moveBalance(ctx context.Context, tx *sqlx.Tx, query string, userID int64, txnType string, amount float64, description string) (err error) {
+	var walletID int64
+	var balance float64
+	err = tx.QueryRowxContext(ctx, query, amount, time.Now().Local().Format("2006-01-02 15:04:05"), userID).Scan(&walletID, &balance)
+	if err == sql.ErrNoRows && amount < 0 {
+		var exists bool
+		err = tx.QueryRowxContext(ctx, walletExistsQuery, userID).Scan(&exists)
+		if err != nil {
+			return
+		}
+		if exists {
+			return errors.ErrInsufficientBalance
+		}
+		return errors.ErrNoWallet
+	}
+	if err == sql.ErrNoRows {
+		return errors.ErrNoWallet
+	}
+	if err != nil {
+		return
+	}
+	_, err = tx.ExecContext(ctx, insertTransactionQuery, walletID, userID, txnType, amount, balance, description)
+	if err != nil {
+		return
+	}
+	setRowCount(ctx, 2)
+	return
+}
+
+// chargeFee moves fee from the wallet of userID to the house revenue wallet
+// of fee.AccountID, recording description on both ledger entries. Like
+// applyMovement it must run inside the tx of the operation charged for.
+func chargeFee(ctx context.Context, tx *sqlx.Tx, userID int64, fee domain.FeeCharge, description string) error {
+	if fee.Amount == 0 {
+		return nil
+	}
+	err := applyMovement(ctx, tx, userID, domain.TransactionDebit, -fee.Amount, description)
+	if err != nil {
+		return err
+	}
+	err = applyMovement(ctx, tx, fee.AccountID, domain.TransactionCredit, fee.Amount, fmt.Sprintf("%s from user %d", description, userID))
+	if err == errors.ErrNoWallet {
+		return errors.ErrNoFeeWallet
+	}
+	return err
+}
+
+// refundFee reverses chargeFee. The refund never fails for want of revenue,
+// so whatever it refunds along with can not get stuck behind it.
+func refundFee(ctx context.Context, tx *sqlx.Tx, userID int64, fee domain.FeeCharge, description string) error {
+	if fee.Amount == 0 {
+		return nil
+	}
+	err := overdrawMovement(ctx, tx, fee.AccountID, domain.TransactionDebit, -fee.Amount, fmt.Sprintf("%s to user %d", description, userID))
+	if err == errors.ErrNoWallet {
+		return errors.ErrNoFeeWallet
+	}
+	if err != nil {
+		return err
+	}
+	return applyMovement(ctx, tx, userID, domain.TransactionCredit, fee.Amount, description)
+}
+
+func (s *pgStore) ListTransactions(ctx context.Context, userID int64, from time.Time, to time.Time) (transactions []domain.Transaction, err error) {
+	const query = `SELECT id, wallet_id, user_id, type, amount, balance_after, description, created_at FROM "wallet_transaction" WHERE user_id = $1 AND created_at >= $2 AND created_at < $3 ORDER BY created_at, id`
+	ctx, finish := startQuery(ctx, "ListTransactions", query)
+	defer finish(&err)
+	transactions = []domain.Transaction{}
+	rows, err := s.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTransactions.Error())
+		return transactions, errors.ErrFetchingTransactions
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txn domain.Transaction
+		err = rows.Scan(&txn.ID, &txn.WalletID, &txn.UserID, &txn.Type, &txn.Amount, &txn.BalanceAfter, &txn.Description, &txn.CreatedAt)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTransactions.Error())
+			return []domain.Transaction{}, errors.ErrFetchingTransactions
+		}
+		transactions = append(transactions, txn)
+	}
+	setRowCount(ctx, int64(len(transactions)))
+	return transactions, nil
+}
+
+// ListRecentTransactions returns up to limit of the user's transactions,
+// newest first, starting after the transaction with ID beforeID. A beforeID
+// of zero starts from the most recent transaction.
+func (s *pgStore) ListRecentTransactions(ctx context.Context, userID int64, beforeID int64, limit int) (transactions []domain.Transaction, err error) {
+	const query = `SELECT id, wallet_id, user_id, type, amount, balance_after, description, created_at FROM "wallet_transaction" WHERE user_id = $1 AND ($2 = 0 OR id < $2) ORDER BY id DESC LIMIT $3`
+	ctx, finish := startQuery(ctx, "ListRecentTransactions", query)
+	defer finish(&err)
+	transactions = []domain.Transaction{}
+	rows, err := s.db.QueryContext(ctx, query, userID, beforeID, limit)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTransactions.Error())
+		return transactions, errors.ErrFetchingTransactions
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txn domain.Transaction
+		err = rows.Scan(&txn.ID, &txn.WalletID, &txn.UserID, &txn.Type, &txn.Amount, &txn.BalanceAfter, &txn.Description, &txn.CreatedAt)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTransactions.Error())
+			return []domain.Transaction{}, errors.ErrFetchingTransactions
+		}
+		transactions = append(transactions, txn)
+	}
+	setRowCount(ctx, int64(len(transactions)))
+	return transactions, nil
+}
+
+// ListTransactionsSince returns up to limit of the user's transactions with
+// an ID above afterID, oldest first.
+func (s *pgStore) ListTransactionsSince(ctx context.Context, userID int64, afterID int64, limit int) (transactions []domain.Transaction, err error) {
+	const query = `SELECT id, wallet_id, user_id, type, amount, balance_after, description, created_at FROM "wallet_transaction" WHERE user_id = $1 AND id > $2 ORDER BY id LIMIT $3`
+	ctx, finish := startQuery(ctx, "ListTransactionsSince", query)
+	defer finish(&err)
+	transactions = []domain.Transaction{}
+	rows, err := s.db.QueryContext(ctx, query, userID, afterID, limit)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTransactions.Error())
+		return transactions, errors.ErrFetchingTransactions
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var txn domain.Transaction
+		err = rows.Scan(&txn.ID, &txn.WalletID, &txn.UserID, &txn.Type, &txn.Amount, &txn.BalanceAfter, &txn.Description, &txn.CreatedAt)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTransactions.Error())
+			return []domain.Transaction{}, errors.ErrFetchingTransactions
+		}
+		transactions = append(transactions, txn)
+	}
+	setRowCount(ctx, int64(len(transactions)))
+	return transactions, nil
+}
+
+// GetBalanceAt returns the balance of the user's wallet as recorded by the
+// ledger immediately before the given instant.
+func (s *pgStore) GetBalanceAt(ctx context.Context, userID int64, at time.Time) (balance float64, err error) {
+	const query = `SELECT COALESCE(SUM(amount), 0) FROM "wallet_transaction" WHERE user_id = $1 AND created_at < $2`
+	ctx, finish := startQuery(ctx, "GetBalanceAt", query)
+	defer finish(&err)
+	err = s.db.QueryRowContext(ctx, query, userID, at).Scan(&balance)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingBalance.Error())
+		return 0, errors.ErrFetchingBalance
+	}
+	return balance, nil
+}
+
+// ListWalletBalances returns every wallet with its stored balance alongside
+// the balance recomputed from the ledger.
+func (s *pgStore) ListWalletBalances(ctx context.Context) (balances []domain.WalletLedgerBalance, err error) {
+	const query = `SELECT w.id, COALESCE(w.user_id, 0), w.status, w.balance, COALESCE(SUM(t.amount), 0) FROM "wallet" w LEFT JOIN "wallet_transaction" t ON t.wallet_id = w.id GROUP BY w.id, w.user_id, w.status, w.balance ORDER BY w.id`
+	ctx, finish := startQuery(ctx, "ListWalletBalances", query)
+	defer finish(&err)
+	balances = []domain.WalletLedgerBalance{}
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTransactions.Error())
+		return balances, errors.ErrFetchingTransactions
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var balance domain.WalletLedgerBalance
+		err = rows.Scan(&balance.WalletID, &balance.UserID, &balance.Status, &balance.RecordedBalance, &balance.LedgerBalance)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingTransactions.Error())
+			return []domain.WalletLedgerBalance{}, errors.ErrFetchingTransactions
+		}
+		balances = append(balances, balance)
+	}
+	setRowCount(ctx, int64(len(balances)))
+	return balances, nil
+}
@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"nickPay/wallet/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_ListTransactions() {
+	t := suite.T()
+	from := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	type args struct {
+		ctx    context.Context
+		userID int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []domain.Transaction
+		wantErr bool
+	}{
+		{
+			name: "List transactions in period",
+			args: args{
+				ctx:    context.Background(),
+				userID: 1,
+			},
+			want: []domain.Transaction{
+				{ID: 1, WalletID: 1, UserID: 1, Type: domain.TransactionCredit, Amount: 500, BalanceAfter: 500, Description: "Wallet credit", CreatedAt: from.Add(time.Hour)},
+				{ID: 2, WalletID: 1, UserID: 1, Type: domain.TransactionDebit, Amount: -200, BalanceAfter: 300, Description: "Wallet debit", CreatedAt: from.Add(2 * time.Hour)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Error while listing transactions",
+			args: args{
+				ctx:    context.Background(),
+				userID: 2,
+			},
+			want:    []domain.Transaction{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := suite.mock.ExpectQuery(`SELECT (.+) FROM "wallet_transaction"`).WithArgs(tt.args.userID, from, to)
+			if tt.wantErr {
+				query.WillReturnError(errors.New("mocked error"))
+			} else {
+				rows := sqlxmock.NewRows([]string{"id", "wallet_id", "user_id", "type", "amount", "balance_after", "description", "created_at"})
+				for _, txn := range tt.want {
+					rows.AddRow(txn.ID, txn.WalletID, txn.UserID, txn.Type, txn.Amount, txn.BalanceAfter, txn.Description, txn.CreatedAt)
+				}
+				query.WillReturnRows(rows)
+			}
+
+			got, err := suite.repo.ListTransactions(tt.args.ctx, tt.args.userID, from, to)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ListRecentTransactions() {
+	t := suite.T()
+	at := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		beforeID int64
+		want     []domain.Transaction
+		wantErr  bool
+	}{
+		{
+			name: "List most recent transactions",
+			want: []domain.Transaction{
+				{ID: 9, WalletID: 1, UserID: 1, Type: domain.TransactionDebit, Amount: -200, BalanceAfter: 300, Description: "Wallet debit", CreatedAt: at.Add(2 * time.Hour)},
+				{ID: 8, WalletID: 1, UserID: 1, Type: domain.TransactionCredit, Amount: 500, BalanceAfter: 500, Description: "Wallet credit", CreatedAt: at.Add(time.Hour)},
+			},
+		},
+		{
+			name:     "List transactions after a cursor",
+			beforeID: 8,
+			want:     []domain.Transaction{},
+		},
+		{
+			name:    "Error while listing transactions",
+			want:    []domain.Transaction{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := suite.mock.ExpectQuery(`SELECT (.+) FROM "wallet_transaction" (.+) ORDER BY id DESC LIMIT`).WithArgs(int64(1), tt.beforeID, 3)
+			if tt.wantErr {
+				query.WillReturnError(errors.New("mocked error"))
+			} else {
+				rows := sqlxmock.NewRows([]string{"id", "wallet_id", "user_id", "type", "amount", "balance_after", "description", "created_at"})
+				for _, txn := range tt.want {
+					rows.AddRow(txn.ID, txn.WalletID, txn.UserID, txn.Type, txn.Amount, txn.BalanceAfter, txn.Description, txn.CreatedAt)
+				}
+				query.WillReturnRows(rows)
+			}
+
+			got, err := suite.repo.ListRecentTransactions(context.Background(), 1, tt.beforeID, 3)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ListTransactionsSince() {
+	t := suite.T()
+	at := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	want := []domain.Transaction{
+		{ID: 8, WalletID: 1, UserID: 1, Type: domain.TransactionCredit, Amount: 500, BalanceAfter: 500, Description: "Wallet credit", CreatedAt: at},
+		{ID: 9, WalletID: 1, UserID: 1, Type: domain.TransactionDebit, Amount: -200, BalanceAfter: 300, Description: "Wallet debit", CreatedAt: at.Add(time.Hour)},
+	}
+	rows := sqlxmock.NewRows([]string{"id", "wallet_id", "user_id", "type", "amount", "balance_after", "description", "created_at"})
+	for _, txn := range want {
+		rows.AddRow(txn.ID, txn.WalletID, txn.UserID, txn.Type, txn.Amount, txn.BalanceAfter, txn.Description, txn.CreatedAt)
+	}
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "wallet_transaction" WHERE user_id = \$1 AND id > \$2 ORDER BY id LIMIT`).WithArgs(int64(1), int64(7), 100).WillReturnRows(rows)
+
+	got, err := suite.repo.ListTransactionsSince(context.Background(), 1, 7, 100)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "wallet_transaction"`).WithArgs(int64(1), int64(9), 100).WillReturnError(errors.New("mocked error"))
+	got, err = suite.repo.ListTransactionsSince(context.Background(), 1, 9, 100)
+	require.Error(t, err)
+	require.Equal(t, []domain.Transaction{}, got)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_GetBalanceAt() {
+	t := suite.T()
+	at := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		userID  int64
+		want    float64
+		wantErr bool
+	}{
+		{
+			name:    "Balance from ledger",
+			userID:  1,
+			want:    750,
+			wantErr: false,
+		},
+		{
+			name:    "Error while summing ledger",
+			userID:  2,
+			want:    0,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := suite.mock.ExpectQuery(`SELECT COALESCE\(SUM\(amount\), 0\) FROM "wallet_transaction"`).WithArgs(tt.userID, at)
+			if tt.wantErr {
+				query.WillReturnError(errors.New("mocked error"))
+			} else {
+				query.WillReturnRows(sqlxmock.NewRows([]string{"sum"}).AddRow(tt.want))
+			}
+
+			got, err := suite.repo.GetBalanceAt(context.Background(), tt.userID, at)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func (suite *StoreTestSuite) Test_pgStore_ListWalletBalances() {
+	t := suite.T()
+	tests := []struct {
+		name    string
+		want    []domain.WalletLedgerBalance
+		wantErr bool
+	}{
+		{
+			name: "Wallets with ledger sums",
+			want: []domain.WalletLedgerBalance{
+				{WalletID: 1, UserID: 1, Status: domain.WalletStatusActive, RecordedBalance: 500, LedgerBalance: 500},
+				{WalletID: 2, UserID: 2, Status: domain.WalletStatusActive, RecordedBalance: 300, LedgerBalance: 250},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "Error while listing balances",
+			want:    []domain.WalletLedgerBalance{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := suite.mock.ExpectQuery(`SELECT w.id, COALESCE\(w.user_id, 0\), w.status, w.balance, COALESCE\(SUM\(t.amount\), 0\) FROM "wallet" w`)
+			if tt.wantErr {
+				query.WillReturnError(errors.New("mocked error"))
+			} else {
+				rows := sqlxmock.NewRows([]string{"id", "user_id", "status", "balance", "coalesce"})
+				for _, balance := range tt.want {
+					rows.AddRow(balance.WalletID, balance.UserID, balance.Status, balance.RecordedBalance, balance.LedgerBalance)
+				}
+				query.WillReturnRows(rows)
+			}
+
+			got, err := suite.repo.ListWalletBalances(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
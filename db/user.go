@@ -5,39 +5,97 @@ import (
 	"database/sql"
 	"nickPay/wallet/internal/domain"
 	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
 
-	logger "github.com/sirupsen/logrus"
+	"github.com/lib/pq"
 )
 
-func (s *pgStore) RegisterUser(ctx context.Context, user domain.User) error {
-	rows, err := s.db.QueryContext(ctx, `INSERT INTO "user" (name, email, number, password) VALUES ($1, $2, $3, $4)`, user.Name, user.Email, user.PhoneNumber, user.Password)
+// emailUniqueIndex keeps emails of active users unique regardless of case.
+const emailUniqueIndex = "user_email_lower_idx"
+
+// isUniqueViolation reports whether err is Postgres refusing a write that
+// would break the named unique index or constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+// RegisterUser creates an unverified user and returns its ID.
+func (s *pgStore) RegisterUser(ctx context.Context, user domain.User) (userID int64, err error) {
+	const query = `INSERT INTO "user" (name, email, number, password) VALUES ($1, $2, $3, $4) RETURNING id`
+	ctx, finish := startQuery(ctx, "RegisterUser", query)
+	defer finish(&err)
+	err = s.db.QueryRowContext(ctx, query, user.Name, user.Email, user.PhoneNumber, user.Password).Scan(&userID)
+	if isUniqueViolation(err, emailUniqueIndex) {
+		return 0, errors.ErrEmailTaken
+	}
 	if err != nil {
-		logger.WithField("err", err).Error("Error while registering user")
-		return errors.ErrRegisteringUser
+		logging.FromContext(ctx).WithField("err", err).Error("Error while registering user")
+		return 0, errors.ErrRegisteringUser
 	}
-	defer rows.Close()
-	return nil
+	setRowCount(ctx, 1)
+	return userID, nil
 }
 
 func (s *pgStore) LoginUser(ctx context.Context, requestEmail string) (loginResponse domain.LoginDbResponse, err error) {
+	const query = `SELECT id, password, session_version FROM "user" WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL`
+	ctx, finish := startQuery(ctx, "LoginUser", query)
+	defer finish(&err)
 	loginResponse = domain.LoginDbResponse{}
-	rows, err := s.db.QueryContext(ctx, `SELECT id, password FROM "user" WHERE email = $1`, requestEmail)
+	rows, err := s.db.QueryContext(ctx, query, requestEmail)
 	if err == sql.ErrNoRows {
-		logger.WithField("err", err).Error("user not found")
+		logging.FromContext(ctx).WithField("err", err).Error("user not found")
 		return loginResponse, err
 	}
 	if err != nil {
-		logger.WithField("err", err).Error("Error while logging in user")
+		logging.FromContext(ctx).WithField("err", err).Error("Error while logging in user")
 		return loginResponse, err
 	}
 	defer rows.Close()
 
+	var count int64
 	for rows.Next() {
-		err = rows.Scan(&loginResponse.ID, &loginResponse.Password)
+		err = rows.Scan(&loginResponse.ID, &loginResponse.Password, &loginResponse.SessionVersion)
 		if err != nil {	
-			logger.WithField("err", err).Error("Error while scanning login response")
+			logging.FromContext(ctx).WithField("err", err).Error("Error while scanning login response")
 			return loginResponse, err
 		}
+		count++
 	}
+	setRowCount(ctx, count)
 	return loginResponse, nil
 }
+
+func (s *pgStore) GetUser(ctx context.Context, userID int64) (user domain.User, err error) {
+	const query = `SELECT id, name, email, number, email_verified_at IS NOT NULL, phone_verified_at IS NOT NULL, COALESCE(pending_email, '') FROM "user" WHERE id = $1 AND deleted_at IS NULL`
+	ctx, finish := startQuery(ctx, "GetUser", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, userID).Scan(&user.ID, &user.Name, &user.Email, &user.PhoneNumber, &user.EmailVerified, &user.PhoneVerified, &user.PendingEmail)
+	if err == sql.ErrNoRows {
+		return domain.User{}, errors.ErrUserNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingUser.Error())
+		return domain.User{}, errors.ErrFetchingUser
+	}
+	setRowCount(ctx, 1)
+	return user, nil
+}
+
+// FindUser returns the active user whose email, compared case-insensitively,
+// or phone number is contact.
+func (s *pgStore) FindUser(ctx context.Context, contact string) (user domain.User, err error) {
+	const query = `SELECT id, name, email, number, email_verified_at IS NOT NULL, phone_verified_at IS NOT NULL, COALESCE(pending_email, '') FROM "user" WHERE (LOWER(email) = LOWER($1) OR number = $1) AND deleted_at IS NULL`
+	ctx, finish := startQuery(ctx, "FindUser", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, contact).Scan(&user.ID, &user.Name, &user.Email, &user.PhoneNumber, &user.EmailVerified, &user.PhoneVerified, &user.PendingEmail)
+	if err == sql.ErrNoRows {
+		return domain.User{}, errors.ErrUserNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingUser.Error())
+		return domain.User{}, errors.ErrFetchingUser
+	}
+	setRowCount(ctx, 1)
+	return user, nil
+}
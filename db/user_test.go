@@ -90,7 +90,7 @@ func (suite *StoreTestSuite) Test_pgStore_RegisterUser() {
 				WillReturnRows(rows).
 				WillReturnError(err)
 							
-			err = suite.repo.RegisterUser(tt.args.ctx, tt.args.user)
+			_, err = suite.repo.RegisterUser(tt.args.ctx, tt.args.user)
 			if tt.wantUserQueryErr {
 				require.Error(t, err)
 			} else {
@@ -144,9 +144,9 @@ func (suite *StoreTestSuite) Test_pgStore_LoginUser() {
 				err = nil
 			}
 
-			rows := sqlxmock.NewRows([]string{"id", "password"}).AddRow(1, "12345678")
+			rows := sqlxmock.NewRows([]string{"id", "password", "session_version"}).AddRow(1, "12345678", 0)
 
-			suite.mock.ExpectQuery(`SELECT id, password FROM "user"`).WithArgs(tt.args.email).WillReturnError(err).WillReturnRows(rows)
+			suite.mock.ExpectQuery(`SELECT id, password, session_version FROM "user"`).WithArgs(tt.args.email).WillReturnError(err).WillReturnRows(rows)
 
 			got, err := suite.repo.LoginUser(tt.args.ctx, tt.args.email)
 
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// verifiedColumns maps a verification channel to the "user" column recording
+// when it was verified. Channels are never interpolated into SQL directly.
+var verifiedColumns = map[string]string{
+	domain.VerificationEmail: "email_verified_at",
+	domain.VerificationPhone: "phone_verified_at",
+}
+
+// SaveVerificationCode stores a new code for the user and channel, replacing
+// any pending one. The attempts at a pending code that has not expired carry
+// over, so sending a new code does not grant more guesses.
+func (s *pgStore) SaveVerificationCode(ctx context.Context, code domain.VerificationCode) (err error) {
+	const query = `INSERT INTO "verification_code" (user_id, channel, code_hash, attempts, expires_at) VALUES ($1, $2, $3, 0, $4)
+		ON CONFLICT (user_id, channel) DO UPDATE SET code_hash = EXCLUDED.code_hash, expires_at = EXCLUDED.expires_at,
+		attempts = CASE WHEN "verification_code".expires_at > $5 THEN "verification_code".attempts ELSE 0 END`
+	ctx, finish := startQuery(ctx, "SaveVerificationCode", query)
+	defer finish(&err)
+	_, err = s.db.ExecContext(ctx, query, code.UserID, code.Channel, code.CodeHash, code.ExpiresAt, time.Now())
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrSavingVerificationCode.Error())
+		return errors.ErrSavingVerificationCode
+	}
+	setRowCount(ctx, 1)
+	return nil
+}
+
+// GetVerificationCode returns the pending code for the user and channel, or
+// ErrNoVerificationCode when there is none.
+func (s *pgStore) GetVerificationCode(ctx context.Context, userID int64, channel string) (code domain.VerificationCode, err error) {
+	const query = `SELECT user_id, channel, code_hash, attempts, expires_at FROM "verification_code" WHERE user_id = $1 AND channel = $2`
+	ctx, finish := startQuery(ctx, "GetVerificationCode", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, userID, channel).StructScan(&code)
+	if err == sql.ErrNoRows {
+		return domain.VerificationCode{}, errors.ErrNoVerificationCode
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrVerifying.Error())
+		return domain.VerificationCode{}, errors.ErrVerifying
+	}
+	setRowCount(ctx, 1)
+	return code, nil
+}
+
+// IncrementVerificationAttempts counts a wrong code against the pending one.
+func (s *pgStore) IncrementVerificationAttempts(ctx context.Context, userID int64, channel string) (err error) {
+	const query = `UPDATE "verification_code" SET attempts = attempts + 1 WHERE user_id = $1 AND channel = $2`
+	ctx, finish := startQuery(ctx, "IncrementVerificationAttempts", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, userID, channel)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrVerifying.Error())
+		return errors.ErrVerifying
+	}
+	rowsAffected, _ := result.RowsAffected()
+	setRowCount(ctx, rowsAffected)
+	return nil
+}
+
+// MarkVerified records that the user proved ownership of the channel and
+// discards its pending code.
+func (s *pgStore) MarkVerified(ctx context.Context, userID int64, channel string) (err error) {
+	column, ok := verifiedColumns[channel]
+	if !ok {
+		return errors.ErrInvalidVerificationChannel
+	}
+	updateQuery := `UPDATE "user" SET ` + column + ` = $1 WHERE id = $2`
+	const deleteQuery = `DELETE FROM "verification_code" WHERE user_id = $1 AND channel = $2`
+	ctx, finish := startQuery(ctx, "MarkVerified", updateQuery+"; "+deleteQuery)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, updateQuery, time.Now(), userID)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAffected == 0 {
+			return errors.ErrUserNotFound
+		}
+		_, err = tx.ExecContext(ctx, deleteQuery, userID, channel)
+		return err
+	})
+	if err == errors.ErrUserNotFound {
+		return
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrVerifying.Error())
+		return errors.ErrVerifying
+	}
+	setRowCount(ctx, 2)
+	return nil
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_SaveVerificationCode() {
+	t := suite.T()
+	code := domain.VerificationCode{UserID: 1, Channel: domain.VerificationEmail, CodeHash: "hash", ExpiresAt: time.Date(2023, time.May, 1, 12, 15, 0, 0, time.UTC)}
+
+	suite.mock.ExpectExec(`INSERT INTO "verification_code" (.+) attempts = CASE WHEN "verification_code".expires_at > \$5 THEN "verification_code".attempts ELSE 0 END`).
+		WithArgs(code.UserID, code.Channel, code.CodeHash, code.ExpiresAt, sqlxmock.AnyArg()).WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.SaveVerificationCode(context.Background(), code))
+}
+
+func (suite *StoreTestSuite) Test_pgStore_GetVerificationCode() {
+	t := suite.T()
+	expiresAt := time.Date(2023, time.May, 1, 12, 15, 0, 0, time.UTC)
+	columns := []string{"user_id", "channel", "code_hash", "attempts", "expires_at"}
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "verification_code"`).WithArgs(int64(1), domain.VerificationPhone).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(1, domain.VerificationPhone, "hash", 2, expiresAt))
+	got, err := suite.repo.GetVerificationCode(context.Background(), 1, domain.VerificationPhone)
+	require.NoError(t, err)
+	require.Equal(t, domain.VerificationCode{UserID: 1, Channel: domain.VerificationPhone, CodeHash: "hash", Attempts: 2, ExpiresAt: expiresAt}, got)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "verification_code"`).WithArgs(int64(2), domain.VerificationPhone).
+		WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetVerificationCode(context.Background(), 2, domain.VerificationPhone)
+	require.Equal(t, errors.ErrNoVerificationCode, err)
+}
+
+func (suite *StoreTestSuite) Test_pgStore_MarkVerified() {
+	t := suite.T()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec(`UPDATE "user" SET phone_verified_at = \$1`).WithArgs(sqlxmock.AnyArg(), int64(1)).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectExec(`DELETE FROM "verification_code"`).WithArgs(int64(1), domain.VerificationPhone).WillReturnResult(sqlxmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	require.NoError(t, suite.repo.MarkVerified(context.Background(), 1, domain.VerificationPhone))
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+
+	require.Equal(t, errors.ErrInvalidVerificationChannel, suite.repo.MarkVerified(context.Background(), 1, "fax"))
+}
@@ -5,76 +5,144 @@ import (
 	"database/sql"
 	"nickPay/wallet/internal/domain"
 	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
 	"time"
 
-	logger "github.com/sirupsen/logrus"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 func (s *pgStore) CreateWallet(ctx context.Context, userID int64) (err error){
-	rows, err := s.db.QueryContext(ctx, `INSERT INTO "wallet" (user_id, balance, creation_date, last_updated, status) VALUES ($1, $2, $3, $4, $5) RETURNING id`, &userID, 0.0, time.Now().Local().Format("2006-01-02"), time.Now().Local().Format("2006-01-02 15:04:05"), "active")
+	const query = `INSERT INTO "wallet" (user_id, balance, creation_date, last_updated, status) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	ctx, finish := startQuery(ctx, "CreateWallet", query)
+	defer finish(&err)
+	rows, err := s.db.QueryContext(ctx, query, &userID, 0.0, time.Now().Local().Format("2006-01-02"), time.Now().Local().Format("2006-01-02 15:04:05"), "active")
 	if err != nil {
-		logger.WithField("err", err.Error()).Error("Cannot insert wallet")
+		logging.FromContext(ctx).WithField("err", err.Error()).Error("Cannot insert wallet")
 		return err
 	}
 	defer rows.Close()
+	setRowCount(ctx, 1)
 	return nil
 }
 
 
 func (s *pgStore) GetWallet(ctx context.Context, userID int64) (wallet domain.Wallet, err error) {
+	const query = "SELECT * FROM wallet where user_id = $1"
+	ctx, finish := startQuery(ctx, "GetWallet", query)
+	defer finish(&err)
 	wallet = domain.Wallet{}
-	rows, err := s.db.Query("SELECT * FROM wallet where user_id = $1", &userID)
+	rows, err := s.db.QueryContext(ctx, query, &userID)
 	if err != nil && err == sql.ErrNoRows {
-		logger.WithField("err", err.Error()).Error(errors.ErrNoWallet.Error())
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrNoWallet.Error())
 		return wallet, errors.ErrNoWallet
 	} else if err != nil {
-		logger.WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
 		return wallet, errors.ErrFetchingWallet
 	}
 	defer rows.Close()
+	var count int64
 	for rows.Next() {
 		err = rows.Scan(&wallet.ID, &wallet.UserID, &wallet.Balance, &wallet.CreationDate, &wallet.LastUpdated, &wallet.Status)
 		if err != nil {
-			logger.WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
 			return wallet, errors.ErrFetchingWallet
 		}
+		count++
 	}
+	setRowCount(ctx, count)
 	return
 }
 
-func (s *pgStore) CreditWallet(ctx context.Context, userID int64, amount float64) (err error) {
-	result, err := s.db.Exec(`UPDATE "wallet" SET balance = balance + $1, last_updated = $2 WHERE user_id = $3`, &amount, time.Now().Local().Format("2006-01-02 15:04:05"), &userID)
+// GetWalletsByID returns the wallets among walletIDs that belong to the
+// user, in no particular order.
+func (s *pgStore) GetWalletsByID(ctx context.Context, userID int64, walletIDs []int64) (wallets []domain.Wallet, err error) {
+	const query = `SELECT id, user_id, balance, creation_date, last_updated, status FROM "wallet" WHERE user_id = $1 AND id = ANY($2)`
+	ctx, finish := startQuery(ctx, "GetWalletsByID", query)
+	defer finish(&err)
+	wallets = []domain.Wallet{}
+	rows, err := s.db.QueryContext(ctx, query, userID, pq.Array(walletIDs))
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(errors.ErrUpdatingWallet.Error())
-		return errors.ErrUpdatingWallet
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
+		return wallets, errors.ErrFetchingWallet
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wallet domain.Wallet
+		err = rows.Scan(&wallet.ID, &wallet.UserID, &wallet.Balance, &wallet.CreationDate, &wallet.LastUpdated, &wallet.Status)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
+			return []domain.Wallet{}, errors.ErrFetchingWallet
+		}
+		wallets = append(wallets, wallet)
+	}
+	setRowCount(ctx, int64(len(wallets)))
+	return wallets, nil
+}
+
+// DebitWallet takes amount from the user's wallet, and fee to the house
+// revenue wallet in the same transaction.
+func (s *pgStore) DebitWallet(ctx context.Context, userID int64, amount float64, fee domain.FeeCharge) (err error) {
+	ctx, finish := startQuery(ctx, "DebitWallet", movementQueries)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		err := applyMovement(ctx, tx, userID, domain.TransactionDebit, -amount, "Wallet debit")
+		if err != nil {
+			return err
+		}
+		return chargeFee(ctx, tx, userID, fee, "Debit fee")
+	})
+	if err == errors.ErrInsufficientBalance {
+		return err
 	}
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(errors.ErrUpdatingWallet.Error())
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingWallet.Error())
 		return errors.ErrUpdatingWallet
 	}
-	if rowsAffected == 0 && rowsAffected != 1 {
-		logger.WithField("err", err.Error()).Error(errors.ErrUpdatingWallet.Error())
+	return
+}
+
+// AdjustWallet moves amount into the user's wallet, or out of it when
+// negative, recording description on the ledger entry. Unlike DebitWallet it
+// is meant for corrections made by operators.
+func (s *pgStore) AdjustWallet(ctx context.Context, userID int64, amount float64, description string) (err error) {
+	ctx, finish := startQuery(ctx, "AdjustWallet", movementQueries)
+	defer finish(&err)
+	txnType := domain.TransactionCredit
+	if amount < 0 {
+		txnType = domain.TransactionDebit
+	}
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		return applyMovement(ctx, tx, userID, txnType, amount, description)
+	})
+	if err == errors.ErrNoWallet || err == errors.ErrInsufficientBalance {
+		return err
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingWallet.Error())
 		return errors.ErrUpdatingWallet
 	}
 	return
 }
 
-
-func (s *pgStore) DebitWallet(ctx context.Context, userID int64, amount float64) (err error) {
-	result, err := s.db.Exec(`UPDATE "wallet" SET balance = balance - $1, last_updated = $2 WHERE user_id = $3`, &amount, time.Now().Local().Format("2006-01-02 15:04:05"), &userID)
+func (s *pgStore) SetWalletStatus(ctx context.Context, walletID int64, status string) (err error) {
+	const query = `UPDATE "wallet" SET status = $1, last_updated = $2 WHERE id = $3`
+	ctx, finish := startQuery(ctx, "SetWalletStatus", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, status, time.Now().Local().Format("2006-01-02 15:04:05"), walletID)
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(err.Error())
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingWallet.Error())
 		return errors.ErrUpdatingWallet
 	}
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(err.Error())
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingWallet.Error())
 		return errors.ErrUpdatingWallet
 	}
-	if rowsAffected == 0 && rowsAffected != 1 {
-		logger.WithField("err", err.Error()).Error(err.Error())
-		return errors.ErrUpdatingWallet
+	setRowCount(ctx, rowsAffected)
+	if rowsAffected == 0 {
+		return errors.ErrNoWallet
 	}
 	return
-}
\ No newline at end of file
+}
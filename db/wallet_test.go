@@ -4,11 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	"nickPay/wallet/internal/domain"
+	walleterrors "nickPay/wallet/internal/errors"
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	sqlxmock "github.com/zhashkevych/go-sqlxmock"
 )
@@ -130,62 +131,50 @@ func (suite *StoreTestSuite) Test_pgStore_GetWallet() {
 	}
 }
 
-func (suite *StoreTestSuite) Test_pgStore_CreditWallet() {
+func (suite *StoreTestSuite) Test_pgStore_GetWalletsByID() {
 	t := suite.T()
-	type args struct {
-		ctx    context.Context
-		userID int64
-		amount float64
-	}
 	tests := []struct {
 		name    string
-		args    args
+		want    []domain.Wallet
 		wantErr bool
 	}{
 		{
-			name: "Credit Valid Wallet",
-			args: args{
-				ctx:    context.Background(),
-				userID: 1,
-				amount: 1000.0,
+			name: "Get the user's wallets",
+			want: []domain.Wallet{
+				{ID: 1, UserID: 1, Balance: 1000, CreationDate: "2023-05-01", LastUpdated: "2023-05-01 10:00:00", Status: domain.WalletStatusActive},
 			},
-			wantErr: false,
 		},
 		{
-			name: "Credit Invalid Wallet",
-			args: args{
-				ctx:    context.Background(),
-				userID: 2,
-				amount: 1000.0,
-			},
+			name:    "Error while fetching wallets",
+			want:    []domain.Wallet{},
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var err error
-
+			query := suite.mock.ExpectQuery(`SELECT (.+) FROM "wallet" WHERE user_id = \$1 AND id = ANY\(\$2\)`).WithArgs(int64(1), pq.Array([]int64{1, 2}))
 			if tt.wantErr {
-				err = errors.New("mocked error")
+				query.WillReturnError(errors.New("mocked error"))
 			} else {
-				err = nil
+				rows := sqlxmock.NewRows([]string{"id", "user_id", "balance", "creation_date", "last_updated", "status"})
+				for _, wallet := range tt.want {
+					rows.AddRow(wallet.ID, wallet.UserID, wallet.Balance, wallet.CreationDate, wallet.LastUpdated, wallet.Status)
+				}
+				query.WillReturnRows(rows)
 			}
 
-			result := sqlxmock.NewResult(0, 1)
-			suite.mock.ExpectExec(`UPDATE "wallet"`).WithArgs(tt.args.amount, time.Now().Local().Format("2006-01-02 15:04:05"), tt.args.userID).WillReturnError(err).WillReturnResult(result)
-			fmt.Println(err)
-			err = suite.repo.CreditWallet(tt.args.ctx, tt.args.userID, tt.args.amount)
-			if (err != nil) == tt.wantErr {
-				require.Equal(t, err, nil)
+			got, err := suite.repo.GetWalletsByID(context.Background(), 1, []int64{1, 2})
+			if tt.wantErr {
+				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
 			}
+			require.Equal(t, tt.want, got)
 		})
 	}
 }
 
-
 func (suite *StoreTestSuite) Test_pgStore_DebitWallet() {
 	t := suite.T()
 	type args struct {
@@ -198,15 +187,15 @@ func (suite *StoreTestSuite) Test_pgStore_DebitWallet() {
 		args    args
 		wantErr bool
 	}{
-		// {
-		// 	name: "Debit Valid Wallet",
-		// 	args: args{
-		// 		ctx:    context.Background(),
-		// 		userID: 1,
-		// 		amount: 1000.0,
-		// 	},
-		// 	wantErr: false,
-		// },
+		{
+			name: "Debit Valid Wallet",
+			args: args{
+				ctx:    context.Background(),
+				userID: 1,
+				amount: 1000.0,
+			},
+			wantErr: false,
+		},
 		{
 			name: "Debit Invalid Wallet",
 			args: args{
@@ -220,23 +209,162 @@ func (suite *StoreTestSuite) Test_pgStore_DebitWallet() {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var err error
+			suite.mock.ExpectBegin()
+			update := suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).
+				WithArgs(-tt.args.amount, sqlxmock.AnyArg(), tt.args.userID)
+			if tt.wantErr {
+				update.WillReturnError(sql.ErrNoRows)
+				suite.mock.ExpectQuery(`SELECT EXISTS`).WithArgs(tt.args.userID).WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(false))
+				suite.mock.ExpectRollback()
+			} else {
+				update.WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(1, 500.0))
+				suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).
+					WithArgs(1, tt.args.userID, domain.TransactionDebit, -tt.args.amount, 500.0, "Wallet debit").
+					WillReturnResult(sqlxmock.NewResult(1, 1))
+				suite.mock.ExpectCommit()
+			}
 
+			err := suite.repo.DebitWallet(tt.args.ctx, tt.args.userID, tt.args.amount, domain.FeeCharge{})
 			if tt.wantErr {
-				err = errors.New("mocked error")
-				fmt.Println(err)
+				require.Error(t, err)
 			} else {
-				err = nil
+				require.NoError(t, err)
 			}
-			result := sqlxmock.NewResult(0, 1)
-			suite.mock.ExpectExec(`UPDATE "wallet"`).WithArgs(tt.args.amount, time.Now().Local().Format("2006-01-02 15:04:05"), tt.args.userID).WillReturnError(err).WillReturnResult(result)
-			err = suite.repo.DebitWallet(tt.args.ctx, tt.args.userID, tt.args.amount)
-			fmt.Println(err)
-			if (err != nil) == tt.wantErr {
-				require.Equal(t, err, nil)
+			require.NoError(t, suite.mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (suite *StoreTestSuite) Test_pgStore_DebitWalletFee() {
+	t := suite.T()
+	debit := func() {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-100.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 400.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -100.0, 400.0, "Wallet debit").
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-2.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 398.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -2.0, 398.0, "Debit fee").
+			WillReturnResult(sqlxmock.NewResult(2, 1))
+	}
+
+	t.Run("Fee goes to the revenue wallet", func(t *testing.T) {
+		debit()
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(2.0, sqlxmock.AnyArg(), int64(1)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(1, 12.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(1, int64(1), domain.TransactionCredit, 2.0, 12.0, "Debit fee from user 42").
+			WillReturnResult(sqlxmock.NewResult(3, 1))
+		suite.mock.ExpectCommit()
+
+		require.NoError(t, suite.repo.DebitWallet(context.Background(), 42, 100, domain.FeeCharge{Amount: 2, AccountID: 1}))
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing revenue wallet rolls the debit back", func(t *testing.T) {
+		debit()
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(2.0, sqlxmock.AnyArg(), int64(1)).
+			WillReturnError(sql.ErrNoRows)
+		suite.mock.ExpectRollback()
+
+		require.Error(t, suite.repo.DebitWallet(context.Background(), 42, 100, domain.FeeCharge{Amount: 2, AccountID: 1}))
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("Fee beyond the balance rolls the debit back", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET (.+) WHERE user_id = \$3 AND \(\$1 >= 0 OR balance \+ \$1 >= 0\)`).WithArgs(-100.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 1.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -100.0, 1.0, "Wallet debit").
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-2.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}))
+		suite.mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM "wallet" WHERE user_id = \$1\)`).WithArgs(int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(true))
+		suite.mock.ExpectRollback()
+
+		require.Equal(t, walleterrors.ErrInsufficientBalance, suite.repo.DebitWallet(context.Background(), 42, 100, domain.FeeCharge{Amount: 2, AccountID: 1}))
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+}
+
+func (suite *StoreTestSuite) Test_pgStore_AdjustWallet() {
+	t := suite.T()
+	tests := []struct {
+		name     string
+		amount   float64
+		txnType  string
+		noWallet bool
+		overdraw bool
+		wantErr  error
+	}{
+		{name: "Positive adjustment is a credit", amount: 25, txnType: domain.TransactionCredit},
+		{name: "Negative adjustment is a debit", amount: -25, txnType: domain.TransactionDebit},
+		{name: "Missing wallet", amount: 25, noWallet: true, wantErr: walleterrors.ErrNoWallet},
+		{name: "Debit beyond the balance", amount: -125, overdraw: true, wantErr: walleterrors.ErrInsufficientBalance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite.mock.ExpectBegin()
+			update := suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).
+				WithArgs(tt.amount, sqlxmock.AnyArg(), int64(1))
+			if tt.noWallet {
+				update.WillReturnError(sql.ErrNoRows)
+				suite.mock.ExpectRollback()
+			} else if tt.overdraw {
+				update.WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}))
+				suite.mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int64(1)).WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(true))
+				suite.mock.ExpectRollback()
+			} else {
+				update.WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(1, 100.0+tt.amount))
+				suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).
+					WithArgs(1, int64(1), tt.txnType, tt.amount, 100.0+tt.amount, "Adjustment: refund for ticket 12").
+					WillReturnResult(sqlxmock.NewResult(1, 1))
+				suite.mock.ExpectCommit()
+			}
+
+			err := suite.repo.AdjustWallet(context.Background(), 1, tt.amount, "Adjustment: refund for ticket 12")
+			require.Equal(t, tt.wantErr, err)
+			require.NoError(t, suite.mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func (suite *StoreTestSuite) Test_pgStore_SetWalletStatus() {
+	t := suite.T()
+	tests := []struct {
+		name         string
+		walletID     int64
+		rowsAffected int64
+		wantErr      bool
+	}{
+		{
+			name:         "Freeze existing wallet",
+			walletID:     1,
+			rowsAffected: 1,
+			wantErr:      false,
+		},
+		{
+			name:         "Freeze missing wallet",
+			walletID:     2,
+			rowsAffected: 0,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite.mock.ExpectExec(`UPDATE "wallet" SET status = \$1`).
+				WithArgs(domain.WalletStatusFrozen, sqlxmock.AnyArg(), tt.walletID).
+				WillReturnResult(sqlxmock.NewResult(0, tt.rowsAffected))
+
+			err := suite.repo.SetWalletStatus(context.Background(), tt.walletID, domain.WalletStatusFrozen)
+			if tt.wantErr {
+				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
 			}
 		})
 	}
-}
\ No newline at end of file
+}
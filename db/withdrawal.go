@@ -0,0 +1,231 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// bankAccountUnique stops a user linking the same account twice.
+	bankAccountUnique = "bank_account_unique"
+
+	insertWithdrawalQuery    = `INSERT INTO "withdrawal" (user_id, bank_account_id, amount, fee, fee_user_id, status, provider) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	withdrawalDescription    = "Withdrawal to bank account"
+	withdrawalFeeDescription = "Withdrawal fee"
+	createWithdrawalQueries  = insertWithdrawalQuery + "; " + movementQueries
+
+	// claimWithdrawalQuery settles a withdrawal if it is still pending, which
+	// also locks it, so a failed transfer is reversed once.
+	claimWithdrawalQuery      = `UPDATE "withdrawal" SET status = $1, failure_reason = $2, completed_at = NOW() WHERE id = $3 AND status = 'pending' RETURNING user_id, amount, fee, COALESCE(fee_user_id, 0)`
+	reversalDescription       = "Withdrawal reversal"
+	feeRefundDescription      = "Withdrawal fee refund"
+	completeWithdrawalQueries = claimWithdrawalQuery + "; " + movementQueries
+)
+
+// CreateBankAccount links a bank account to its user and returns its ID.
+func (s *pgStore) CreateBankAccount(ctx context.Context, account domain.BankAccount) (accountID int64, err error) {
+	const query = `INSERT INTO "bank_account" (user_id, holder_name, account_number, ifsc, iban) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	ctx, finish := startQuery(ctx, "CreateBankAccount", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, account.UserID, account.HolderName, account.AccountNumber, account.IFSC, account.IBAN).Scan(&accountID)
+	if isUniqueViolation(err, bankAccountUnique) {
+		return 0, errors.ErrBankAccountExists
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrLinkingBankAccount.Error())
+		return 0, errors.ErrLinkingBankAccount
+	}
+	setRowCount(ctx, 1)
+	return accountID, nil
+}
+
+// ListBankAccounts returns the user's bank accounts, oldest first.
+func (s *pgStore) ListBankAccounts(ctx context.Context, userID int64) (accounts []domain.BankAccount, err error) {
+	const query = `SELECT id, user_id, holder_name, account_number, ifsc, iban, created_at FROM "bank_account" WHERE user_id = $1 ORDER BY id`
+	ctx, finish := startQuery(ctx, "ListBankAccounts", query)
+	defer finish(&err)
+	accounts = []domain.BankAccount{}
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingBankAccounts.Error())
+		return accounts, errors.ErrFetchingBankAccounts
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var account domain.BankAccount
+		err = rows.Scan(&account.ID, &account.UserID, &account.HolderName, &account.AccountNumber, &account.IFSC, &account.IBAN, &account.CreatedAt)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingBankAccounts.Error())
+			return []domain.BankAccount{}, errors.ErrFetchingBankAccounts
+		}
+		accounts = append(accounts, account)
+	}
+	setRowCount(ctx, int64(len(accounts)))
+	return accounts, nil
+}
+
+func (s *pgStore) GetBankAccount(ctx context.Context, accountID int64) (account domain.BankAccount, err error) {
+	const query = `SELECT id, user_id, holder_name, account_number, ifsc, iban, created_at FROM "bank_account" WHERE id = $1`
+	ctx, finish := startQuery(ctx, "GetBankAccount", query)
+	defer finish(&err)
+	err = s.db.QueryRowxContext(ctx, query, accountID).Scan(&account.ID, &account.UserID, &account.HolderName, &account.AccountNumber, &account.IFSC, &account.IBAN, &account.CreatedAt)
+	if err == sql.ErrNoRows {
+		return domain.BankAccount{}, errors.ErrBankAccountNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingBankAccounts.Error())
+		return domain.BankAccount{}, errors.ErrFetchingBankAccounts
+	}
+	setRowCount(ctx, 1)
+	return account, nil
+}
+
+// CreateWithdrawal stores a pending withdrawal and debits its amount from the
+// wallet in the same transaction, holding it until the withdrawal settles.
+// The fee goes to the house revenue wallet at once. Nothing is stored when
+// the balance no longer covers both.
+func (s *pgStore) CreateWithdrawal(ctx context.Context, withdrawal domain.Withdrawal, fee domain.FeeCharge) (withdrawalID int64, err error) {
+	ctx, finish := startQuery(ctx, "CreateWithdrawal", createWithdrawalQueries)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		feeUserID := sql.NullInt64{Int64: fee.AccountID, Valid: fee.Amount != 0}
+		err := tx.QueryRowxContext(ctx, insertWithdrawalQuery, withdrawal.UserID, withdrawal.BankAccountID, withdrawal.Amount, fee.Amount, feeUserID, domain.WithdrawalStatusPending, withdrawal.Provider).Scan(&withdrawalID)
+		if err != nil {
+			return err
+		}
+		err = applyMovement(ctx, tx, withdrawal.UserID, domain.TransactionDebit, -withdrawal.Amount, withdrawalDescription)
+		if err != nil {
+			return err
+		}
+		return chargeFee(ctx, tx, withdrawal.UserID, fee, withdrawalFeeDescription)
+	})
+	if err == errors.ErrNoWallet || err == errors.ErrInsufficientBalance {
+		return 0, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingWithdrawal.Error())
+		return 0, errors.ErrCreatingWithdrawal
+	}
+	return withdrawalID, nil
+}
+
+// SetWithdrawalTransfer records the provider transfer paying a withdrawal.
+func (s *pgStore) SetWithdrawalTransfer(ctx context.Context, withdrawalID int64, transferID string) (err error) {
+	const query = `UPDATE "withdrawal" SET transfer_id = $1 WHERE id = $2`
+	ctx, finish := startQuery(ctx, "SetWithdrawalTransfer", query)
+	defer finish(&err)
+	result, err := s.db.ExecContext(ctx, query, transferID, withdrawalID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingWithdrawal.Error())
+		return errors.ErrCreatingWithdrawal
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCreatingWithdrawal.Error())
+		return errors.ErrCreatingWithdrawal
+	}
+	setRowCount(ctx, rowsAffected)
+	if rowsAffected == 0 {
+		return errors.ErrWithdrawalNotFound
+	}
+	return nil
+}
+
+func (s *pgStore) GetWithdrawal(ctx context.Context, withdrawalID int64) (withdrawal domain.Withdrawal, err error) {
+	const query = `SELECT id, user_id, bank_account_id, amount, fee, status, provider, COALESCE(transfer_id, ''), failure_reason, created_at, completed_at FROM "withdrawal" WHERE id = $1`
+	ctx, finish := startQuery(ctx, "GetWithdrawal", query)
+	defer finish(&err)
+	withdrawal, err = scanWithdrawal(s.db.QueryRowxContext(ctx, query, withdrawalID))
+	if err == sql.ErrNoRows {
+		return domain.Withdrawal{}, errors.ErrWithdrawalNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWithdrawal.Error())
+		return domain.Withdrawal{}, errors.ErrFetchingWithdrawal
+	}
+	setRowCount(ctx, 1)
+	return withdrawal, nil
+}
+
+// ListPendingWithdrawals returns up to limit pending withdrawals, oldest
+// first. Those without a transfer are left out until they are older than
+// unsubmittedAge, giving the request that holds their funds time to record
+// it.
+func (s *pgStore) ListPendingWithdrawals(ctx context.Context, limit int, unsubmittedAge time.Duration) (withdrawals []domain.Withdrawal, err error) {
+	const query = `SELECT id, user_id, bank_account_id, amount, fee, status, provider, COALESCE(transfer_id, ''), failure_reason, created_at, completed_at FROM "withdrawal"
+		WHERE status = 'pending' AND (transfer_id IS NOT NULL OR created_at < NOW() - $2 * INTERVAL '1 second') ORDER BY id LIMIT $1`
+	ctx, finish := startQuery(ctx, "ListPendingWithdrawals", query)
+	defer finish(&err)
+	withdrawals = []domain.Withdrawal{}
+	rows, err := s.db.QueryxContext(ctx, query, limit, int64(unsubmittedAge/time.Second))
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWithdrawal.Error())
+		return withdrawals, errors.ErrFetchingWithdrawal
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		withdrawal, err := scanWithdrawal(rows)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWithdrawal.Error())
+			return []domain.Withdrawal{}, errors.ErrFetchingWithdrawal
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	setRowCount(ctx, int64(len(withdrawals)))
+	return withdrawals, nil
+}
+
+// CompleteWithdrawal settles a pending withdrawal as settled or failed,
+// returning the held amount and the fee to the wallet in the same
+// transaction when it failed. It reports false, and changes nothing, when
+// the withdrawal was already settled.
+func (s *pgStore) CompleteWithdrawal(ctx context.Context, withdrawalID int64, status string, failureReason string) (completed bool, err error) {
+	ctx, finish := startQuery(ctx, "CompleteWithdrawal", completeWithdrawalQueries)
+	defer finish(&err)
+	err = s.withTx(ctx, func(tx *sqlx.Tx) error {
+		var userID int64
+		var amount float64
+		var fee domain.FeeCharge
+		err := tx.QueryRowxContext(ctx, claimWithdrawalQuery, status, failureReason, withdrawalID).Scan(&userID, &amount, &fee.Amount, &fee.AccountID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		completed = true
+		if status != domain.WithdrawalStatusFailed {
+			return nil
+		}
+		err = applyMovement(ctx, tx, userID, domain.TransactionCredit, amount, reversalDescription)
+		if err != nil {
+			return err
+		}
+		return refundFee(ctx, tx, userID, fee, feeRefundDescription)
+	})
+	if err == errors.ErrNoWallet {
+		return false, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrCompletingWithdrawal.Error())
+		return false, errors.ErrCompletingWithdrawal
+	}
+	return completed, nil
+}
+
+func scanWithdrawal(row interface{ Scan(...interface{}) error }) (withdrawal domain.Withdrawal, err error) {
+	var completedAt sql.NullTime
+	err = row.Scan(&withdrawal.ID, &withdrawal.UserID, &withdrawal.BankAccountID, &withdrawal.Amount, &withdrawal.Fee, &withdrawal.Status, &withdrawal.Provider, &withdrawal.TransferID, &withdrawal.FailureReason, &withdrawal.CreatedAt, &completedAt)
+	if completedAt.Valid {
+		withdrawal.CompletedAt = &completedAt.Time
+	}
+	return withdrawal, err
+}
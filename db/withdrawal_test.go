@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	sqlxmock "github.com/zhashkevych/go-sqlxmock"
+)
+
+func (suite *StoreTestSuite) Test_pgStore_BankAccounts() {
+	t := suite.T()
+	account := domain.BankAccount{UserID: 42, HolderName: "John Doe", AccountNumber: "123456789012", IFSC: "SBIN0001234"}
+	suite.mock.ExpectQuery(`INSERT INTO "bank_account"`).WithArgs(int64(42), "John Doe", "123456789012", "SBIN0001234", "").
+		WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(5))
+	accountID, err := suite.repo.CreateBankAccount(context.Background(), account)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), accountID)
+
+	suite.mock.ExpectQuery(`INSERT INTO "bank_account"`).WillReturnError(&pq.Error{Code: "23505", Constraint: bankAccountUnique})
+	_, err = suite.repo.CreateBankAccount(context.Background(), account)
+	require.Equal(t, errors.ErrBankAccountExists, err)
+
+	createdAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "holder_name", "account_number", "ifsc", "iban", "created_at"}
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "bank_account" WHERE user_id = \$1`).WithArgs(int64(42)).
+		WillReturnRows(sqlxmock.NewRows(columns).
+			AddRow(5, 42, "John Doe", "123456789012", "SBIN0001234", "", createdAt).
+			AddRow(6, 42, "John Doe", "", "", "GB82WEST12345698765432", createdAt))
+	accounts, err := suite.repo.ListBankAccounts(context.Background(), 42)
+	require.NoError(t, err)
+	require.Equal(t, []domain.BankAccount{
+		{ID: 5, UserID: 42, HolderName: "John Doe", AccountNumber: "123456789012", IFSC: "SBIN0001234", CreatedAt: createdAt},
+		{ID: 6, UserID: 42, HolderName: "John Doe", IBAN: "GB82WEST12345698765432", CreatedAt: createdAt},
+	}, accounts)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "bank_account" WHERE id = \$1`).WithArgs(int64(7)).WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetBankAccount(context.Background(), 7)
+	require.Equal(t, errors.ErrBankAccountNotFound, err)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_CreateWithdrawal() {
+	t := suite.T()
+	withdrawal := domain.Withdrawal{UserID: 42, BankAccountID: 5, Amount: 50, Provider: "fake"}
+
+	t.Run("holds the amount", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery(`INSERT INTO "withdrawal"`).WithArgs(int64(42), int64(5), 50.0, 0.0, nil, domain.WithdrawalStatusPending, "fake").
+			WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(9))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-50.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 100.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -50.0, 100.0, "Withdrawal to bank account").
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+		suite.mock.ExpectCommit()
+
+		withdrawalID, err := suite.repo.CreateWithdrawal(context.Background(), withdrawal, domain.FeeCharge{})
+		require.NoError(t, err)
+		require.Equal(t, int64(9), withdrawalID)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("charges the fee to the revenue wallet", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery(`INSERT INTO "withdrawal"`).WithArgs(int64(42), int64(5), 50.0, 1.5, int64(1), domain.WithdrawalStatusPending, "fake").
+			WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(9))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-50.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 100.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -50.0, 100.0, "Withdrawal to bank account").
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(-1.5, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 98.5))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionDebit, -1.5, 98.5, "Withdrawal fee").
+			WillReturnResult(sqlxmock.NewResult(2, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(1.5, sqlxmock.AnyArg(), int64(1)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(1, 11.5))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(1, int64(1), domain.TransactionCredit, 1.5, 11.5, "Withdrawal fee from user 42").
+			WillReturnResult(sqlxmock.NewResult(3, 1))
+		suite.mock.ExpectCommit()
+
+		_, err := suite.repo.CreateWithdrawal(context.Background(), withdrawal, domain.FeeCharge{Amount: 1.5, AccountID: 1})
+		require.NoError(t, err)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back without a wallet", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery(`INSERT INTO "withdrawal"`).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(9))
+		suite.mock.ExpectQuery(`UPDATE "wallet"`).WillReturnError(sql.ErrNoRows)
+		suite.mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int64(42)).WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(false))
+		suite.mock.ExpectRollback()
+
+		_, err := suite.repo.CreateWithdrawal(context.Background(), withdrawal, domain.FeeCharge{})
+		require.Equal(t, errors.ErrNoWallet, err)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses to overdraw the wallet", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery(`INSERT INTO "withdrawal"`).WillReturnRows(sqlxmock.NewRows([]string{"id"}).AddRow(9))
+		suite.mock.ExpectQuery(`UPDATE "wallet"`).WithArgs(-50.0, sqlxmock.AnyArg(), int64(42)).WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}))
+		suite.mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int64(42)).WillReturnRows(sqlxmock.NewRows([]string{"exists"}).AddRow(true))
+		suite.mock.ExpectRollback()
+
+		_, err := suite.repo.CreateWithdrawal(context.Background(), withdrawal, domain.FeeCharge{})
+		require.Equal(t, errors.ErrInsufficientBalance, err)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	suite.mock.ExpectExec(`UPDATE "withdrawal" SET transfer_id = \$1 WHERE id = \$2`).WithArgs("fake_1", int64(9)).
+		WillReturnResult(sqlxmock.NewResult(0, 1))
+	require.NoError(t, suite.repo.SetWithdrawalTransfer(context.Background(), 9, "fake_1"))
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_GetWithdrawal() {
+	t := suite.T()
+	createdAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	columns := []string{"id", "user_id", "bank_account_id", "amount", "fee", "status", "provider", "transfer_id", "failure_reason", "created_at", "completed_at"}
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "withdrawal" WHERE id = \$1`).WithArgs(int64(9)).
+		WillReturnRows(sqlxmock.NewRows(columns).AddRow(9, 42, 5, 50.0, 1.5, domain.WithdrawalStatusFailed, "fake", "fake_1", "account closed", createdAt, createdAt.Add(time.Minute)))
+
+	got, err := suite.repo.GetWithdrawal(context.Background(), 9)
+	require.NoError(t, err)
+	completedAt := createdAt.Add(time.Minute)
+	require.Equal(t, domain.Withdrawal{
+		ID:            9,
+		UserID:        42,
+		BankAccountID: 5,
+		Amount:        50,
+		Fee:           1.5,
+		Status:        domain.WithdrawalStatusFailed,
+		Provider:      "fake",
+		TransferID:    "fake_1",
+		FailureReason: "account closed",
+		CreatedAt:     createdAt,
+		CompletedAt:   &completedAt,
+	}, got)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "withdrawal" WHERE id = \$1`).WithArgs(int64(10)).WillReturnRows(sqlxmock.NewRows(columns))
+	_, err = suite.repo.GetWithdrawal(context.Background(), 10)
+	require.Equal(t, errors.ErrWithdrawalNotFound, err)
+
+	suite.mock.ExpectQuery(`SELECT (.+) FROM "withdrawal"\s+WHERE status = 'pending' AND \(transfer_id IS NOT NULL OR created_at < NOW\(\) - \$2 \* INTERVAL '1 second'\) ORDER BY id LIMIT \$1`).WithArgs(100, int64(900)).
+		WillReturnRows(sqlxmock.NewRows(columns).
+			AddRow(9, 42, 5, 50.0, 0.0, domain.WithdrawalStatusPending, "fake", "fake_1", "", createdAt, nil).
+			AddRow(10, 42, 5, 20.0, 0.0, domain.WithdrawalStatusPending, "fake", "", "", createdAt, nil))
+	pending, err := suite.repo.ListPendingWithdrawals(context.Background(), 100, 15*time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, []domain.Withdrawal{
+		{ID: 9, UserID: 42, BankAccountID: 5, Amount: 50, Status: domain.WithdrawalStatusPending, Provider: "fake", TransferID: "fake_1", CreatedAt: createdAt},
+		{ID: 10, UserID: 42, BankAccountID: 5, Amount: 20, Status: domain.WithdrawalStatusPending, Provider: "fake", CreatedAt: createdAt},
+	}, pending)
+	require.NoError(t, suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StoreTestSuite) Test_pgStore_CompleteWithdrawal() {
+	t := suite.T()
+	claim := func(status string, reason string) *sqlxmock.ExpectedQuery {
+		return suite.mock.ExpectQuery(`UPDATE "withdrawal" SET status = \$1, failure_reason = \$2, completed_at = NOW\(\) WHERE id = \$3 AND status = 'pending'`).WithArgs(status, reason, int64(9))
+	}
+	claimed := []string{"user_id", "amount", "fee", "fee_user_id"}
+
+	t.Run("reverses failed withdrawals", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		claim(domain.WithdrawalStatusFailed, "account closed").WillReturnRows(sqlxmock.NewRows(claimed).AddRow(42, 50.0, 1.5, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(50.0, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 150.0))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionCredit, 50.0, 150.0, "Withdrawal reversal").
+			WillReturnResult(sqlxmock.NewResult(1, 1))
+		// The fee comes back from the revenue wallet too, even when that
+		// takes it below zero
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1, last_updated = \$2 WHERE user_id = \$3 RETURNING`).WithArgs(-1.5, sqlxmock.AnyArg(), int64(1)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(1, -0.5))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(1, int64(1), domain.TransactionDebit, -1.5, -0.5, "Withdrawal fee refund to user 42").
+			WillReturnResult(sqlxmock.NewResult(2, 1))
+		suite.mock.ExpectQuery(`UPDATE "wallet" SET balance = balance \+ \$1`).WithArgs(1.5, sqlxmock.AnyArg(), int64(42)).
+			WillReturnRows(sqlxmock.NewRows([]string{"id", "balance"}).AddRow(3, 151.5))
+		suite.mock.ExpectExec(`INSERT INTO "wallet_transaction"`).WithArgs(3, int64(42), domain.TransactionCredit, 1.5, 151.5, "Withdrawal fee refund").
+			WillReturnResult(sqlxmock.NewResult(3, 1))
+		suite.mock.ExpectCommit()
+
+		completed, err := suite.repo.CompleteWithdrawal(context.Background(), 9, domain.WithdrawalStatusFailed, "account closed")
+		require.NoError(t, err)
+		require.True(t, completed)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("only marks settled withdrawals", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		claim(domain.WithdrawalStatusSettled, "").WillReturnRows(sqlxmock.NewRows(claimed).AddRow(42, 50.0, 0.0, 0))
+		suite.mock.ExpectCommit()
+
+		completed, err := suite.repo.CompleteWithdrawal(context.Background(), 9, domain.WithdrawalStatusSettled, "")
+		require.NoError(t, err)
+		require.True(t, completed)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("leaves completed withdrawals alone", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		claim(domain.WithdrawalStatusFailed, "account closed").WillReturnRows(sqlxmock.NewRows(claimed))
+		suite.mock.ExpectCommit()
+
+		completed, err := suite.repo.CompleteWithdrawal(context.Background(), 9, domain.WithdrawalStatusFailed, "account closed")
+		require.NoError(t, err)
+		require.False(t, completed)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+
+	t.Run("rolls back on errors", func(t *testing.T) {
+		suite.mock.ExpectBegin()
+		claim(domain.WithdrawalStatusSettled, "").WillReturnError(sql.ErrConnDone)
+		suite.mock.ExpectRollback()
+
+		completed, err := suite.repo.CompleteWithdrawal(context.Background(), 9, domain.WithdrawalStatusSettled, "")
+		require.Equal(t, errors.ErrCompletingWithdrawal, err)
+		require.False(t, completed)
+		require.NoError(t, suite.mock.ExpectationsWereMet())
+	})
+}
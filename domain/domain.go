@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type RegisterUserRequest struct {
 	Email       string `json:"email"`
 	Name        string `json:"name"`
@@ -14,11 +16,13 @@ type RegisterUserResponse struct {
 type LoginUserRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	ClientIP string `json:"-"`
 }
 
 type LoginUserResponse struct {
 	Message string `json:"message"`
 	Token 	string `json:"token"`
+	MFAToken string `json:"mfa_token,omitempty"`
 }
 type User struct {
 	ID          int64  `db:"id" json:"id"`
@@ -26,11 +30,57 @@ type User struct {
 	Name        string `db:"name" json:"name"`
 	PhoneNumber string `db:"number" json:"phone_number"`
 	Password    string `db:"password" json:"password"` // Don't return password
+	EmailVerified bool `db:"email_verified" json:"email_verified"`
+	PhoneVerified bool `db:"phone_verified" json:"phone_verified"`
+	PendingEmail string `db:"pending_email" json:"pending_email"`
+}
+
+// UserProfile is what a user sees of their own account. It deliberately has
+// no password field.
+type UserProfile struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	PhoneNumber   string `json:"phone_number"`
+	EmailVerified bool   `json:"email_verified"`
+	PhoneVerified bool   `json:"phone_verified"`
+	PendingEmail  string `json:"pending_email,omitempty"`
+}
+
+func NewUserProfile(user User) UserProfile {
+	return UserProfile{
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		PhoneNumber:   user.PhoneNumber,
+		EmailVerified: user.EmailVerified,
+		PhoneVerified: user.PhoneVerified,
+		PendingEmail:  user.PendingEmail,
+	}
+}
+
+// UpdateProfileRequest is a partial update, fields left out are unchanged.
+type UpdateProfileRequest struct {
+	Name        *string `json:"name"`
+	Email       *string `json:"email"`
+	PhoneNumber *string `json:"phone_number"`
+}
+
+// UserUpdate is the full set of editable user columns after applying an
+// UpdateProfileRequest.
+type UserUpdate struct {
+	Name         string
+	PhoneNumber  string
+	PendingEmail string
+	// ResetPhoneVerification is set when the phone number changed and has to
+	// be verified again
+	ResetPhoneVerification bool
 }
 
 type LoginDbResponse struct {
 	ID  	  int64  `db:"id" json:"id"`
 	Password	string `db:"password" json:"-"`
+	SessionVersion int64 `db:"session_version" json:"-"`
 }
 
 type Wallet struct {
@@ -54,10 +104,547 @@ type Message struct {
 	Message string `json:"message"`
 }
 
+// FieldErrorResponse describes one invalid field of a request body.
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationErrorResponse lists every invalid field of a request body.
+type ValidationErrorResponse struct {
+	Message string               `json:"message"`
+	Errors  []FieldErrorResponse `json:"errors"`
+}
+
 type Credit struct {
 	Amount float64 `json:"amount"`
 }
 
 type Debit struct {
 	Amount float64 `json:"amount"`
-}
\ No newline at end of file
+}
+
+const (
+	TransactionCredit = "credit"
+	TransactionDebit  = "debit"
+)
+
+// Transaction is a single recorded movement on a wallet. Amount is signed:
+// credits are positive and debits negative, so the wallet balance is the sum
+// of all amounts.
+type Transaction struct {
+	ID           int64     `db:"id" json:"id"`
+	WalletID     int64     `db:"wallet_id" json:"wallet_id"`
+	UserID       int64     `db:"user_id" json:"-"`
+	Type         string    `db:"type" json:"type"`
+	Amount       float64   `db:"amount" json:"amount"`
+	BalanceAfter float64   `db:"balance_after" json:"balance_after"`
+	Description  string    `db:"description" json:"description"`
+	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+}
+
+// ActivityPage is one page of a user's transactions, newest first.
+// NextCursor is empty on the last page.
+type ActivityPage struct {
+	Transactions []Transaction
+	NextCursor   string
+}
+
+type StatementEntry struct {
+	TransactionID int64     `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Type          string    `json:"type"`
+	Description   string    `json:"description"`
+	Credit        float64   `json:"credit"`
+	Debit         float64   `json:"debit"`
+	Balance       float64   `json:"balance"`
+}
+
+type Statement struct {
+	WalletID       int64            `json:"wallet_id"`
+	PeriodStart    time.Time        `json:"period_start"`
+	PeriodEnd      time.Time        `json:"period_end"`
+	OpeningBalance float64          `json:"opening_balance"`
+	TotalCredits   float64          `json:"total_credits"`
+	TotalDebits    float64          `json:"total_debits"`
+	ClosingBalance float64          `json:"closing_balance"`
+	Entries        []StatementEntry `json:"entries"`
+}
+
+const (
+	WalletStatusActive = "active"
+	WalletStatusFrozen = "frozen"
+	WalletStatusClosed = "closed"
+)
+
+// WalletLedgerBalance pairs the balance stored on a wallet with the balance
+// implied by the sum of its ledger entries. UserID is 0 for the wallet of a
+// merchant.
+type WalletLedgerBalance struct {
+	WalletID        int64   `db:"wallet_id" json:"wallet_id"`
+	UserID          int64   `db:"user_id" json:"user_id"`
+	Status          string  `db:"status" json:"status"`
+	RecordedBalance float64 `db:"recorded_balance" json:"recorded_balance"`
+	LedgerBalance   float64 `db:"ledger_balance" json:"ledger_balance"`
+}
+
+type ReconciliationMismatch struct {
+	WalletID        int64   `json:"wallet_id"`
+	UserID          int64   `json:"user_id"`
+	RecordedBalance float64 `json:"recorded_balance"`
+	LedgerBalance   float64 `json:"ledger_balance"`
+	Drift           float64 `json:"drift"`
+	Frozen          bool    `json:"frozen"`
+}
+
+type ReconciliationReport struct {
+	StartedAt      time.Time                `json:"started_at"`
+	FinishedAt     time.Time                `json:"finished_at"`
+	WalletsChecked int                      `json:"wallets_checked"`
+	Mismatches     []ReconciliationMismatch `json:"mismatches"`
+}
+
+// WalletAdjustment is a credit or debit made by an operator on a user's
+// behalf. Reason ends up on the ledger entry.
+type WalletAdjustment struct {
+	UserID   int64
+	Type     string
+	Amount   float64
+	Reason   string
+	Operator string
+}
+
+// WalletStatusChange is an operator freezing or unfreezing a user's wallet.
+type WalletStatusChange struct {
+	UserID   int64
+	Status   string
+	Reason   string
+	Operator string
+}
+
+// UserExport is everything held about a user, for support and data requests.
+type UserExport struct {
+	ExportedAt   time.Time     `json:"exported_at"`
+	Profile      UserProfile   `json:"profile"`
+	Wallet       *Wallet       `json:"wallet"`
+	Transactions []Transaction `json:"transactions"`
+}
+
+const (
+	PayoutModeAllOrNothing = "all_or_nothing"
+	PayoutModeBestEffort   = "best_effort"
+)
+
+// Statuses of payout batches and their rows. A batch is pending, processing,
+// completed or failed; a row is pending, paid, failed, or cancelled when an
+// all-or-nothing batch failed on another row.
+const (
+	PayoutStatusPending    = "pending"
+	PayoutStatusProcessing = "processing"
+	PayoutStatusCompleted  = "completed"
+	PayoutStatusFailed     = "failed"
+	PayoutStatusPaid       = "paid"
+	PayoutStatusCancelled  = "cancelled"
+)
+
+// PayoutInstruction is one row of an uploaded payout file. Recipient is the
+// email or phone number of the user to credit.
+type PayoutInstruction struct {
+	Recipient string  `json:"recipient"`
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+}
+
+// PayoutRequest asks to pay every instruction, numbered from line 1.
+type PayoutRequest struct {
+	Mode         string
+	CreatedBy    string
+	Instructions []PayoutInstruction
+}
+
+// PayoutRecipient is a user a payout can be addressed to, with their wallet.
+// WalletID is zero for users without a wallet.
+type PayoutRecipient struct {
+	UserID       int64
+	Email        string
+	PhoneNumber  string
+	WalletID     int64
+	WalletStatus string
+}
+
+// PayoutRow is a stored payout instruction and its outcome. Error says why a
+// failed row was not paid.
+type PayoutRow struct {
+	Line      int     `json:"line"`
+	Recipient string  `json:"recipient"`
+	UserID    int64   `json:"user_id"`
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+	Status    string  `json:"status"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// PayoutBatch is a payout batch and how far it got.
+type PayoutBatch struct {
+	ID            int64      `json:"id"`
+	Mode          string     `json:"mode"`
+	Status        string     `json:"status"`
+	CreatedBy     string     `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	FinishedAt    *time.Time `json:"finished_at"`
+	TotalRows     int        `json:"total_rows"`
+	TotalAmount   float64    `json:"total_amount"`
+	PaidRows      int        `json:"paid_rows"`
+	PaidAmount    float64    `json:"paid_amount"`
+	FailedRows    int        `json:"failed_rows"`
+	CancelledRows int        `json:"cancelled_rows"`
+	PendingRows   int        `json:"pending_rows"`
+}
+
+// Statuses of a top-up, following its payment at the gateway.
+const (
+	TopUpStatusPending   = "pending"
+	TopUpStatusSucceeded = "succeeded"
+	TopUpStatusFailed    = "failed"
+)
+
+// TopUp funds a wallet through a payment gateway. The wallet is credited
+// when the top-up succeeds, once the gateway confirms the payment.
+type TopUp struct {
+	ID          int64      `json:"id"`
+	UserID      int64      `json:"-"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"`
+	Gateway     string     `json:"-"`
+	PaymentID   string     `json:"-"`
+	CheckoutURL string     `json:"checkout_url,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// Operations fees can be charged on. A transfer is a payment to a merchant.
+const (
+	FeeOperationDebit      = "debit"
+	FeeOperationWithdrawal = "withdrawal"
+	FeeOperationTransfer   = "transfer"
+)
+
+// Kinds of FeeComponent. Minimum and maximum lines bring the fee up or down
+// to the caps of its rule.
+const (
+	FeeFlat       = "flat"
+	FeePercentage = "percentage"
+	FeeMinimum    = "minimum"
+	FeeMaximum    = "maximum"
+)
+
+// QuoteRequest asks what moving Amount through Operation would cost.
+type QuoteRequest struct {
+	Operation string  `json:"operation"`
+	Amount    float64 `json:"amount"`
+}
+
+// FeeComponent is one line of a fee breakdown. Rate is the percentage of
+// percentage lines.
+type FeeComponent struct {
+	Kind   string  `json:"kind"`
+	Rate   float64 `json:"rate,omitempty"`
+	Amount float64 `json:"amount"`
+}
+
+// FeeQuote is what moving Amount costs. Total, the amount plus Fee, leaves
+// the wallet, and Fee goes to the house revenue wallet.
+type FeeQuote struct {
+	Operation string         `json:"operation"`
+	Amount    float64        `json:"amount"`
+	Fee       float64        `json:"fee"`
+	Total     float64        `json:"total"`
+	Breakdown []FeeComponent `json:"breakdown"`
+}
+
+// FeeCharge is a fee collected with a money movement and credited to the
+// wallet of the user AccountID.
+type FeeCharge struct {
+	Amount    float64
+	AccountID int64
+}
+
+// DebitResult reports a debit and the fee charged with it.
+type DebitResult struct {
+	Message string   `json:"message"`
+	Quote   FeeQuote `json:"quote"`
+}
+
+// BankAccount is a bank account a user withdraws to, identified either by an
+// IFSC and account number or by an IBAN.
+type BankAccount struct {
+	ID            int64     `json:"id"`
+	UserID        int64     `json:"-"`
+	HolderName    string    `json:"holder_name"`
+	AccountNumber string    `json:"account_number,omitempty"`
+	IFSC          string    `json:"ifsc,omitempty"`
+	IBAN          string    `json:"iban,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Statuses of a withdrawal, following its transfer at the payout provider.
+const (
+	WithdrawalStatusPending = "pending"
+	WithdrawalStatusSettled = "settled"
+	WithdrawalStatusFailed  = "failed"
+)
+
+// WithdrawalRequest asks to move Amount from the wallet to a linked bank
+// account.
+type WithdrawalRequest struct {
+	BankAccountID int64   `json:"bank_account_id"`
+	Amount        float64 `json:"amount"`
+}
+
+// Withdrawal moves money from a wallet to a bank account. The amount and Fee
+// are debited when the withdrawal is requested and the amount held until the
+// payout provider settles the transfer; a failed transfer returns both to
+// the wallet.
+type Withdrawal struct {
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"-"`
+	BankAccountID int64      `json:"bank_account_id"`
+	Amount        float64    `json:"amount"`
+	Fee           float64    `json:"fee"`
+	Status        string     `json:"status"`
+	Provider      string     `json:"-"`
+	TransferID    string     `json:"-"`
+	FailureReason string     `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	// FeeBreakdown is only filled in on the response to a new withdrawal.
+	FeeBreakdown []FeeComponent `json:"fee_breakdown,omitempty"`
+}
+
+// Operations a cashback campaign can reward. A transfer is a payment to a
+// merchant.
+const (
+	CampaignOperationDebit    = "debit"
+	CampaignOperationTransfer = "transfer"
+)
+
+// Campaign pays back Percent of every eligible operation of at least
+// MinAmount made between StartsAt and EndsAt, capped at MaxReward per
+// operation, UserLimit per user and Budget in total when they are set.
+// Rewards go to the promo balance and expire RewardValidityDays after they
+// are granted.
+type Campaign struct {
+	ID                 int64     `json:"id"`
+	Name               string    `json:"name"`
+	Operation          string    `json:"operation"`
+	Percent            float64   `json:"percent"`
+	MinAmount          float64   `json:"min_amount"`
+	MaxReward          float64   `json:"max_reward"`
+	UserLimit          float64   `json:"user_limit"`
+	Budget             float64   `json:"budget"`
+	Spent              float64   `json:"spent"`
+	RewardValidityDays int       `json:"reward_validity_days"`
+	StartsAt           time.Time `json:"starts_at"`
+	EndsAt             time.Time `json:"ends_at"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// PromoReward is cashback granted to a user by a campaign. It counts towards
+// the promo balance until ExpiresAt and can never be withdrawn.
+type PromoReward struct {
+	ID           int64     `json:"id"`
+	UserID       int64     `json:"-"`
+	CampaignID   int64     `json:"campaign_id"`
+	CampaignName string    `json:"campaign_name"`
+	SourceAmount float64   `json:"source_amount"`
+	Amount       float64   `json:"amount"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PromoBalance is the sum of a user's unexpired rewards, kept apart from
+// the wallet balance.
+type PromoBalance struct {
+	Balance float64       `json:"balance"`
+	Rewards []PromoReward `json:"rewards"`
+}
+
+// Merchant lets a user accept wallet payments by QR code. Payments settle at
+// once into the merchant wallet, which is kept apart from the user's own.
+type Merchant struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Kinds of payment QR code. A static code only names the merchant and can be
+// paid any amount any number of times; a dynamic one charges its Amount and
+// is paid once before it expires.
+const (
+	PaymentQRStatic  = "static"
+	PaymentQRDynamic = "dynamic"
+)
+
+// Statuses of a dynamic payment QR code. Expired is never stored: pending
+// codes past their expiry read as expired.
+const (
+	PaymentQRStatusPending = "pending"
+	PaymentQRStatusPaid    = "paid"
+	PaymentQRStatusExpired = "expired"
+)
+
+// PaymentQRRequest asks for a dynamic QR code charging Amount. It expires
+// ExpiresIn seconds after it is created, or after the default expiry when
+// ExpiresIn is zero.
+type PaymentQRRequest struct {
+	Amount    float64 `json:"amount"`
+	Reference string  `json:"reference"`
+	ExpiresIn int     `json:"expires_in"`
+}
+
+// PaymentQR is a merchant's payment QR code. Payload is the string encoded in
+// the code, which the payer's app scans and sends to /pay/qr. Only dynamic
+// codes have an ID, an amount and an expiry.
+type PaymentQR struct {
+	ID         int64      `json:"id,omitempty"`
+	MerchantID int64      `json:"merchant_id"`
+	Kind       string     `json:"kind"`
+	Amount     float64    `json:"amount,omitempty"`
+	Reference  string     `json:"reference,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Payload    string     `json:"payload"`
+}
+
+// QRPaymentRequest pays the QR code Payload was scanned from. Amount is only
+// needed for static codes; dynamic ones charge their own.
+type QRPaymentRequest struct {
+	Payload string  `json:"payload"`
+	Amount  float64 `json:"amount"`
+}
+
+// MerchantPayment is a payment from a wallet to a merchant through one of its
+// QR codes. QRID is zero for payments through the static code.
+type MerchantPayment struct {
+	ID           int64     `json:"id"`
+	MerchantID   int64     `json:"merchant_id"`
+	MerchantName string    `json:"merchant_name"`
+	PayerID      int64     `json:"-"`
+	QRID         int64     `json:"qr_id,omitempty"`
+	Amount       float64   `json:"amount"`
+	Reference    string    `json:"reference,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Fee and FeeBreakdown are only filled in on the response to a payment.
+	Fee          float64        `json:"fee,omitempty"`
+	FeeBreakdown []FeeComponent `json:"fee_breakdown,omitempty"`
+}
+
+// SettlementDay totals the payments a merchant received on Date, formatted
+// as YYYY-MM-DD.
+type SettlementDay struct {
+	Date     string  `json:"date"`
+	Payments int     `json:"payments"`
+	Amount   float64 `json:"amount"`
+}
+
+// SettlementReport totals the payments a merchant received from From up to,
+// but excluding, To. Each payment was settled into the merchant wallet as it
+// was made.
+type SettlementReport struct {
+	MerchantID int64           `json:"merchant_id"`
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Payments   int             `json:"payments"`
+	Amount     float64         `json:"amount"`
+	Days       []SettlementDay `json:"days"`
+}
+
+// LoginAttempt tracks recent failed logins for one account or client IP.
+type LoginAttempt struct {
+	Key           string    `db:"key" json:"key"`
+	Failures      int       `db:"failures" json:"failures"`
+	LastFailureAt time.Time `db:"last_failure_at" json:"last_failure_at"`
+	LockedUntil   time.Time `db:"locked_until" json:"locked_until"`
+}
+
+// UnlockLoginRequest names the account, client IP or both to unlock.
+type UnlockLoginRequest struct {
+	Email string `json:"email"`
+	IP    string `json:"ip"`
+}
+
+// UserMFA is a user's TOTP enrollment. Secret is set as soon as enrollment
+// starts, Enabled only once a code generated from it has been confirmed.
+type UserMFA struct {
+	UserID       int64  `db:"user_id" json:"-"`
+	Secret       string `db:"secret" json:"-"`
+	Enabled      bool   `db:"enabled" json:"enabled"`
+	LastUsedStep int64  `db:"last_used_step" json:"-"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFALoginRequest completes a login that returned an MFA challenge, with
+// either a TOTP code or one of the user's recovery codes.
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+	ClientIP     string `json:"-"`
+}
+
+const (
+	VerificationEmail = "email"
+	VerificationPhone = "phone"
+	// VerificationEmailChange codes are sent to a user's pending email
+	VerificationEmailChange = "email_change"
+)
+
+// VerificationCode is a pending one-time code proving ownership of a user's
+// email address or phone number.
+type VerificationCode struct {
+	UserID    int64     `db:"user_id"`
+	Channel   string    `db:"channel"`
+	CodeHash  string    `db:"code_hash"`
+	Attempts  int       `db:"attempts"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type VerificationRequest struct {
+	Code string `json:"code"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// PasswordResetToken is an outstanding single-use password reset. Only the
+// hash of the token sent to the user is kept.
+type PasswordResetToken struct {
+	TokenHash string    `db:"token_hash"`
+	UserID    int64     `db:"user_id"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
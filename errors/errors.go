@@ -19,4 +19,135 @@ var (
 	ErrInsufficientBalance = errors.New("insufficient balance")
 	ErrFetchingBalance = errors.New("error fetching balance from wallet")
 	ErrDebitingWallet = errors.New("error debiting wallet")
+	ErrFetchingTransactions = errors.New("error fetching transactions")
+	ErrInvalidStatementPeriod = errors.New("invalid statement period, expected YYYY-MM")
+	ErrInvalidStatementFormat = errors.New("invalid statement format, expected csv, pdf or json")
+	ErrGeneratingStatement = errors.New("error generating statement")
+	ErrWalletFrozen = errors.New("wallet is frozen")
+	ErrReconcilingWallets = errors.New("error reconciling wallets")
+	ErrFreezingWallet = errors.New("error freezing wallet")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrLoginLocked = errors.New("too many failed login attempts, try again later")
+	ErrTrackingLogin = errors.New("error tracking login attempts")
+	ErrUnlockingLogin = errors.New("error unlocking login")
+	ErrInvalidUnlockRequest = errors.New("email or ip is required")
+	ErrUserNotFound = errors.New("user not found")
+	ErrFetchingUser = errors.New("error fetching user")
+	ErrFetchingMFA = errors.New("error fetching two-factor settings")
+	ErrUpdatingMFA = errors.New("error updating two-factor settings")
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled = errors.New("two-factor authentication is not set up")
+	ErrMFARequired = errors.New("two-factor code required")
+	ErrInvalidMFACode = errors.New("invalid two-factor code")
+	ErrInvalidMFAToken = errors.New("invalid or expired mfa token")
+	ErrStepUpRequired = errors.New("two-factor code required for this amount")
+	ErrStepUpLocked = errors.New("too many wrong two-factor codes, try again later")
+	ErrUnverifiedUser = errors.New("verify your email and phone number first")
+	ErrInvalidVerificationChannel = errors.New("invalid verification channel, expected email or phone")
+	ErrInvalidVerificationCode = errors.New("invalid or expired verification code")
+	ErrNoVerificationCode = errors.New("no pending verification code")
+	ErrTooManyVerificationAttempts = errors.New("too many wrong verification codes, try again once the last code expires")
+	ErrAlreadyVerified = errors.New("already verified")
+	ErrSavingVerificationCode = errors.New("error saving verification code")
+	ErrSendingVerificationCode = errors.New("error sending verification code")
+	ErrVerifying = errors.New("error verifying contact details")
+	ErrPasswordTooLong = errors.New("password must be at most 72 bytes")
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrRequestingPasswordReset = errors.New("error requesting password reset")
+	ErrUpdatingPassword = errors.New("error updating password")
+	ErrSessionExpired = errors.New("session expired, please log in again")
+	ErrUpdatingUser = errors.New("error updating user")
+	ErrDeletingUser = errors.New("error deleting user")
+	ErrEmptyProfileUpdate = errors.New("no profile fields to update")
+	ErrEmailTaken = errors.New("email is already registered")
+	ErrNoPendingEmail = errors.New("no email change is pending")
+	ErrNonZeroBalance = errors.New("wallet balance must be zero to delete the account")
+	ErrAmountNotPositive = errors.New("amount must be greater than zero")
+	ErrAmountNotFinite = errors.New("amount must be a finite number")
+	ErrAmountTooLarge = errors.New("amount exceeds the maximum allowed per transaction")
+	ErrAmountTooPrecise = errors.New("amount can have at most two decimal places")
+	ErrInvalidFieldType = errors.New("value has the wrong type")
+	ErrInvalidAccessToken = errors.New("invalid or expired access token")
+	ErrInvalidPageSize = errors.New("page size must be between 1 and 100")
+	ErrInvalidCursor = errors.New("invalid pagination cursor")
+	ErrReasonRequired = errors.New("a reason is required")
+	ErrWalletClosed = errors.New("wallet is closed")
+	ErrInvalidWalletStatus = errors.New("invalid wallet status, expected active or frozen")
+	ErrInvalidAdjustmentType = errors.New("invalid adjustment type, expected credit or debit")
+	ErrInvalidExportFormat = errors.New("invalid export format, expected csv or json")
+	ErrInvalidPayoutMode = errors.New("invalid payout mode, expected all_or_nothing or best_effort")
+	ErrEmptyPayout = errors.New("payout file has no rows")
+	ErrTooManyPayoutRows = errors.New("payout file has too many rows")
+	ErrInvalidPayoutFile = errors.New("invalid payout file, expected a recipient, amount and reference per row")
+	ErrInvalidRecipient = errors.New("recipient must be an email or phone number")
+	ErrUnknownRecipient = errors.New("no user with this email or phone number")
+	ErrReferenceRequired = errors.New("reference is required")
+	ErrReferenceTooLong = errors.New("reference must be at most 100 characters")
+	ErrDuplicateReference = errors.New("reference is used by another row")
+	ErrPayoutBatchNotFound = errors.New("payout batch not found")
+	ErrCreatingPayoutBatch = errors.New("error creating payout batch")
+	ErrFetchingPayoutBatch = errors.New("error fetching payout batch")
+	ErrPayingOut = errors.New("error paying out")
+	ErrNoPaymentGateway = errors.New("no payment gateway is configured")
+	ErrInvalidGatewaySignature = errors.New("invalid gateway callback signature")
+	ErrInvalidGatewayCallback = errors.New("invalid gateway callback")
+	ErrPaymentNotFound = errors.New("payment not found at the gateway")
+	ErrTopUpNotFound = errors.New("top-up not found")
+	ErrTopUpMismatch = errors.New("gateway payment does not match the top-up")
+	ErrCreatingTopUp = errors.New("error creating top-up")
+	ErrFetchingTopUp = errors.New("error fetching top-up")
+	ErrCompletingTopUp = errors.New("error completing top-up")
+	ErrNoPayoutProvider = errors.New("no payout provider is configured")
+	ErrTransferNotFound = errors.New("transfer not found at the payout provider")
+	ErrTransferRejected = errors.New("payout provider rejected the transfer")
+	ErrHolderNameRequired = errors.New("account holder name is required")
+	ErrInvalidIFSC = errors.New("invalid IFSC, expected four letters, a zero and six letters or digits")
+	ErrInvalidAccountNumber = errors.New("account number must have 9 to 18 digits")
+	ErrInvalidIBAN = errors.New("invalid IBAN")
+	ErrBankAccountRequired = errors.New("either an IBAN or an IFSC and account number is required")
+	ErrBankAccountExists = errors.New("bank account is already linked")
+	ErrBankAccountNotFound = errors.New("bank account not found")
+	ErrLinkingBankAccount = errors.New("error linking bank account")
+	ErrFetchingBankAccounts = errors.New("error fetching bank accounts")
+	ErrWithdrawalNotFound = errors.New("withdrawal not found")
+	ErrWithdrawalMismatch = errors.New("provider transfer does not match the withdrawal")
+	ErrTransferRefused = errors.New("payout provider refused the withdrawal, the funds were returned to the wallet")
+	ErrCreatingWithdrawal = errors.New("error creating withdrawal")
+	ErrFetchingWithdrawal = errors.New("error fetching withdrawal")
+	ErrCompletingWithdrawal = errors.New("error completing withdrawal")
+	ErrInvalidFeeOperation = errors.New("invalid operation, expected debit, withdrawal or transfer")
+	ErrNoFeeWallet = errors.New("the fee revenue wallet does not exist")
+	ErrCampaignNameRequired = errors.New("campaign name is required")
+	ErrInvalidCampaignOperation = errors.New("invalid operation, expected debit or transfer")
+	ErrInvalidCashbackPercent = errors.New("cashback percent must be above 0 and at most 100")
+	ErrInvalidCampaignLimit = errors.New("campaign limits can not be negative")
+	ErrInvalidCampaignBudget = errors.New("campaign budget must be positive")
+	ErrInvalidRewardValidity = errors.New("reward validity must be at least one day")
+	ErrInvalidCampaignPeriod = errors.New("campaign must end after it starts")
+	ErrCreatingCampaign = errors.New("error creating campaign")
+	ErrFetchingCampaign = errors.New("error fetching campaign")
+	ErrCampaignNotFound = errors.New("campaign not found")
+	ErrGrantingReward = errors.New("error granting cashback reward")
+	ErrFetchingPromoBalance = errors.New("error fetching promo balance")
+	ErrMerchantNameRequired = errors.New("merchant name is required")
+	ErrMerchantNameTooLong = errors.New("merchant name must be at most 100 characters")
+	ErrMerchantExists = errors.New("user already has a merchant account")
+	ErrMerchantNotFound = errors.New("merchant not found")
+	ErrCreatingMerchant = errors.New("error creating merchant")
+	ErrFetchingMerchant = errors.New("error fetching merchant")
+	ErrInvalidQRExpiry = errors.New("QR code expiry must be between 60 and 86400 seconds")
+	ErrInvalidQRFormat = errors.New("invalid QR code format, expected json, png or svg")
+	ErrInvalidQRPayload = errors.New("invalid payment QR code")
+	ErrPaymentQRNotFound = errors.New("payment QR code not found")
+	ErrQRExpired = errors.New("payment QR code has expired")
+	ErrQRAlreadyPaid = errors.New("payment QR code has already been paid")
+	ErrQRAmountMismatch = errors.New("amount does not match the payment QR code")
+	ErrCreatingPaymentQR = errors.New("error creating payment QR code")
+	ErrFetchingPaymentQR = errors.New("error fetching payment QR code")
+	ErrSelfPayment = errors.New("merchants can not pay themselves")
+	ErrNoMerchantWallet = errors.New("the merchant has no wallet")
+	ErrPayingMerchant = errors.New("error paying merchant")
+	ErrInvalidSettlementPeriod = errors.New("invalid settlement period, expected from and to dates as YYYY-MM-DD at most a year apart")
+	ErrFetchingSettlements = errors.New("error fetching settlement report")
 )
\ No newline at end of file
@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"strings"
+)
+
+// Codes tell clients why a field is invalid without parsing messages.
+const (
+	CodeRequired    = "required"
+	CodeInvalid     = "invalid"
+	CodeInvalidType = "invalid_type"
+	CodeTooLong     = "too_long"
+	CodeNotPositive = "not_positive"
+	CodeNotFinite   = "not_finite"
+	CodeTooLarge    = "too_large"
+	CodeTooPrecise  = "too_precise"
+	CodeNotFound    = "not_found"
+	CodeDuplicate   = "duplicate"
+)
+
+// FieldError ties a validation error to the request field that caused it.
+type FieldError struct {
+	Field string
+	Code  string
+	Err   error
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationErrors holds every invalid field of a request, so clients can
+// fix them all at once.
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, fieldErr := range v {
+		messages[i] = fieldErr.Err.Error()
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Unwrap lets errors.Is match the error of any field.
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(v))
+	for i, fieldErr := range v {
+		errs[i] = fieldErr
+	}
+	return errs
+}
+
+// OrNil returns nil when there are no errors, so a ValidationErrors can be
+// returned as an error without wrapping an empty list.
+func (v ValidationErrors) OrNil() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
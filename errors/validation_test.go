@@ -0,0 +1,20 @@
+package errors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationErrors(t *testing.T) {
+	var errs ValidationErrors
+	require.NoError(t, errs.OrNil())
+
+	errs = append(errs, FieldError{Field: "email", Code: CodeInvalid, Err: ErrInvalidEmail}, FieldError{Field: "name", Code: CodeRequired, Err: ErrInvalidName})
+	err := errs.OrNil()
+	require.Error(t, err)
+	require.Equal(t, "invalid email, invalid name", err.Error())
+	require.True(t, errors.Is(err, ErrInvalidName))
+	require.False(t, errors.Is(err, ErrInvalidPassword))
+}
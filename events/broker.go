@@ -0,0 +1,101 @@
+// Package events streams committed wallet transactions to the connections of
+// their owners. A Broker fans transactions out within one process, and
+// ListenPostgres feeds it every transaction committed by any replica.
+package events
+
+import (
+	"nickPay/wallet/internal/domain"
+	"sync"
+)
+
+// subscriptionBuffer is how many transactions a subscriber may fall behind
+// before it is dropped.
+const subscriptionBuffer = 64
+
+// Broker delivers published transactions to the subscriptions of their user.
+// Subscribers that fall behind are dropped rather than slowing publishers
+// down: their channel is closed and they are expected to subscribe again and
+// catch up from the last transaction they saw.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[int64]map[chan domain.Transaction]struct{}
+	closed bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: map[int64]map[chan domain.Transaction]struct{}{}}
+}
+
+// Subscribe returns a channel receiving the user's transactions as they are
+// published, and a function to stop receiving them. The channel is closed
+// when the subscription is dropped.
+func (b *Broker) Subscribe(userID int64) (<-chan domain.Transaction, func()) {
+	ch := make(chan domain.Transaction, subscriptionBuffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = map[chan domain.Transaction]struct{}{}
+	}
+	b.subs[userID][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.drop(userID, ch)
+	}
+}
+
+// Publish hands txn to every subscription of its user without blocking.
+func (b *Broker) Publish(txn domain.Transaction) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[txn.UserID] {
+		select {
+		case ch <- txn:
+		default:
+			b.drop(txn.UserID, ch)
+		}
+	}
+}
+
+// Reset drops every subscription, for when transactions may have been missed
+// and subscribers need to catch up.
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dropAll()
+}
+
+// Close drops every subscription and refuses new ones, so open streams end
+// and the server can shut down.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.dropAll()
+}
+
+// dropAll drops every subscription. b.mu must be held.
+func (b *Broker) dropAll() {
+	for userID, subs := range b.subs {
+		for ch := range subs {
+			b.drop(userID, ch)
+		}
+	}
+}
+
+// drop removes and closes a subscription. b.mu must be held.
+func (b *Broker) drop(userID int64, ch chan domain.Transaction) {
+	if _, ok := b.subs[userID][ch]; !ok {
+		return
+	}
+	delete(b.subs[userID], ch)
+	if len(b.subs[userID]) == 0 {
+		delete(b.subs, userID)
+	}
+	close(ch)
+}
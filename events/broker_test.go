@@ -0,0 +1,88 @@
+package events
+
+import (
+	"nickPay/wallet/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	broker := NewBroker()
+	first, cancelFirst := broker.Subscribe(1)
+	second, cancelSecond := broker.Subscribe(1)
+	other, cancelOther := broker.Subscribe(2)
+	defer cancelOther()
+
+	broker.Publish(domain.Transaction{ID: 7, UserID: 1})
+	assert.Equal(t, int64(7), (<-first).ID)
+	assert.Equal(t, int64(7), (<-second).ID)
+	assert.Empty(t, other)
+
+	cancelFirst()
+	_, open := <-first
+	assert.False(t, open, "cancelled subscriptions are closed")
+	cancelFirst()
+
+	broker.Publish(domain.Transaction{ID: 8, UserID: 1})
+	assert.Equal(t, int64(8), (<-second).ID)
+	cancelSecond()
+	assert.Empty(t, broker.subs[1])
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker()
+	slow, cancel := broker.Subscribe(1)
+	defer cancel()
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		broker.Publish(domain.Transaction{ID: int64(i + 1), UserID: 1})
+	}
+	received := 0
+	for range slow {
+		received++
+	}
+	assert.Equal(t, subscriptionBuffer, received)
+}
+
+func TestBrokerResetAndClose(t *testing.T) {
+	broker := NewBroker()
+	events, cancel := broker.Subscribe(1)
+	defer cancel()
+	broker.Reset()
+	_, open := <-events
+	assert.False(t, open)
+
+	events, _ = broker.Subscribe(1)
+	broker.Close()
+	_, open = <-events
+	assert.False(t, open)
+
+	events, _ = broker.Subscribe(1)
+	select {
+	case _, open = <-events:
+		assert.False(t, open, "subscriptions after Close are closed right away")
+	case <-time.After(time.Second):
+		t.Fatal("subscription after Close was left open")
+	}
+}
+
+func TestDecodeNotification(t *testing.T) {
+	txn, err := decodeNotification(`{"id":9,"wallet_id":3,"user_id":42,"type":"debit","amount":-20.5,"balance_after":79.5,"description":"Wallet debit","created_at":"2024-05-01T10:00:00.123456Z"}`)
+	require.NoError(t, err)
+	assert.Equal(t, domain.Transaction{
+		ID:           9,
+		WalletID:     3,
+		UserID:       42,
+		Type:         domain.TransactionDebit,
+		Amount:       -20.5,
+		BalanceAfter: 79.5,
+		Description:  "Wallet debit",
+		CreatedAt:    time.Date(2024, time.May, 1, 10, 0, 0, 123456000, time.UTC),
+	}, txn)
+
+	_, err = decodeNotification(`{"id":"nine"}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"nickPay/wallet/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+	logger "github.com/sirupsen/logrus"
+)
+
+// Channel is the Postgres notification channel a trigger on
+// wallet_transaction announces every committed entry on.
+const Channel = "wallet_events"
+
+// pingInterval is how often an idle listener checks its connection.
+const pingInterval = 90 * time.Second
+
+// notification is the payload of the trigger, in the JSON form of
+// domain.Transaction plus the user it belongs to.
+type notification struct {
+	domain.Transaction
+	UserID int64 `json:"user_id"`
+}
+
+// ListenPostgres publishes every transaction announced on Channel to broker
+// until ctx is done, so that streams on all replicas see transactions
+// committed by any of them. The listener reconnects on its own; as
+// notifications sent while it was away are lost, it then resets the broker
+// so subscribers catch up from the database.
+func ListenPostgres(ctx context.Context, uri string, broker *Broker) error {
+	listener := pq.NewListener(uri, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.WithField("err", err.Error()).Warn("Wallet event listener lost its connection")
+		}
+	})
+	defer listener.Close()
+	err := listener.Listen(Channel)
+	if err != nil {
+		return err
+	}
+	logger.WithField("channel", Channel).Info("Listening for wallet events")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			if n == nil {
+				broker.Reset()
+				continue
+			}
+			txn, err := decodeNotification(n.Extra)
+			if err != nil {
+				logger.WithField("err", err.Error()).Error("Cannot decode wallet event")
+				continue
+			}
+			broker.Publish(txn)
+		case <-time.After(pingInterval):
+			go listener.Ping()
+		}
+	}
+}
+
+func decodeNotification(payload string) (domain.Transaction, error) {
+	var n notification
+	err := json.Unmarshal([]byte(payload), &n)
+	if err != nil {
+		return domain.Transaction{}, err
+	}
+	n.Transaction.UserID = n.UserID
+	return n.Transaction, nil
+}
@@ -0,0 +1,98 @@
+package gateway
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	errors "nickPay/wallet/internal/errors"
+	"sync"
+)
+
+// FakeGateway keeps payments in memory, for local development and tests.
+// Nothing is charged: a payment stays pending until Complete settles it and
+// returns the signed callback the gateway would send. Callbacks can also be
+// sent by hand, signed as described at Sign.
+type FakeGateway struct {
+	secret   []byte
+	mu       sync.Mutex
+	payments map[string]Payment
+}
+
+func NewFakeGateway(secret string) *FakeGateway {
+	return &FakeGateway{secret: []byte(secret), payments: map[string]Payment{}}
+}
+
+func (f *FakeGateway) Name() string {
+	return "fake"
+}
+
+func (f *FakeGateway) CreateIntent(ctx context.Context, request IntentRequest) (Intent, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return Intent{}, err
+	}
+	payment := Payment{ID: "fake_" + hex.EncodeToString(id), Reference: request.Reference, Amount: request.Amount, Status: StatusPending}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.payments[payment.ID] = payment
+	return Intent{PaymentID: payment.ID, CheckoutURL: "https://checkout.fake.invalid/pay/" + payment.ID}, nil
+}
+
+func (f *FakeGateway) VerifyCallback(payload []byte, signature string) (Payment, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, f.mac(payload)) {
+		return Payment{}, errors.ErrInvalidGatewaySignature
+	}
+	var payment Payment
+	if err := json.Unmarshal(payload, &payment); err != nil {
+		return Payment{}, errors.ErrInvalidGatewayCallback
+	}
+	return payment, nil
+}
+
+func (f *FakeGateway) QueryStatus(ctx context.Context, paymentID string) (Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	payment, ok := f.payments[paymentID]
+	if !ok {
+		return Payment{}, errors.ErrPaymentNotFound
+	}
+	return payment, nil
+}
+
+// Complete settles a pending payment as succeeded or failed and returns the
+// callback body and signature reporting it.
+func (f *FakeGateway) Complete(paymentID string, status string) (payload []byte, signature string, err error) {
+	f.mu.Lock()
+	payment, ok := f.payments[paymentID]
+	if ok && payment.Status == StatusPending {
+		payment.Status = status
+		f.payments[paymentID] = payment
+	}
+	f.mu.Unlock()
+	if !ok {
+		return nil, "", errors.ErrPaymentNotFound
+	}
+
+	payload, err = json.Marshal(payment)
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, f.Sign(payload), nil
+}
+
+// Sign returns the signature of a callback body: the hex encoded
+// HMAC-SHA256 of the body keyed with the secret.
+func (f *FakeGateway) Sign(payload []byte) string {
+	return hex.EncodeToString(f.mac(payload))
+}
+
+func (f *FakeGateway) mac(payload []byte) []byte {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
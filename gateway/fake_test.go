@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"context"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeGateway(t *testing.T) {
+	g, err := New("fake", "secret")
+	require.NoError(t, err)
+	fake := g.(*FakeGateway)
+
+	intent, err := fake.CreateIntent(context.Background(), IntentRequest{Reference: "7", Amount: 50})
+	require.NoError(t, err)
+	require.Contains(t, intent.CheckoutURL, intent.PaymentID)
+	payment, err := fake.QueryStatus(context.Background(), intent.PaymentID)
+	require.NoError(t, err)
+	require.Equal(t, Payment{ID: intent.PaymentID, Reference: "7", Amount: 50, Status: StatusPending}, payment)
+
+	payload, signature, err := fake.Complete(intent.PaymentID, StatusSucceeded)
+	require.NoError(t, err)
+	payment, err = fake.VerifyCallback(payload, signature)
+	require.NoError(t, err)
+	require.Equal(t, StatusSucceeded, payment.Status)
+
+	// Settled payments stay settled
+	_, _, err = fake.Complete(intent.PaymentID, StatusFailed)
+	require.NoError(t, err)
+	payment, err = fake.QueryStatus(context.Background(), intent.PaymentID)
+	require.NoError(t, err)
+	require.Equal(t, StatusSucceeded, payment.Status)
+
+	_, err = fake.VerifyCallback(append(payload, ' '), signature)
+	require.Equal(t, errors.ErrInvalidGatewaySignature, err)
+	_, err = fake.VerifyCallback(payload, "not hex")
+	require.Equal(t, errors.ErrInvalidGatewaySignature, err)
+	_, err = NewFakeGateway("other").VerifyCallback(payload, signature)
+	require.Equal(t, errors.ErrInvalidGatewaySignature, err)
+	_, err = fake.QueryStatus(context.Background(), "fake_unknown")
+	require.Equal(t, errors.ErrPaymentNotFound, err)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New("fake", "")
+	require.Error(t, err)
+	_, err = New("stripe", "secret")
+	require.Error(t, err)
+	_, err = New("", "secret")
+	require.Error(t, err)
+}
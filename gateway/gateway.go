@@ -0,0 +1,64 @@
+// Package gateway collects wallet top-ups through payment gateways. Each
+// gateway is an adapter behind PaymentGateway; the wallet is only credited
+// once the gateway confirms a payment through a signed callback or a status
+// query.
+package gateway
+
+import (
+	"context"
+	"fmt"
+)
+
+// Statuses of a payment at the gateway. Succeeded and failed are final.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// IntentRequest asks the gateway to collect Amount. Reference identifies the
+// top-up on our side and is echoed back in every Payment.
+type IntentRequest struct {
+	Reference string
+	Amount    float64
+}
+
+// Intent is a payment opened at the gateway, which the user completes at
+// CheckoutURL.
+type Intent struct {
+	PaymentID   string
+	CheckoutURL string
+}
+
+// Payment is what the gateway reports about a payment.
+type Payment struct {
+	ID        string  `json:"id"`
+	Reference string  `json:"reference"`
+	Amount    float64 `json:"amount"`
+	Status    string  `json:"status"`
+}
+
+// PaymentGateway is implemented by every gateway adapter.
+type PaymentGateway interface {
+	// Name identifies the gateway on stored top-ups.
+	Name() string
+	CreateIntent(context.Context, IntentRequest) (Intent, error)
+	// VerifyCallback checks the signature of a callback body as received and
+	// returns the payment it reports. It fails with
+	// errors.ErrInvalidGatewaySignature when the signature does not match.
+	VerifyCallback(payload []byte, signature string) (Payment, error)
+	QueryStatus(ctx context.Context, paymentID string) (Payment, error)
+}
+
+// New returns the gateway named by kind, signing and verifying callbacks
+// with secret. Only "fake" exists so far.
+func New(kind string, secret string) (PaymentGateway, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("gateway %q needs a callback secret", kind)
+	}
+	switch kind {
+	case "fake":
+		return NewFakeGateway(secret), nil
+	}
+	return nil, fmt.Errorf("unknown payment gateway %q", kind)
+}
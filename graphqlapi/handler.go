@@ -0,0 +1,141 @@
+// Package graphqlapi serves a read-only GraphQL API over the signed in
+// user's profile, wallet and transactions, so dashboards can fetch them in a
+// single round trip.
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/service"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// maxBodyBytes bounds the request body, and so the query, before parsing.
+const maxBodyBytes = 64 << 10
+
+var (
+	errInvalidRequest   = stderrors.New("request body must be JSON with a query")
+	errUnknownOperation = stderrors.New("operationName must name one of the operations in the query")
+	errQueriesOnly      = stderrors.New("only queries are supported")
+)
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Handler answers POSTed GraphQL queries. It has to run behind the
+// controller's auth middleware, which puts the signed in user's ID in the
+// request context under "id".
+//
+// Queries that fail to parse or validate, or exceed MaxDepth or
+// MaxComplexity, are rejected with 400 before any resolver runs. Otherwise
+// the response is 200, with resolver errors listed next to the data.
+func Handler(NikPay service.WalletService) http.HandlerFunc {
+	schema, err := newSchema(NikPay)
+	if err != nil {
+		panic("graphqlapi: invalid schema: " + err.Error())
+	}
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		err := json.NewDecoder(http.MaxBytesReader(rw, r.Body, maxBodyBytes)).Decode(&req)
+		if err != nil || req.Query == "" {
+			writeResult(rw, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(errInvalidRequest)})
+			return
+		}
+
+		doc, errs := prepare(&schema, req)
+		if len(errs) > 0 {
+			writeResult(rw, http.StatusBadRequest, &graphql.Result{Errors: errs})
+			return
+		}
+
+		userID := r.Context().Value("id").(int64)
+		ctx := context.WithValue(r.Context(), requestKey{}, &request{
+			userID:  userID,
+			wallets: newWalletLoader(NikPay, userID),
+		})
+		result := graphql.Execute(graphql.ExecuteParams{
+			Schema:        schema,
+			AST:           doc,
+			OperationName: req.OperationName,
+			Args:          req.Variables,
+			Context:       ctx,
+		})
+		if result.HasErrors() {
+			logging.FromContext(ctx).WithField("errors", result.Errors).Warn("GraphQL query returned errors")
+		}
+		writeResult(rw, http.StatusOK, result)
+	}
+}
+
+// prepare parses and validates the query and checks the operation to run
+// against the limits.
+func prepare(schema *graphql.Schema, req graphQLRequest) (*ast.Document, []gqlerrors.FormattedError) {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+	validation := graphql.ValidateDocument(schema, doc, nil)
+	if !validation.IsValid {
+		return nil, validation.Errors
+	}
+
+	operation := findOperation(doc, req.OperationName)
+	if operation == nil {
+		return nil, gqlerrors.FormatErrors(errUnknownOperation)
+	}
+	if operation.Operation != ast.OperationTypeQuery {
+		return nil, gqlerrors.FormatErrors(errQueriesOnly)
+	}
+	err = checkLimits(doc, operation, req.Variables)
+	if err != nil {
+		return nil, gqlerrors.FormatErrors(err)
+	}
+	return doc, nil
+}
+
+// findOperation returns the operation called name, or the only operation
+// of the document when name is empty.
+func findOperation(doc *ast.Document, name string) *ast.OperationDefinition {
+	var found *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil
+			}
+			found = operation
+		} else if operation.GetName() != nil && operation.GetName().Value == name {
+			return operation
+		}
+	}
+	return found
+}
+
+func writeResult(rw http.ResponseWriter, status int, result *graphql.Result) {
+	resp, err := json.Marshal(result)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	rw.Write(resp)
+}
@@ -0,0 +1,172 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service/mocks"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	} `json:"errors"`
+}
+
+// query posts body to Handler as user 42 and decodes the response.
+func query(t *testing.T, NikPay *mocks.WalletService, body string) (int, response) {
+	req := httptest.NewRequest("POST", "/v1/graphql", strings.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), "id", int64(42)))
+	rr := httptest.NewRecorder()
+	Handler(NikPay).ServeHTTP(rr, req)
+
+	var resp response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp), rr.Body.String())
+	return rr.Code, resp
+}
+
+func TestHandler(t *testing.T) {
+	createdAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	wallet := domain.Wallet{ID: 7, UserID: 42, Balance: 150, CreationDate: "2024-05-01", LastUpdated: "2024-05-01 11:00:00", Status: domain.WalletStatusActive}
+	page := domain.ActivityPage{
+		Transactions: []domain.Transaction{
+			{ID: 3, WalletID: 7, UserID: 42, Type: domain.TransactionDebit, Amount: -50, BalanceAfter: 150, Description: "Wallet debit", CreatedAt: createdAt.Add(time.Hour)},
+			{ID: 2, WalletID: 7, UserID: 42, Type: domain.TransactionCredit, Amount: 200, BalanceAfter: 200, Description: "Wallet credit", CreatedAt: createdAt},
+		},
+		NextCursor: "next",
+	}
+
+	t.Run("resolves me, wallet and activity in one request", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("GetProfile", mock.Anything, int64(42)).Return(domain.UserProfile{ID: 42, Name: "John", Email: "john@mail.com"}, nil).Once()
+		NikPay.On("GetWallet", mock.Anything, int64(42)).Return(wallet, nil).Once()
+		NikPay.On("ListActivity", mock.Anything, int64(42), 2, "").Return(page, nil).Once()
+		// Root fields resolve in no fixed order, so the transactions' wallet
+		// is only batched when activity comes before the own wallet is known.
+		NikPay.On("GetWalletsByID", mock.Anything, int64(42), []int64{7}).Return([]domain.Wallet{wallet}, nil).Maybe()
+
+		code, resp := query(t, NikPay, `{"query":"{ me { name pendingEmail wallet { id } } wallet { balance status } activity(first: 2) { nodes { id amount createdAt wallet { id } } pageInfo { hasNextPage endCursor } } }"}`)
+		require.Equal(t, http.StatusOK, code)
+		require.Empty(t, resp.Errors)
+		assert.Equal(t, map[string]interface{}{"name": "John", "pendingEmail": nil, "wallet": map[string]interface{}{"id": "7"}}, resp.Data["me"])
+		assert.Equal(t, map[string]interface{}{"balance": 150.0, "status": "active"}, resp.Data["wallet"])
+		assert.Equal(t, map[string]interface{}{
+			"nodes": []interface{}{
+				map[string]interface{}{"id": "3", "amount": -50.0, "createdAt": "2024-05-01T11:00:00Z", "wallet": map[string]interface{}{"id": "7"}},
+				map[string]interface{}{"id": "2", "amount": 200.0, "createdAt": "2024-05-01T10:00:00Z", "wallet": map[string]interface{}{"id": "7"}},
+			},
+			"pageInfo": map[string]interface{}{"hasNextPage": true, "endCursor": "next"},
+		}, resp.Data["activity"])
+		NikPay.AssertExpectations(t)
+	})
+
+	t.Run("batches wallet lookups across transactions", func(t *testing.T) {
+		page := domain.ActivityPage{Transactions: []domain.Transaction{{ID: 3, WalletID: 7}, {ID: 2, WalletID: 8}, {ID: 1, WalletID: 7}}}
+		NikPay := &mocks.WalletService{}
+		NikPay.On("ListActivity", mock.Anything, int64(42), 20, "cursor").Return(page, nil).Once()
+		NikPay.On("GetWalletsByID", mock.Anything, int64(42), []int64{7, 8}).Return([]domain.Wallet{wallet}, nil).Once()
+
+		code, resp := query(t, NikPay, `{"query":"query Activity($after: String) { activity(after: $after) { nodes { id wallet { balance } } } }","variables":{"after":"cursor"}}`)
+		require.Equal(t, http.StatusOK, code)
+		nodes := resp.Data["activity"].(map[string]interface{})["nodes"]
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"id": "3", "wallet": map[string]interface{}{"balance": 150.0}},
+			map[string]interface{}{"id": "2", "wallet": nil},
+			map[string]interface{}{"id": "1", "wallet": map[string]interface{}{"balance": 150.0}},
+		}, nodes)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, errors.ErrNoWallet.Error(), resp.Errors[0].Message)
+		NikPay.AssertExpectations(t)
+	})
+
+	t.Run("reports service errors next to the data", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("GetProfile", mock.Anything, int64(42)).Return(domain.UserProfile{ID: 42, Name: "John"}, nil).Once()
+		NikPay.On("ListActivity", mock.Anything, int64(42), 500, "").Return(domain.ActivityPage{}, errors.ValidationErrors{
+			{Field: "first", Code: errors.CodeInvalid, Err: errors.ErrInvalidPageSize},
+		}).Once()
+
+		code, resp := query(t, NikPay, `{"query":"{ me { name } activity(first: 500) { pageInfo { hasNextPage } } }"}`)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, map[string]interface{}{"me": map[string]interface{}{"name": "John"}, "activity": nil}, resp.Data)
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, errors.ErrInvalidPageSize.Error(), resp.Errors[0].Message)
+		assert.Equal(t, []interface{}{"activity"}, resp.Errors[0].Path)
+	})
+}
+
+func TestHandlerIntrospection(t *testing.T) {
+	code, resp := query(t, &mocks.WalletService{}, `{"query":"{ __schema { queryType { fields { name type { ofType { ofType { ofType { name } } } } } } } }"}`)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+	assert.NotNil(t, resp.Data["__schema"])
+}
+
+func TestHandlerRejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		message string
+	}{
+		{name: "malformed body", body: `{`, message: errInvalidRequest.Error()},
+		{name: "missing query", body: `{}`, message: errInvalidRequest.Error()},
+		{name: "syntax error", body: `{"query":"{ me { "}`, message: "Syntax Error"},
+		{name: "unknown field", body: `{"query":"{ me { password } }"}`, message: `Cannot query field "password"`},
+		{name: "ambiguous operation", body: `{"query":"query A { me { id } } query B { wallet { id } }"}`, message: errUnknownOperation.Error()},
+		{name: "too complex", body: `{"query":"query($n: Int) { activity(first: $n) { nodes { id type amount balanceAfter description createdAt wallet { id balance status creationDate lastUpdated } } } }","variables":{"n":100}}`, message: "query is too complex"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, resp := query(t, &mocks.WalletService{}, test.body)
+			require.Equal(t, http.StatusBadRequest, code)
+			require.NotEmpty(t, resp.Errors)
+			assert.Contains(t, resp.Errors[0].Message, test.message)
+		})
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		err       error
+	}{
+		{name: "shallow query", query: `{ me { wallet { id } } }`},
+		{name: "paginated query", query: `{ activity(first: 100) { nodes { id amount wallet { id } } } }`},
+		{name: "nested fragments", query: `{ ...A } fragment A on Query { activity { ...B } } fragment B on ActivityConnection { nodes { ... on Transaction { wallet { id } } } }`},
+		{name: "nested too deeply", query: `{ a { b { c { d { e { f { g } } } } } } }`, err: errTooDeep},
+		{name: "nested too deeply through fragments", query: `{ a { ...F } } fragment F on X { b { c { d { e { f { g } } } } } }`, err: errTooDeep},
+		{name: "introspection is not limited", query: `{ __schema { types { fields { type { ofType { ofType { ofType { name } } } } } } } }`},
+		{name: "large page", query: `{ activity(first: 100) { nodes { a b c d e f g h i j } } }`, err: errTooComplex},
+		{name: "large page through a variable", query: `query($n: Int) { activity(first: $n) { nodes { a b c d e f g h i j } } }`, variables: map[string]interface{}{"n": 100.0}, err: errTooComplex},
+		{name: "large page through a variable default", query: `query($n: Int = 100) { activity(first: $n) { nodes { a b c d e f g h i j } } }`, err: errTooComplex},
+		{name: "oversized page counts as the largest", query: `{ activity(first: 100000) { nodes { a b c d e f g h i j } } }`, err: errTooComplex},
+		{name: "small page", query: `query($n: Int) { activity(first: $n) { nodes { a b c d e f g h i j } } }`, variables: map[string]interface{}{"n": 5.0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: test.query})
+			require.NoError(t, err)
+			err = checkLimits(doc, findOperation(doc, ""), test.variables)
+			if test.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, test.err)
+			}
+		})
+	}
+}
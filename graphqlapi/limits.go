@@ -0,0 +1,130 @@
+package graphqlapi
+
+import (
+	stderrors "errors"
+	"fmt"
+	"nickPay/wallet/internal/service"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// MaxDepth is how deeply selections may nest. { activity { nodes {
+	// wallet { id } } } } is 4 deep.
+	MaxDepth = 6
+	// MaxComplexity caps the estimated number of fields a query resolves.
+	// Each field costs 1, and the selections under a paginated field are
+	// counted once per item it may return.
+	MaxComplexity = 1000
+)
+
+var (
+	errTooDeep    = stderrors.New("query is nested too deeply")
+	errTooComplex = stderrors.New("query is too complex")
+)
+
+// checkLimits rejects operation before it runs if it nests deeper than
+// MaxDepth or costs more than MaxComplexity. The document must have been
+// validated, so fragments exist and don't form cycles.
+func checkLimits(doc *ast.Document, operation *ast.OperationDefinition, variables map[string]interface{}) error {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+	// Variables left out of the request take their declared default.
+	values := map[string]interface{}{}
+	for _, definition := range operation.VariableDefinitions {
+		if value, ok := definition.DefaultValue.(*ast.IntValue); ok {
+			values[definition.Variable.Name.Value], _ = strconv.Atoi(value.Value)
+		}
+	}
+	for name, value := range variables {
+		values[name] = value
+	}
+	analyzer := limitAnalyzer{fragments: fragments, variables: values}
+
+	depth, cost := analyzer.selectionSet(operation.SelectionSet)
+	if depth > MaxDepth {
+		return fmt.Errorf("%w: depth %d exceeds %d", errTooDeep, depth, MaxDepth)
+	}
+	if cost > MaxComplexity {
+		return fmt.Errorf("%w: complexity %d exceeds %d", errTooComplex, cost, MaxComplexity)
+	}
+	return nil
+}
+
+type limitAnalyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// selectionSet returns the depth and cost of the selections, with fragments
+// expanded in place.
+func (a limitAnalyzer) selectionSet(set *ast.SelectionSet) (depth int, cost int) {
+	if set == nil {
+		return 0, 0
+	}
+	for _, selection := range set.Selections {
+		var d, c int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			d, c = a.field(selection)
+		case *ast.InlineFragment:
+			d, c = a.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			if fragment, ok := a.fragments[selection.Name.Value]; ok {
+				d, c = a.selectionSet(fragment.SelectionSet)
+			}
+		}
+		if d > depth {
+			depth = d
+		}
+		cost += c
+	}
+	return depth, cost
+}
+
+func (a limitAnalyzer) field(field *ast.Field) (depth int, cost int) {
+	// Introspection is answered from the schema in memory, and tools ask
+	// for type references nested deeper than MaxDepth.
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return 0, 0
+	}
+	depth, cost = a.selectionSet(field.SelectionSet)
+	return depth + 1, 1 + a.pageSize(field)*cost
+}
+
+// pageSize is how many items a paginated field may return, read from its
+// first argument, and 1 for every other field. Sizes the service would
+// reject count as the largest it accepts.
+func (a limitAnalyzer) pageSize(field *ast.Field) int {
+	if field.Name.Value != "activity" {
+		return 1
+	}
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		var n int
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			n, _ = strconv.Atoi(value.Value)
+		case *ast.Variable:
+			switch v := a.variables[value.Name.Value].(type) {
+			case int:
+				n = v
+			case float64:
+				n = int(v)
+			}
+		}
+		if n > 0 && n <= service.MaxActivityPageSize {
+			return n
+		}
+		return service.MaxActivityPageSize
+	}
+	return defaultPageSize
+}
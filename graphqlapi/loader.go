@@ -0,0 +1,104 @@
+package graphqlapi
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+	"sync"
+)
+
+type walletResult struct {
+	wallet domain.Wallet
+	err    error
+}
+
+// walletLoader batches wallet lookups for one request. load only queues the
+// ID and returns a thunk; the executor calls the thunks of a query level
+// once every field on that level has resolved, and the first of them fetches
+// all queued IDs with a single GetWalletsByID. Results are kept for the rest
+// of the request, so a wallet is never fetched twice.
+type walletLoader struct {
+	NikPay service.WalletService
+	userID int64
+
+	mu      sync.Mutex
+	pending []int64
+	results map[int64]walletResult
+	ownID   int64
+	ownErr  error
+}
+
+func newWalletLoader(NikPay service.WalletService, userID int64) *walletLoader {
+	return &walletLoader{NikPay: NikPay, userID: userID, results: map[int64]walletResult{}}
+}
+
+// own returns the signed in user's wallet, fetching it at most once per
+// request. It also answers later loads of the same wallet.
+func (l *walletLoader) own(ctx context.Context) (interface{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ownID == 0 && l.ownErr == nil {
+		wallet, err := l.NikPay.GetWallet(ctx, l.userID)
+		if err != nil {
+			l.ownErr = err
+		} else {
+			l.ownID = wallet.ID
+			l.results[wallet.ID] = walletResult{wallet: wallet}
+		}
+	}
+	if l.ownErr != nil {
+		return nil, l.ownErr
+	}
+	return l.results[l.ownID].wallet, nil
+}
+
+func (l *walletLoader) load(ctx context.Context, walletID int64) func() (interface{}, error) {
+	l.mu.Lock()
+	if _, ok := l.results[walletID]; !ok {
+		l.pending = append(l.pending, walletID)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 {
+			l.dispatch(ctx)
+		}
+		result := l.results[walletID]
+		if result.err != nil {
+			return nil, result.err
+		}
+		return result.wallet, nil
+	}
+}
+
+// dispatch fetches every pending wallet. IDs missing from the response,
+// which includes other users' wallets, resolve to errors.ErrNoWallet.
+func (l *walletLoader) dispatch(ctx context.Context) {
+	ids := make([]int64, 0, len(l.pending))
+	seen := map[int64]bool{}
+	for _, id := range l.pending {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	l.pending = nil
+
+	wallets, err := l.NikPay.GetWalletsByID(ctx, l.userID, ids)
+	for _, wallet := range wallets {
+		l.results[wallet.ID] = walletResult{wallet: wallet}
+	}
+	for _, id := range ids {
+		if _, ok := l.results[id]; ok {
+			continue
+		}
+		if err != nil {
+			l.results[id] = walletResult{err: err}
+		} else {
+			l.results[id] = walletResult{err: errors.ErrNoWallet}
+		}
+	}
+}
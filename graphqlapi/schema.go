@@ -0,0 +1,165 @@
+package graphqlapi
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/service"
+
+	"github.com/graphql-go/graphql"
+)
+
+// defaultPageSize is used when activity is asked for without first.
+const defaultPageSize = 20
+
+type requestKey struct{}
+
+// request holds what resolvers need to know about the HTTP request they
+// serve: whose data to return and the loaders caching it.
+type request struct {
+	userID  int64
+	wallets *walletLoader
+}
+
+func requestFromContext(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// resolve adapts a function of the source value to a field resolver.
+func resolve[T any](fn func(T) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		return fn(p.Source.(T)), nil
+	}
+}
+
+// newSchema builds the read-only schema:
+//
+//	type Query {
+//	  me: User
+//	  wallet: Wallet
+//	  activity(first: Int = 20, after: String): ActivityConnection
+//	}
+//
+// Every field only ever returns data of the signed in user. Fields that load
+// data are nullable, so one failing lookup only nulls its own part of the
+// response.
+func newSchema(NikPay service.WalletService) (graphql.Schema, error) {
+	walletType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Wallet",
+		Fields: graphql.Fields{
+			"id":           {Type: graphql.NewNonNull(graphql.ID), Resolve: resolve(func(w domain.Wallet) interface{} { return w.ID })},
+			"balance":      {Type: graphql.NewNonNull(graphql.Float), Resolve: resolve(func(w domain.Wallet) interface{} { return w.Balance })},
+			"status":       {Type: graphql.NewNonNull(graphql.String), Description: "One of active, frozen or closed.", Resolve: resolve(func(w domain.Wallet) interface{} { return w.Status })},
+			"creationDate": {Type: graphql.NewNonNull(graphql.String), Resolve: resolve(func(w domain.Wallet) interface{} { return w.CreationDate })},
+			"lastUpdated":  {Type: graphql.NewNonNull(graphql.String), Resolve: resolve(func(w domain.Wallet) interface{} { return w.LastUpdated })},
+		},
+	})
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":            {Type: graphql.NewNonNull(graphql.ID), Resolve: resolve(func(u domain.UserProfile) interface{} { return u.ID })},
+			"name":          {Type: graphql.NewNonNull(graphql.String), Resolve: resolve(func(u domain.UserProfile) interface{} { return u.Name })},
+			"email":         {Type: graphql.NewNonNull(graphql.String), Resolve: resolve(func(u domain.UserProfile) interface{} { return u.Email })},
+			"phoneNumber":   {Type: graphql.NewNonNull(graphql.String), Resolve: resolve(func(u domain.UserProfile) interface{} { return u.PhoneNumber })},
+			"emailVerified": {Type: graphql.NewNonNull(graphql.Boolean), Resolve: resolve(func(u domain.UserProfile) interface{} { return u.EmailVerified })},
+			"phoneVerified": {Type: graphql.NewNonNull(graphql.Boolean), Resolve: resolve(func(u domain.UserProfile) interface{} { return u.PhoneVerified })},
+			"pendingEmail": {Type: graphql.String, Description: "Email waiting to be confirmed, if any.", Resolve: resolve(func(u domain.UserProfile) interface{} {
+				if u.PendingEmail == "" {
+					return nil
+				}
+				return u.PendingEmail
+			})},
+			"wallet": {
+				Type:        walletType,
+				Description: "Null when the user has no wallet.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return requestFromContext(p.Context).wallets.own(p.Context)
+				},
+			},
+		},
+	})
+
+	transactionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Transaction",
+		Fields: graphql.Fields{
+			"id":           {Type: graphql.NewNonNull(graphql.ID), Resolve: resolve(func(t domain.Transaction) interface{} { return t.ID })},
+			"type":         {Type: graphql.NewNonNull(graphql.String), Description: "Either credit or debit.", Resolve: resolve(func(t domain.Transaction) interface{} { return t.Type })},
+			"amount":       {Type: graphql.NewNonNull(graphql.Float), Description: "Positive for credits, negative for debits.", Resolve: resolve(func(t domain.Transaction) interface{} { return t.Amount })},
+			"balanceAfter": {Type: graphql.NewNonNull(graphql.Float), Resolve: resolve(func(t domain.Transaction) interface{} { return t.BalanceAfter })},
+			"description":  {Type: graphql.NewNonNull(graphql.String), Resolve: resolve(func(t domain.Transaction) interface{} { return t.Description })},
+			"createdAt":    {Type: graphql.NewNonNull(graphql.DateTime), Resolve: resolve(func(t domain.Transaction) interface{} { return t.CreatedAt })},
+			"wallet": {
+				Type:        walletType,
+				Description: "Fetched for all transactions of a page at once.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return requestFromContext(p.Context).wallets.load(p.Context, p.Source.(domain.Transaction).WalletID), nil
+				},
+			},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": {Type: graphql.NewNonNull(graphql.Boolean), Resolve: resolve(func(p domain.ActivityPage) interface{} { return p.NextCursor != "" })},
+			"endCursor": {Type: graphql.String, Description: "Pass as after to fetch the next page.", Resolve: resolve(func(p domain.ActivityPage) interface{} {
+				if p.NextCursor == "" {
+					return nil
+				}
+				return p.NextCursor
+			})},
+		},
+	})
+
+	activityType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ActivityConnection",
+		Fields: graphql.Fields{
+			"nodes":    {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(transactionType))), Resolve: resolve(func(p domain.ActivityPage) interface{} { return p.Transactions })},
+			"pageInfo": {Type: graphql.NewNonNull(pageInfoType), Resolve: resolve(func(p domain.ActivityPage) interface{} { return p })},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					profile, err := NikPay.GetProfile(p.Context, requestFromContext(p.Context).userID)
+					// graphql-go renders any value returned alongside an
+					// error, so failed lookups must return nil.
+					if err != nil {
+						return nil, err
+					}
+					return profile, nil
+				},
+			},
+			"wallet": {
+				Type:        walletType,
+				Description: "Null when the user has no wallet.",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return requestFromContext(p.Context).wallets.own(p.Context)
+				},
+			},
+			"activity": {
+				Type:        activityType,
+				Description: "Transactions of the signed in user, newest first.",
+				Args: graphql.FieldConfigArgument{
+					"first": {Type: graphql.Int, DefaultValue: defaultPageSize},
+					"after": {Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					first, _ := p.Args["first"].(int)
+					after, _ := p.Args["after"].(string)
+					page, err := NikPay.ListActivity(p.Context, requestFromContext(p.Context).userID, first, after)
+					if err != nil {
+						return nil, err
+					}
+					return page, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
@@ -0,0 +1,85 @@
+package grpcserver
+
+import (
+	"context"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/service"
+	"nickPay/wallet/internal/walletpb"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods can be called without an access token.
+var publicMethods = map[string]bool{
+	walletpb.WalletService_RegisterUser_FullMethodName: true,
+	walletpb.WalletService_LoginUser_FullMethodName:    true,
+}
+
+type userIDKey struct{}
+
+func userIDFromContext(ctx context.Context) int64 {
+	userID, _ := ctx.Value(userIDKey{}).(int64)
+	return userID
+}
+
+// authInterceptor accepts the same access tokens as the REST API, sent as
+// "Bearer <token>" in the authorization metadata, and rejects those whose
+// session has since been signed out.
+func authInterceptor(NikPay service.WalletService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+		userID, sessionVersion, err := service.ParseAccessToken(strings.TrimPrefix(values[0], "Bearer "))
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		err = NikPay.ValidateSession(ctx, userID, sessionVersion)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		ctx = context.WithValue(ctx, userIDKey{}, userID)
+		logging.AddFields(ctx, logger.Fields{"user_id": userID})
+		return handler(ctx, req)
+	}
+}
+
+// loggingInterceptor stores a logger carrying the method in the context and
+// writes one access log line per call once it returns. Requests are never
+// logged since they may carry passwords.
+func loggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	ctx = logging.WithLogger(ctx, logger.WithFields(logger.Fields{
+		"grpc_method": info.FullMethod,
+	}))
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	entry := logging.FromContext(ctx).WithFields(logger.Fields{
+		"grpc_code":  code.String(),
+		"latency_ms": time.Since(start).Milliseconds(),
+	})
+	switch code {
+	case codes.OK:
+		entry.Info("Request completed")
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		entry.Error("Request failed")
+	default:
+		entry.Warn("Request rejected")
+	}
+	return resp, err
+}
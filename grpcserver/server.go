@@ -0,0 +1,109 @@
+// Package grpcserver serves walletpb.WalletService on top of
+// service.WalletService, for internal services that talk gRPC rather than
+// the JSON API.
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+	"nickPay/wallet/internal/walletpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+type walletServer struct {
+	walletpb.UnimplementedWalletServiceServer
+	NikPay service.WalletService
+}
+
+// NewServer returns a gRPC server with WalletService registered behind the
+// logging and authentication interceptors.
+func NewServer(NikPay service.WalletService, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(loggingInterceptor, authInterceptor(NikPay)))
+	srv := grpc.NewServer(opts...)
+	walletpb.RegisterWalletServiceServer(srv, &walletServer{NikPay: NikPay})
+	return srv
+}
+
+func (s *walletServer) RegisterUser(ctx context.Context, req *walletpb.RegisterUserRequest) (*walletpb.RegisterUserResponse, error) {
+	err := s.NikPay.RegisterUser(ctx, domain.User{
+		Name:        req.GetName(),
+		Email:       req.GetEmail(),
+		PhoneNumber: req.GetPhoneNumber(),
+		Password:    req.GetPassword(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &walletpb.RegisterUserResponse{}, nil
+}
+
+func (s *walletServer) LoginUser(ctx context.Context, req *walletpb.LoginUserRequest) (*walletpb.LoginUserResponse, error) {
+	token, err := s.NikPay.LoginUser(ctx, domain.LoginUserRequest{
+		Email:    req.GetEmail(),
+		Password: req.GetPassword(),
+		ClientIP: peerIP(ctx),
+	})
+	if err == errors.ErrMFARequired {
+		return &walletpb.LoginUserResponse{MfaToken: token}, nil
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &walletpb.LoginUserResponse{Token: token}, nil
+}
+
+func (s *walletServer) GetWallet(ctx context.Context, req *walletpb.GetWalletRequest) (*walletpb.Wallet, error) {
+	wallet, err := s.NikPay.GetWallet(ctx, userIDFromContext(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &walletpb.Wallet{
+		Id:           wallet.ID,
+		Balance:      wallet.Balance,
+		CreationDate: wallet.CreationDate,
+		LastUpdated:  wallet.LastUpdated,
+		Status:       wallet.Status,
+	}, nil
+}
+
+func (s *walletServer) CreditWallet(ctx context.Context, req *walletpb.CreditWalletRequest) (*walletpb.CreditWalletResponse, error) {
+	topUp, err := s.NikPay.CreditWallet(ctx, userIDFromContext(ctx), req.GetAmount())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &walletpb.CreditWalletResponse{
+		TopUpId:     topUp.ID,
+		Status:      topUp.Status,
+		CheckoutUrl: topUp.CheckoutURL,
+	}, nil
+}
+
+func (s *walletServer) DebitWallet(ctx context.Context, req *walletpb.DebitWalletRequest) (*walletpb.DebitWalletResponse, error) {
+	if code := req.GetMfaCode(); code != "" {
+		ctx = service.WithStepUpCode(ctx, code)
+	}
+	quote, err := s.NikPay.DebitWallet(ctx, userIDFromContext(ctx), req.GetAmount())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &walletpb.DebitWalletResponse{Fee: quote.Fee, Total: quote.Total}, nil
+}
+
+// peerIP returns the IP of the caller, which login lockout counts failures
+// against.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,229 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/service"
+	"nickPay/wallet/internal/service/mocks"
+	"nickPay/wallet/internal/walletpb"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves NikPay over an in-memory listener and returns a client for it.
+func dial(t *testing.T, NikPay service.WalletService) walletpb.WalletServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	srv := NewServer(NikPay)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return walletpb.NewWalletServiceClient(conn)
+}
+
+// signedIn returns a context carrying an access token for user 42.
+func signedIn(t *testing.T) context.Context {
+	token, err := service.GenerateToken(domain.LoginDbResponse{ID: 42, SessionVersion: 1})
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestRegisterUser(t *testing.T) {
+	t.Run("registers the user", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("RegisterUser", mock.Anything, domain.User{Name: "John", Email: "john@mail.com", PhoneNumber: "8123467890", Password: "pass"}).Return(nil)
+		client := dial(t, NikPay)
+
+		_, err := client.RegisterUser(context.Background(), &walletpb.RegisterUserRequest{Name: "John", Email: "john@mail.com", PhoneNumber: "8123467890", Password: "pass"})
+		require.NoError(t, err)
+		NikPay.AssertExpectations(t)
+	})
+
+	t.Run("lists invalid fields", func(t *testing.T) {
+		NikPay := &mocks.WalletService{}
+		NikPay.On("RegisterUser", mock.Anything, mock.Anything).Return(errors.ValidationErrors{
+			{Field: "email", Code: errors.CodeInvalid, Err: errors.ErrInvalidEmail},
+			{Field: "password", Code: errors.CodeRequired, Err: errors.ErrInvalidPassword},
+		})
+		client := dial(t, NikPay)
+
+		_, err := client.RegisterUser(context.Background(), &walletpb.RegisterUserRequest{Email: "john"})
+		st := status.Convert(err)
+		require.Equal(t, codes.InvalidArgument, st.Code())
+		require.Len(t, st.Details(), 1)
+		badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+		require.True(t, ok)
+		require.Len(t, badRequest.FieldViolations, 2)
+		assert.Equal(t, "email", badRequest.FieldViolations[0].Field)
+		assert.Equal(t, "INVALID", badRequest.FieldViolations[0].Reason)
+		assert.Equal(t, errors.ErrInvalidEmail.Error(), badRequest.FieldViolations[0].Description)
+		assert.Equal(t, "REQUIRED", badRequest.FieldViolations[1].Reason)
+	})
+}
+
+func TestLoginUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		err      error
+		code     codes.Code
+		expected *walletpb.LoginUserResponse
+	}{
+		{name: "returns the access token", token: "token", code: codes.OK, expected: &walletpb.LoginUserResponse{Token: "token"}},
+		{name: "returns the mfa token", token: "mfa-token", err: errors.ErrMFARequired, code: codes.OK, expected: &walletpb.LoginUserResponse{MfaToken: "mfa-token"}},
+		{name: "rejects wrong credentials", err: errors.ErrInvalidCredentials, code: codes.Unauthenticated},
+		{name: "reports lockouts", err: errors.ErrLoginLocked, code: codes.ResourceExhausted},
+		{name: "hides unexpected errors", err: errors.ErrLoggingIn, code: codes.Internal},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			NikPay := &mocks.WalletService{}
+			NikPay.On("LoginUser", mock.Anything, mock.MatchedBy(func(req domain.LoginUserRequest) bool {
+				return req.Email == "john@mail.com" && req.Password == "pass" && req.ClientIP != ""
+			})).Return(test.token, test.err)
+			client := dial(t, NikPay)
+
+			resp, err := client.LoginUser(context.Background(), &walletpb.LoginUserRequest{Email: "john@mail.com", Password: "pass"})
+			require.Equal(t, test.code, status.Code(err))
+			if test.expected != nil {
+				assert.Equal(t, test.expected.Token, resp.GetToken())
+				assert.Equal(t, test.expected.MfaToken, resp.GetMfaToken())
+			}
+			NikPay.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	mfaToken, err := service.GenerateMFAToken(42)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		prepare func(*mocks.WalletService)
+		code    codes.Code
+	}{
+		{name: "missing token", ctx: context.Background(), code: codes.Unauthenticated},
+		{name: "malformed token", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope"), code: codes.Unauthenticated},
+		{name: "mfa token", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+mfaToken), code: codes.Unauthenticated},
+		{name: "signed out session", ctx: signedIn(t), code: codes.Unauthenticated, prepare: func(m *mocks.WalletService) {
+			m.On("ValidateSession", mock.Anything, int64(42), int64(1)).Return(errors.ErrSessionExpired)
+		}},
+		{name: "session check failing", ctx: signedIn(t), code: codes.Internal, prepare: func(m *mocks.WalletService) {
+			m.On("ValidateSession", mock.Anything, int64(42), int64(1)).Return(errors.ErrFetchingUser)
+		}},
+		{name: "current session", ctx: signedIn(t), code: codes.OK, prepare: func(m *mocks.WalletService) {
+			m.On("ValidateSession", mock.Anything, int64(42), int64(1)).Return(nil)
+			m.On("GetWallet", mock.Anything, int64(42)).Return(domain.Wallet{ID: 7, UserID: 42, Balance: 150, Status: domain.WalletStatusActive}, nil)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			NikPay := &mocks.WalletService{}
+			if test.prepare != nil {
+				test.prepare(NikPay)
+			}
+			client := dial(t, NikPay)
+
+			wallet, err := client.GetWallet(test.ctx, &walletpb.GetWalletRequest{})
+			require.Equal(t, test.code, status.Code(err), err)
+			if test.code == codes.OK {
+				assert.Equal(t, int64(7), wallet.GetId())
+				assert.Equal(t, 150.0, wallet.GetBalance())
+				assert.Equal(t, domain.WalletStatusActive, wallet.GetStatus())
+			}
+			NikPay.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreditWallet(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "starts a top-up", code: codes.OK},
+		{name: "unverified user", err: errors.ErrUnverifiedUser, code: codes.PermissionDenied},
+		{name: "frozen wallet", err: errors.ErrWalletFrozen, code: codes.FailedPrecondition},
+		{name: "no payment gateway", err: errors.ErrNoPaymentGateway, code: codes.Unavailable},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			NikPay := &mocks.WalletService{}
+			NikPay.On("ValidateSession", mock.Anything, int64(42), int64(1)).Return(nil)
+			topUp := domain.TopUp{ID: 9, Amount: 50, Status: domain.TopUpStatusPending, CheckoutURL: "https://pay/fake_1"}
+			NikPay.On("CreditWallet", mock.Anything, int64(42), 50.0).Return(topUp, test.err)
+			client := dial(t, NikPay)
+
+			resp, err := client.CreditWallet(signedIn(t), &walletpb.CreditWalletRequest{Amount: 50})
+			require.Equal(t, test.code, status.Code(err))
+			if test.code == codes.OK {
+				assert.Equal(t, int64(9), resp.GetTopUpId())
+				assert.Equal(t, domain.TopUpStatusPending, resp.GetStatus())
+				assert.Equal(t, "https://pay/fake_1", resp.GetCheckoutUrl())
+			}
+			NikPay.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDebitWallet(t *testing.T) {
+	tests := []struct {
+		name    string
+		mfaCode string
+		err     error
+		code    codes.Code
+	}{
+		{name: "debits the wallet", code: codes.OK},
+		{name: "debits with a step-up code", mfaCode: "123456", code: codes.OK},
+		{name: "step-up required", err: errors.ErrStepUpRequired, code: codes.PermissionDenied},
+		{name: "insufficient balance", err: errors.ErrInsufficientBalance, code: codes.FailedPrecondition},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			NikPay := &mocks.WalletService{}
+			NikPay.On("ValidateSession", mock.Anything, int64(42), int64(1)).Return(nil)
+			NikPay.On("DebitWallet", mock.MatchedBy(func(ctx context.Context) bool {
+				return userIDFromContext(ctx) == 42
+			}), int64(42), 50.0).Return(domain.FeeQuote{Amount: 50, Fee: 0.5, Total: 50.5}, test.err)
+			client := dial(t, NikPay)
+
+			resp, err := client.DebitWallet(signedIn(t), &walletpb.DebitWalletRequest{Amount: 50, MfaCode: test.mfaCode})
+			require.Equal(t, test.code, status.Code(err))
+			if test.err == nil {
+				assert.Equal(t, 0.5, resp.GetFee())
+				assert.Equal(t, 50.5, resp.GetTotal())
+			}
+			NikPay.AssertExpectations(t)
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	assert.Equal(t, codes.AlreadyExists, status.Code(toStatus(errors.ErrEmailTaken)))
+	assert.Equal(t, codes.NotFound, status.Code(toStatus(errors.ErrNoWallet)))
+	assert.Equal(t, codes.Canceled, status.Code(toStatus(context.Canceled)))
+	assert.Equal(t, codes.Internal, status.Code(toStatus(errors.ErrUpdatingWallet)))
+	assert.Equal(t, errors.ErrInsufficientBalance.Error(), status.Convert(toStatus(errors.ErrInsufficientBalance)).Message())
+}
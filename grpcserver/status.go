@@ -0,0 +1,70 @@
+package grpcserver
+
+import (
+	"context"
+	stderrors "errors"
+	errors "nickPay/wallet/internal/errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes maps errors package sentinels to the status code callers see.
+// Anything missing is reported as Internal.
+var errorCodes = map[error]codes.Code{
+	errors.ErrInvalidEmail:        codes.InvalidArgument,
+	errors.ErrInvalidName:         codes.InvalidArgument,
+	errors.ErrInvalidPassword:     codes.InvalidArgument,
+	errors.ErrInvalidPhoneNumber:  codes.InvalidArgument,
+	errors.ErrPasswordTooLong:     codes.InvalidArgument,
+	errors.ErrAmountNotPositive:   codes.InvalidArgument,
+	errors.ErrAmountNotFinite:     codes.InvalidArgument,
+	errors.ErrAmountTooLarge:      codes.InvalidArgument,
+	errors.ErrAmountTooPrecise:    codes.InvalidArgument,
+	errors.ErrInvalidCredentials:  codes.Unauthenticated,
+	errors.ErrInvalidAccessToken:  codes.Unauthenticated,
+	errors.ErrSessionExpired:      codes.Unauthenticated,
+	errors.ErrStepUpRequired:      codes.PermissionDenied,
+	errors.ErrInvalidMFACode:      codes.PermissionDenied,
+	errors.ErrMFANotEnrolled:      codes.PermissionDenied,
+	errors.ErrUnverifiedUser:      codes.PermissionDenied,
+	errors.ErrUserNotFound:        codes.NotFound,
+	errors.ErrNoWallet:            codes.NotFound,
+	errors.ErrEmailTaken:          codes.AlreadyExists,
+	errors.ErrInsufficientBalance: codes.FailedPrecondition,
+	errors.ErrWalletFrozen:        codes.FailedPrecondition,
+	errors.ErrLoginLocked:         codes.ResourceExhausted,
+	errors.ErrStepUpLocked:        codes.ResourceExhausted,
+	errors.ErrNoPaymentGateway:    codes.Unavailable,
+}
+
+// toStatus converts an error from the service layer into a status error.
+// errors.ValidationErrors become InvalidArgument with a BadRequest detail
+// listing every invalid field.
+func toStatus(err error) error {
+	var fieldErrs errors.ValidationErrors
+	if stderrors.As(err, &fieldErrs) {
+		badRequest := &errdetails.BadRequest{}
+		for _, fieldErr := range fieldErrs {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fieldErr.Field,
+				Description: fieldErr.Err.Error(),
+				Reason:      strings.ToUpper(fieldErr.Code),
+			})
+		}
+		st, detailErr := status.New(codes.InvalidArgument, "invalid request").WithDetails(badRequest)
+		if detailErr != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return st.Err()
+	}
+	if code, ok := errorCodes[err]; ok {
+		return status.Error(code, err.Error())
+	}
+	if stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+// requestLogger holds the request-scoped entry. It is shared by pointer so
+// that fields added deeper in the stack, such as the user ID resolved by the
+// auth middleware, also show up in the access log written on the way out.
+type requestLogger struct {
+	mu    sync.Mutex
+	entry *logger.Entry
+}
+
+// WithLogger returns a copy of ctx carrying entry as its logger.
+func WithLogger(ctx context.Context, entry *logger.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestLogger{entry: entry})
+}
+
+// FromContext returns the logger stored in ctx, or the standard logger when
+// ctx has none, so callers can always log through it.
+func FromContext(ctx context.Context) *logger.Entry {
+	if rl, ok := ctx.Value(contextKey{}).(*requestLogger); ok {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		return rl.entry
+	}
+	return logger.NewEntry(logger.StandardLogger())
+}
+
+// AddFields attaches fields to the logger stored in ctx for the rest of the
+// request. It is a no-op when ctx carries no logger.
+func AddFields(ctx context.Context, fields logger.Fields) {
+	if rl, ok := ctx.Value(contextKey{}).(*requestLogger); ok {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		rl.entry = rl.entry.WithFields(fields)
+	}
+}
+
+// sensitiveKeys are field names whose values must never reach the logs.
+var sensitiveKeys = []string{"password", "token", "secret", "authorization"}
+
+// RedactHook masks the value of any field whose name looks like it holds a
+// credential. It is a safety net; code should not log such values at all.
+type RedactHook struct{}
+
+func (RedactHook) Levels() []logger.Level {
+	return logger.AllLevels
+}
+
+func (RedactHook) Fire(entry *logger.Entry) error {
+	for key := range entry.Data {
+		lower := strings.ToLower(key)
+		for _, sensitive := range sensitiveKeys {
+			if strings.Contains(lower, sensitive) {
+				entry.Data[key] = "[REDACTED]"
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Run("Falls back to the standard logger", func(t *testing.T) {
+		entry := FromContext(context.Background())
+		require.NotNil(t, entry)
+		require.Empty(t, entry.Data)
+	})
+
+	t.Run("Fields added later are visible to every holder of the context", func(t *testing.T) {
+		ctx := WithLogger(context.Background(), logger.WithField("request_id", "abc"))
+		AddFields(ctx, logger.Fields{"user_id": int64(7)})
+
+		entry := FromContext(ctx)
+		require.Equal(t, "abc", entry.Data["request_id"])
+		require.Equal(t, int64(7), entry.Data["user_id"])
+	})
+}
+
+func TestRedactHook(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New()
+	log.SetOutput(&out)
+	log.SetFormatter(&logger.JSONFormatter{})
+	log.AddHook(RedactHook{})
+
+	log.WithFields(logger.Fields{"password": "hunter2", "reset_token": "abc", "user_id": 1}).Info("login")
+
+	require.NotContains(t, out.String(), "hunter2")
+	require.NotContains(t, out.String(), `"abc"`)
+	require.Contains(t, out.String(), `"user_id":1`)
+}
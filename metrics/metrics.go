@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	logger "github.com/sirupsen/logrus"
+)
+
+const namespace = "wallet"
+
+const (
+	OperationCredit          = "credit"
+	OperationDebit           = "debit"
+	OperationWithdrawal      = "withdrawal"
+	OperationMerchantPayment = "merchant_payment"
+
+	ResultSuccess             = "success"
+	ResultInsufficientBalance = "insufficient_balance"
+	ResultFrozen              = "frozen"
+	ResultDeclined            = "declined"
+	ResultError               = "error"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	operations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operations_total",
+		Help:      "Wallet credits, debits, withdrawals and merchant payments by outcome. Insufficient balance rejections have result=\"insufficient_balance\". Credits count when their top-up settles, with result=\"declined\" when the gateway declined the payment. Withdrawals count when their transfer settles, with result=\"declined\" when it failed and was reversed.",
+	}, []string{"operation", "result"})
+
+	operationAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "operation_amount_total",
+		Help:      "Sum of successfully credited and debited amounts.",
+	}, []string{"operation"})
+
+	cashbackAmount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cashback_amount_total",
+		Help:      "Sum of cashback granted to promo balances by campaigns.",
+	})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Latency of Storer calls by query name.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"query"})
+)
+
+// ObserveHTTPRequest records one served request.
+func ObserveHTTPRequest(route string, method string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// ObserveOperation records the outcome of a credit, debit, withdrawal or
+// merchant payment. The amount only counts towards the totals when the
+// operation succeeded.
+func ObserveOperation(operation string, result string, amount float64) {
+	operations.WithLabelValues(operation, result).Inc()
+	if result == ResultSuccess {
+		operationAmount.WithLabelValues(operation).Add(amount)
+	}
+}
+
+// ObserveCashback records cashback granted to a promo balance.
+func ObserveCashback(amount float64) {
+	cashbackAmount.Add(amount)
+}
+
+// TimeQuery starts timing the named query. Call the returned function when
+// the query finishes, typically with defer metrics.TimeQuery("name")().
+func TimeQuery(query string) func() {
+	start := time.Now()
+	return func() {
+		queryDuration.WithLabelValues(query).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDBStats exports the connection pool statistics of db.
+func RegisterDBStats(db *sql.DB) {
+	err := prometheus.Register(collectors.NewDBStatsCollector(db, namespace))
+	if err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			logger.WithField("err", err.Error()).Error("Cannot register database pool metrics")
+		}
+	}
+}
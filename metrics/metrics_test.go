@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveOperation(t *testing.T) {
+	before := testutil.ToFloat64(operationAmount.WithLabelValues(OperationDebit))
+
+	ObserveOperation(OperationDebit, ResultSuccess, 250)
+	ObserveOperation(OperationDebit, ResultInsufficientBalance, 1000)
+
+	require.Equal(t, before+250, testutil.ToFloat64(operationAmount.WithLabelValues(OperationDebit)))
+	require.GreaterOrEqual(t, testutil.ToFloat64(operations.WithLabelValues(OperationDebit, ResultInsufficientBalance)), 1.0)
+}
+
+func TestObserveCashback(t *testing.T) {
+	before := testutil.ToFloat64(cashbackAmount)
+
+	ObserveCashback(12.5)
+
+	require.Equal(t, before+12.5, testutil.ToFloat64(cashbackAmount))
+}
+
+func TestObserveHTTPRequest(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("/wallet", "GET", "200"))
+
+	ObserveHTTPRequest("/wallet", "GET", 200, 15*time.Millisecond)
+
+	require.Equal(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues("/wallet", "GET", "200")))
+}
+
+func TestTimeQuery(t *testing.T) {
+	done := TimeQuery("TestQuery")
+	done()
+
+	require.Equal(t, 1, testutil.CollectAndCount(queryDuration, "wallet_db_query_duration_seconds"))
+}
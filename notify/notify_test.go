@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"nickPay/wallet/internal/logging"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogNotifier(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New()
+	log.SetOutput(&out)
+	ctx := logging.WithLogger(context.Background(), logger.NewEntry(log))
+
+	require.NoError(t, LogNotifier{}.Notify(ctx, Message{Channel: ChannelEmail, To: "john@mail.com", Subject: "Verify", Body: "Your code is 123456"}))
+	require.Contains(t, out.String(), "john@mail.com")
+	require.NotContains(t, out.String(), "123456")
+}
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	notifier, err := New("file", path)
+	require.NoError(t, err)
+
+	require.NoError(t, notifier.Notify(context.Background(), Message{Channel: ChannelEmail, To: "john@mail.com", Subject: "Verify", Body: "123456"}))
+	require.NoError(t, notifier.Notify(context.Background(), Message{Channel: ChannelSMS, To: "9876543210", Body: "654321"}))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	var got Message
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &got))
+	require.Equal(t, Message{Channel: ChannelSMS, To: "9876543210", Body: "654321"}, got)
+}
+
+func TestNew(t *testing.T) {
+	notifier, err := New("log", "")
+	require.NoError(t, err)
+	require.IsType(t, LogNotifier{}, notifier)
+
+	_, err = New("carrier-pigeon", "")
+	require.Error(t, err)
+	_, err = New("", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,151 @@
+// Package phone parses phone numbers as users type them into E.164 form,
+// e.g. "+918123467890", which is how numbers are stored and compared.
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidNumber = errors.New("invalid phone number")
+
+// Region describes the numbering plan of a country.
+type Region struct {
+	CallingCode string
+	// Lengths lists the valid lengths of national significant numbers, the
+	// digits that follow the calling code.
+	Lengths []int
+	// TrunkPrefix is dialled before numbers within the country and is not
+	// part of the E.164 form.
+	TrunkPrefix string
+}
+
+// regions are keyed by ISO 3166-1 alpha-2 code.
+var regions = map[string]Region{
+	"AE": {CallingCode: "971", Lengths: []int{8, 9}, TrunkPrefix: "0"},
+	"AU": {CallingCode: "61", Lengths: []int{9}, TrunkPrefix: "0"},
+	"BD": {CallingCode: "880", Lengths: []int{10}, TrunkPrefix: "0"},
+	"BR": {CallingCode: "55", Lengths: []int{10, 11}, TrunkPrefix: "0"},
+	"CA": {CallingCode: "1", Lengths: []int{10}, TrunkPrefix: "1"},
+	"CN": {CallingCode: "86", Lengths: []int{10, 11}, TrunkPrefix: "0"},
+	"DE": {CallingCode: "49", Lengths: []int{7, 8, 9, 10, 11}, TrunkPrefix: "0"},
+	"ES": {CallingCode: "34", Lengths: []int{9}},
+	"FR": {CallingCode: "33", Lengths: []int{9}, TrunkPrefix: "0"},
+	"GB": {CallingCode: "44", Lengths: []int{9, 10}, TrunkPrefix: "0"},
+	"IE": {CallingCode: "353", Lengths: []int{7, 8, 9}, TrunkPrefix: "0"},
+	"IN": {CallingCode: "91", Lengths: []int{10}, TrunkPrefix: "0"},
+	"IT": {CallingCode: "39", Lengths: []int{6, 7, 8, 9, 10, 11}},
+	"JP": {CallingCode: "81", Lengths: []int{9, 10}, TrunkPrefix: "0"},
+	"KE": {CallingCode: "254", Lengths: []int{9}, TrunkPrefix: "0"},
+	"LK": {CallingCode: "94", Lengths: []int{9}, TrunkPrefix: "0"},
+	"MX": {CallingCode: "52", Lengths: []int{10}},
+	"NG": {CallingCode: "234", Lengths: []int{8, 10}, TrunkPrefix: "0"},
+	"NL": {CallingCode: "31", Lengths: []int{9}, TrunkPrefix: "0"},
+	"NP": {CallingCode: "977", Lengths: []int{8, 9, 10}, TrunkPrefix: "0"},
+	"NZ": {CallingCode: "64", Lengths: []int{8, 9, 10}, TrunkPrefix: "0"},
+	"PK": {CallingCode: "92", Lengths: []int{9, 10}, TrunkPrefix: "0"},
+	"SA": {CallingCode: "966", Lengths: []int{9}, TrunkPrefix: "0"},
+	"SG": {CallingCode: "65", Lengths: []int{8}},
+	"US": {CallingCode: "1", Lengths: []int{10}, TrunkPrefix: "1"},
+	"ZA": {CallingCode: "27", Lengths: []int{9}, TrunkPrefix: "0"},
+}
+
+// E.164 numbers have at most 15 digits. Numbers in countries missing from
+// regions are accepted as long as they have at least minDigits.
+const (
+	maxDigits = 15
+	minDigits = 8
+)
+
+// KnownRegion reports whether national numbers can be parsed for region.
+func KnownRegion(region string) bool {
+	_, ok := regions[strings.ToUpper(region)]
+	return ok
+}
+
+// Normalize returns number in E.164 form. Numbers starting with + or the 00
+// international prefix are validated against the plan of their calling
+// code, anything else is taken as a national number of defaultRegion.
+// Spaces, dashes, dots, slashes and parentheses are ignored.
+func Normalize(number string, defaultRegion string) (string, error) {
+	digits, international, ok := clean(number)
+	if !ok {
+		return "", ErrInvalidNumber
+	}
+	if international {
+		return normalizeInternational(digits)
+	}
+
+	region, ok := regions[strings.ToUpper(defaultRegion)]
+	if !ok {
+		return "", ErrInvalidNumber
+	}
+	switch {
+	case region.valid(digits):
+	case region.TrunkPrefix != "" && strings.HasPrefix(digits, region.TrunkPrefix) && region.valid(strings.TrimPrefix(digits, region.TrunkPrefix)):
+		digits = strings.TrimPrefix(digits, region.TrunkPrefix)
+	case strings.HasPrefix(digits, region.CallingCode) && region.valid(strings.TrimPrefix(digits, region.CallingCode)):
+		// The calling code was typed without the leading +
+		digits = strings.TrimPrefix(digits, region.CallingCode)
+	default:
+		return "", ErrInvalidNumber
+	}
+	return "+" + region.CallingCode + digits, nil
+}
+
+func normalizeInternational(digits string) (string, error) {
+	if len(digits) < minDigits || len(digits) > maxDigits || digits[0] == '0' {
+		return "", ErrInvalidNumber
+	}
+	// Calling codes are prefix free, so at most one length matches
+	for n := 1; n <= 3; n++ {
+		code, national := digits[:n], digits[n:]
+		known := false
+		for _, region := range regions {
+			if region.CallingCode != code {
+				continue
+			}
+			known = true
+			if region.valid(national) {
+				return "+" + digits, nil
+			}
+		}
+		if known {
+			return "", ErrInvalidNumber
+		}
+	}
+	return "+" + digits, nil
+}
+
+func (r Region) valid(national string) bool {
+	for _, length := range r.Lengths {
+		if len(national) == length {
+			return national[0] != '0' || r.TrunkPrefix == ""
+		}
+	}
+	return false
+}
+
+// clean strips formatting from number and reports whether it was written in
+// international form.
+func clean(number string) (digits string, international bool, ok bool) {
+	number = strings.TrimSpace(number)
+	if strings.HasPrefix(number, "+") {
+		number, international = number[1:], true
+	}
+	var b strings.Builder
+	for _, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case strings.ContainsRune(" -./()", r):
+		default:
+			return "", false, false
+		}
+	}
+	digits = b.String()
+	if !international && strings.HasPrefix(digits, "00") {
+		digits, international = digits[2:], true
+	}
+	return digits, international, digits != ""
+}
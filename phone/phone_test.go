@@ -0,0 +1,49 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		number string
+		region string
+		want   string
+	}{
+		{number: "8123467890", region: "IN", want: "+918123467890"},
+		{number: "08123467890", region: "IN", want: "+918123467890"},
+		{number: "91 81234 67890", region: "IN", want: "+918123467890"},
+		{number: "+44 20 7946 0958", region: "IN", want: "+442079460958"},
+		{number: "0044 7700 900123", region: "IN", want: "+447700900123"},
+		{number: "07700 900123", region: "gb", want: "+447700900123"},
+		{number: "(555) 123-4567", region: "US", want: "+15551234567"},
+		{number: "1-555-123-4567", region: "US", want: "+15551234567"},
+		{number: "+7 912 345 67 89", region: "IN", want: "+79123456789"},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.number, tt.region)
+		require.NoError(t, err, tt.number)
+		require.Equal(t, tt.want, got, tt.number)
+	}
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	tests := []struct {
+		number string
+		region string
+	}{
+		{number: "812346789", region: "IN"},
+		{number: "+91 812346789", region: "GB"},
+		{number: "+44 0207946095", region: "IN"},
+		{number: "81234x67890", region: "IN"},
+		{number: "+1234567890123456", region: "IN"},
+		{number: "8123467890", region: "XX"},
+		{number: "", region: "IN"},
+	}
+	for _, tt := range tests {
+		_, err := Normalize(tt.number, tt.region)
+		require.Equal(t, ErrInvalidNumber, err, tt.number)
+	}
+}
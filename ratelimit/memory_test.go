@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	now := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := PerMinute(60, 3)
+
+	t.Run("Allows a burst then rejects", func(t *testing.T) {
+		for i := 2; i >= 0; i-- {
+			result, err := store.Take(context.Background(), "ip:1.2.3.4", limit)
+			require.NoError(t, err)
+			require.True(t, result.Allowed)
+			require.Equal(t, 3, result.Limit)
+			require.Equal(t, i, result.Remaining)
+		}
+
+		result, err := store.Take(context.Background(), "ip:1.2.3.4", limit)
+		require.NoError(t, err)
+		require.False(t, result.Allowed)
+		require.Equal(t, time.Second, result.RetryAfter)
+		require.Equal(t, 3*time.Second, result.ResetAfter)
+	})
+
+	t.Run("Keys are independent", func(t *testing.T) {
+		result, err := store.Take(context.Background(), "ip:5.6.7.8", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	})
+
+	t.Run("Refills over time", func(t *testing.T) {
+		now = now.Add(time.Second)
+		result, err := store.Take(context.Background(), "ip:1.2.3.4", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, 0, result.Remaining)
+	})
+
+	t.Run("Full buckets are swept", func(t *testing.T) {
+		now = now.Add(2 * sweepInterval)
+		_, err := store.Take(context.Background(), "ip:9.9.9.9", limit)
+		require.NoError(t, err)
+		require.Len(t, store.buckets, 1)
+	})
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit describes a token bucket: it holds at most Burst tokens and refills
+// at Rate tokens per second. Every request takes one token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute returns a limit allowing n requests per minute with bursts of up
+// to burst requests.
+func PerMinute(n int, burst int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+// Result is the outcome of taking a token, with what is needed to fill in the
+// RateLimit-* and Retry-After response headers.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, zero when allowed
+}
+
+// Store keeps bucket state per key. Implementations must be safe for
+// concurrent use, and Take must be atomic per key so that replicas sharing a
+// store (e.g. Redis running the refill as a script) enforce a single limit.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// take applies the token bucket algorithm to a bucket holding tokens that was
+// last refilled elapsed ago, and returns the bucket's new token count.
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	burst := float64(limit.Burst)
+	tokens += elapsed.Seconds() * limit.Rate
+	if tokens > burst {
+		tokens = burst
+	}
+
+	result := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	result.Remaining = int(tokens)
+	result.ResetAfter = secondsToDuration((burst - tokens) / limit.Rate)
+	return tokens, result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/tracing"
+	"strconv"
+	"strings"
+)
+
+// MaxActivityPageSize caps how many transactions ListActivity returns at once.
+const MaxActivityPageSize = 100
+
+const cursorPrefix = "txn:"
+
+// ListActivity returns up to first of the user's transactions, newest first.
+// after is the NextCursor of the previous page, empty for the first page.
+func (w *walletService) ListActivity(ctx context.Context, userID int64, first int, after string) (page domain.ActivityPage, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ListActivity")
+	defer tracing.End(span, &err)
+	var fieldErrs errors.ValidationErrors
+	if first < 1 || first > MaxActivityPageSize {
+		fieldErrs = append(fieldErrs, errors.FieldError{Field: "first", Code: errors.CodeInvalid, Err: errors.ErrInvalidPageSize})
+	}
+	var beforeID int64
+	if after != "" {
+		beforeID, err = decodeCursor(after)
+		if err != nil {
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: "after", Code: errors.CodeInvalid, Err: err})
+		}
+	}
+	if err = fieldErrs.OrNil(); err != nil {
+		return domain.ActivityPage{}, err
+	}
+
+	// Fetch one extra row to learn whether another page follows.
+	transactions, err := w.store.ListRecentTransactions(ctx, userID, beforeID, first+1)
+	if err != nil {
+		return domain.ActivityPage{}, err
+	}
+	page.Transactions = transactions
+	if len(transactions) > first {
+		page.Transactions = transactions[:first]
+		page.NextCursor = encodeCursor(page.Transactions[first-1].ID)
+	}
+	return page, nil
+}
+
+// GetWalletsByID returns the wallets among walletIDs that belong to the user.
+// IDs of other users' wallets are silently left out.
+func (w *walletService) GetWalletsByID(ctx context.Context, userID int64, walletIDs []int64) (wallets []domain.Wallet, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetWalletsByID")
+	defer tracing.End(span, &err)
+	return w.store.GetWalletsByID(ctx, userID, walletIDs)
+}
+
+// ListTransactionsSince returns up to limit of the user's transactions
+// recorded after the one with ID afterID, oldest first. Transaction IDs only
+// grow, so clients can use the last one they saw to catch up.
+func (w *walletService) ListTransactionsSince(ctx context.Context, userID int64, afterID int64, limit int) (transactions []domain.Transaction, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ListTransactionsSince")
+	defer tracing.End(span, &err)
+	return w.store.ListTransactionsSince(ctx, userID, afterID, limit)
+}
+
+// encodeCursor makes an opaque cursor pointing just past the transaction, so
+// clients don't come to rely on it being an ID.
+func encodeCursor(transactionID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(transactionID, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, errors.ErrInvalidCursor
+	}
+	transactionID, err := strconv.ParseInt(strings.TrimPrefix(string(raw), cursorPrefix), 10, 64)
+	if err != nil || transactionID <= 0 {
+		return 0, errors.ErrInvalidCursor
+	}
+	return transactionID, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *ServiceTestSuite) TestWalletService_ListActivity() {
+	t := suite.T()
+	transactions := []domain.Transaction{{ID: 9}, {ID: 8}, {ID: 7}}
+	suite.repository.On("ListRecentTransactions", mock.Anything, int64(1), int64(0), 3).Return(transactions, nil).Once()
+
+	page, err := suite.service.ListActivity(context.Background(), 1, 2, "")
+	require.NoError(t, err)
+	require.Equal(t, transactions[:2], page.Transactions)
+	require.Equal(t, encodeCursor(8), page.NextCursor)
+
+	suite.repository.On("ListRecentTransactions", mock.Anything, int64(1), int64(8), 3).Return(transactions[2:], nil).Once()
+	page, err = suite.service.ListActivity(context.Background(), 1, 2, page.NextCursor)
+	require.NoError(t, err)
+	require.Equal(t, transactions[2:], page.Transactions)
+	require.Empty(t, page.NextCursor)
+
+	_, err = suite.service.ListActivity(context.Background(), 1, 0, "not a cursor")
+	var fieldErrs errors.ValidationErrors
+	require.True(t, stderrors.As(err, &fieldErrs))
+	require.Equal(t, errors.ValidationErrors{
+		{Field: "first", Code: errors.CodeInvalid, Err: errors.ErrInvalidPageSize},
+		{Field: "after", Code: errors.CodeInvalid, Err: errors.ErrInvalidCursor},
+	}, fieldErrs)
+
+	_, err = suite.service.ListActivity(context.Background(), 1, MaxActivityPageSize+1, "")
+	require.ErrorIs(t, err, errors.ErrInvalidPageSize)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetWalletsByID() {
+	t := suite.T()
+	wallets := []domain.Wallet{{ID: 3, UserID: 1}}
+	suite.repository.On("GetWalletsByID", mock.Anything, int64(1), []int64{3, 4}).Return(wallets, nil).Once()
+
+	got, err := suite.service.GetWalletsByID(context.Background(), 1, []int64{3, 4})
+	require.NoError(t, err)
+	require.Equal(t, wallets, got)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ListTransactionsSince() {
+	t := suite.T()
+	transactions := []domain.Transaction{{ID: 8}, {ID: 9}}
+	suite.repository.On("ListTransactionsSince", mock.Anything, int64(1), int64(7), 50).Return(transactions, nil).Once()
+
+	got, err := suite.service.ListTransactionsSince(context.Background(), 1, 7, 50)
+	require.NoError(t, err)
+	require.Equal(t, transactions, got)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	transactionID, err := decodeCursor(encodeCursor(42))
+	require.NoError(t, err)
+	require.Equal(t, int64(42), transactionID)
+
+	for _, cursor := range []string{"42", "dHhuOg", "dHhuOi0x", "!!"} {
+		_, err := decodeCursor(cursor)
+		require.Equal(t, errors.ErrInvalidCursor, err, cursor)
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/tracing"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+)
+
+// adjustmentPrefix marks ledger entries made by operators.
+const adjustmentPrefix = "Adjustment: "
+
+// The operations below are for operators acting on any user's account. They
+// skip the checks that protect users, such as contact verification and
+// two-factor step-up, so each one requires a reason and is logged.
+
+// LookupUser finds a user by email or phone number. Phone numbers without a
+// country code are read as numbers of the configured region.
+func (w *walletService) LookupUser(ctx context.Context, contact string) (profile domain.UserProfile, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.LookupUser")
+	defer tracing.End(span, &err)
+	if strings.Contains(contact, "@") {
+		contact, err = NormalizeEmail(contact)
+	} else {
+		contact, err = NormalizePhoneNumber(contact, w.phoneRegion)
+	}
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	user, err := w.store.FindUser(ctx, contact)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	return domain.NewUserProfile(user), nil
+}
+
+// AdjustWallet credits or debits a user's wallet on an operator's behalf.
+// Frozen wallets can be adjusted, closed ones cannot, and debits never take
+// the balance below zero.
+func (w *walletService) AdjustWallet(ctx context.Context, adjustment domain.WalletAdjustment) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.AdjustWallet")
+	defer tracing.End(span, &err)
+	if adjustment.Type != domain.TransactionCredit && adjustment.Type != domain.TransactionDebit {
+		return errors.ErrInvalidAdjustmentType
+	}
+	err = ValidateAmount(adjustment.Amount, w.maxAmount)
+	if err != nil {
+		return err
+	}
+	reason := strings.TrimSpace(adjustment.Reason)
+	if reason == "" {
+		return errors.ErrReasonRequired
+	}
+	wallet, err := w.operatedWallet(ctx, adjustment.UserID)
+	if err != nil {
+		return err
+	}
+	amount := adjustment.Amount
+	// The store refuses debits that would overdraw the wallet
+	if adjustment.Type == domain.TransactionDebit {
+		amount = -amount
+	}
+	err = w.store.AdjustWallet(ctx, adjustment.UserID, amount, adjustmentPrefix+reason)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logger.Fields{
+		"user_id":   adjustment.UserID,
+		"wallet_id": wallet.ID,
+		"type":      adjustment.Type,
+		"amount":    adjustment.Amount,
+		"reason":    reason,
+		"operator":  adjustment.Operator,
+	}).Info("Wallet adjusted by operator")
+	return nil
+}
+
+// ChangeWalletStatus freezes or unfreezes a user's wallet on an operator's
+// behalf. Closed wallets stay closed.
+func (w *walletService) ChangeWalletStatus(ctx context.Context, change domain.WalletStatusChange) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ChangeWalletStatus")
+	defer tracing.End(span, &err)
+	if change.Status != domain.WalletStatusActive && change.Status != domain.WalletStatusFrozen {
+		return errors.ErrInvalidWalletStatus
+	}
+	reason := strings.TrimSpace(change.Reason)
+	if reason == "" {
+		return errors.ErrReasonRequired
+	}
+	wallet, err := w.operatedWallet(ctx, change.UserID)
+	if err != nil {
+		return err
+	}
+	if wallet.Status == change.Status {
+		return nil
+	}
+	err = w.store.SetWalletStatus(ctx, wallet.ID, change.Status)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logger.Fields{
+		"user_id":   change.UserID,
+		"wallet_id": wallet.ID,
+		"from":      wallet.Status,
+		"to":        change.Status,
+		"reason":    reason,
+		"operator":  change.Operator,
+	}).Info("Wallet status changed by operator")
+	return nil
+}
+
+// ExportUserData collects the user's profile, wallet and whole transaction
+// history. Wallet is nil for users without one.
+func (w *walletService) ExportUserData(ctx context.Context, userID int64) (export domain.UserExport, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ExportUserData")
+	defer tracing.End(span, &err)
+	export.ExportedAt = time.Now().UTC()
+	user, err := w.store.GetUser(ctx, userID)
+	if err != nil {
+		return domain.UserExport{}, err
+	}
+	export.Profile = domain.NewUserProfile(user)
+	wallet, err := w.store.GetWallet(ctx, userID)
+	if err != nil {
+		return domain.UserExport{}, errors.ErrFetchingWallet
+	}
+	if wallet.ID != 0 {
+		export.Wallet = &wallet
+	}
+	export.Transactions, err = w.store.ListTransactions(ctx, userID, time.Time{}, export.ExportedAt.Add(time.Second))
+	if err != nil {
+		return domain.UserExport{}, err
+	}
+	return export, nil
+}
+
+// operatedWallet returns the user's wallet if operators may still act on it.
+func (w *walletService) operatedWallet(ctx context.Context, userID int64) (domain.Wallet, error) {
+	wallet, err := w.store.GetWallet(ctx, userID)
+	if err != nil {
+		return domain.Wallet{}, errors.ErrFetchingWallet
+	}
+	if wallet.ID == 0 {
+		return domain.Wallet{}, errors.ErrNoWallet
+	}
+	if wallet.Status == domain.WalletStatusClosed {
+		return domain.Wallet{}, errors.ErrWalletClosed
+	}
+	return wallet, nil
+}
+
+// WriteUserExport renders export to out. CSV holds the transactions only, one
+// per row, as the profile and wallet do not fit its rows.
+func WriteUserExport(out io.Writer, export domain.UserExport, format string) error {
+	switch format {
+	case ExportFormatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(export)
+	case ExportFormatCSV:
+		return writeTransactionsCSV(out, export.Transactions)
+	}
+	return errors.ErrInvalidExportFormat
+}
+
+func writeTransactionsCSV(out io.Writer, transactions []domain.Transaction) error {
+	w := csv.NewWriter(out)
+	records := [][]string{{"transaction_id", "wallet_id", "created_at", "type", "description", "amount", "balance_after"}}
+	for _, txn := range transactions {
+		records = append(records, []string{
+			fmt.Sprint(txn.ID),
+			fmt.Sprint(txn.WalletID),
+			txn.CreatedAt.UTC().Format(time.RFC3339),
+			txn.Type,
+			txn.Description,
+			formatAmount(txn.Amount),
+			formatAmount(txn.BalanceAfter),
+		})
+	}
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+	return w.Error()
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *ServiceTestSuite) TestWalletService_LookupUser() {
+	t := suite.T()
+	user := domain.User{ID: 1, Name: "John Doe", Email: "john@mail.com", PhoneNumber: "+918123467890"}
+	suite.repository.On("FindUser", mock.Anything, "john@mail.com").Return(user, nil).Once()
+	suite.repository.On("FindUser", mock.Anything, "+918123467890").Return(user, nil).Once()
+
+	profile, err := suite.service.LookupUser(context.Background(), " John@Mail.com")
+	require.NoError(t, err)
+	require.Equal(t, domain.NewUserProfile(user), profile)
+	_, err = suite.service.LookupUser(context.Background(), "8123467890")
+	require.NoError(t, err)
+
+	_, err = suite.service.LookupUser(context.Background(), "not a number")
+	require.Equal(t, errors.ErrInvalidPhoneNumber, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_AdjustWallet() {
+	t := suite.T()
+	wallets := map[int64]domain.Wallet{
+		1: {ID: 11, UserID: 1, Balance: 100, Status: domain.WalletStatusFrozen},
+		2: {ID: 12, UserID: 2, Status: domain.WalletStatusClosed},
+		3: {},
+	}
+	for userID, wallet := range wallets {
+		suite.repository.On("GetWallet", mock.Anything, userID).Return(wallet, nil)
+	}
+	suite.repository.On("AdjustWallet", mock.Anything, int64(1), 25.5, "Adjustment: refund for ticket 12").Return(nil).Once()
+	suite.repository.On("AdjustWallet", mock.Anything, int64(1), -100.0, "Adjustment: duplicate top-up").Return(nil).Once()
+	suite.repository.On("AdjustWallet", mock.Anything, int64(1), -100.01, "Adjustment: duplicate top-up").Return(errors.ErrInsufficientBalance).Once()
+
+	tests := []struct {
+		name       string
+		adjustment domain.WalletAdjustment
+		wantErr    error
+	}{
+		{name: "credits frozen wallets", adjustment: domain.WalletAdjustment{UserID: 1, Type: domain.TransactionCredit, Amount: 25.5, Reason: " refund for ticket 12 "}},
+		{name: "debits the whole balance", adjustment: domain.WalletAdjustment{UserID: 1, Type: domain.TransactionDebit, Amount: 100, Reason: "duplicate top-up"}},
+		{name: "never overdraws", adjustment: domain.WalletAdjustment{UserID: 1, Type: domain.TransactionDebit, Amount: 100.01, Reason: "duplicate top-up"}, wantErr: errors.ErrInsufficientBalance},
+		{name: "requires a reason", adjustment: domain.WalletAdjustment{UserID: 1, Type: domain.TransactionCredit, Amount: 10, Reason: " "}, wantErr: errors.ErrReasonRequired},
+		{name: "validates the amount", adjustment: domain.WalletAdjustment{UserID: 1, Type: domain.TransactionCredit, Amount: -10, Reason: "refund"}, wantErr: errors.ErrAmountNotPositive},
+		{name: "validates the type", adjustment: domain.WalletAdjustment{UserID: 1, Type: "refund", Amount: 10, Reason: "refund"}, wantErr: errors.ErrInvalidAdjustmentType},
+		{name: "leaves closed wallets alone", adjustment: domain.WalletAdjustment{UserID: 2, Type: domain.TransactionCredit, Amount: 10, Reason: "refund"}, wantErr: errors.ErrWalletClosed},
+		{name: "needs a wallet", adjustment: domain.WalletAdjustment{UserID: 3, Type: domain.TransactionCredit, Amount: 10, Reason: "refund"}, wantErr: errors.ErrNoWallet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := suite.service.AdjustWallet(context.Background(), tt.adjustment)
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ChangeWalletStatus() {
+	t := suite.T()
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 11, Status: domain.WalletStatusActive}, nil)
+	suite.repository.On("GetWallet", mock.Anything, int64(2)).Return(domain.Wallet{ID: 12, Status: domain.WalletStatusClosed}, nil)
+	suite.repository.On("SetWalletStatus", mock.Anything, int64(11), domain.WalletStatusFrozen).Return(nil).Once()
+
+	err := suite.service.ChangeWalletStatus(context.Background(), domain.WalletStatusChange{UserID: 1, Status: domain.WalletStatusFrozen, Reason: "chargeback"})
+	require.NoError(t, err)
+	err = suite.service.ChangeWalletStatus(context.Background(), domain.WalletStatusChange{UserID: 1, Status: domain.WalletStatusActive, Reason: "resolved"})
+	require.NoError(t, err, "changing to the current status is a no-op")
+	err = suite.service.ChangeWalletStatus(context.Background(), domain.WalletStatusChange{UserID: 1, Status: domain.WalletStatusFrozen})
+	require.Equal(t, errors.ErrReasonRequired, err)
+	err = suite.service.ChangeWalletStatus(context.Background(), domain.WalletStatusChange{UserID: 1, Status: domain.WalletStatusClosed, Reason: "chargeback"})
+	require.Equal(t, errors.ErrInvalidWalletStatus, err)
+	err = suite.service.ChangeWalletStatus(context.Background(), domain.WalletStatusChange{UserID: 2, Status: domain.WalletStatusActive, Reason: "reopen"})
+	require.Equal(t, errors.ErrWalletClosed, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ExportUserData() {
+	t := suite.T()
+	user := domain.User{ID: 1, Name: "John Doe", Email: "john@mail.com"}
+	wallet := domain.Wallet{ID: 11, UserID: 1, Balance: 50}
+	transactions := []domain.Transaction{{ID: 3, WalletID: 11, Amount: 50}}
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(user, nil).Once()
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(wallet, nil).Once()
+	suite.repository.On("ListTransactions", mock.Anything, int64(1), time.Time{}, mock.Anything).Return(transactions, nil).Once()
+
+	export, err := suite.service.ExportUserData(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, domain.NewUserProfile(user), export.Profile)
+	require.Equal(t, &wallet, export.Wallet)
+	require.Equal(t, transactions, export.Transactions)
+
+	suite.repository.On("GetUser", mock.Anything, int64(2)).Return(domain.User{}, errors.ErrUserNotFound).Once()
+	_, err = suite.service.ExportUserData(context.Background(), 2)
+	require.Equal(t, errors.ErrUserNotFound, err)
+}
+
+func TestWriteUserExport(t *testing.T) {
+	createdAt := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	export := domain.UserExport{
+		ExportedAt: createdAt,
+		Profile:    domain.UserProfile{ID: 1, Name: "John Doe"},
+		Transactions: []domain.Transaction{
+			{ID: 3, WalletID: 11, Type: domain.TransactionCredit, Amount: 50, BalanceAfter: 50, Description: "Wallet credit", CreatedAt: createdAt},
+			{ID: 4, WalletID: 11, Type: domain.TransactionDebit, Amount: -20, BalanceAfter: 30, Description: "Adjustment: fee, refunded", CreatedAt: createdAt.Add(time.Hour)},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteUserExport(&out, export, ExportFormatCSV))
+	assert.Equal(t, "transaction_id,wallet_id,created_at,type,description,amount,balance_after\n"+
+		"3,11,2024-05-01T10:00:00Z,credit,Wallet credit,50.00,50.00\n"+
+		"4,11,2024-05-01T11:00:00Z,debit,\"Adjustment: fee, refunded\",-20.00,30.00\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WriteUserExport(&out, export, ExportFormatJSON))
+	assert.Contains(t, out.String(), `"wallet": null`)
+
+	assert.Equal(t, errors.ErrInvalidExportFormat, WriteUserExport(&out, export, "pdf"))
+}
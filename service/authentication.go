@@ -2,6 +2,8 @@ package service
 
 import (
 	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"strconv"
 	"time"
 	jwt "github.com/dgrijalva/jwt-go"
 )
@@ -12,8 +14,77 @@ func GenerateToken(loginResponse domain.LoginDbResponse) (string, error) {
 	tokenExpirationTime := time.Now().Add(time.Minute * 30)
 	tokenObject := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id": loginResponse.ID,
+		"sv":      loginResponse.SessionVersion,
 		"exp":     tokenExpirationTime.Unix(),
 	})
 	token, err := tokenObject.SignedString(secretKey)
 	return token, err
 }
+
+// ParseAccessToken returns the user ID and session version of a valid,
+// unexpired access token issued by GenerateToken. Callers still have to
+// check the session version is current with ValidateSession.
+func ParseAccessToken(token string) (userID int64, sessionVersion int64, err error) {
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return secretKey, nil
+	})
+	if err != nil || !parsed.Valid {
+		return 0, 0, errors.ErrInvalidAccessToken
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, 0, errors.ErrInvalidAccessToken
+	}
+	// JSON numbers decode as float64
+	id, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, 0, errors.ErrInvalidAccessToken
+	}
+	// Tokens issued before session versions existed carry none, which
+	// matches version 0
+	version, _ := claims["sv"].(float64)
+	return int64(id), int64(version), nil
+}
+
+// mfaTokenPurpose marks MFA challenge tokens. They carry no user_id claim so
+// authMiddleware never accepts them as access tokens.
+const mfaTokenPurpose = "mfa"
+
+// mfaTokenTTL bounds how long a user has to enter their two-factor code
+// after the password step.
+const mfaTokenTTL = 5 * time.Minute
+
+func GenerateMFAToken(userID int64) (string, error) {
+	tokenObject := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     strconv.FormatInt(userID, 10),
+		"purpose": mfaTokenPurpose,
+		"exp":     time.Now().Add(mfaTokenTTL).Unix(),
+	})
+	return tokenObject.SignedString(secretKey)
+}
+
+// ParseMFAToken returns the user ID an MFA challenge token was issued for.
+func ParseMFAToken(token string) (int64, error) {
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return secretKey, nil
+	})
+	if err != nil || !parsed.Valid {
+		return 0, errors.ErrInvalidMFAToken
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != mfaTokenPurpose {
+		return 0, errors.ErrInvalidMFAToken
+	}
+	subject, _ := claims["sub"].(string)
+	userID, err := strconv.ParseInt(subject, 10, 64)
+	if err != nil {
+		return 0, errors.ErrInvalidMFAToken
+	}
+	return userID, nil
+}
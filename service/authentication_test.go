@@ -2,7 +2,12 @@ package service
 
 import (
 	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"strings"
 	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
 )
 
 func TestGenerateToken(t *testing.T) {
@@ -21,9 +26,11 @@ func TestGenerateToken(t *testing.T) {
 				loginResponse: domain.LoginDbResponse{
 					ID: 1,
 					Password: "12345678",
+					SessionVersion: 2,
 				},
 			},
-			want: "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJsb2dp",
+			// The header of an HS256 JWT, the claims hold the expiry
+			want: "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.",
 			wantErr: false,
 		},
 	}
@@ -34,9 +41,50 @@ func TestGenerateToken(t *testing.T) {
 				t.Errorf("GenerateToken() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if got != tt.want {
-				t.Errorf("GenerateToken() = %v, want %v", got, tt.want)
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("GenerateToken() = %v, want prefix %v", got, tt.want)
+			}
+			userID, sessionVersion, err := ParseAccessToken(got)
+			if err != nil || userID != tt.args.loginResponse.ID || sessionVersion != tt.args.loginResponse.SessionVersion {
+				t.Errorf("ParseAccessToken() = %v, %v, %v, want %v, %v", userID, sessionVersion, err, tt.args.loginResponse.ID, tt.args.loginResponse.SessionVersion)
+			}
+		})
+	}
+}
+
+func TestParseAccessToken(t *testing.T) {
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secretKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	accessToken, _ := GenerateToken(domain.LoginDbResponse{ID: 42, SessionVersion: 3})
+	mfaToken, _ := GenerateMFAToken(42)
+
+	tests := []struct {
+		name        string
+		token       string
+		wantID      int64
+		wantVersion int64
+		wantErr     error
+	}{
+		{name: "access token", token: accessToken, wantID: 42, wantVersion: 3},
+		{name: "token without session version", token: sign(jwt.MapClaims{"user_id": 42, "exp": time.Now().Add(time.Minute).Unix()}), wantID: 42},
+		{name: "expired token", token: sign(jwt.MapClaims{"user_id": 42, "exp": time.Now().Add(-time.Minute).Unix()}), wantErr: errors.ErrInvalidAccessToken},
+		{name: "mfa token", token: mfaToken, wantErr: errors.ErrInvalidAccessToken},
+		{name: "malformed token", token: "nope", wantErr: errors.ErrInvalidAccessToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID, sessionVersion, err := ParseAccessToken(tt.token)
+			if err != tt.wantErr {
+				t.Fatalf("ParseAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if userID != tt.wantID || sessionVersion != tt.wantVersion {
+				t.Errorf("ParseAccessToken() = %v, %v, want %v, %v", userID, sessionVersion, tt.wantID, tt.wantVersion)
 			}
 		})
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"math"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/metrics"
+	"nickPay/wallet/internal/tracing"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// CreateCampaign validates and stores a cashback campaign. It starts paying
+// rewards at its StartsAt.
+func (w *walletService) CreateCampaign(ctx context.Context, campaign domain.Campaign) (created domain.Campaign, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.CreateCampaign")
+	defer tracing.End(span, &err)
+	campaign, err = validateCampaign(campaign)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+	campaignID, err := w.store.CreateCampaign(ctx, campaign)
+	if err != nil {
+		return domain.Campaign{}, err
+	}
+	logging.FromContext(ctx).WithFields(logger.Fields{"campaign_id": campaignID, "budget": campaign.Budget}).Info("Cashback campaign created")
+	return w.store.GetCampaign(ctx, campaignID)
+}
+
+func (w *walletService) ListCampaigns(ctx context.Context) (campaigns []domain.Campaign, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ListCampaigns")
+	defer tracing.End(span, &err)
+	return w.store.ListCampaigns(ctx)
+}
+
+// validateCampaign trims the fields of campaign, clears those only the store
+// sets and reports every invalid one.
+func validateCampaign(campaign domain.Campaign) (domain.Campaign, error) {
+	var errs errors.ValidationErrors
+	campaign.ID, campaign.Spent, campaign.CreatedAt = 0, 0, time.Time{}
+	campaign.Name = strings.TrimSpace(campaign.Name)
+	if campaign.Name == "" {
+		errs = append(errs, errors.FieldError{Field: "name", Code: errors.CodeRequired, Err: errors.ErrCampaignNameRequired})
+	}
+	if !isCampaignOperation(campaign.Operation) {
+		errs = append(errs, errors.FieldError{Field: "operation", Code: requiredOrInvalid(campaign.Operation), Err: errors.ErrInvalidCampaignOperation})
+	}
+	if campaign.Percent <= 0 || campaign.Percent > 100 {
+		errs = append(errs, errors.FieldError{Field: "percent", Code: errors.CodeInvalid, Err: errors.ErrInvalidCashbackPercent})
+	}
+	limits := []struct {
+		field string
+		value float64
+	}{{"min_amount", campaign.MinAmount}, {"max_reward", campaign.MaxReward}, {"user_limit", campaign.UserLimit}}
+	for _, limit := range limits {
+		if limit.value < 0 {
+			errs = append(errs, errors.FieldError{Field: limit.field, Code: errors.CodeInvalid, Err: errors.ErrInvalidCampaignLimit})
+		}
+	}
+	if err := ValidateAmount(campaign.Budget, 0); err != nil {
+		errs = append(errs, errors.FieldError{Field: "budget", Code: err.(errors.ValidationErrors)[0].Code, Err: errors.ErrInvalidCampaignBudget})
+	}
+	if campaign.RewardValidityDays < 1 {
+		errs = append(errs, errors.FieldError{Field: "reward_validity_days", Code: errors.CodeInvalid, Err: errors.ErrInvalidRewardValidity})
+	}
+	if campaign.StartsAt.IsZero() || !campaign.EndsAt.After(campaign.StartsAt) {
+		errs = append(errs, errors.FieldError{Field: "ends_at", Code: errors.CodeInvalid, Err: errors.ErrInvalidCampaignPeriod})
+	}
+	return campaign, errs.OrNil()
+}
+
+func isCampaignOperation(operation string) bool {
+	switch operation {
+	case domain.CampaignOperationDebit, domain.CampaignOperationTransfer:
+		return true
+	}
+	return false
+}
+
+// grantCashback rewards a successful operation of amount with every running
+// campaign it is eligible for. The operation already happened, so failures
+// are logged and the reward skipped rather than returned.
+func (w *walletService) grantCashback(ctx context.Context, userID int64, operation string, amount float64) {
+	now := time.Now()
+	campaigns, err := w.store.ListActiveCampaigns(ctx, operation, now)
+	if err != nil {
+		return
+	}
+	for _, campaign := range campaigns {
+		if amount < campaign.MinAmount {
+			continue
+		}
+		reward := roundCents(amount * campaign.Percent / 100)
+		if campaign.MaxReward > 0 {
+			reward = math.Min(reward, campaign.MaxReward)
+		}
+		if reward <= 0 {
+			continue
+		}
+		granted, err := w.store.GrantReward(ctx, domain.PromoReward{
+			UserID:       userID,
+			CampaignID:   campaign.ID,
+			SourceAmount: amount,
+			Amount:       reward,
+			ExpiresAt:    now.AddDate(0, 0, campaign.RewardValidityDays),
+		})
+		if err != nil || granted == 0 {
+			continue
+		}
+		metrics.ObserveCashback(granted)
+		logging.FromContext(ctx).WithFields(logger.Fields{"user_id": userID, "campaign_id": campaign.ID, "amount": granted}).Info("Cashback granted")
+	}
+}
+
+// GetPromoBalance returns the user's unexpired cashback. It is kept apart
+// from the wallet balance and can not be withdrawn.
+func (w *walletService) GetPromoBalance(ctx context.Context, userID int64) (balance domain.PromoBalance, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetPromoBalance")
+	defer tracing.End(span, &err)
+	rewards, err := w.store.ListPromoRewards(ctx, userID, time.Now())
+	if err != nil {
+		return domain.PromoBalance{}, err
+	}
+	balance.Rewards = rewards
+	for _, reward := range rewards {
+		balance.Balance += reward.Amount
+	}
+	balance.Balance = roundCents(balance.Balance)
+	return balance, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCampaign(t *testing.T) {
+	startsAt := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	got, err := validateCampaign(domain.Campaign{ID: 9, Name: " Week cashback ", Operation: domain.CampaignOperationDebit, Percent: 5, MaxReward: 100, Budget: 5000, Spent: 10, RewardValidityDays: 30, StartsAt: startsAt, EndsAt: startsAt.AddDate(0, 0, 7)})
+	require.NoError(t, err)
+	assert.Equal(t, domain.Campaign{Name: "Week cashback", Operation: domain.CampaignOperationDebit, Percent: 5, MaxReward: 100, Budget: 5000, RewardValidityDays: 30, StartsAt: startsAt, EndsAt: startsAt.AddDate(0, 0, 7)}, got)
+
+	_, err = validateCampaign(domain.Campaign{Name: "QR cashback", Operation: domain.CampaignOperationTransfer, Percent: 5, Budget: 5000, RewardValidityDays: 30, StartsAt: startsAt, EndsAt: startsAt.AddDate(0, 0, 7)})
+	require.NoError(t, err)
+
+	_, err = validateCampaign(domain.Campaign{Operation: "conversion", Percent: 150, MaxReward: -1, RewardValidityDays: 0, StartsAt: startsAt, EndsAt: startsAt})
+	assert.Equal(t, errors.ValidationErrors{
+		{Field: "name", Code: errors.CodeRequired, Err: errors.ErrCampaignNameRequired},
+		{Field: "operation", Code: errors.CodeInvalid, Err: errors.ErrInvalidCampaignOperation},
+		{Field: "percent", Code: errors.CodeInvalid, Err: errors.ErrInvalidCashbackPercent},
+		{Field: "max_reward", Code: errors.CodeInvalid, Err: errors.ErrInvalidCampaignLimit},
+		{Field: "budget", Code: errors.CodeNotPositive, Err: errors.ErrInvalidCampaignBudget},
+		{Field: "reward_validity_days", Code: errors.CodeInvalid, Err: errors.ErrInvalidRewardValidity},
+		{Field: "ends_at", Code: errors.CodeInvalid, Err: errors.ErrInvalidCampaignPeriod},
+	}, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_CreateCampaign() {
+	t := suite.T()
+	startsAt := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	campaign := domain.Campaign{Name: "Week cashback", Operation: domain.CampaignOperationDebit, Percent: 5, Budget: 5000, RewardValidityDays: 30, StartsAt: startsAt, EndsAt: startsAt.AddDate(0, 0, 7)}
+	stored := campaign
+	stored.ID = 3
+	suite.repository.On("CreateCampaign", mock.Anything, campaign).Return(int64(3), nil).Once()
+	suite.repository.On("GetCampaign", mock.Anything, int64(3)).Return(stored, nil).Once()
+
+	got, err := suite.service.CreateCampaign(context.Background(), campaign)
+	require.NoError(t, err)
+	require.Equal(t, stored, got)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_DebitWalletCashback() {
+	t := suite.T()
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Once()
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 3, UserID: 1, Balance: 5000, Status: domain.WalletStatusActive}, nil).Once()
+	suite.repository.On("DebitWallet", mock.Anything, int64(1), 4000.0, domain.FeeCharge{}).Return(nil).Once()
+	suite.repository.On("ListActiveCampaigns", mock.Anything, domain.CampaignOperationDebit, mock.Anything).Return([]domain.Campaign{
+		{ID: 3, Percent: 5, MaxReward: 100, RewardValidityDays: 30},
+		{ID: 4, Percent: 1, RewardValidityDays: 7},
+		{ID: 5, Percent: 10, MinAmount: 5000, RewardValidityDays: 7},
+	}, nil).Once()
+
+	var expiresAt time.Time
+	// 5% of 4000 is capped at 100
+	suite.repository.On("GrantReward", mock.Anything, mock.MatchedBy(func(reward domain.PromoReward) bool {
+		if reward.CampaignID != 3 {
+			return false
+		}
+		expiresAt = reward.ExpiresAt
+		return reward.UserID == 1 && reward.SourceAmount == 4000 && reward.Amount == 100
+	})).Return(100.0, nil).Once()
+	// A failed grant leaves the debit alone
+	suite.repository.On("GrantReward", mock.Anything, mock.MatchedBy(func(reward domain.PromoReward) bool {
+		return reward.CampaignID == 4 && reward.Amount == 40
+	})).Return(0.0, errors.ErrGrantingReward).Once()
+
+	_, err := suite.service.DebitWallet(context.Background(), 1, 4000)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().AddDate(0, 0, 30), expiresAt, time.Minute)
+	suite.repository.AssertExpectations(t)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_PayQRCashback() {
+	t := suite.T()
+	merchant := domain.Merchant{ID: 12, UserID: 7, Name: "Corner Cafe"}
+	suite.repository.On("GetMerchant", mock.Anything, int64(12)).Return(merchant, nil).Once()
+	suite.payerFixture()
+	suite.repository.On("GetMerchantWallet", mock.Anything, int64(12)).Return(domain.Wallet{ID: 5, Status: domain.WalletStatusActive}, nil).Once()
+	suite.repository.On("PayMerchant", mock.Anything, merchant, domain.MerchantPayment{PayerID: 1, Amount: 40}, domain.FeeCharge{}).
+		Return(domain.MerchantPayment{ID: 56, MerchantID: 12, PayerID: 1, Amount: 40}, nil).Once()
+	suite.repository.On("ListActiveCampaigns", mock.Anything, domain.CampaignOperationTransfer, mock.Anything).Return([]domain.Campaign{
+		{ID: 6, Operation: domain.CampaignOperationTransfer, Percent: 5, RewardValidityDays: 30},
+	}, nil).Once()
+	suite.repository.On("GrantReward", mock.Anything, mock.MatchedBy(func(reward domain.PromoReward) bool {
+		return reward.UserID == 1 && reward.CampaignID == 6 && reward.SourceAmount == 40 && reward.Amount == 2
+	})).Return(2.0, nil).Once()
+
+	_, err := suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12", Amount: 40})
+	require.NoError(t, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetPromoBalance() {
+	t := suite.T()
+	rewards := []domain.PromoReward{{ID: 1, CampaignID: 3, Amount: 0.1}, {ID: 2, CampaignID: 4, Amount: 0.2}}
+	suite.repository.On("ListPromoRewards", mock.Anything, int64(1), mock.Anything).Return(rewards, nil).Once()
+
+	balance, err := suite.service.GetPromoBalance(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, domain.PromoBalance{Balance: 0.3, Rewards: rewards}, balance)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/tracing"
+	"strings"
+)
+
+// FeeSchedule holds the fee rule of each operation that is charged for, keyed
+// by domain.FeeOperationDebit, domain.FeeOperationWithdrawal or
+// domain.FeeOperationTransfer. Operations without a rule are free.
+type FeeSchedule map[string]FeeRule
+
+// FeeRule prices one operation. The fee is Flat plus Percent of the amount,
+// or, when Tiers are set, the flat and percentage of the first tier the
+// amount fits in. The result is then raised to Min and capped at Max when
+// they are set.
+type FeeRule struct {
+	Flat    float64   `json:"flat"`
+	Percent float64   `json:"percent"`
+	Min     float64   `json:"min"`
+	Max     float64   `json:"max"`
+	Tiers   []FeeTier `json:"tiers"`
+}
+
+// FeeTier prices amounts up to UpTo, inclusive. The last tier may leave UpTo
+// at zero to cover every larger amount.
+type FeeTier struct {
+	UpTo    float64 `json:"up_to"`
+	Flat    float64 `json:"flat"`
+	Percent float64 `json:"percent"`
+}
+
+// ParseFeeSchedule reads a fee schedule from JSON such as
+//
+//	{"debit": {"percent": 1, "min": 0.5}, "transfer": {"flat": 0.1}, "withdrawal": {"tiers": [{"up_to": 1000, "flat": 5}, {"percent": 0.5}]}}
+//
+// An empty string is an empty schedule, under which nothing is charged.
+func ParseFeeSchedule(raw string) (FeeSchedule, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	var schedule FeeSchedule
+	if err := decoder.Decode(&schedule); err != nil {
+		return nil, fmt.Errorf("invalid fee schedule: %w", err)
+	}
+	for operation, rule := range schedule {
+		if err := rule.validate(operation); err != nil {
+			return nil, fmt.Errorf("invalid fee rule for %q: %w", operation, err)
+		}
+	}
+	return schedule, nil
+}
+
+func (r FeeRule) validate(operation string) error {
+	if !isFeeOperation(operation) {
+		return errors.ErrInvalidFeeOperation
+	}
+	if r.Flat < 0 || r.Percent < 0 || r.Min < 0 || r.Max < 0 {
+		return fmt.Errorf("fees can not be negative")
+	}
+	if r.Max > 0 && r.Min > r.Max {
+		return fmt.Errorf("min %v is above max %v", r.Min, r.Max)
+	}
+	if len(r.Tiers) > 0 && (r.Flat != 0 || r.Percent != 0) {
+		return fmt.Errorf("set either tiers or a flat and percentage fee")
+	}
+	for i, tier := range r.Tiers {
+		if tier.Flat < 0 || tier.Percent < 0 || tier.UpTo < 0 {
+			return fmt.Errorf("tier %d: fees can not be negative", i+1)
+		}
+		last := i == len(r.Tiers)-1
+		if tier.UpTo == 0 && !last {
+			return fmt.Errorf("tier %d: only the last tier can be unbounded", i+1)
+		}
+		if i > 0 && tier.UpTo != 0 && tier.UpTo <= r.Tiers[i-1].UpTo {
+			return fmt.Errorf("tier %d: up_to must be above the previous tier", i+1)
+		}
+	}
+	return nil
+}
+
+func isFeeOperation(operation string) bool {
+	switch operation {
+	case domain.FeeOperationDebit, domain.FeeOperationWithdrawal, domain.FeeOperationTransfer:
+		return true
+	}
+	return false
+}
+
+// Quote prices moving amount through operation. Every line of the breakdown
+// is rounded to cents, so the fee is always their sum.
+func (s FeeSchedule) Quote(operation string, amount float64) domain.FeeQuote {
+	quote := domain.FeeQuote{Operation: operation, Amount: amount, Breakdown: []domain.FeeComponent{}}
+	rule, ok := s[operation]
+	if ok {
+		flat, percent := rule.Flat, rule.Percent
+		if len(rule.Tiers) > 0 {
+			flat, percent = 0, 0
+			for _, tier := range rule.Tiers {
+				if tier.UpTo == 0 || amount <= tier.UpTo {
+					flat, percent = tier.Flat, tier.Percent
+					break
+				}
+			}
+		}
+		if flat > 0 {
+			quote.Breakdown = append(quote.Breakdown, domain.FeeComponent{Kind: domain.FeeFlat, Amount: roundCents(flat)})
+		}
+		if percent > 0 {
+			quote.Breakdown = append(quote.Breakdown, domain.FeeComponent{Kind: domain.FeePercentage, Rate: percent, Amount: roundCents(amount * percent / 100)})
+		}
+		fee := sumFee(quote.Breakdown)
+		switch {
+		case rule.Min > 0 && fee < rule.Min:
+			quote.Breakdown = append(quote.Breakdown, domain.FeeComponent{Kind: domain.FeeMinimum, Amount: roundCents(rule.Min - fee)})
+		case rule.Max > 0 && fee > rule.Max:
+			quote.Breakdown = append(quote.Breakdown, domain.FeeComponent{Kind: domain.FeeMaximum, Amount: roundCents(rule.Max - fee)})
+		}
+	}
+	quote.Fee = sumFee(quote.Breakdown)
+	quote.Total = roundCents(amount + quote.Fee)
+	return quote
+}
+
+func sumFee(breakdown []domain.FeeComponent) float64 {
+	var fee float64
+	for _, component := range breakdown {
+		fee += component.Amount
+	}
+	return roundCents(fee)
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// WithFees charges fees on debits, withdrawals and transfers according to
+// schedule, crediting them to the wallet of the user accountID.
+func WithFees(schedule FeeSchedule, accountID int64) Option {
+	return func(w *walletService) {
+		w.fees = schedule
+		w.feeAccount = accountID
+	}
+}
+
+// quoteFee prices an operation and returns the charge to collect with it.
+func (w *walletService) quoteFee(operation string, amount float64) (domain.FeeQuote, domain.FeeCharge) {
+	quote := w.fees.Quote(operation, amount)
+	return quote, domain.FeeCharge{Amount: quote.Fee, AccountID: w.feeAccount}
+}
+
+// QuoteFee tells what a debit, withdrawal or transfer of an amount would
+// cost, without moving any money.
+func (w *walletService) QuoteFee(ctx context.Context, request domain.QuoteRequest) (quote domain.FeeQuote, err error) {
+	_, span := tracer.Start(ctx, "WalletService.QuoteFee")
+	defer tracing.End(span, &err)
+	var errs errors.ValidationErrors
+	if !isFeeOperation(request.Operation) {
+		errs = append(errs, errors.FieldError{Field: "operation", Code: requiredOrInvalid(request.Operation), Err: errors.ErrInvalidFeeOperation})
+	}
+	if err := ValidateAmount(request.Amount, w.maxAmount); err != nil {
+		errs = append(errs, err.(errors.ValidationErrors)...)
+	}
+	if err := errs.OrNil(); err != nil {
+		return domain.FeeQuote{}, err
+	}
+	quote, _ = w.quoteFee(request.Operation, request.Amount)
+	return quote, nil
+}
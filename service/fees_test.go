@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFeeSchedule(t *testing.T) {
+	schedule, err := ParseFeeSchedule(`{"debit": {"percent": 1, "min": 0.5}, "transfer": {"flat": 0.1}, "withdrawal": {"tiers": [{"up_to": 1000, "flat": 5}, {"percent": 0.5}]}}`)
+	require.NoError(t, err)
+	assert.Equal(t, FeeSchedule{
+		domain.FeeOperationDebit:      {Percent: 1, Min: 0.5},
+		domain.FeeOperationTransfer:   {Flat: 0.1},
+		domain.FeeOperationWithdrawal: {Tiers: []FeeTier{{UpTo: 1000, Flat: 5}, {Percent: 0.5}}},
+	}, schedule)
+
+	schedule, err = ParseFeeSchedule(" ")
+	require.NoError(t, err)
+	assert.Empty(t, schedule)
+
+	for _, raw := range []string{
+		`{"conversion": {"flat": 1}}`,
+		`{"debit": {"flat": -1}}`,
+		`{"debit": {"min": 5, "max": 1}}`,
+		`{"debit": {"flat": 1, "tiers": [{"percent": 1}]}}`,
+		`{"debit": {"tiers": [{"flat": 1}, {"up_to": 100, "flat": 2}]}}`,
+		`{"debit": {"tiers": [{"up_to": 100, "flat": 1}, {"up_to": 50, "flat": 2}]}}`,
+		`{"debit": {"fixed": 1}}`,
+		`not json`,
+	} {
+		_, err := ParseFeeSchedule(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestFeeSchedule_Quote(t *testing.T) {
+	schedule := FeeSchedule{
+		domain.FeeOperationDebit:      {Flat: 0.3, Percent: 2.9, Max: 10},
+		domain.FeeOperationWithdrawal: {Tiers: []FeeTier{{UpTo: 1000, Flat: 5}, {Percent: 0.5}}, Min: 6},
+	}
+	tests := []struct {
+		name      string
+		operation string
+		amount    float64
+		want      domain.FeeQuote
+	}{
+		{
+			name:      "Flat and percentage",
+			operation: domain.FeeOperationDebit,
+			amount:    100,
+			want: domain.FeeQuote{Operation: domain.FeeOperationDebit, Amount: 100, Fee: 3.2, Total: 103.2, Breakdown: []domain.FeeComponent{
+				{Kind: domain.FeeFlat, Amount: 0.3},
+				{Kind: domain.FeePercentage, Rate: 2.9, Amount: 2.9},
+			}},
+		},
+		{
+			name:      "Capped at the maximum",
+			operation: domain.FeeOperationDebit,
+			amount:    1000,
+			want: domain.FeeQuote{Operation: domain.FeeOperationDebit, Amount: 1000, Fee: 10, Total: 1010, Breakdown: []domain.FeeComponent{
+				{Kind: domain.FeeFlat, Amount: 0.3},
+				{Kind: domain.FeePercentage, Rate: 2.9, Amount: 29},
+				{Kind: domain.FeeMaximum, Amount: -19.3},
+			}},
+		},
+		{
+			name:      "Raised to the minimum in the first tier",
+			operation: domain.FeeOperationWithdrawal,
+			amount:    1000,
+			want: domain.FeeQuote{Operation: domain.FeeOperationWithdrawal, Amount: 1000, Fee: 6, Total: 1006, Breakdown: []domain.FeeComponent{
+				{Kind: domain.FeeFlat, Amount: 5},
+				{Kind: domain.FeeMinimum, Amount: 1},
+			}},
+		},
+		{
+			name:      "Unbounded last tier",
+			operation: domain.FeeOperationWithdrawal,
+			amount:    2000.5,
+			want: domain.FeeQuote{Operation: domain.FeeOperationWithdrawal, Amount: 2000.5, Fee: 10, Total: 2010.5, Breakdown: []domain.FeeComponent{
+				{Kind: domain.FeePercentage, Rate: 0.5, Amount: 10},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, schedule.Quote(tt.operation, tt.amount))
+		})
+	}
+
+	free := FeeSchedule(nil).Quote(domain.FeeOperationDebit, 50)
+	assert.Equal(t, domain.FeeQuote{Operation: domain.FeeOperationDebit, Amount: 50, Total: 50, Breakdown: []domain.FeeComponent{}}, free)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_DebitWalletFee() {
+	t := suite.T()
+	service := NewWalletService(suite.repository, WithFees(FeeSchedule{domain.FeeOperationDebit: {Flat: 1}}, 7))
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Times(3)
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 3, UserID: 1, Balance: 100, Status: domain.WalletStatusActive}, nil).Times(3)
+
+	// The balance has to cover the fee as well
+	_, err := service.DebitWallet(context.Background(), 1, 100)
+	require.Equal(t, errors.ErrInsufficientBalance, err)
+
+	suite.repository.On("DebitWallet", mock.Anything, int64(1), 99.0, domain.FeeCharge{Amount: 1, AccountID: 7}).Return(nil).Once()
+	suite.repository.On("ListActiveCampaigns", mock.Anything, domain.CampaignOperationDebit, mock.Anything).Return([]domain.Campaign{}, nil).Once()
+	quote, err := service.DebitWallet(context.Background(), 1, 99)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, quote.Fee)
+	require.Equal(t, 100.0, quote.Total)
+
+	// A concurrent debit got to the balance first
+	suite.repository.On("DebitWallet", mock.Anything, int64(1), 99.0, domain.FeeCharge{Amount: 1, AccountID: 7}).Return(errors.ErrInsufficientBalance).Once()
+	_, err = service.DebitWallet(context.Background(), 1, 99)
+	require.Equal(t, errors.ErrInsufficientBalance, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_PayQRFee() {
+	t := suite.T()
+	service := NewWalletService(suite.repository, WithFees(FeeSchedule{domain.FeeOperationTransfer: {Percent: 1}}, 7))
+	merchant := domain.Merchant{ID: 12, UserID: 7, Name: "Corner Cafe"}
+	suite.repository.On("GetMerchant", mock.Anything, int64(12)).Return(merchant, nil).Twice()
+	suite.payerFixture()
+	suite.payerFixture()
+	suite.repository.On("GetMerchantWallet", mock.Anything, int64(12)).Return(domain.Wallet{ID: 5, Status: domain.WalletStatusActive}, nil).Once()
+
+	// The balance has to cover the fee as well
+	_, err := service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12", Amount: 100})
+	require.Equal(t, errors.ErrInsufficientBalance, err)
+
+	suite.repository.On("PayMerchant", mock.Anything, merchant, domain.MerchantPayment{PayerID: 1, Amount: 50}, domain.FeeCharge{Amount: 0.5, AccountID: 7}).
+		Return(domain.MerchantPayment{ID: 56, MerchantID: 12, PayerID: 1, Amount: 50}, nil).Once()
+	suite.repository.On("ListActiveCampaigns", mock.Anything, domain.CampaignOperationTransfer, mock.Anything).Return([]domain.Campaign{}, nil).Once()
+	payment, err := service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12", Amount: 50})
+	require.NoError(t, err)
+	require.Equal(t, 0.5, payment.Fee)
+	require.Equal(t, []domain.FeeComponent{{Kind: domain.FeePercentage, Rate: 1, Amount: 0.5}}, payment.FeeBreakdown)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_QuoteFee() {
+	t := suite.T()
+	service := NewWalletService(suite.repository, WithFees(FeeSchedule{domain.FeeOperationWithdrawal: {Percent: 1}, domain.FeeOperationTransfer: {Flat: 0.1}}, 7))
+	quote, err := service.QuoteFee(context.Background(), domain.QuoteRequest{Operation: domain.FeeOperationWithdrawal, Amount: 250})
+	require.NoError(t, err)
+	require.Equal(t, 2.5, quote.Fee)
+	require.Equal(t, 252.5, quote.Total)
+
+	quote, err = service.QuoteFee(context.Background(), domain.QuoteRequest{Operation: domain.FeeOperationTransfer, Amount: 20})
+	require.NoError(t, err)
+	require.Equal(t, 0.1, quote.Fee)
+	require.Equal(t, 20.1, quote.Total)
+
+	_, err = service.QuoteFee(context.Background(), domain.QuoteRequest{Operation: "conversion", Amount: -1})
+	require.Equal(t, errors.ValidationErrors{
+		{Field: "operation", Code: errors.CodeInvalid, Err: errors.ErrInvalidFeeOperation},
+		{Field: "amount", Code: errors.CodeNotPositive, Err: errors.ErrAmountNotPositive},
+	}, err)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/tracing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LockoutPolicy controls how failed logins are throttled. Every failure locks
+// its account and client IP for a delay that doubles with each failure, and
+// reaching the maximum locks them for LockoutDuration. Locks expire on their
+// own.
+type LockoutPolicy struct {
+	MaxAccountFailures int           // failures before an account is locked, 0 disables
+	MaxIPFailures      int           // failures before a client IP is locked, 0 disables
+	LockoutDuration    time.Duration // how long a lock lasts
+	FailureWindow      time.Duration // failures older than this are forgotten
+	BaseDelay          time.Duration // delay after the first failure
+	MaxDelay           time.Duration // cap on the progressive delay
+}
+
+var DefaultLockoutPolicy = LockoutPolicy{
+	MaxAccountFailures: 5,
+	MaxIPFailures:      20,
+	LockoutDuration:    15 * time.Minute,
+	FailureWindow:      15 * time.Minute,
+	BaseDelay:          time.Second,
+	MaxDelay:           30 * time.Second,
+}
+
+// delay returns how long to hold off after the given number of failures.
+func (p LockoutPolicy) delay(failures int) time.Duration {
+	if p.BaseDelay <= 0 || failures < 1 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// dummyPasswordHash is compared against when the email is unknown, so that
+// rejecting it takes as long as rejecting a wrong password.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+
+// loginKey identifies the account and client IP a login attempt counts
+// against. Accounts are keyed by email whether or not they exist.
+type loginKey struct {
+	key         string
+	maxFailures int
+}
+
+func (w *walletService) loginKeys(account string, clientIP string) []loginKey {
+	keys := []loginKey{}
+	if w.lockout.MaxAccountFailures > 0 {
+		keys = append(keys, loginKey{account, w.lockout.MaxAccountFailures})
+	}
+	if w.lockout.MaxIPFailures > 0 && clientIP != "" {
+		keys = append(keys, loginKey{ipLoginKey(clientIP), w.lockout.MaxIPFailures})
+	}
+	return keys
+}
+
+func accountLoginKey(email string) string {
+	return "account:" + canonicalEmail(email)
+}
+
+func ipLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginLocks returns ErrLoginLocked while any of keys is locked. Errors
+// reading the locks refuse the login rather than skip the check.
+func (w *walletService) checkLoginLocks(ctx context.Context, keys []loginKey) error {
+	now := time.Now()
+	for _, k := range keys {
+		attempt, err := w.store.GetLoginAttempt(ctx, k.key)
+		if err != nil {
+			return errors.ErrLoggingIn
+		}
+		if now.Before(attempt.LockedUntil) {
+			logging.FromContext(ctx).WithField("login_key", k.key).Warn("Login attempt while locked")
+			return errors.ErrLoginLocked
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failure against every key and locks each one
+// for its progressive delay, or for the lockout duration once it has failed
+// too often.
+func (w *walletService) recordLoginFailure(ctx context.Context, keys []loginKey) {
+	now := time.Now()
+	for _, k := range keys {
+		attempt, err := w.store.RecordLoginFailure(ctx, k.key, now.Add(-w.lockout.FailureWindow))
+		if err != nil {
+			continue
+		}
+		until := now.Add(w.lockout.delay(attempt.Failures))
+		if attempt.Failures >= k.maxFailures {
+			until = now.Add(w.lockout.LockoutDuration)
+			logging.FromContext(ctx).WithFields(logger.Fields{
+				"login_key": k.key,
+				"failures":  attempt.Failures,
+				"until":     until,
+			}).Warn("Login locked after repeated failures")
+		}
+		if !until.After(now) {
+			continue
+		}
+		w.store.LockLogin(ctx, k.key, until)
+	}
+}
+
+// UnlockLogin clears the failures and lock of an account, a client IP or
+// both, ahead of their automatic expiry.
+func (w *walletService) UnlockLogin(ctx context.Context, request domain.UnlockLoginRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.UnlockLogin")
+	defer tracing.End(span, &err)
+	keys := []string{}
+	if request.Email != "" {
+		keys = append(keys, accountLoginKey(request.Email))
+	}
+	if request.IP != "" {
+		keys = append(keys, ipLoginKey(request.IP))
+	}
+	if len(keys) == 0 {
+		return errors.ErrInvalidUnlockRequest
+	}
+	for _, key := range keys {
+		err = w.store.ClearLoginAttempts(ctx, key)
+		if err != nil {
+			return errors.ErrUnlockingLogin
+		}
+		logging.FromContext(ctx).WithField("login_key", key).Info("Login unlocked")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/db/mocks"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (suite *ServiceTestSuite) TestWalletService_LoginUserLockout() {
+	t := suite.T()
+	hash, err := bcrypt.GenerateFromPassword([]byte("12345678"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := domain.LoginDbResponse{ID: 1, Password: string(hash)}
+
+	type test struct {
+		name    string
+		request domain.LoginUserRequest
+		wantErr error
+		prepare func(*mocks.Storer)
+	}
+	tests := []test{
+		{
+			name:    "Valid password clears the account failures",
+			request: domain.LoginUserRequest{Email: "John@mail.com", Password: "12345678", ClientIP: "10.0.0.1"},
+			wantErr: nil,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetLoginAttempt", mock.Anything, "account:john@mail.com").Return(domain.LoginAttempt{}, nil).Once()
+				s.On("GetLoginAttempt", mock.Anything, "ip:10.0.0.1").Return(domain.LoginAttempt{}, nil).Once()
+				s.On("LoginUser", mock.Anything, "john@mail.com").Return(user, nil).Once()
+				s.On("ClearLoginAttempts", mock.Anything, "account:john@mail.com").Return(nil).Once()
+				s.On("GetUserMFA", mock.Anything, int64(1)).Return(domain.UserMFA{UserID: 1}, nil).Once()
+			},
+		},
+		{
+			name:    "Locked account is refused before the password is checked",
+			request: domain.LoginUserRequest{Email: "john@mail.com", Password: "12345678", ClientIP: "10.0.0.1"},
+			wantErr: errors.ErrLoginLocked,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetLoginAttempt", mock.Anything, "account:john@mail.com").Return(domain.LoginAttempt{Failures: 5, LockedUntil: time.Now().Add(time.Minute)}, nil).Once()
+			},
+		},
+		{
+			name:    "Wrong password adds a progressive delay",
+			request: domain.LoginUserRequest{Email: "john@mail.com", Password: "wrong", ClientIP: "10.0.0.1"},
+			wantErr: errors.ErrInvalidCredentials,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetLoginAttempt", mock.Anything, mock.Anything).Return(domain.LoginAttempt{}, nil).Twice()
+				s.On("LoginUser", mock.Anything, "john@mail.com").Return(user, nil).Once()
+				s.On("RecordLoginFailure", mock.Anything, "account:john@mail.com", mock.Anything).Return(domain.LoginAttempt{Failures: 2}, nil).Once()
+				s.On("RecordLoginFailure", mock.Anything, "ip:10.0.0.1", mock.Anything).Return(domain.LoginAttempt{Failures: 2}, nil).Once()
+				s.On("LockLogin", mock.Anything, mock.Anything, mock.MatchedBy(func(until time.Time) bool {
+					return until.Sub(time.Now()) <= 2*time.Second
+				})).Return(nil).Twice()
+			},
+		},
+		{
+			name:    "Too many failures locks the account",
+			request: domain.LoginUserRequest{Email: "john@mail.com", Password: "wrong"},
+			wantErr: errors.ErrInvalidCredentials,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetLoginAttempt", mock.Anything, "account:john@mail.com").Return(domain.LoginAttempt{}, nil).Once()
+				s.On("LoginUser", mock.Anything, "john@mail.com").Return(user, nil).Once()
+				s.On("RecordLoginFailure", mock.Anything, "account:john@mail.com", mock.Anything).Return(domain.LoginAttempt{Failures: 5}, nil).Once()
+				s.On("LockLogin", mock.Anything, "account:john@mail.com", mock.MatchedBy(func(until time.Time) bool {
+					return until.Sub(time.Now()) > 14*time.Minute
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name:    "Unknown email fails like a wrong password",
+			request: domain.LoginUserRequest{Email: "nobody@mail.com", Password: "12345678"},
+			wantErr: errors.ErrInvalidCredentials,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetLoginAttempt", mock.Anything, "account:nobody@mail.com").Return(domain.LoginAttempt{}, nil).Once()
+				s.On("LoginUser", mock.Anything, "nobody@mail.com").Return(domain.LoginDbResponse{}, nil).Once()
+				s.On("RecordLoginFailure", mock.Anything, "account:nobody@mail.com", mock.Anything).Return(domain.LoginAttempt{Failures: 1}, nil).Once()
+				s.On("LockLogin", mock.Anything, "account:nobody@mail.com", mock.Anything).Return(nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare(suite.repository)
+			_, err := suite.service.LoginUser(context.Background(), tt.request)
+			require.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func (suite *ServiceTestSuite) TestWalletService_UnlockLogin() {
+	t := suite.T()
+	suite.repository.On("ClearLoginAttempts", mock.Anything, "account:john@mail.com").Return(nil).Once()
+	suite.repository.On("ClearLoginAttempts", mock.Anything, "ip:10.0.0.1").Return(nil).Once()
+
+	err := suite.service.UnlockLogin(context.Background(), domain.UnlockLoginRequest{Email: "john@mail.com", IP: "10.0.0.1"})
+	require.NoError(t, err)
+
+	err = suite.service.UnlockLogin(context.Background(), domain.UnlockLoginRequest{})
+	require.Equal(t, errors.ErrInvalidUnlockRequest, err)
+}
+
+func TestLockoutPolicy_delay(t *testing.T) {
+	policy := DefaultLockoutPolicy
+	require.Equal(t, time.Duration(0), policy.delay(0))
+	require.Equal(t, time.Second, policy.delay(1))
+	require.Equal(t, 4*time.Second, policy.delay(3))
+	require.Equal(t, 30*time.Second, policy.delay(10))
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/metrics"
+	"nickPay/wallet/internal/tracing"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const maxMerchantNameLength = 100
+
+// DefaultQRExpiry is how long a dynamic payment QR code can be paid for when
+// its request does not say.
+const DefaultQRExpiry = 15 * time.Minute
+
+// Bounds of the expiry of a dynamic payment QR code.
+const (
+	minQRExpiry = time.Minute
+	maxQRExpiry = 24 * time.Hour
+)
+
+// maxSettlementPeriod is the longest period one settlement report covers.
+const maxSettlementPeriod = 366 * 24 * time.Hour
+
+// RegisterMerchant opens a merchant account for the user, who can then take
+// payments by QR code into the wallet of the merchant.
+func (w *walletService) RegisterMerchant(ctx context.Context, userID int64, merchant domain.Merchant) (registered domain.Merchant, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.RegisterMerchant")
+	defer tracing.End(span, &err)
+	merchant.Name = strings.TrimSpace(merchant.Name)
+	switch {
+	case merchant.Name == "":
+		return domain.Merchant{}, errors.ValidationErrors{{Field: "name", Code: errors.CodeRequired, Err: errors.ErrMerchantNameRequired}}
+	case len(merchant.Name) > maxMerchantNameLength:
+		return domain.Merchant{}, errors.ValidationErrors{{Field: "name", Code: errors.CodeTooLong, Err: errors.ErrMerchantNameTooLong}}
+	}
+	err = w.requireVerified(ctx, userID)
+	if err != nil {
+		return domain.Merchant{}, err
+	}
+	merchant.UserID = userID
+	merchant.ID, err = w.store.CreateMerchant(ctx, merchant)
+	if err != nil {
+		return domain.Merchant{}, err
+	}
+	logging.FromContext(ctx).WithFields(logger.Fields{"user_id": userID, "merchant_id": merchant.ID}).Info("Merchant registered")
+	return w.store.GetMerchant(ctx, merchant.ID)
+}
+
+// GetMerchant returns the user's merchant account.
+func (w *walletService) GetMerchant(ctx context.Context, userID int64) (merchant domain.Merchant, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetMerchant")
+	defer tracing.End(span, &err)
+	return w.store.GetMerchantByUser(ctx, userID)
+}
+
+// GetMerchantWallet returns the wallet the user's merchant is paid into,
+// which is kept apart from the user's own.
+func (w *walletService) GetMerchantWallet(ctx context.Context, userID int64) (wallet domain.Wallet, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetMerchantWallet")
+	defer tracing.End(span, &err)
+	merchant, err := w.store.GetMerchantByUser(ctx, userID)
+	if err != nil {
+		return domain.Wallet{}, err
+	}
+	return w.store.GetMerchantWallet(ctx, merchant.ID)
+}
+
+// GetStaticQR returns the static QR code of the user's merchant, which
+// payers can pay any amount.
+func (w *walletService) GetStaticQR(ctx context.Context, userID int64) (qr domain.PaymentQR, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetStaticQR")
+	defer tracing.End(span, &err)
+	merchant, err := w.store.GetMerchantByUser(ctx, userID)
+	if err != nil {
+		return domain.PaymentQR{}, err
+	}
+	qr = domain.PaymentQR{MerchantID: merchant.ID, Kind: domain.PaymentQRStatic}
+	qr.Payload = encodeQRPayload(qr)
+	return qr, nil
+}
+
+// CreatePaymentQR creates a dynamic QR code charging the requested amount
+// to the first payer before it expires.
+func (w *walletService) CreatePaymentQR(ctx context.Context, userID int64, request domain.PaymentQRRequest) (qr domain.PaymentQR, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.CreatePaymentQR")
+	defer tracing.End(span, &err)
+	var errs errors.ValidationErrors
+	if err := ValidateAmount(request.Amount, w.maxAmount); err != nil {
+		errs = append(errs, err.(errors.ValidationErrors)...)
+	}
+	request.Reference = strings.TrimSpace(request.Reference)
+	if len(request.Reference) > maxReferenceLength {
+		errs = append(errs, errors.FieldError{Field: "reference", Code: errors.CodeTooLong, Err: errors.ErrReferenceTooLong})
+	}
+	expiry := DefaultQRExpiry
+	if request.ExpiresIn != 0 {
+		expiry = time.Duration(request.ExpiresIn) * time.Second
+	}
+	if expiry < minQRExpiry || expiry > maxQRExpiry {
+		errs = append(errs, errors.FieldError{Field: "expires_in", Code: errors.CodeInvalid, Err: errors.ErrInvalidQRExpiry})
+	}
+	if err := errs.OrNil(); err != nil {
+		return domain.PaymentQR{}, err
+	}
+
+	merchant, err := w.store.GetMerchantByUser(ctx, userID)
+	if err != nil {
+		return domain.PaymentQR{}, err
+	}
+	// The payload carries the expiry in whole seconds
+	expiresAt := time.Now().Add(expiry).Truncate(time.Second)
+	qr = domain.PaymentQR{
+		MerchantID: merchant.ID,
+		Kind:       domain.PaymentQRDynamic,
+		Amount:     request.Amount,
+		Reference:  request.Reference,
+		Status:     domain.PaymentQRStatusPending,
+		ExpiresAt:  &expiresAt,
+	}
+	qr.ID, err = w.store.CreatePaymentQR(ctx, qr)
+	if err != nil {
+		return domain.PaymentQR{}, err
+	}
+	qr.Payload = encodeQRPayload(qr)
+	return qr, nil
+}
+
+// GetPaymentQR returns one of the dynamic QR codes of the user's merchant.
+// Pending codes past their expiry read as expired.
+func (w *walletService) GetPaymentQR(ctx context.Context, userID int64, qrID int64) (qr domain.PaymentQR, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetPaymentQR")
+	defer tracing.End(span, &err)
+	merchant, err := w.store.GetMerchantByUser(ctx, userID)
+	if err != nil {
+		return domain.PaymentQR{}, err
+	}
+	qr, err = w.store.GetPaymentQR(ctx, qrID)
+	if err == nil && qr.MerchantID != merchant.ID {
+		err = errors.ErrPaymentQRNotFound
+	}
+	if err != nil {
+		return domain.PaymentQR{}, err
+	}
+	qr.Payload = encodeQRPayload(qr)
+	if qr.Status == domain.PaymentQRStatusPending && !time.Now().Before(*qr.ExpiresAt) {
+		qr.Status = domain.PaymentQRStatusExpired
+	}
+	return qr, nil
+}
+
+// PayQR pays the merchant of a scanned QR code from the payer's wallet,
+// along with the transfer fee for it. The amount of a dynamic code is the one
+// it was created with; a static code is paid request.Amount. The merchant
+// wallet is credited in the same transaction, so the payment is settled as
+// soon as it returns. The payment then earns cashback from the transfer
+// campaigns running.
+func (w *walletService) PayQR(ctx context.Context, payerID int64, request domain.QRPaymentRequest) (payment domain.MerchantPayment, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.PayQR")
+	defer tracing.End(span, &err)
+	defer func() {
+		metrics.ObserveOperation(metrics.OperationMerchantPayment, operationResult(err), payment.Amount)
+	}()
+	scanned, ok := parseQRPayload(request.Payload)
+	if !ok {
+		return domain.MerchantPayment{}, errors.ValidationErrors{{Field: "payload", Code: requiredOrInvalid(request.Payload), Err: errors.ErrInvalidQRPayload}}
+	}
+	merchant, err := w.store.GetMerchant(ctx, scanned.MerchantID)
+	if err == errors.ErrMerchantNotFound {
+		return domain.MerchantPayment{}, errors.ValidationErrors{{Field: "payload", Code: errors.CodeNotFound, Err: errors.ErrMerchantNotFound}}
+	}
+	if err != nil {
+		return domain.MerchantPayment{}, err
+	}
+	if merchant.UserID == payerID {
+		return domain.MerchantPayment{}, errors.ErrSelfPayment
+	}
+
+	pending := domain.MerchantPayment{PayerID: payerID, Amount: request.Amount}
+	if scanned.Kind == domain.PaymentQRDynamic {
+		qr, err := w.store.GetPaymentQR(ctx, scanned.ID)
+		if err == errors.ErrPaymentQRNotFound || (err == nil && !sameQR(qr, scanned)) {
+			return domain.MerchantPayment{}, errors.ValidationErrors{{Field: "payload", Code: errors.CodeInvalid, Err: errors.ErrInvalidQRPayload}}
+		}
+		if err != nil {
+			return domain.MerchantPayment{}, err
+		}
+		switch {
+		case qr.Status != domain.PaymentQRStatusPending:
+			return domain.MerchantPayment{}, errors.ErrQRAlreadyPaid
+		case !time.Now().Before(*qr.ExpiresAt):
+			return domain.MerchantPayment{}, errors.ErrQRExpired
+		case request.Amount != 0 && request.Amount != qr.Amount:
+			return domain.MerchantPayment{}, errors.ValidationErrors{{Field: "amount", Code: errors.CodeInvalid, Err: errors.ErrQRAmountMismatch}}
+		}
+		pending.QRID, pending.Amount, pending.Reference = qr.ID, qr.Amount, qr.Reference
+	} else if err := ValidateAmount(request.Amount, w.maxAmount); err != nil {
+		return domain.MerchantPayment{}, err
+	}
+
+	err = w.requireVerified(ctx, payerID)
+	if err != nil {
+		return domain.MerchantPayment{}, err
+	}
+	wallet, err := w.store.GetWallet(ctx, payerID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingBalance.Error())
+		return domain.MerchantPayment{}, errors.ErrFetchingBalance
+	}
+	if wallet.ID == 0 {
+		return domain.MerchantPayment{}, errors.ErrNoWallet
+	}
+	if wallet.Status == domain.WalletStatusFrozen {
+		return domain.MerchantPayment{}, errors.ErrWalletFrozen
+	}
+	err = w.verifyStepUp(ctx, payerID, pending.Amount)
+	if err != nil {
+		return domain.MerchantPayment{}, err
+	}
+	quote, fee := w.quoteFee(domain.FeeOperationTransfer, pending.Amount)
+	// PayMerchant checks the balance again as it debits
+	if wallet.Balance < quote.Total {
+		logging.FromContext(ctx).WithField("user_id", payerID).Error(errors.ErrInsufficientBalance.Error())
+		return domain.MerchantPayment{}, errors.ErrInsufficientBalance
+	}
+	merchantWallet, err := w.store.GetMerchantWallet(ctx, merchant.ID)
+	if err != nil {
+		return domain.MerchantPayment{}, err
+	}
+	// Deleting the account of its user closes the merchant wallet too
+	if merchantWallet.Status == domain.WalletStatusClosed {
+		return domain.MerchantPayment{}, errors.ErrNoMerchantWallet
+	}
+	payment, err = w.store.PayMerchant(ctx, merchant, pending, fee)
+	if err != nil {
+		return domain.MerchantPayment{}, err
+	}
+	payment.Fee, payment.FeeBreakdown = quote.Fee, quote.Breakdown
+	logging.FromContext(ctx).WithFields(logger.Fields{"user_id": payerID, "merchant_id": merchant.ID, "payment_id": payment.ID}).Info("Merchant paid")
+	w.grantCashback(ctx, payerID, domain.CampaignOperationTransfer, payment.Amount)
+	return payment, nil
+}
+
+// sameQR reports whether a scanned payload tells the truth about the stored
+// dynamic code it names, so payers are shown what they pay.
+func sameQR(stored domain.PaymentQR, scanned domain.PaymentQR) bool {
+	return stored.MerchantID == scanned.MerchantID && stored.Amount == scanned.Amount &&
+		stored.Reference == scanned.Reference && stored.ExpiresAt.Unix() == scanned.ExpiresAt.Unix()
+}
+
+// GetSettlementReport totals the payments the user's merchant received from
+// from up to, but excluding, to, by day.
+func (w *walletService) GetSettlementReport(ctx context.Context, userID int64, from time.Time, to time.Time) (report domain.SettlementReport, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetSettlementReport")
+	defer tracing.End(span, &err)
+	if !to.After(from) || to.Sub(from) > maxSettlementPeriod {
+		return domain.SettlementReport{}, errors.ErrInvalidSettlementPeriod
+	}
+	merchant, err := w.store.GetMerchantByUser(ctx, userID)
+	if err != nil {
+		return domain.SettlementReport{}, err
+	}
+	days, err := w.store.ListSettlementDays(ctx, merchant.ID, from, to)
+	if err != nil {
+		return domain.SettlementReport{}, err
+	}
+	report = domain.SettlementReport{MerchantID: merchant.ID, From: from, To: to, Days: days}
+	for _, day := range days {
+		report.Payments += day.Payments
+		report.Amount += day.Amount
+	}
+	report.Amount = roundCents(report.Amount)
+	return report, nil
+}
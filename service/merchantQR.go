@@ -0,0 +1,148 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"strconv"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	QRFormatJSON = "json"
+	QRFormatPNG  = "png"
+	QRFormatSVG  = "svg"
+)
+
+// qrScheme and qrHost make up the URI a payment QR code encodes, such as
+// nikpay://pay?merchant=12 for a static code and
+// nikpay://pay?amount=250.00&expires=1714557600&merchant=12&qr=34&ref=INV-1
+// for a dynamic one.
+const (
+	qrScheme = "nikpay"
+	qrHost   = "pay"
+)
+
+// qrImageSize is the width and height, in pixels, of PNG QR codes.
+const qrImageSize = 256
+
+// QRContentType returns the MIME type for a QR code format, or an empty
+// string if the format is not supported.
+func QRContentType(format string) string {
+	switch format {
+	case QRFormatJSON:
+		return "application/json"
+	case QRFormatPNG:
+		return "image/png"
+	case QRFormatSVG:
+		return "image/svg+xml"
+	}
+	return ""
+}
+
+// WriteQRCode renders qr to out in the requested format: as JSON, or as an
+// image of the QR code encoding its payload.
+func WriteQRCode(out io.Writer, qr domain.PaymentQR, format string) error {
+	switch format {
+	case QRFormatJSON:
+		return json.NewEncoder(out).Encode(qr)
+	case QRFormatPNG:
+		code, err := qrcode.New(qr.Payload, qrcode.Medium)
+		if err != nil {
+			return err
+		}
+		image, err := code.PNG(qrImageSize)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(image)
+		return err
+	case QRFormatSVG:
+		return writeQRCodeSVG(out, qr.Payload)
+	}
+	return errors.ErrInvalidQRFormat
+}
+
+// writeQRCodeSVG draws one unit square per dark module, quiet zone included,
+// so the image scales without blurring.
+func writeQRCodeSVG(out io.Writer, payload string) error {
+	code, err := qrcode.New(payload, qrcode.Medium)
+	if err != nil {
+		return err
+	}
+	bitmap := code.Bitmap()
+	size := len(bitmap)
+	_, err = fmt.Fprintf(out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`+
+		`<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, qrImageSize, qrImageSize, size, size, size, size)
+	if err != nil {
+		return err
+	}
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			if _, err := fmt.Fprintf(out, "M%d %dh1v1h-1z", x, y); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = io.WriteString(out, `"/></svg>`)
+	return err
+}
+
+// encodeQRPayload returns the string the QR code of qr encodes.
+func encodeQRPayload(qr domain.PaymentQR) string {
+	values := url.Values{}
+	values.Set("merchant", strconv.FormatInt(qr.MerchantID, 10))
+	if qr.Kind == domain.PaymentQRDynamic {
+		values.Set("qr", strconv.FormatInt(qr.ID, 10))
+		values.Set("amount", formatAmount(qr.Amount))
+		values.Set("expires", strconv.FormatInt(qr.ExpiresAt.Unix(), 10))
+		if qr.Reference != "" {
+			values.Set("ref", qr.Reference)
+		}
+	}
+	return (&url.URL{Scheme: qrScheme, Host: qrHost, RawQuery: values.Encode()}).String()
+}
+
+// parseQRPayload reads a payload written by encodeQRPayload back. What it
+// claims about a dynamic code still has to be checked against the stored
+// code.
+func parseQRPayload(payload string) (qr domain.PaymentQR, ok bool) {
+	uri, err := url.Parse(payload)
+	if err != nil || uri.Scheme != qrScheme || uri.Host != qrHost {
+		return domain.PaymentQR{}, false
+	}
+	values := uri.Query()
+	qr.MerchantID, err = strconv.ParseInt(values.Get("merchant"), 10, 64)
+	if err != nil || qr.MerchantID <= 0 {
+		return domain.PaymentQR{}, false
+	}
+	qr.Kind = domain.PaymentQRStatic
+	if !values.Has("qr") {
+		return qr, true
+	}
+
+	qr.Kind, qr.Reference = domain.PaymentQRDynamic, values.Get("ref")
+	qr.ID, err = strconv.ParseInt(values.Get("qr"), 10, 64)
+	if err != nil || qr.ID <= 0 {
+		return domain.PaymentQR{}, false
+	}
+	qr.Amount, err = strconv.ParseFloat(values.Get("amount"), 64)
+	if err != nil {
+		return domain.PaymentQR{}, false
+	}
+	expires, err := strconv.ParseInt(values.Get("expires"), 10, 64)
+	if err != nil {
+		return domain.PaymentQR{}, false
+	}
+	expiresAt := time.Unix(expires, 0)
+	qr.ExpiresAt = &expiresAt
+	return qr, true
+}
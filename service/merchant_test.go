@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRPayload(t *testing.T) {
+	static := domain.PaymentQR{MerchantID: 12, Kind: domain.PaymentQRStatic}
+	assert.Equal(t, "nikpay://pay?merchant=12", encodeQRPayload(static))
+	scanned, ok := parseQRPayload("nikpay://pay?merchant=12")
+	require.True(t, ok)
+	assert.Equal(t, static, scanned)
+
+	expiresAt := time.Unix(1714557600, 0)
+	dynamic := domain.PaymentQR{ID: 34, MerchantID: 12, Kind: domain.PaymentQRDynamic, Amount: 250, Reference: "INV 1", ExpiresAt: &expiresAt}
+	payload := encodeQRPayload(dynamic)
+	assert.Equal(t, "nikpay://pay?amount=250.00&expires=1714557600&merchant=12&qr=34&ref=INV+1", payload)
+	scanned, ok = parseQRPayload(payload)
+	require.True(t, ok)
+	assert.Equal(t, dynamic, scanned)
+
+	for _, payload := range []string{
+		"",
+		"https://pay?merchant=12",
+		"nikpay://transfer?merchant=12",
+		"nikpay://pay?merchant=0",
+		"nikpay://pay?merchant=12&qr=34&amount=ten&expires=1714557600",
+		"nikpay://pay?merchant=12&qr=34&amount=250.00",
+	} {
+		_, ok := parseQRPayload(payload)
+		assert.False(t, ok, payload)
+	}
+}
+
+func TestWriteQRCode(t *testing.T) {
+	qr := domain.PaymentQR{MerchantID: 12, Kind: domain.PaymentQRStatic, Payload: "nikpay://pay?merchant=12"}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteQRCode(&out, qr, QRFormatPNG))
+	image, err := png.Decode(&out)
+	require.NoError(t, err)
+	assert.Equal(t, qrImageSize, image.Bounds().Dx())
+
+	out.Reset()
+	require.NoError(t, WriteQRCode(&out, qr, QRFormatSVG))
+	assert.True(t, strings.HasPrefix(out.String(), `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.True(t, strings.HasSuffix(out.String(), `"/></svg>`))
+
+	assert.Equal(t, errors.ErrInvalidQRFormat, WriteQRCode(&out, qr, "gif"))
+	assert.Empty(t, QRContentType("gif"))
+}
+
+func (suite *ServiceTestSuite) TestWalletService_RegisterMerchant() {
+	t := suite.T()
+	merchant := domain.Merchant{ID: 12, UserID: 1, Name: "Corner Cafe"}
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Once()
+	suite.repository.On("CreateMerchant", mock.Anything, domain.Merchant{UserID: 1, Name: "Corner Cafe"}).Return(int64(12), nil).Once()
+	suite.repository.On("GetMerchant", mock.Anything, int64(12)).Return(merchant, nil).Once()
+
+	got, err := suite.service.RegisterMerchant(context.Background(), 1, domain.Merchant{Name: " Corner Cafe "})
+	require.NoError(t, err)
+	require.Equal(t, merchant, got)
+
+	_, err = suite.service.RegisterMerchant(context.Background(), 1, domain.Merchant{Name: " "})
+	require.Equal(t, errors.ValidationErrors{{Field: "name", Code: errors.CodeRequired, Err: errors.ErrMerchantNameRequired}}, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetMerchantWallet() {
+	t := suite.T()
+	wallet := domain.Wallet{ID: 5, Balance: 250, Status: domain.WalletStatusActive}
+	suite.repository.On("GetMerchantByUser", mock.Anything, int64(1)).Return(domain.Merchant{ID: 12, UserID: 1, Name: "Corner Cafe"}, nil).Once()
+	suite.repository.On("GetMerchantWallet", mock.Anything, int64(12)).Return(wallet, nil).Once()
+	got, err := suite.service.GetMerchantWallet(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, wallet, got)
+
+	suite.repository.On("GetMerchantByUser", mock.Anything, int64(2)).Return(domain.Merchant{}, errors.ErrMerchantNotFound).Once()
+	_, err = suite.service.GetMerchantWallet(context.Background(), 2)
+	require.Equal(t, errors.ErrMerchantNotFound, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_CreatePaymentQR() {
+	t := suite.T()
+	suite.repository.On("GetMerchantByUser", mock.Anything, int64(1)).Return(domain.Merchant{ID: 12, UserID: 1, Name: "Corner Cafe"}, nil).Once()
+	suite.repository.On("CreatePaymentQR", mock.Anything, mock.MatchedBy(func(qr domain.PaymentQR) bool {
+		return qr.MerchantID == 12 && qr.Amount == 250 && qr.Reference == "INV-1" && time.Until(*qr.ExpiresAt) > 9*time.Minute && time.Until(*qr.ExpiresAt) <= 10*time.Minute
+	})).Return(int64(34), nil).Once()
+
+	qr, err := suite.service.CreatePaymentQR(context.Background(), 1, domain.PaymentQRRequest{Amount: 250, Reference: "INV-1", ExpiresIn: 600})
+	require.NoError(t, err)
+	require.Equal(t, int64(34), qr.ID)
+	require.Equal(t, domain.PaymentQRStatusPending, qr.Status)
+	scanned, ok := parseQRPayload(qr.Payload)
+	require.True(t, ok)
+	require.True(t, sameQR(qr, scanned))
+
+	_, err = suite.service.CreatePaymentQR(context.Background(), 1, domain.PaymentQRRequest{Amount: -1, Reference: strings.Repeat("x", 101), ExpiresIn: 30})
+	require.Equal(t, errors.ValidationErrors{
+		{Field: "amount", Code: errors.CodeNotPositive, Err: errors.ErrAmountNotPositive},
+		{Field: "reference", Code: errors.CodeTooLong, Err: errors.ErrReferenceTooLong},
+		{Field: "expires_in", Code: errors.CodeInvalid, Err: errors.ErrInvalidQRExpiry},
+	}, err)
+}
+
+// payerFixture expects the checks PayQR makes of user 1, who has 100 in
+// their wallet, before paying.
+func (suite *ServiceTestSuite) payerFixture() {
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Once()
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 3, UserID: 1, Balance: 100, Status: domain.WalletStatusActive}, nil).Once()
+}
+
+func (suite *ServiceTestSuite) TestWalletService_PayQR() {
+	t := suite.T()
+	merchant := domain.Merchant{ID: 12, UserID: 7, Name: "Corner Cafe"}
+	expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+	qr := domain.PaymentQR{ID: 34, MerchantID: 12, Kind: domain.PaymentQRDynamic, Amount: 60, Reference: "INV-1", Status: domain.PaymentQRStatusPending, ExpiresAt: &expiresAt}
+	dynamic := encodeQRPayload(qr)
+	suite.repository.On("GetMerchant", mock.Anything, int64(12)).Return(merchant, nil)
+	suite.repository.On("ListActiveCampaigns", mock.Anything, domain.CampaignOperationTransfer, mock.Anything).Return([]domain.Campaign{}, nil)
+
+	t.Run("static code", func(t *testing.T) {
+		suite.payerFixture()
+		suite.repository.On("GetMerchantWallet", mock.Anything, int64(12)).Return(domain.Wallet{ID: 5, Status: domain.WalletStatusActive}, nil).Once()
+		paid := domain.MerchantPayment{ID: 56, MerchantID: 12, MerchantName: "Corner Cafe", PayerID: 1, Amount: 20}
+		suite.repository.On("PayMerchant", mock.Anything, merchant, domain.MerchantPayment{PayerID: 1, Amount: 20}, domain.FeeCharge{}).Return(paid, nil).Once()
+
+		payment, err := suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12", Amount: 20})
+		require.NoError(t, err)
+		paid.FeeBreakdown = []domain.FeeComponent{}
+		require.Equal(t, paid, payment)
+
+		_, err = suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12"})
+		require.Equal(t, errors.ValidationErrors{{Field: "amount", Code: errors.CodeNotPositive, Err: errors.ErrAmountNotPositive}}, err)
+	})
+
+	t.Run("dynamic code", func(t *testing.T) {
+		suite.payerFixture()
+		suite.repository.On("GetMerchantWallet", mock.Anything, int64(12)).Return(domain.Wallet{ID: 5, Status: domain.WalletStatusActive}, nil).Once()
+		suite.repository.On("GetPaymentQR", mock.Anything, int64(34)).Return(qr, nil).Once()
+		suite.repository.On("PayMerchant", mock.Anything, merchant, domain.MerchantPayment{PayerID: 1, QRID: 34, Amount: 60, Reference: "INV-1"}, domain.FeeCharge{}).
+			Return(domain.MerchantPayment{ID: 57, QRID: 34, Amount: 60}, nil).Once()
+
+		payment, err := suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: dynamic})
+		require.NoError(t, err)
+		require.Equal(t, int64(57), payment.ID)
+	})
+
+	t.Run("dynamic code with another amount", func(t *testing.T) {
+		tampered := strings.Replace(dynamic, "amount=60.00", "amount=6.00", 1)
+		suite.repository.On("GetPaymentQR", mock.Anything, int64(34)).Return(qr, nil).Twice()
+
+		_, err := suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: tampered})
+		require.Equal(t, errors.ValidationErrors{{Field: "payload", Code: errors.CodeInvalid, Err: errors.ErrInvalidQRPayload}}, err)
+		_, err = suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: dynamic, Amount: 6})
+		require.Equal(t, errors.ValidationErrors{{Field: "amount", Code: errors.CodeInvalid, Err: errors.ErrQRAmountMismatch}}, err)
+	})
+
+	t.Run("paid or expired dynamic code", func(t *testing.T) {
+		paid := qr
+		paid.Status = domain.PaymentQRStatusPaid
+		suite.repository.On("GetPaymentQR", mock.Anything, int64(34)).Return(paid, nil).Once()
+		_, err := suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: dynamic})
+		require.Equal(t, errors.ErrQRAlreadyPaid, err)
+
+		expired := qr
+		expiredAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+		expired.ExpiresAt = &expiredAt
+		suite.repository.On("GetPaymentQR", mock.Anything, int64(34)).Return(expired, nil).Once()
+		_, err = suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: encodeQRPayload(expired)})
+		require.Equal(t, errors.ErrQRExpired, err)
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		suite.payerFixture()
+		_, err := suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12", Amount: 150})
+		require.Equal(t, errors.ErrInsufficientBalance, err)
+	})
+
+	t.Run("closed merchant wallet", func(t *testing.T) {
+		suite.payerFixture()
+		suite.repository.On("GetMerchantWallet", mock.Anything, int64(12)).Return(domain.Wallet{ID: 5, Status: domain.WalletStatusClosed}, nil).Once()
+		_, err := suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12", Amount: 20})
+		require.Equal(t, errors.ErrNoMerchantWallet, err)
+	})
+
+	t.Run("the merchant paying itself", func(t *testing.T) {
+		_, err := suite.service.PayQR(context.Background(), 7, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=12", Amount: 20})
+		require.Equal(t, errors.ErrSelfPayment, err)
+	})
+
+	t.Run("unknown merchant", func(t *testing.T) {
+		suite.repository.On("GetMerchant", mock.Anything, int64(13)).Return(domain.Merchant{}, errors.ErrMerchantNotFound).Once()
+		_, err := suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "nikpay://pay?merchant=13", Amount: 20})
+		require.Equal(t, errors.ValidationErrors{{Field: "payload", Code: errors.CodeNotFound, Err: errors.ErrMerchantNotFound}}, err)
+
+		_, err = suite.service.PayQR(context.Background(), 1, domain.QRPaymentRequest{Payload: "not a code", Amount: 20})
+		require.Equal(t, errors.ValidationErrors{{Field: "payload", Code: errors.CodeInvalid, Err: errors.ErrInvalidQRPayload}}, err)
+	})
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetPaymentQR() {
+	t := suite.T()
+	expiredAt := time.Now().Add(-time.Minute)
+	suite.repository.On("GetMerchantByUser", mock.Anything, int64(1)).Return(domain.Merchant{ID: 12, UserID: 1}, nil).Twice()
+	suite.repository.On("GetPaymentQR", mock.Anything, int64(34)).
+		Return(domain.PaymentQR{ID: 34, MerchantID: 12, Kind: domain.PaymentQRDynamic, Amount: 60, Status: domain.PaymentQRStatusPending, ExpiresAt: &expiredAt}, nil).Once()
+	suite.repository.On("GetPaymentQR", mock.Anything, int64(35)).Return(domain.PaymentQR{ID: 35, MerchantID: 13, Kind: domain.PaymentQRDynamic, ExpiresAt: &expiredAt}, nil).Once()
+
+	qr, err := suite.service.GetPaymentQR(context.Background(), 1, 34)
+	require.NoError(t, err)
+	require.Equal(t, domain.PaymentQRStatusExpired, qr.Status)
+	require.NotEmpty(t, qr.Payload)
+
+	// Codes of other merchants are not found
+	_, err = suite.service.GetPaymentQR(context.Background(), 1, 35)
+	require.Equal(t, errors.ErrPaymentQRNotFound, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetSettlementReport() {
+	t := suite.T()
+	from := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	days := []domain.SettlementDay{{Date: "2024-05-01", Payments: 2, Amount: 270.1}, {Date: "2024-05-03", Payments: 1, Amount: 15.2}}
+	suite.repository.On("GetMerchantByUser", mock.Anything, int64(1)).Return(domain.Merchant{ID: 12, UserID: 1}, nil).Once()
+	suite.repository.On("ListSettlementDays", mock.Anything, int64(12), from, to).Return(days, nil).Once()
+
+	report, err := suite.service.GetSettlementReport(context.Background(), 1, from, to)
+	require.NoError(t, err)
+	require.Equal(t, domain.SettlementReport{MerchantID: 12, From: from, To: to, Payments: 3, Amount: 285.3, Days: days}, report)
+
+	_, err = suite.service.GetSettlementReport(context.Background(), 1, to, from)
+	require.Equal(t, errors.ErrInvalidSettlementPeriod, err)
+	_, err = suite.service.GetSettlementReport(context.Background(), 1, from, from.AddDate(2, 0, 0))
+	require.Equal(t, errors.ErrInvalidSettlementPeriod, err)
+}
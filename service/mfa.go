@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/totp"
+	"nickPay/wallet/internal/tracing"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTOTPIssuer = "NikPay"
+
+	recoveryCodeCount = 10
+)
+
+type stepUpCodeKey struct{}
+
+// WithStepUpCode attaches the TOTP code a client sent along with a request,
+// for operations that need step-up verification.
+func WithStepUpCode(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, stepUpCodeKey{}, code)
+}
+
+func stepUpCode(ctx context.Context) string {
+	code, _ := ctx.Value(stepUpCodeKey{}).(string)
+	return code
+}
+
+// EnrollTOTP starts TOTP enrollment with a fresh secret. Two-factor
+// authentication is only enabled once ConfirmTOTP accepts a code from it.
+func (w *walletService) EnrollTOTP(ctx context.Context, userID int64) (enrollment domain.TOTPEnrollment, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.EnrollTOTP")
+	defer tracing.End(span, &err)
+	user, err := w.store.GetUser(ctx, userID)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return domain.TOTPEnrollment{}, errors.ErrUpdatingMFA
+	}
+	err = w.store.SaveTOTPSecret(ctx, userID, secret)
+	if err != nil {
+		return domain.TOTPEnrollment{}, err
+	}
+	return domain.TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(w.totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables two-factor authentication once the user proves their
+// authenticator works, and returns single-use recovery codes. The codes are
+// only stored hashed, so this is the one time they can be shown.
+func (w *walletService) ConfirmTOTP(ctx context.Context, userID int64, code string) (recoveryCodes []string, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ConfirmTOTP")
+	defer tracing.End(span, &err)
+	mfa, err := w.store.GetUserMFA(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, errors.ErrMFAAlreadyEnabled
+	}
+	if mfa.Secret == "" {
+		return nil, errors.ErrMFANotEnrolled
+	}
+	err = w.verifyTOTP(ctx, mfa, code)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingMFA.Error())
+		return nil, errors.ErrUpdatingMFA
+	}
+	err = w.store.EnableMFA(ctx, userID, hashes)
+	if err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Two-factor authentication enabled")
+	return recoveryCodes, nil
+}
+
+// VerifyLoginMFA completes a login that LoginUser answered with
+// ErrMFARequired, and returns the access token. Wrong codes count towards the
+// same lockout as wrong passwords.
+func (w *walletService) VerifyLoginMFA(ctx context.Context, request domain.MFALoginRequest) (token string, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.VerifyLoginMFA")
+	defer tracing.End(span, &err)
+	userID, err := ParseMFAToken(request.MFAToken)
+	if err != nil {
+		return "", err
+	}
+	keys := w.loginKeys(mfaLoginKey(userID), request.ClientIP)
+	err = w.checkLoginLocks(ctx, keys)
+	if err != nil {
+		return "", err
+	}
+
+	mfa, err := w.store.GetUserMFA(ctx, userID)
+	if err != nil {
+		return "", errors.ErrLoggingIn
+	}
+	if !mfa.Enabled {
+		return "", errors.ErrInvalidMFAToken
+	}
+
+	if request.RecoveryCode != "" {
+		used, err := w.store.UseRecoveryCode(ctx, userID, hashRecoveryCode(request.RecoveryCode))
+		if err != nil {
+			return "", errors.ErrLoggingIn
+		}
+		if !used {
+			w.recordLoginFailure(ctx, keys)
+			return "", errors.ErrInvalidMFACode
+		}
+		logging.FromContext(ctx).WithField("user_id", userID).Warn("Login completed with a recovery code")
+	} else {
+		err = w.verifyTOTP(ctx, mfa, request.Code)
+		if err == errors.ErrInvalidMFACode {
+			w.recordLoginFailure(ctx, keys)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if w.lockout.MaxAccountFailures > 0 {
+		w.store.ClearLoginAttempts(ctx, mfaLoginKey(userID))
+	}
+	sessionVersion, err := w.store.GetSessionVersion(ctx, userID)
+	if err != nil {
+		return "", errors.ErrLoggingIn
+	}
+	token, err = GenerateToken(domain.LoginDbResponse{ID: userID, SessionVersion: sessionVersion})
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrGenJWTToken.Error())
+		return "", errors.ErrGenJWTToken
+	}
+	return token, nil
+}
+
+// verifyStepUp requires a valid TOTP code, passed with WithStepUpCode, for
+// amounts above the step-up threshold. Users without two-factor
+// authentication can not move such amounts until they enroll. Wrong codes
+// count towards the same lockout as wrong codes at login.
+func (w *walletService) verifyStepUp(ctx context.Context, userID int64, amount float64) error {
+	if w.stepUpThreshold <= 0 || amount <= w.stepUpThreshold {
+		return nil
+	}
+	mfa, err := w.store.GetUserMFA(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !mfa.Enabled {
+		return errors.ErrMFANotEnrolled
+	}
+	code := stepUpCode(ctx)
+	if code == "" {
+		return errors.ErrStepUpRequired
+	}
+	keys := w.loginKeys(mfaLoginKey(userID), "")
+	err = w.checkLoginLocks(ctx, keys)
+	if err == errors.ErrLoginLocked {
+		return errors.ErrStepUpLocked
+	}
+	if err != nil {
+		return err
+	}
+	err = w.verifyTOTP(ctx, mfa, code)
+	if err == errors.ErrInvalidMFACode {
+		w.recordLoginFailure(ctx, keys)
+	}
+	if err != nil {
+		return err
+	}
+	if w.lockout.MaxAccountFailures > 0 {
+		w.store.ClearLoginAttempts(ctx, mfaLoginKey(userID))
+	}
+	return nil
+}
+
+// verifyTOTP checks code against the user's secret and burns its time step,
+// so that an intercepted code can not be replayed.
+func (w *walletService) verifyTOTP(ctx context.Context, mfa domain.UserMFA, code string) error {
+	step, ok := totp.Validate(mfa.Secret, code, time.Now())
+	if !ok {
+		return errors.ErrInvalidMFACode
+	}
+	accepted, err := w.store.MarkTOTPStepUsed(ctx, mfa.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return errors.ErrInvalidMFACode
+	}
+	return nil
+}
+
+func mfaLoginKey(userID int64) string {
+	return "mfa:" + strconv.FormatInt(userID, 10)
+}
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateRecoveryCodes returns new recovery codes, formatted as xxxxx-xxxxx,
+// together with the hashes to store.
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		_, err = rand.Read(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed the
+// way they are read. Recovery codes carry 50 random bits, so a fast hash is
+// enough.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
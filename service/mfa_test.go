@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/db/mocks"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/totp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func currentCode(t *testing.T) string {
+	code, err := totp.Code(testTOTPSecret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func (suite *ServiceTestSuite) TestWalletService_EnrollTOTP() {
+	t := suite.T()
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, Email: "john@mail.com"}, nil).Once()
+	suite.repository.On("SaveTOTPSecret", mock.Anything, int64(1), mock.AnythingOfType("string")).Return(nil).Once()
+
+	enrollment, err := suite.service.EnrollTOTP(context.Background(), 1)
+	require.NoError(t, err)
+	require.NotEmpty(t, enrollment.Secret)
+	require.Contains(t, enrollment.URI, "otpauth://totp/NikPay:john@mail.com?")
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ConfirmTOTP() {
+	t := suite.T()
+	pending := domain.UserMFA{UserID: 1, Secret: testTOTPSecret}
+
+	t.Run("Valid code enables MFA and returns recovery codes", func(t *testing.T) {
+		suite.repository.On("GetUserMFA", mock.Anything, int64(1)).Return(pending, nil).Once()
+		suite.repository.On("MarkTOTPStepUsed", mock.Anything, int64(1), mock.Anything).Return(true, nil).Once()
+		suite.repository.On("EnableMFA", mock.Anything, int64(1), mock.MatchedBy(func(hashes []string) bool {
+			return len(hashes) == recoveryCodeCount
+		})).Return(nil).Once()
+
+		codes, err := suite.service.ConfirmTOTP(context.Background(), 1, currentCode(t))
+		require.NoError(t, err)
+		require.Len(t, codes, recoveryCodeCount)
+		require.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	})
+
+	t.Run("Wrong code is rejected", func(t *testing.T) {
+		suite.repository.On("GetUserMFA", mock.Anything, int64(1)).Return(pending, nil).Once()
+
+		_, err := suite.service.ConfirmTOTP(context.Background(), 1, "000000")
+		require.Equal(t, errors.ErrInvalidMFACode, err)
+	})
+
+	t.Run("Not enrolled", func(t *testing.T) {
+		suite.repository.On("GetUserMFA", mock.Anything, int64(2)).Return(domain.UserMFA{UserID: 2}, nil).Once()
+
+		_, err := suite.service.ConfirmTOTP(context.Background(), 2, "123456")
+		require.Equal(t, errors.ErrMFANotEnrolled, err)
+	})
+}
+
+func (suite *ServiceTestSuite) TestWalletService_LoginUserMFA() {
+	t := suite.T()
+	hash, err := bcrypt.GenerateFromPassword([]byte("12345678"), bcrypt.MinCost)
+	require.NoError(t, err)
+	enabled := domain.UserMFA{UserID: 1, Secret: testTOTPSecret, Enabled: true}
+
+	suite.repository.On("GetLoginAttempt", mock.Anything, mock.Anything).Return(domain.LoginAttempt{}, nil)
+	suite.repository.On("LoginUser", mock.Anything, "john@mail.com").Return(domain.LoginDbResponse{ID: 1, Password: string(hash)}, nil).Once()
+	suite.repository.On("ClearLoginAttempts", mock.Anything, mock.Anything).Return(nil)
+	suite.repository.On("GetUserMFA", mock.Anything, int64(1)).Return(enabled, nil)
+	suite.repository.On("GetSessionVersion", mock.Anything, int64(1)).Return(int64(0), nil)
+
+	challenge, err := suite.service.LoginUser(context.Background(), domain.LoginUserRequest{Email: "john@mail.com", Password: "12345678"})
+	require.Equal(t, errors.ErrMFARequired, err)
+	userID, err := ParseMFAToken(challenge)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), userID)
+
+	t.Run("TOTP code completes the login", func(t *testing.T) {
+		suite.repository.On("MarkTOTPStepUsed", mock.Anything, int64(1), mock.Anything).Return(true, nil).Once()
+
+		token, err := suite.service.VerifyLoginMFA(context.Background(), domain.MFALoginRequest{MFAToken: challenge, Code: currentCode(t)})
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+	})
+
+	t.Run("Replayed code is refused", func(t *testing.T) {
+		suite.repository.On("MarkTOTPStepUsed", mock.Anything, int64(1), mock.Anything).Return(false, nil).Once()
+		suite.repository.On("RecordLoginFailure", mock.Anything, "mfa:1", mock.Anything).Return(domain.LoginAttempt{Failures: 1}, nil).Once()
+		suite.repository.On("LockLogin", mock.Anything, "mfa:1", mock.Anything).Return(nil).Once()
+
+		_, err := suite.service.VerifyLoginMFA(context.Background(), domain.MFALoginRequest{MFAToken: challenge, Code: currentCode(t)})
+		require.Equal(t, errors.ErrInvalidMFACode, err)
+	})
+
+	t.Run("Recovery code completes the login", func(t *testing.T) {
+		suite.repository.On("UseRecoveryCode", mock.Anything, int64(1), hashRecoveryCode("abcde-fghij")).Return(true, nil).Once()
+
+		token, err := suite.service.VerifyLoginMFA(context.Background(), domain.MFALoginRequest{MFAToken: challenge, RecoveryCode: "ABCDE FGHIJ"})
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+	})
+
+	t.Run("Access tokens are not challenge tokens", func(t *testing.T) {
+		access, err := GenerateToken(domain.LoginDbResponse{ID: 1})
+		require.NoError(t, err)
+
+		_, err = suite.service.VerifyLoginMFA(context.Background(), domain.MFALoginRequest{MFAToken: access, Code: currentCode(t)})
+		require.Equal(t, errors.ErrInvalidMFAToken, err)
+	})
+}
+
+func (suite *ServiceTestSuite) TestWalletService_DebitWalletStepUp() {
+	t := suite.T()
+	service := NewWalletService(suite.repository, WithStepUpThreshold(1000))
+	wallet := domain.Wallet{ID: 1, UserID: 1, Balance: 5000, Status: domain.WalletStatusActive}
+	enabled := domain.UserMFA{UserID: 1, Secret: testTOTPSecret, Enabled: true}
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil)
+	suite.repository.On("ListActiveCampaigns", mock.Anything, domain.CampaignOperationDebit, mock.Anything).Return([]domain.Campaign{}, nil)
+
+	type test struct {
+		name    string
+		ctx     context.Context
+		amount  float64
+		wantErr error
+		prepare func(*mocks.Storer)
+	}
+	tests := []test{
+		{
+			name:    "Below threshold needs no code",
+			ctx:     context.Background(),
+			amount:  1000,
+			wantErr: nil,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetWallet", mock.Anything, int64(1)).Return(wallet, nil).Once()
+				s.On("DebitWallet", mock.Anything, int64(1), 1000.0, domain.FeeCharge{}).Return(nil).Once()
+			},
+		},
+		{
+			name:    "Above threshold without code",
+			ctx:     context.Background(),
+			amount:  2000,
+			wantErr: errors.ErrStepUpRequired,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetWallet", mock.Anything, int64(1)).Return(wallet, nil).Once()
+				s.On("GetUserMFA", mock.Anything, int64(1)).Return(enabled, nil).Once()
+			},
+		},
+		{
+			name:    "Above threshold with valid code",
+			ctx:     WithStepUpCode(context.Background(), currentCode(t)),
+			amount:  2000,
+			wantErr: nil,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetWallet", mock.Anything, int64(1)).Return(wallet, nil).Once()
+				s.On("GetUserMFA", mock.Anything, int64(1)).Return(enabled, nil).Once()
+				s.On("GetLoginAttempt", mock.Anything, "mfa:1").Return(domain.LoginAttempt{}, nil).Once()
+				s.On("MarkTOTPStepUsed", mock.Anything, int64(1), mock.Anything).Return(true, nil).Once()
+				s.On("ClearLoginAttempts", mock.Anything, "mfa:1").Return(nil).Once()
+				s.On("DebitWallet", mock.Anything, int64(1), 2000.0, domain.FeeCharge{}).Return(nil).Once()
+			},
+		},
+		{
+			name:    "Above threshold with wrong code",
+			ctx:     WithStepUpCode(context.Background(), "not-a-code"),
+			amount:  2000,
+			wantErr: errors.ErrInvalidMFACode,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetWallet", mock.Anything, int64(1)).Return(wallet, nil).Once()
+				s.On("GetUserMFA", mock.Anything, int64(1)).Return(enabled, nil).Once()
+				s.On("GetLoginAttempt", mock.Anything, "mfa:1").Return(domain.LoginAttempt{}, nil).Once()
+				s.On("RecordLoginFailure", mock.Anything, "mfa:1", mock.Anything).Return(domain.LoginAttempt{Key: "mfa:1", Failures: DefaultLockoutPolicy.MaxAccountFailures}, nil).Once()
+				s.On("LockLogin", mock.Anything, "mfa:1", mock.Anything).Return(nil).Once()
+			},
+		},
+		{
+			name:    "Above threshold while locked",
+			ctx:     WithStepUpCode(context.Background(), currentCode(t)),
+			amount:  2000,
+			wantErr: errors.ErrStepUpLocked,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetWallet", mock.Anything, int64(1)).Return(wallet, nil).Once()
+				s.On("GetUserMFA", mock.Anything, int64(1)).Return(enabled, nil).Once()
+				s.On("GetLoginAttempt", mock.Anything, "mfa:1").Return(domain.LoginAttempt{Key: "mfa:1", LockedUntil: time.Now().Add(time.Minute)}, nil).Once()
+			},
+		},
+		{
+			name:    "Above threshold without enrollment",
+			ctx:     WithStepUpCode(context.Background(), "123456"),
+			amount:  2000,
+			wantErr: errors.ErrMFANotEnrolled,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetWallet", mock.Anything, int64(1)).Return(wallet, nil).Once()
+				s.On("GetUserMFA", mock.Anything, int64(1)).Return(domain.UserMFA{UserID: 1}, nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare(suite.repository)
+			_, err := service.DebitWallet(tt.ctx, 1, tt.amount)
+			require.Equal(t, tt.wantErr, err)
+		})
+	}
+}
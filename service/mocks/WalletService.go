@@ -7,6 +7,8 @@ import (
 	domain "nickPay/wallet/internal/domain"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // WalletService is an autogenerated mock type for the WalletService type
@@ -14,12 +16,26 @@ type WalletService struct {
 	mock.Mock
 }
 
-// CreditWallet provides a mock function with given fields: _a0, _a1, _a2
-func (_m *WalletService) CreditWallet(_a0 context.Context, _a1 int64, _a2 float64) error {
+// AdjustWallet provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) AdjustWallet(_a0 context.Context, _a1 domain.WalletAdjustment) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WalletAdjustment) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ChangePassword provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) ChangePassword(_a0 context.Context, _a1 int64, _a2 domain.ChangePasswordRequest) error {
 	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.ChangePasswordRequest) error); ok {
 		r0 = rf(_a0, _a1, _a2)
 	} else {
 		r0 = ret.Error(0)
@@ -28,12 +44,26 @@ func (_m *WalletService) CreditWallet(_a0 context.Context, _a1 int64, _a2 float6
 	return r0
 }
 
-// DebitWallet provides a mock function with given fields: _a0, _a1, _a2
-func (_m *WalletService) DebitWallet(_a0 context.Context, _a1 int64, _a2 float64) error {
+// ChangeWalletStatus provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) ChangeWalletStatus(_a0 context.Context, _a1 domain.WalletStatusChange) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.WalletStatusChange) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ConfirmEmailChange provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) ConfirmEmailChange(_a0 context.Context, _a1 int64, _a2 string) error {
 	ret := _m.Called(_a0, _a1, _a2)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
 		r0 = rf(_a0, _a1, _a2)
 	} else {
 		r0 = ret.Error(0)
@@ -42,19 +72,179 @@ func (_m *WalletService) DebitWallet(_a0 context.Context, _a1 int64, _a2 float64
 	return r0
 }
 
-// GetWallet provides a mock function with given fields: _a0, _a1
-func (_m *WalletService) GetWallet(_a0 context.Context, _a1 int64) (domain.Wallet, error) {
+// ConfirmTOTP provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) ConfirmTOTP(_a0 context.Context, _a1 int64, _a2 string) ([]string, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) ([]string, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) []string); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCampaign provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) CreateCampaign(_a0 context.Context, _a1 domain.Campaign) (domain.Campaign, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 domain.Wallet
+	var r0 domain.Campaign
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Wallet, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Campaign) (domain.Campaign, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Wallet); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, domain.Campaign) domain.Campaign); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(domain.Wallet)
+		r0 = ret.Get(0).(domain.Campaign)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.Campaign) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePaymentQR provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) CreatePaymentQR(_a0 context.Context, _a1 int64, _a2 domain.PaymentQRRequest) (domain.PaymentQR, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.PaymentQR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.PaymentQRRequest) (domain.PaymentQR, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.PaymentQRRequest) domain.PaymentQR); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.PaymentQR)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.PaymentQRRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreatePayoutBatch provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) CreatePayoutBatch(_a0 context.Context, _a1 domain.PayoutRequest) (domain.PayoutBatch, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PayoutBatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PayoutRequest) (domain.PayoutBatch, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.PayoutRequest) domain.PayoutBatch); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PayoutBatch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.PayoutRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreditWallet provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) CreditWallet(_a0 context.Context, _a1 int64, _a2 float64) (domain.TopUp, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) (domain.TopUp, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) domain.TopUp); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.TopUp)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, float64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DebitWallet provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) DebitWallet(_a0 context.Context, _a1 int64, _a2 float64) (domain.FeeQuote, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.FeeQuote
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) (domain.FeeQuote, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, float64) domain.FeeQuote); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.FeeQuote)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, float64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAccount provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) DeleteAccount(_a0 context.Context, _a1 int64) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EnrollTOTP provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) EnrollTOTP(_a0 context.Context, _a1 int64) (domain.TOTPEnrollment, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.TOTPEnrollment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.TOTPEnrollment, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.TOTPEnrollment); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.TOTPEnrollment)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
@@ -66,22 +256,22 @@ func (_m *WalletService) GetWallet(_a0 context.Context, _a1 int64) (domain.Walle
 	return r0, r1
 }
 
-// LoginUser provides a mock function with given fields: _a0, _a1
-func (_m *WalletService) LoginUser(_a0 context.Context, _a1 domain.LoginUserRequest) (string, error) {
+// ExecutePayoutBatch provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) ExecutePayoutBatch(_a0 context.Context, _a1 int64) (domain.PayoutBatch, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 string
+	var r0 domain.PayoutBatch
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.LoginUserRequest) (string, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.PayoutBatch, error)); ok {
 		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, domain.LoginUserRequest) string); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.PayoutBatch); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(domain.PayoutBatch)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, domain.LoginUserRequest) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
 		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
@@ -90,12 +280,36 @@ func (_m *WalletService) LoginUser(_a0 context.Context, _a1 domain.LoginUserRequ
 	return r0, r1
 }
 
-// RegisterUser provides a mock function with given fields: _a0, _a1
-func (_m *WalletService) RegisterUser(_a0 context.Context, _a1 domain.User) error {
+// ExportUserData provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) ExportUserData(_a0 context.Context, _a1 int64) (domain.UserExport, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.UserExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.UserExport, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.UserExport); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.UserExport)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForgotPassword provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) ForgotPassword(_a0 context.Context, _a1 string) error {
 	ret := _m.Called(_a0, _a1)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.User) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
 		r0 = rf(_a0, _a1)
 	} else {
 		r0 = ret.Error(0)
@@ -104,6 +318,834 @@ func (_m *WalletService) RegisterUser(_a0 context.Context, _a1 domain.User) erro
 	return r0
 }
 
+// GenerateStatement provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) GenerateStatement(_a0 context.Context, _a1 int64, _a2 time.Time) (domain.Statement, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Statement
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) (domain.Statement, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) domain.Statement); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Statement)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMerchant provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) GetMerchant(_a0 context.Context, _a1 int64) (domain.Merchant, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Merchant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Merchant, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Merchant); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Merchant)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMerchantWallet provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) GetMerchantWallet(_a0 context.Context, _a1 int64) (domain.Wallet, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Wallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Wallet, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Wallet); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Wallet)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPaymentQR provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) GetPaymentQR(_a0 context.Context, _a1 int64, _a2 int64) (domain.PaymentQR, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.PaymentQR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (domain.PaymentQR, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) domain.PaymentQR); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.PaymentQR)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPayoutBatch provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) GetPayoutBatch(_a0 context.Context, _a1 int64) (domain.PayoutBatch, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PayoutBatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.PayoutBatch, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.PayoutBatch); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PayoutBatch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPayoutReport provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) GetPayoutReport(_a0 context.Context, _a1 int64) ([]domain.PayoutRow, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.PayoutRow
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]domain.PayoutRow, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []domain.PayoutRow); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.PayoutRow)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetProfile provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) GetProfile(_a0 context.Context, _a1 int64) (domain.UserProfile, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.UserProfile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.UserProfile, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.UserProfile); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.UserProfile)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPromoBalance provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) GetPromoBalance(_a0 context.Context, _a1 int64) (domain.PromoBalance, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PromoBalance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.PromoBalance, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.PromoBalance); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PromoBalance)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSettlementReport provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *WalletService) GetSettlementReport(_a0 context.Context, _a1 int64, _a2 time.Time, _a3 time.Time) (domain.SettlementReport, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.SettlementReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time, time.Time) (domain.SettlementReport, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time, time.Time) domain.SettlementReport); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.SettlementReport)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, time.Time, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStaticQR provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) GetStaticQR(_a0 context.Context, _a1 int64) (domain.PaymentQR, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.PaymentQR
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.PaymentQR, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.PaymentQR); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.PaymentQR)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetTopUp provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) GetTopUp(_a0 context.Context, _a1 int64, _a2 int64) (domain.TopUp, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (domain.TopUp, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) domain.TopUp); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.TopUp)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWallet provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) GetWallet(_a0 context.Context, _a1 int64) (domain.Wallet, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.Wallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (domain.Wallet, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) domain.Wallet); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.Wallet)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWalletsByID provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) GetWalletsByID(_a0 context.Context, _a1 int64, _a2 []int64) ([]domain.Wallet, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []domain.Wallet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []int64) ([]domain.Wallet, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []int64) []domain.Wallet); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Wallet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, []int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWithdrawal provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) GetWithdrawal(_a0 context.Context, _a1 int64, _a2 int64) (domain.Withdrawal, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Withdrawal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (domain.Withdrawal, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) domain.Withdrawal); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Withdrawal)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HandleTopUpCallback provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) HandleTopUpCallback(_a0 context.Context, _a1 []byte, _a2 string) (domain.TopUp, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.TopUp
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) (domain.TopUp, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []byte, string) domain.TopUp); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.TopUp)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []byte, string) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LinkBankAccount provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) LinkBankAccount(_a0 context.Context, _a1 int64, _a2 domain.BankAccount) (domain.BankAccount, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.BankAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.BankAccount) (domain.BankAccount, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.BankAccount) domain.BankAccount); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.BankAccount)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.BankAccount) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListActivity provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *WalletService) ListActivity(_a0 context.Context, _a1 int64, _a2 int, _a3 string) (domain.ActivityPage, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 domain.ActivityPage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int, string) (domain.ActivityPage, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int, string) domain.ActivityPage); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Get(0).(domain.ActivityPage)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int, string) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListBankAccounts provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) ListBankAccounts(_a0 context.Context, _a1 int64) ([]domain.BankAccount, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []domain.BankAccount
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]domain.BankAccount, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []domain.BankAccount); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.BankAccount)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCampaigns provides a mock function with given fields: _a0
+func (_m *WalletService) ListCampaigns(_a0 context.Context) ([]domain.Campaign, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []domain.Campaign
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]domain.Campaign, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []domain.Campaign); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Campaign)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListTransactionsSince provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *WalletService) ListTransactionsSince(_a0 context.Context, _a1 int64, _a2 int64, _a3 int) ([]domain.Transaction, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 []domain.Transaction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) ([]domain.Transaction, error)); ok {
+		return rf(_a0, _a1, _a2, _a3)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int) []domain.Transaction); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Transaction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoginUser provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) LoginUser(_a0 context.Context, _a1 domain.LoginUserRequest) (string, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.LoginUserRequest) (string, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.LoginUserRequest) string); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.LoginUserRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LookupUser provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) LookupUser(_a0 context.Context, _a1 string) (domain.UserProfile, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.UserProfile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (domain.UserProfile, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) domain.UserProfile); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.UserProfile)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PayQR provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) PayQR(_a0 context.Context, _a1 int64, _a2 domain.QRPaymentRequest) (domain.MerchantPayment, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.MerchantPayment
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.QRPaymentRequest) (domain.MerchantPayment, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.QRPaymentRequest) domain.MerchantPayment); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.MerchantPayment)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.QRPaymentRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Ping provides a mock function with given fields: _a0
+func (_m *WalletService) Ping(_a0 context.Context) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QuoteFee provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) QuoteFee(_a0 context.Context, _a1 domain.QuoteRequest) (domain.FeeQuote, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.FeeQuote
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.QuoteRequest) (domain.FeeQuote, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.QuoteRequest) domain.FeeQuote); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.FeeQuote)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.QuoteRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reconcile provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) Reconcile(_a0 context.Context, _a1 bool) (domain.ReconciliationReport, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 domain.ReconciliationReport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) (domain.ReconciliationReport, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) domain.ReconciliationReport); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(domain.ReconciliationReport)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegisterMerchant provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) RegisterMerchant(_a0 context.Context, _a1 int64, _a2 domain.Merchant) (domain.Merchant, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Merchant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.Merchant) (domain.Merchant, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.Merchant) domain.Merchant); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Merchant)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.Merchant) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RegisterUser provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) RegisterUser(_a0 context.Context, _a1 domain.User) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.User) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResendVerification provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) ResendVerification(_a0 context.Context, _a1 int64, _a2 string) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) ResetPassword(_a0 context.Context, _a1 domain.ResetPasswordRequest) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ResetPasswordRequest) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SettleWithdrawals provides a mock function with given fields: _a0
+func (_m *WalletService) SettleWithdrawals(_a0 context.Context) (int, error) {
+	ret := _m.Called(_a0)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UnlockLogin provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) UnlockLogin(_a0 context.Context, _a1 domain.UnlockLoginRequest) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.UnlockLoginRequest) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateProfile provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) UpdateProfile(_a0 context.Context, _a1 int64, _a2 domain.UpdateProfileRequest) (domain.UserProfile, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.UserProfile
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.UpdateProfileRequest) (domain.UserProfile, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.UpdateProfileRequest) domain.UserProfile); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.UserProfile)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.UpdateProfileRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ValidateSession provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) ValidateSession(_a0 context.Context, _a1 int64, _a2 int64) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyContact provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *WalletService) VerifyContact(_a0 context.Context, _a1 int64, _a2 string, _a3 string) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VerifyLoginMFA provides a mock function with given fields: _a0, _a1
+func (_m *WalletService) VerifyLoginMFA(_a0 context.Context, _a1 domain.MFALoginRequest) (string, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.MFALoginRequest) (string, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, domain.MFALoginRequest) string); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, domain.MFALoginRequest) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Withdraw provides a mock function with given fields: _a0, _a1, _a2
+func (_m *WalletService) Withdraw(_a0 context.Context, _a1 int64, _a2 domain.WithdrawalRequest) (domain.Withdrawal, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 domain.Withdrawal
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.WithdrawalRequest) (domain.Withdrawal, error)); ok {
+		return rf(_a0, _a1, _a2)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.WithdrawalRequest) domain.Withdrawal); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Get(0).(domain.Withdrawal)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.WithdrawalRequest) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewWalletService interface {
 	mock.TestingT
 	Cleanup(func())
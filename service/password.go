@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/notify"
+	"nickPay/wallet/internal/tracing"
+	"time"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+// ForgotPassword emails a single-use password reset token to the account
+// registered with email. It succeeds whether or not such an account exists,
+// so it can not be used to find out which emails are registered.
+func (w *walletService) ForgotPassword(ctx context.Context, email string) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ForgotPassword")
+	defer tracing.End(span, &err)
+	email, err = NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	account, err := w.store.LoginUser(ctx, email)
+	if err != nil {
+		return errors.ErrRequestingPasswordReset
+	}
+	if account.ID == 0 {
+		return nil
+	}
+
+	token, err := resetToken()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrRequestingPasswordReset.Error())
+		return errors.ErrRequestingPasswordReset
+	}
+	err = w.store.SavePasswordResetToken(ctx, domain.PasswordResetToken{
+		TokenHash: hashResetToken(token),
+		UserID:    account.ID,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	err = w.notifier.Notify(ctx, notify.Message{
+		Channel: notify.ChannelEmail,
+		To:      email,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Your %s password reset token is %s. It can be used once and expires in %d minutes. If you did not ask to reset your password you can ignore this email.", w.totpIssuer, token, int(passwordResetTTL.Minutes())),
+	})
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrRequestingPasswordReset.Error())
+		return errors.ErrRequestingPasswordReset
+	}
+	logging.FromContext(ctx).WithField("user_id", account.ID).Info("Password reset requested")
+	return nil
+}
+
+// ResetPassword sets a new password using a token sent by ForgotPassword.
+// Every session of the user is signed out.
+func (w *walletService) ResetPassword(ctx context.Context, request domain.ResetPasswordRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ResetPassword")
+	defer tracing.End(span, &err)
+	if request.Token == "" {
+		return errors.ErrInvalidResetToken
+	}
+	err = ValidatePassword(request.NewPassword)
+	if err != nil {
+		return err
+	}
+	passwordHash, err := HashPassword(request.NewPassword)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingPassword.Error())
+		return errors.ErrUpdatingPassword
+	}
+	userID, err := w.store.ResetPassword(ctx, hashResetToken(request.Token), passwordHash)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Password reset")
+	return nil
+}
+
+// ChangePassword replaces the password of a signed in user who knows the
+// current one. Every session of the user, including the current one, is
+// signed out.
+func (w *walletService) ChangePassword(ctx context.Context, userID int64, request domain.ChangePasswordRequest) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ChangePassword")
+	defer tracing.End(span, &err)
+	err = ValidatePassword(request.NewPassword)
+	if err != nil {
+		return err
+	}
+	credentials, err := w.store.GetUserCredentials(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !CheckPassword(credentials.Password, request.CurrentPassword) {
+		return errors.ErrIncorrectPassword
+	}
+	passwordHash, err := HashPassword(request.NewPassword)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingPassword.Error())
+		return errors.ErrUpdatingPassword
+	}
+	err = w.store.ChangePassword(ctx, userID, passwordHash)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Password changed")
+	return nil
+}
+
+// upgradePasswordHash stores a bcrypt hash of password in place of the
+// legacy digest it was just checked against. The login already succeeded, so
+// a failure is logged and the digest left for the next one.
+func (w *walletService) upgradePasswordHash(ctx context.Context, userID int64, legacyHash string, password string) {
+	passwordHash, err := HashPassword(password)
+	if err == nil {
+		err = w.store.UpgradePasswordHash(ctx, userID, legacyHash, passwordHash)
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrUpdatingPassword.Error())
+		return
+	}
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Password hash upgraded")
+}
+
+// ValidateSession returns ErrSessionExpired if the password of the user has
+// changed since an access token carrying sessionVersion was issued.
+func (w *walletService) ValidateSession(ctx context.Context, userID int64, sessionVersion int64) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ValidateSession")
+	defer tracing.End(span, &err)
+	current, err := w.store.GetSessionVersion(ctx, userID)
+	if err == errors.ErrUserNotFound {
+		return errors.ErrSessionExpired
+	}
+	if err != nil {
+		return err
+	}
+	if current != sessionVersion {
+		return errors.ErrSessionExpired
+	}
+	return nil
+}
+
+// resetToken returns a random URL safe token with 256 bits of entropy.
+func resetToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
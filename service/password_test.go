@@ -0,0 +1,137 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"regexp"
+	"strings"
+	"testing"
+
+	logger "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var resetTokenPattern = regexp.MustCompile(`token is ([A-Za-z0-9_-]{43})\.`)
+
+func (suite *ServiceTestSuite) TestWalletService_ForgotAndResetPassword() {
+	t := suite.T()
+	notifier := &recordingNotifier{}
+	service := NewWalletService(suite.repository, WithNotifier(notifier))
+
+	t.Run("Unknown email sends nothing", func(t *testing.T) {
+		suite.repository.On("LoginUser", mock.Anything, "nobody@mail.com").Return(domain.LoginDbResponse{}, nil).Once()
+
+		require.NoError(t, service.ForgotPassword(context.Background(), "nobody@mail.com"))
+		require.Empty(t, notifier.sent)
+	})
+
+	var token string
+	t.Run("Registered email gets a token", func(t *testing.T) {
+		suite.repository.On("LoginUser", mock.Anything, "john@mail.com").Return(domain.LoginDbResponse{ID: 1}, nil).Once()
+		suite.repository.On("SavePasswordResetToken", mock.Anything, mock.AnythingOfType("domain.PasswordResetToken")).Return(nil).Once()
+
+		require.NoError(t, service.ForgotPassword(context.Background(), "john@mail.com"))
+		require.Len(t, notifier.sent, 1)
+		require.Equal(t, "john@mail.com", notifier.sent[0].To)
+		match := resetTokenPattern.FindStringSubmatch(notifier.sent[0].Body)
+		require.NotNil(t, match)
+		token = match[1]
+
+		saved := suite.repository.Calls[len(suite.repository.Calls)-1].Arguments.Get(1).(domain.PasswordResetToken)
+		require.Equal(t, hashResetToken(token), saved.TokenHash)
+		require.Equal(t, int64(1), saved.UserID)
+	})
+
+	t.Run("Log notifier keeps the token out of the log", func(t *testing.T) {
+		var out bytes.Buffer
+		log := logger.New()
+		log.SetOutput(&out)
+		ctx := logging.WithLogger(context.Background(), logger.NewEntry(log))
+		suite.repository.On("LoginUser", mock.Anything, "john@mail.com").Return(domain.LoginDbResponse{ID: 1}, nil).Once()
+		suite.repository.On("SavePasswordResetToken", mock.Anything, mock.AnythingOfType("domain.PasswordResetToken")).Return(nil).Once()
+
+		require.NoError(t, suite.service.ForgotPassword(ctx, "john@mail.com"))
+		require.Contains(t, out.String(), "Notification sent")
+		require.NotContains(t, out.String(), "token is")
+	})
+
+	t.Run("Token resets the password", func(t *testing.T) {
+		suite.repository.On("ResetPassword", mock.Anything, hashResetToken(token), mock.MatchedBy(func(hash string) bool {
+			return CheckPassword(hash, "new password")
+		})).Return(int64(1), nil).Once()
+
+		require.NoError(t, service.ResetPassword(context.Background(), domain.ResetPasswordRequest{Token: token, NewPassword: "new password"}))
+	})
+
+	t.Run("Overlong password is refused", func(t *testing.T) {
+		err := service.ResetPassword(context.Background(), domain.ResetPasswordRequest{Token: token, NewPassword: strings.Repeat("a", 73)})
+		require.Equal(t, errors.ErrPasswordTooLong, err)
+	})
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ChangePassword() {
+	t := suite.T()
+	hash, err := bcrypt.GenerateFromPassword([]byte("12345678"), bcrypt.MinCost)
+	require.NoError(t, err)
+	suite.repository.On("GetUserCredentials", mock.Anything, int64(1)).Return(domain.LoginDbResponse{ID: 1, Password: string(hash)}, nil)
+
+	err = suite.service.ChangePassword(context.Background(), 1, domain.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new password"})
+	require.Equal(t, errors.ErrIncorrectPassword, err)
+
+	suite.repository.On("ChangePassword", mock.Anything, int64(1), mock.MatchedBy(func(hash string) bool {
+		return CheckPassword(hash, "new password")
+	})).Return(nil).Once()
+	err = suite.service.ChangePassword(context.Background(), 1, domain.ChangePasswordRequest{CurrentPassword: "12345678", NewPassword: "new password"})
+	require.NoError(t, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ValidateSession() {
+	t := suite.T()
+	suite.repository.On("GetSessionVersion", mock.Anything, int64(1)).Return(int64(2), nil)
+
+	require.NoError(t, suite.service.ValidateSession(context.Background(), 1, 2))
+	require.Equal(t, errors.ErrSessionExpired, suite.service.ValidateSession(context.Background(), 1, 1))
+}
+
+func (suite *ServiceTestSuite) TestWalletService_LoginUserUpgradesLegacyHash() {
+	t := suite.T()
+	sum := sha256.Sum256([]byte("12345678"))
+	legacy := hex.EncodeToString(sum[:])
+	suite.repository.On("GetLoginAttempt", mock.Anything, mock.Anything).Return(domain.LoginAttempt{}, nil).Twice()
+	suite.repository.On("LoginUser", mock.Anything, "john@mail.com").Return(domain.LoginDbResponse{ID: 1, Password: legacy}, nil).Twice()
+	suite.repository.On("ClearLoginAttempts", mock.Anything, mock.Anything).Return(nil).Once()
+	suite.repository.On("RecordLoginFailure", mock.Anything, mock.Anything, mock.Anything).Return(domain.LoginAttempt{Failures: 1}, nil)
+	suite.repository.On("LockLogin", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	suite.repository.On("GetUserMFA", mock.Anything, int64(1)).Return(domain.UserMFA{UserID: 1}, nil).Once()
+	var upgraded string
+	suite.repository.On("UpgradePasswordHash", mock.Anything, int64(1), legacy, mock.MatchedBy(func(hash string) bool {
+		upgraded = hash
+		return true
+	})).Return(nil).Once()
+
+	_, err := suite.service.LoginUser(context.Background(), domain.LoginUserRequest{Email: "john@mail.com", Password: "12345678"})
+	require.NoError(t, err)
+	require.NoError(t, bcrypt.CompareHashAndPassword([]byte(upgraded), []byte("12345678")))
+
+	// A wrong password leaves the digest alone
+	_, err = suite.service.LoginUser(context.Background(), domain.LoginUserRequest{Email: "john@mail.com", Password: "wrong"})
+	require.Equal(t, errors.ErrInvalidCredentials, err)
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword("12345678")
+	require.NoError(t, err)
+	require.True(t, CheckPassword(hash, "12345678"))
+	require.False(t, CheckPassword(hash, "1234567"))
+
+	legacy := sha256.Sum256([]byte("12345678"))
+	require.True(t, CheckPassword(hex.EncodeToString(legacy[:]), "12345678"))
+	require.False(t, CheckPassword(hex.EncodeToString(legacy[:]), "1234567"))
+}
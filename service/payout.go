@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/tracing"
+	"strconv"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	PayoutFormatJSON = "json"
+	PayoutFormatCSV  = "csv"
+)
+
+// MaxPayoutRows caps the rows of one payout batch.
+const MaxPayoutRows = 10000
+
+// PayoutChunkSize is how many rows of a best-effort batch are paid per
+// database transaction. All-or-nothing batches are paid in one transaction.
+const PayoutChunkSize = 100
+
+const maxReferenceLength = 100
+
+// ParsePayoutFile reads payout instructions from a CSV file with a header
+// row naming the recipient, amount and reference columns, or from a JSON
+// array of instructions. Amounts that are not numbers are reported as
+// errors.ValidationErrors naming their row.
+func ParsePayoutFile(in io.Reader, format string) ([]domain.PayoutInstruction, error) {
+	var instructions []domain.PayoutInstruction
+	switch format {
+	case PayoutFormatJSON:
+		err := json.NewDecoder(in).Decode(&instructions)
+		if err != nil {
+			return nil, errors.ErrInvalidPayoutFile
+		}
+	case PayoutFormatCSV:
+		return parsePayoutCSV(in)
+	default:
+		return nil, errors.ErrInvalidPayoutFile
+	}
+	if len(instructions) > MaxPayoutRows {
+		return nil, errors.ErrTooManyPayoutRows
+	}
+	return instructions, nil
+}
+
+func parsePayoutCSV(in io.Reader) ([]domain.PayoutInstruction, error) {
+	r := csv.NewReader(in)
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, errors.ErrInvalidPayoutFile
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	recipientCol, okRecipient := columns["recipient"]
+	amountCol, okAmount := columns["amount"]
+	referenceCol, okReference := columns["reference"]
+	if !okRecipient || !okAmount || !okReference {
+		return nil, errors.ErrInvalidPayoutFile
+	}
+
+	instructions := []domain.PayoutInstruction{}
+	var fieldErrs errors.ValidationErrors
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.ErrInvalidPayoutFile
+		}
+		if len(instructions) == MaxPayoutRows {
+			return nil, errors.ErrTooManyPayoutRows
+		}
+		instruction := domain.PayoutInstruction{
+			Recipient: record[recipientCol],
+			Reference: record[referenceCol],
+		}
+		instruction.Amount, err = strconv.ParseFloat(strings.TrimSpace(record[amountCol]), 64)
+		if err != nil {
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: rowField(len(instructions)+1, "amount"), Code: errors.CodeInvalidType, Err: errors.ErrInvalidFieldType})
+		}
+		instructions = append(instructions, instruction)
+	}
+	if err := fieldErrs.OrNil(); err != nil {
+		return nil, err
+	}
+	return instructions, nil
+}
+
+// rowField names a field of a payout row in validation errors, counting rows
+// from 1 and leaving out any CSV header.
+func rowField(line int, field string) string {
+	return fmt.Sprintf("rows[%d].%s", line, field)
+}
+
+// CreatePayoutBatch validates every instruction up front and stores them as
+// a pending batch. Nothing is stored unless all rows are valid: each must
+// name a user with an active wallet, move a valid amount and carry a
+// reference unique within the batch. The batch is paid by
+// ExecutePayoutBatch.
+func (w *walletService) CreatePayoutBatch(ctx context.Context, request domain.PayoutRequest) (batch domain.PayoutBatch, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.CreatePayoutBatch")
+	defer tracing.End(span, &err)
+	if request.Mode != domain.PayoutModeAllOrNothing && request.Mode != domain.PayoutModeBestEffort {
+		return domain.PayoutBatch{}, errors.ErrInvalidPayoutMode
+	}
+	if len(request.Instructions) == 0 {
+		return domain.PayoutBatch{}, errors.ErrEmptyPayout
+	}
+	if len(request.Instructions) > MaxPayoutRows {
+		return domain.PayoutBatch{}, errors.ErrTooManyPayoutRows
+	}
+
+	var fieldErrs errors.ValidationErrors
+	rows := make([]domain.PayoutRow, len(request.Instructions))
+	contacts := make([]string, len(request.Instructions))
+	references := map[string]bool{}
+	for i, instruction := range request.Instructions {
+		line := i + 1
+		rows[i] = domain.PayoutRow{Line: line, Recipient: strings.TrimSpace(instruction.Recipient), Amount: instruction.Amount, Reference: strings.TrimSpace(instruction.Reference)}
+		contacts[i], err = normalizeContact(rows[i].Recipient, w.phoneRegion)
+		if err != nil {
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: rowField(line, "recipient"), Code: errors.CodeInvalid, Err: err})
+		}
+		if err := ValidateAmount(instruction.Amount, w.maxAmount); err != nil {
+			for _, fieldErr := range err.(errors.ValidationErrors) {
+				fieldErr.Field = rowField(line, fieldErr.Field)
+				fieldErrs = append(fieldErrs, fieldErr)
+			}
+		}
+		switch reference := rows[i].Reference; {
+		case reference == "":
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: rowField(line, "reference"), Code: errors.CodeRequired, Err: errors.ErrReferenceRequired})
+		case len(reference) > maxReferenceLength:
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: rowField(line, "reference"), Code: errors.CodeTooLong, Err: errors.ErrReferenceTooLong})
+		case references[reference]:
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: rowField(line, "reference"), Code: errors.CodeDuplicate, Err: errors.ErrDuplicateReference})
+		}
+		references[rows[i].Reference] = true
+	}
+
+	unique := make([]string, 0, len(contacts))
+	seen := map[string]bool{"": true}
+	for _, contact := range contacts {
+		if !seen[contact] {
+			seen[contact] = true
+			unique = append(unique, contact)
+		}
+	}
+	recipients, err := w.store.FindPayoutRecipients(ctx, unique)
+	if err != nil {
+		return domain.PayoutBatch{}, err
+	}
+	byContact := map[string]domain.PayoutRecipient{}
+	for _, recipient := range recipients {
+		byContact[strings.ToLower(recipient.Email)] = recipient
+		byContact[recipient.PhoneNumber] = recipient
+	}
+	for i := range rows {
+		if contacts[i] == "" {
+			continue
+		}
+		recipient, ok := byContact[contacts[i]]
+		field := rowField(rows[i].Line, "recipient")
+		switch {
+		case !ok:
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: field, Code: errors.CodeNotFound, Err: errors.ErrUnknownRecipient})
+		case recipient.WalletID == 0:
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: field, Code: errors.CodeInvalid, Err: errors.ErrNoWallet})
+		case recipient.WalletStatus == domain.WalletStatusFrozen:
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: field, Code: errors.CodeInvalid, Err: errors.ErrWalletFrozen})
+		case recipient.WalletStatus == domain.WalletStatusClosed:
+			fieldErrs = append(fieldErrs, errors.FieldError{Field: field, Code: errors.CodeInvalid, Err: errors.ErrWalletClosed})
+		}
+		rows[i].UserID = recipient.UserID
+	}
+	if err = fieldErrs.OrNil(); err != nil {
+		return domain.PayoutBatch{}, err
+	}
+
+	batchID, err := w.store.CreatePayoutBatch(ctx, domain.PayoutBatch{Mode: request.Mode, CreatedBy: request.CreatedBy}, rows)
+	if err != nil {
+		return domain.PayoutBatch{}, err
+	}
+	logging.FromContext(ctx).WithFields(logger.Fields{
+		"batch_id":   batchID,
+		"mode":       request.Mode,
+		"rows":       len(rows),
+		"created_by": request.CreatedBy,
+	}).Info("Payout batch created")
+	return w.store.GetPayoutBatch(ctx, batchID)
+}
+
+// normalizeContact returns an email or phone number in its stored form.
+func normalizeContact(contact string, phoneRegion string) (string, error) {
+	if contact == "" {
+		return "", errors.ErrInvalidRecipient
+	}
+	if strings.Contains(contact, "@") {
+		return NormalizeEmail(contact)
+	}
+	number, err := NormalizePhoneNumber(contact, phoneRegion)
+	if err != nil {
+		return "", errors.ErrInvalidRecipient
+	}
+	return number, nil
+}
+
+// ExecutePayoutBatch pays the pending rows of a batch and returns it with its
+// final counts. Best-effort batches are paid PayoutChunkSize rows at a time
+// and complete even if some rows fail; all-or-nothing batches either pay
+// every row or none and fail otherwise. Each row is marked paid together
+// with its credit, so a batch interrupted by an error or ctx can be run
+// again to pay the rows left, and finished batches are returned unchanged.
+func (w *walletService) ExecutePayoutBatch(ctx context.Context, batchID int64) (batch domain.PayoutBatch, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ExecutePayoutBatch")
+	defer tracing.End(span, &err)
+	batch, err = w.store.GetPayoutBatch(ctx, batchID)
+	if err != nil {
+		return domain.PayoutBatch{}, err
+	}
+	if batch.Status == domain.PayoutStatusCompleted || batch.Status == domain.PayoutStatusFailed {
+		return batch, nil
+	}
+	rows, err := w.store.ListPayoutRows(ctx, batchID)
+	if err != nil {
+		return domain.PayoutBatch{}, err
+	}
+	pending := make([]domain.PayoutRow, 0, len(rows))
+	for _, row := range rows {
+		if row.Status == domain.PayoutStatusPending {
+			pending = append(pending, row)
+		}
+	}
+	err = w.store.SetPayoutBatchStatus(ctx, batchID, domain.PayoutStatusProcessing)
+	if err != nil {
+		return domain.PayoutBatch{}, err
+	}
+
+	log := logging.FromContext(ctx).WithField("batch_id", batchID)
+	if batch.Mode == domain.PayoutModeAllOrNothing {
+		err = w.store.PayPayoutRows(ctx, batchID, pending, true)
+	} else {
+		for start := 0; start < len(pending) && err == nil; start += PayoutChunkSize {
+			if err = ctx.Err(); err != nil {
+				break
+			}
+			end := min(start+PayoutChunkSize, len(pending))
+			err = w.store.PayPayoutRows(ctx, batchID, pending[start:end], false)
+			if err == nil {
+				log.WithField("rows", len(rows)-len(pending)+end).Info("Payout batch progress")
+			}
+		}
+	}
+	if err != nil {
+		log.WithField("err", err.Error()).Error("Payout batch interrupted, run it again to pay the remaining rows")
+		return domain.PayoutBatch{}, err
+	}
+
+	batch, err = w.store.GetPayoutBatch(ctx, batchID)
+	if err != nil {
+		return domain.PayoutBatch{}, err
+	}
+	status := domain.PayoutStatusCompleted
+	if batch.Mode == domain.PayoutModeAllOrNothing && batch.PaidRows < batch.TotalRows {
+		status = domain.PayoutStatusFailed
+	}
+	err = w.store.SetPayoutBatchStatus(ctx, batchID, status)
+	if err != nil {
+		return domain.PayoutBatch{}, err
+	}
+	log.WithFields(logger.Fields{
+		"status":      status,
+		"paid_rows":   batch.PaidRows,
+		"paid_amount": batch.PaidAmount,
+		"failed_rows": batch.FailedRows,
+	}).Info("Payout batch finished")
+	return w.store.GetPayoutBatch(ctx, batchID)
+}
+
+func (w *walletService) GetPayoutBatch(ctx context.Context, batchID int64) (batch domain.PayoutBatch, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetPayoutBatch")
+	defer tracing.End(span, &err)
+	return w.store.GetPayoutBatch(ctx, batchID)
+}
+
+// GetPayoutReport returns every row of a batch with its status.
+func (w *walletService) GetPayoutReport(ctx context.Context, batchID int64) (rows []domain.PayoutRow, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetPayoutReport")
+	defer tracing.End(span, &err)
+	_, err = w.store.GetPayoutBatch(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return w.store.ListPayoutRows(ctx, batchID)
+}
+
+// PayoutContentType returns the MIME type for a payout file or report
+// format, or an empty string if the format is not supported.
+func PayoutContentType(format string) string {
+	switch format {
+	case PayoutFormatJSON:
+		return "application/json"
+	case PayoutFormatCSV:
+		return "text/csv"
+	}
+	return ""
+}
+
+// WritePayoutReport renders the rows of a batch to out.
+func WritePayoutReport(out io.Writer, rows []domain.PayoutRow, format string) error {
+	switch format {
+	case PayoutFormatJSON:
+		return json.NewEncoder(out).Encode(rows)
+	case PayoutFormatCSV:
+		w := csv.NewWriter(out)
+		records := [][]string{{"line", "recipient", "user_id", "amount", "reference", "status", "error"}}
+		for _, row := range rows {
+			records = append(records, []string{
+				fmt.Sprint(row.Line),
+				row.Recipient,
+				fmt.Sprint(row.UserID),
+				formatAmount(row.Amount),
+				row.Reference,
+				row.Status,
+				row.Error,
+			})
+		}
+		if err := w.WriteAll(records); err != nil {
+			return err
+		}
+		return w.Error()
+	}
+	return errors.ErrInvalidExportFormat
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePayoutFile(t *testing.T) {
+	want := []domain.PayoutInstruction{
+		{Recipient: "john@mail.com", Amount: 10, Reference: "may-1"},
+		{Recipient: "8123467890", Amount: 20.5, Reference: "may-2"},
+	}
+
+	got, err := ParsePayoutFile(strings.NewReader("reference,Recipient,amount\nmay-1,john@mail.com,10\nmay-2, 8123467890, 20.5\n"), PayoutFormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	got, err = ParsePayoutFile(strings.NewReader(`[{"recipient":"john@mail.com","amount":10,"reference":"may-1"},{"recipient":"8123467890","amount":20.5,"reference":"may-2"}]`), PayoutFormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	_, err = ParsePayoutFile(strings.NewReader("recipient,amount,reference\njohn@mail.com,ten,may-1\njane@mail.com,5,may-2\njim@mail.com,,may-3\n"), PayoutFormatCSV)
+	var fieldErrs errors.ValidationErrors
+	require.True(t, stderrors.As(err, &fieldErrs))
+	assert.Equal(t, errors.ValidationErrors{
+		{Field: "rows[1].amount", Code: errors.CodeInvalidType, Err: errors.ErrInvalidFieldType},
+		{Field: "rows[3].amount", Code: errors.CodeInvalidType, Err: errors.ErrInvalidFieldType},
+	}, fieldErrs)
+
+	for name, file := range map[string]string{
+		"missing column": "recipient,amount\njohn@mail.com,10\n",
+		"short row":      "recipient,amount,reference\njohn@mail.com,10\n",
+		"empty file":     "",
+	} {
+		_, err = ParsePayoutFile(strings.NewReader(file), PayoutFormatCSV)
+		assert.Equal(t, errors.ErrInvalidPayoutFile, err, name)
+	}
+	_, err = ParsePayoutFile(strings.NewReader(`{"recipient":"john@mail.com"}`), PayoutFormatJSON)
+	assert.Equal(t, errors.ErrInvalidPayoutFile, err)
+	_, err = ParsePayoutFile(strings.NewReader("recipient,amount,reference\n"+strings.Repeat("john@mail.com,1,r\n", MaxPayoutRows+1)), PayoutFormatCSV)
+	assert.Equal(t, errors.ErrTooManyPayoutRows, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_CreatePayoutBatch() {
+	t := suite.T()
+	recipients := []domain.PayoutRecipient{
+		{UserID: 1, Email: "john@mail.com", PhoneNumber: "+918123467891", WalletID: 11, WalletStatus: domain.WalletStatusActive},
+		{UserID: 2, Email: "jane@mail.com", PhoneNumber: "+918123467890", WalletID: 12, WalletStatus: domain.WalletStatusActive},
+		{UserID: 3, Email: "jim@mail.com", PhoneNumber: "+918123467892", WalletID: 13, WalletStatus: domain.WalletStatusFrozen},
+		{UserID: 4, Email: "joe@mail.com", PhoneNumber: "+918123467893"},
+	}
+	suite.repository.On("FindPayoutRecipients", mock.Anything, mock.Anything).Return(recipients, nil)
+
+	t.Run("stores valid batches", func(t *testing.T) {
+		rows := []domain.PayoutRow{
+			{Line: 1, Recipient: "John@Mail.com", UserID: 1, Amount: 10, Reference: "may-1"},
+			{Line: 2, Recipient: "8123467890", UserID: 2, Amount: 20.5, Reference: "may-2"},
+		}
+		batch := domain.PayoutBatch{ID: 7, Mode: domain.PayoutModeBestEffort, Status: domain.PayoutStatusPending, TotalRows: 2, TotalAmount: 30.5, PendingRows: 2}
+		suite.repository.On("CreatePayoutBatch", mock.Anything, domain.PayoutBatch{Mode: domain.PayoutModeBestEffort, CreatedBy: "finance"}, rows).Return(int64(7), nil).Once()
+		suite.repository.On("GetPayoutBatch", mock.Anything, int64(7)).Return(batch, nil).Once()
+
+		got, err := suite.service.CreatePayoutBatch(context.Background(), domain.PayoutRequest{
+			Mode:      domain.PayoutModeBestEffort,
+			CreatedBy: "finance",
+			Instructions: []domain.PayoutInstruction{
+				{Recipient: " John@Mail.com", Amount: 10, Reference: "may-1 "},
+				{Recipient: "8123467890", Amount: 20.5, Reference: "may-2"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, batch, got)
+	})
+
+	t.Run("reports every invalid row", func(t *testing.T) {
+		_, err := suite.service.CreatePayoutBatch(context.Background(), domain.PayoutRequest{
+			Mode: domain.PayoutModeAllOrNothing,
+			Instructions: []domain.PayoutInstruction{
+				{Recipient: "john@mail.com", Amount: 10, Reference: "may-1"},
+				{Recipient: "nobody@mail.com", Amount: 0, Reference: "may-1"},
+				{Recipient: "jim@mail.com", Amount: 10.001, Reference: ""},
+				{Recipient: "joe@mail.com", Amount: 10, Reference: strings.Repeat("x", 101)},
+				{Recipient: "", Amount: 10, Reference: "may-5"},
+			},
+		})
+		var fieldErrs errors.ValidationErrors
+		require.True(t, stderrors.As(err, &fieldErrs))
+		assert.ElementsMatch(t, errors.ValidationErrors{
+			{Field: "rows[2].amount", Code: errors.CodeNotPositive, Err: errors.ErrAmountNotPositive},
+			{Field: "rows[2].reference", Code: errors.CodeDuplicate, Err: errors.ErrDuplicateReference},
+			{Field: "rows[2].recipient", Code: errors.CodeNotFound, Err: errors.ErrUnknownRecipient},
+			{Field: "rows[3].amount", Code: errors.CodeTooPrecise, Err: errors.ErrAmountTooPrecise},
+			{Field: "rows[3].reference", Code: errors.CodeRequired, Err: errors.ErrReferenceRequired},
+			{Field: "rows[3].recipient", Code: errors.CodeInvalid, Err: errors.ErrWalletFrozen},
+			{Field: "rows[4].reference", Code: errors.CodeTooLong, Err: errors.ErrReferenceTooLong},
+			{Field: "rows[4].recipient", Code: errors.CodeInvalid, Err: errors.ErrNoWallet},
+			{Field: "rows[5].recipient", Code: errors.CodeInvalid, Err: errors.ErrInvalidRecipient},
+		}, fieldErrs)
+	})
+
+	t.Run("checks the batch", func(t *testing.T) {
+		_, err := suite.service.CreatePayoutBatch(context.Background(), domain.PayoutRequest{Mode: "some", Instructions: []domain.PayoutInstruction{{}}})
+		assert.Equal(t, errors.ErrInvalidPayoutMode, err)
+		_, err = suite.service.CreatePayoutBatch(context.Background(), domain.PayoutRequest{Mode: domain.PayoutModeBestEffort})
+		assert.Equal(t, errors.ErrEmptyPayout, err)
+	})
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ExecutePayoutBatch() {
+	t := suite.T()
+
+	t.Run("pays best-effort batches in chunks", func(t *testing.T) {
+		rows := make([]domain.PayoutRow, PayoutChunkSize+20)
+		for i := range rows {
+			rows[i] = domain.PayoutRow{Line: i + 1, UserID: 1, Amount: 1, Status: domain.PayoutStatusPending}
+		}
+		// Resumed after the first row was paid
+		rows[0].Status = domain.PayoutStatusPaid
+		finished := domain.PayoutBatch{ID: 7, Mode: domain.PayoutModeBestEffort, Status: domain.PayoutStatusProcessing, TotalRows: len(rows), PaidRows: len(rows) - 1, FailedRows: 1}
+		suite.repository.On("GetPayoutBatch", mock.Anything, int64(7)).Return(domain.PayoutBatch{ID: 7, Mode: domain.PayoutModeBestEffort, Status: domain.PayoutStatusProcessing}, nil).Once()
+		suite.repository.On("ListPayoutRows", mock.Anything, int64(7)).Return(rows, nil).Once()
+		suite.repository.On("SetPayoutBatchStatus", mock.Anything, int64(7), domain.PayoutStatusProcessing).Return(nil).Once()
+		suite.repository.On("PayPayoutRows", mock.Anything, int64(7), rows[1:PayoutChunkSize+1], false).Return(nil).Once()
+		suite.repository.On("PayPayoutRows", mock.Anything, int64(7), rows[PayoutChunkSize+1:], false).Return(nil).Once()
+		suite.repository.On("GetPayoutBatch", mock.Anything, int64(7)).Return(finished, nil).Once()
+		suite.repository.On("SetPayoutBatchStatus", mock.Anything, int64(7), domain.PayoutStatusCompleted).Return(nil).Once()
+		finished.Status = domain.PayoutStatusCompleted
+		suite.repository.On("GetPayoutBatch", mock.Anything, int64(7)).Return(finished, nil).Once()
+
+		batch, err := suite.service.ExecutePayoutBatch(context.Background(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, finished, batch)
+	})
+
+	t.Run("fails all-or-nothing batches with unpaid rows", func(t *testing.T) {
+		rows := []domain.PayoutRow{{Line: 1, Status: domain.PayoutStatusPending}, {Line: 2, Status: domain.PayoutStatusPending}}
+		suite.repository.On("GetPayoutBatch", mock.Anything, int64(8)).Return(domain.PayoutBatch{ID: 8, Mode: domain.PayoutModeAllOrNothing, Status: domain.PayoutStatusPending}, nil).Once()
+		suite.repository.On("ListPayoutRows", mock.Anything, int64(8)).Return(rows, nil).Once()
+		suite.repository.On("SetPayoutBatchStatus", mock.Anything, int64(8), domain.PayoutStatusProcessing).Return(nil).Once()
+		suite.repository.On("PayPayoutRows", mock.Anything, int64(8), rows, true).Return(nil).Once()
+		suite.repository.On("GetPayoutBatch", mock.Anything, int64(8)).Return(domain.PayoutBatch{ID: 8, Mode: domain.PayoutModeAllOrNothing, TotalRows: 2, FailedRows: 1, CancelledRows: 1}, nil).Twice()
+		suite.repository.On("SetPayoutBatchStatus", mock.Anything, int64(8), domain.PayoutStatusFailed).Return(nil).Once()
+
+		_, err := suite.service.ExecutePayoutBatch(context.Background(), 8)
+		require.NoError(t, err)
+	})
+
+	t.Run("leaves interrupted batches processing", func(t *testing.T) {
+		rows := []domain.PayoutRow{{Line: 1, Status: domain.PayoutStatusPending}}
+		suite.repository.On("GetPayoutBatch", mock.Anything, int64(9)).Return(domain.PayoutBatch{ID: 9, Mode: domain.PayoutModeBestEffort, Status: domain.PayoutStatusPending}, nil).Once()
+		suite.repository.On("ListPayoutRows", mock.Anything, int64(9)).Return(rows, nil).Once()
+		suite.repository.On("SetPayoutBatchStatus", mock.Anything, int64(9), domain.PayoutStatusProcessing).Return(nil).Once()
+		suite.repository.On("PayPayoutRows", mock.Anything, int64(9), rows, false).Return(errors.ErrPayingOut).Once()
+
+		_, err := suite.service.ExecutePayoutBatch(context.Background(), 9)
+		require.Equal(t, errors.ErrPayingOut, err)
+	})
+
+	t.Run("returns finished batches unchanged", func(t *testing.T) {
+		done := domain.PayoutBatch{ID: 10, Mode: domain.PayoutModeBestEffort, Status: domain.PayoutStatusCompleted}
+		suite.repository.On("GetPayoutBatch", mock.Anything, int64(10)).Return(done, nil).Once()
+
+		batch, err := suite.service.ExecutePayoutBatch(context.Background(), 10)
+		require.NoError(t, err)
+		assert.Equal(t, done, batch)
+	})
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetPayoutReport() {
+	t := suite.T()
+	rows := []domain.PayoutRow{{Line: 1, Status: domain.PayoutStatusPaid}}
+	suite.repository.On("GetPayoutBatch", mock.Anything, int64(7)).Return(domain.PayoutBatch{ID: 7}, nil).Once()
+	suite.repository.On("ListPayoutRows", mock.Anything, int64(7)).Return(rows, nil).Once()
+	suite.repository.On("GetPayoutBatch", mock.Anything, int64(8)).Return(domain.PayoutBatch{}, errors.ErrPayoutBatchNotFound).Once()
+
+	got, err := suite.service.GetPayoutReport(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, rows, got)
+	_, err = suite.service.GetPayoutReport(context.Background(), 8)
+	assert.Equal(t, errors.ErrPayoutBatchNotFound, err)
+}
+
+func TestWritePayoutReport(t *testing.T) {
+	rows := []domain.PayoutRow{
+		{Line: 1, Recipient: "john@mail.com", UserID: 1, Amount: 10, Reference: "may-1", Status: domain.PayoutStatusPaid},
+		{Line: 2, Recipient: "jane@mail.com", UserID: 2, Amount: 20.5, Reference: "may-2", Status: domain.PayoutStatusFailed, Error: "wallet is frozen"},
+	}
+	var out bytes.Buffer
+	require.NoError(t, WritePayoutReport(&out, rows, PayoutFormatCSV))
+	assert.Equal(t, "line,recipient,user_id,amount,reference,status,error\n"+
+		"1,john@mail.com,1,10.00,may-1,paid,\n"+
+		"2,jane@mail.com,2,20.50,may-2,failed,wallet is frozen\n", out.String())
+
+	out.Reset()
+	require.NoError(t, WritePayoutReport(&out, rows[:1], PayoutFormatJSON))
+	assert.JSONEq(t, `[{"line":1,"recipient":"john@mail.com","user_id":1,"amount":10,"reference":"may-1","status":"paid"}]`, out.String())
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/tracing"
+	"strings"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+func (w *walletService) GetProfile(ctx context.Context, userID int64) (profile domain.UserProfile, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetProfile")
+	defer tracing.End(span, &err)
+	user, err := w.store.GetUser(ctx, userID)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	return domain.NewUserProfile(user), nil
+}
+
+// UpdateProfile applies a partial update to the user's account. A new phone
+// number has to be verified again before wallet operations resume. A new
+// email only replaces the current one once confirmed with the code sent to
+// it through ConfirmEmailChange, asking for the current email cancels a
+// pending change.
+func (w *walletService) UpdateProfile(ctx context.Context, userID int64, request domain.UpdateProfileRequest) (profile domain.UserProfile, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.UpdateProfile")
+	defer tracing.End(span, &err)
+	if request.Name == nil && request.Email == nil && request.PhoneNumber == nil {
+		return domain.UserProfile{}, errors.ErrEmptyProfileUpdate
+	}
+	user, err := w.store.GetUser(ctx, userID)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+
+	update := domain.UserUpdate{Name: user.Name, PhoneNumber: user.PhoneNumber, PendingEmail: user.PendingEmail}
+	if request.Name != nil {
+		update.Name = strings.TrimSpace(*request.Name)
+		if update.Name == "" {
+			return domain.UserProfile{}, errors.ErrInvalidName
+		}
+	}
+	if request.PhoneNumber != nil {
+		number, err := NormalizePhoneNumber(*request.PhoneNumber, w.phoneRegion)
+		if err != nil {
+			return domain.UserProfile{}, err
+		}
+		if number != user.PhoneNumber {
+			update.PhoneNumber = number
+			update.ResetPhoneVerification = true
+		}
+	}
+	emailChanged := false
+	if request.Email != nil {
+		email, err := NormalizeEmail(*request.Email)
+		if err != nil {
+			return domain.UserProfile{}, err
+		}
+		switch email {
+		case user.Email:
+			update.PendingEmail = ""
+		case user.PendingEmail:
+		default:
+			err = w.requireEmailAvailable(ctx, email)
+			if err != nil {
+				return domain.UserProfile{}, err
+			}
+			update.PendingEmail = email
+			emailChanged = true
+		}
+	}
+
+	err = w.store.UpdateUser(ctx, userID, update)
+	if err != nil {
+		return domain.UserProfile{}, err
+	}
+	user.Name, user.PhoneNumber, user.PendingEmail = update.Name, update.PhoneNumber, update.PendingEmail
+	if update.ResetPhoneVerification {
+		user.PhoneVerified = false
+		if sendErr := w.sendVerificationCode(ctx, user, domain.VerificationPhone); sendErr != nil {
+			logging.FromContext(ctx).WithFields(logger.Fields{"user_id": userID, "channel": domain.VerificationPhone}).Warn("Verification code not sent after profile update")
+		}
+	}
+	if emailChanged {
+		if sendErr := w.sendVerificationCode(ctx, user, domain.VerificationEmailChange); sendErr != nil {
+			logging.FromContext(ctx).WithFields(logger.Fields{"user_id": userID, "channel": domain.VerificationEmailChange}).Warn("Verification code not sent after profile update")
+		}
+	}
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Profile updated")
+	return domain.NewUserProfile(user), nil
+}
+
+// ConfirmEmailChange replaces the user's email with their pending one if
+// code matches the one sent to it.
+func (w *walletService) ConfirmEmailChange(ctx context.Context, userID int64, code string) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ConfirmEmailChange")
+	defer tracing.End(span, &err)
+	user, err := w.store.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.PendingEmail == "" {
+		return errors.ErrNoPendingEmail
+	}
+	err = w.checkVerificationCode(ctx, userID, domain.VerificationEmailChange, code)
+	if err != nil {
+		return err
+	}
+	// Someone may have registered the address since the change was requested
+	err = w.requireEmailAvailable(ctx, user.PendingEmail)
+	if err != nil {
+		return err
+	}
+
+	err = w.store.ConfirmEmailChange(ctx, userID)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Email changed")
+	return nil
+}
+
+// DeleteAccount closes the user's account. The wallet must be empty.
+func (w *walletService) DeleteAccount(ctx context.Context, userID int64) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.DeleteAccount")
+	defer tracing.End(span, &err)
+	err = w.store.DeleteUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithField("user_id", userID).Info("Account deleted")
+	return nil
+}
+
+func (w *walletService) requireEmailAvailable(ctx context.Context, email string) error {
+	existing, err := w.store.LoginUser(ctx, email)
+	if err != nil {
+		return errors.ErrUpdatingUser
+	}
+	if existing.ID != 0 {
+		return errors.ErrEmailTaken
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/notify"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *ServiceTestSuite) TestWalletService_UpdateProfile() {
+	t := suite.T()
+	notifier := &recordingNotifier{}
+	service := NewWalletService(suite.repository, WithNotifier(notifier))
+	user := domain.User{ID: 1, Name: "John Doe", Email: "john@mail.com", PhoneNumber: "+918123467890", Password: "hash", EmailVerified: true, PhoneVerified: true}
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(user, nil)
+
+	_, err := service.UpdateProfile(context.Background(), 1, domain.UpdateProfileRequest{})
+	require.Equal(t, errors.ErrEmptyProfileUpdate, err)
+
+	phone := "812346789"
+	_, err = service.UpdateProfile(context.Background(), 1, domain.UpdateProfileRequest{PhoneNumber: &phone})
+	require.Equal(t, errors.ErrInvalidPhoneNumber, err)
+
+	name, email := " Jane Doe ", "Jane@Mail.com"
+	phone = "81234 67891"
+	suite.repository.On("LoginUser", mock.Anything, "jane@mail.com").Return(domain.LoginDbResponse{}, nil).Once()
+	suite.repository.On("UpdateUser", mock.Anything, int64(1), domain.UserUpdate{Name: "Jane Doe", PhoneNumber: "+918123467891", PendingEmail: "jane@mail.com", ResetPhoneVerification: true}).Return(nil).Once()
+	suite.repository.On("SaveVerificationCode", mock.Anything, mock.AnythingOfType("domain.VerificationCode")).Return(nil).Twice()
+
+	profile, err := service.UpdateProfile(context.Background(), 1, domain.UpdateProfileRequest{Name: &name, Email: &email, PhoneNumber: &phone})
+	require.NoError(t, err)
+	require.Equal(t, domain.UserProfile{ID: 1, Name: "Jane Doe", Email: "john@mail.com", PhoneNumber: "+918123467891", EmailVerified: true, PendingEmail: "jane@mail.com"}, profile)
+	require.Len(t, notifier.sent, 2)
+	require.Equal(t, notify.Message{Channel: notify.ChannelSMS, To: "+918123467891", Body: notifier.sent[0].Body}, notifier.sent[0])
+	require.Equal(t, "jane@mail.com", notifier.sent[1].To)
+
+	taken := "taken@mail.com"
+	suite.repository.On("LoginUser", mock.Anything, taken).Return(domain.LoginDbResponse{ID: 2}, nil).Once()
+	_, err = service.UpdateProfile(context.Background(), 1, domain.UpdateProfileRequest{Email: &taken})
+	require.Equal(t, errors.ErrEmailTaken, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ConfirmEmailChange() {
+	t := suite.T()
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, Email: "john@mail.com", PendingEmail: "jane@mail.com"}, nil).Once()
+	suite.repository.On("GetVerificationCode", mock.Anything, int64(1), domain.VerificationEmailChange).Return(domain.VerificationCode{
+		UserID:    1,
+		Channel:   domain.VerificationEmailChange,
+		CodeHash:  hashVerificationCode(1, domain.VerificationEmailChange, "123456"),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}, nil).Once()
+	suite.repository.On("LoginUser", mock.Anything, "jane@mail.com").Return(domain.LoginDbResponse{}, nil).Once()
+	suite.repository.On("ConfirmEmailChange", mock.Anything, int64(1)).Return(nil).Once()
+
+	require.NoError(t, suite.service.ConfirmEmailChange(context.Background(), 1, "123456"))
+
+	suite.repository.On("GetUser", mock.Anything, int64(2)).Return(domain.User{ID: 2, Email: "jim@mail.com"}, nil).Once()
+	require.Equal(t, errors.ErrNoPendingEmail, suite.service.ConfirmEmailChange(context.Background(), 2, "123456"))
+}
+
+func (suite *ServiceTestSuite) TestWalletService_DeleteAccount() {
+	t := suite.T()
+	suite.repository.On("DeleteUser", mock.Anything, int64(1)).Return(errors.ErrNonZeroBalance).Once()
+	require.Equal(t, errors.ErrNonZeroBalance, suite.service.DeleteAccount(context.Background(), 1))
+}
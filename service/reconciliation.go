@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"math"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/tracing"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// reconciliationTolerance absorbs floating point noise; the ledger stores
+// amounts with two decimal places.
+const reconciliationTolerance = 0.005
+
+// Reconcile recomputes every wallet balance from the ledger and reports the
+// wallets whose stored balance has drifted. When freeze is set, mismatched
+// wallets are frozen so no further credits or debits are accepted.
+func (w *walletService) Reconcile(ctx context.Context, freeze bool) (report domain.ReconciliationReport, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.Reconcile")
+	defer tracing.End(span, &err)
+	report = domain.ReconciliationReport{
+		StartedAt:  time.Now(),
+		Mismatches: []domain.ReconciliationMismatch{},
+	}
+
+	balances, err := w.store.ListWalletBalances(ctx)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrReconcilingWallets.Error())
+		return domain.ReconciliationReport{}, errors.ErrReconcilingWallets
+	}
+
+	for _, balance := range balances {
+		drift := balance.RecordedBalance - balance.LedgerBalance
+		if math.Abs(drift) < reconciliationTolerance {
+			continue
+		}
+		mismatch := domain.ReconciliationMismatch{
+			WalletID:        balance.WalletID,
+			UserID:          balance.UserID,
+			RecordedBalance: balance.RecordedBalance,
+			LedgerBalance:   balance.LedgerBalance,
+			Drift:           drift,
+			Frozen:          balance.Status == domain.WalletStatusFrozen,
+		}
+		logging.FromContext(ctx).WithFields(logger.Fields{
+			"wallet_id": balance.WalletID,
+			"drift":     drift,
+		}).Warn("Wallet balance does not match ledger")
+
+		if freeze && !mismatch.Frozen {
+			err = w.store.SetWalletStatus(ctx, balance.WalletID, domain.WalletStatusFrozen)
+			if err != nil {
+				logging.FromContext(ctx).WithFields(logger.Fields{
+					"wallet_id": balance.WalletID,
+					"err":       err.Error(),
+				}).Error(errors.ErrFreezingWallet.Error())
+			} else {
+				mismatch.Frozen = true
+			}
+		}
+		report.Mismatches = append(report.Mismatches, mismatch)
+	}
+
+	report.WalletsChecked = len(balances)
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// RunReconciliationJob reconciles wallets every interval until ctx is
+// cancelled. It blocks, so callers usually start it in its own goroutine.
+func RunReconciliationJob(ctx context.Context, NikPay WalletService, interval time.Duration, freeze bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := NikPay.Reconcile(ctx, freeze)
+			if err != nil {
+				continue
+			}
+			logging.FromContext(ctx).WithFields(logger.Fields{
+				"wallets_checked": report.WalletsChecked,
+				"mismatches":      len(report.Mismatches),
+			}).Info("Wallet reconciliation finished")
+		}
+	}
+}
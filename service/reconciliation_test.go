@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"nickPay/wallet/internal/db/mocks"
+	"nickPay/wallet/internal/domain"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *ServiceTestSuite) TestWalletService_Reconcile() {
+	t := suite.T()
+	balances := []domain.WalletLedgerBalance{
+		{WalletID: 1, UserID: 10, Status: domain.WalletStatusActive, RecordedBalance: 500, LedgerBalance: 500},
+		{WalletID: 2, UserID: 20, Status: domain.WalletStatusActive, RecordedBalance: 800, LedgerBalance: 750},
+		{WalletID: 3, UserID: 30, Status: domain.WalletStatusFrozen, RecordedBalance: 100, LedgerBalance: 0},
+	}
+
+	type test struct {
+		name    string
+		freeze  bool
+		want    []domain.ReconciliationMismatch
+		wantErr bool
+		prepare func(*mocks.Storer)
+	}
+	tests := []test{
+		{
+			name:   "Report drift without freezing",
+			freeze: false,
+			want: []domain.ReconciliationMismatch{
+				{WalletID: 2, UserID: 20, RecordedBalance: 800, LedgerBalance: 750, Drift: 50, Frozen: false},
+				{WalletID: 3, UserID: 30, RecordedBalance: 100, LedgerBalance: 0, Drift: 100, Frozen: true},
+			},
+			wantErr: false,
+			prepare: func(s *mocks.Storer) {
+				s.On("ListWalletBalances", mock.Anything).Return(balances, nil).Once()
+			},
+		},
+		{
+			name:   "Freeze mismatched active wallets",
+			freeze: true,
+			want: []domain.ReconciliationMismatch{
+				{WalletID: 2, UserID: 20, RecordedBalance: 800, LedgerBalance: 750, Drift: 50, Frozen: true},
+				{WalletID: 3, UserID: 30, RecordedBalance: 100, LedgerBalance: 0, Drift: 100, Frozen: true},
+			},
+			wantErr: false,
+			prepare: func(s *mocks.Storer) {
+				s.On("ListWalletBalances", mock.Anything).Return(balances, nil).Once()
+				s.On("SetWalletStatus", mock.Anything, int64(2), domain.WalletStatusFrozen).Return(nil).Once()
+			},
+		},
+		{
+			name:    "Error while listing balances",
+			freeze:  false,
+			want:    nil,
+			wantErr: true,
+			prepare: func(s *mocks.Storer) {
+				s.On("ListWalletBalances", mock.Anything).Return([]domain.WalletLedgerBalance{}, errors.New("mocked error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare(suite.repository)
+			report, err := suite.service.Reconcile(context.Background(), tt.freeze)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, len(balances), report.WalletsChecked)
+			require.Equal(t, tt.want, report.Mismatches)
+		})
+	}
+}
+
+func (suite *ServiceTestSuite) TestWallet_DebitFrozenWallet() {
+	t := suite.T()
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Once()
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 1, UserID: 1, Balance: 1000, Status: domain.WalletStatusFrozen}, nil).Once()
+
+	_, err := suite.service.DebitWallet(context.Background(), 1, 100)
+	require.Error(t, err)
+	require.Equal(t, "wallet is frozen", err.Error())
+}
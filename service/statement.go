@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/tracing"
+	"time"
+)
+
+// GenerateStatement builds the wallet statement for the calendar month that
+// contains month, taken as a UTC month whatever the location of month. The
+// opening balance is taken from the ledger as of the first
+// instant of the month and every movement in the month is listed with the
+// running balance after it.
+func (w *walletService) GenerateStatement(ctx context.Context, userID int64, month time.Time) (statement domain.Statement, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GenerateStatement")
+	defer tracing.End(span, &err)
+	start := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	wallet, err := w.store.GetWallet(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
+		return domain.Statement{}, errors.ErrFetchingWallet
+	}
+
+	opening, err := w.store.GetBalanceAt(ctx, userID, start)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrGeneratingStatement.Error())
+		return domain.Statement{}, errors.ErrGeneratingStatement
+	}
+
+	transactions, err := w.store.ListTransactions(ctx, userID, start, end)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrGeneratingStatement.Error())
+		return domain.Statement{}, errors.ErrGeneratingStatement
+	}
+
+	statement = domain.Statement{
+		WalletID:       wallet.ID,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: opening,
+		Entries:        make([]domain.StatementEntry, 0, len(transactions)),
+	}
+	balance := opening
+	for _, txn := range transactions {
+		balance += txn.Amount
+		entry := domain.StatementEntry{
+			TransactionID: txn.ID,
+			Date:          txn.CreatedAt,
+			Type:          txn.Type,
+			Description:   txn.Description,
+			Balance:       balance,
+		}
+		if txn.Amount >= 0 {
+			entry.Credit = txn.Amount
+			statement.TotalCredits += txn.Amount
+		} else {
+			entry.Debit = -txn.Amount
+			statement.TotalDebits -= txn.Amount
+		}
+		statement.Entries = append(statement.Entries, entry)
+	}
+	statement.ClosingBalance = balance
+	return statement, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	StatementFormatJSON = "json"
+	StatementFormatCSV  = "csv"
+	StatementFormatPDF  = "pdf"
+)
+
+// StatementContentType returns the MIME type for a statement format, or an
+// empty string if the format is not supported.
+func StatementContentType(format string) string {
+	switch format {
+	case StatementFormatJSON:
+		return "application/json"
+	case StatementFormatCSV:
+		return "text/csv"
+	case StatementFormatPDF:
+		return "application/pdf"
+	}
+	return ""
+}
+
+// WriteStatement renders statement to out in the requested format.
+func WriteStatement(out io.Writer, statement domain.Statement, format string) error {
+	switch format {
+	case StatementFormatJSON:
+		return json.NewEncoder(out).Encode(statement)
+	case StatementFormatCSV:
+		return writeStatementCSV(out, statement)
+	case StatementFormatPDF:
+		return writeStatementPDF(out, statement)
+	}
+	return errors.ErrInvalidStatementFormat
+}
+
+func formatAmount(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+func writeStatementCSV(out io.Writer, statement domain.Statement) error {
+	w := csv.NewWriter(out)
+	records := [][]string{
+		{"date", "transaction_id", "type", "description", "debit", "credit", "balance"},
+		{statement.PeriodStart.Format("2006-01-02"), "", "", "Opening balance", "", "", formatAmount(statement.OpeningBalance)},
+	}
+	for _, entry := range statement.Entries {
+		records = append(records, []string{
+			entry.Date.Format("2006-01-02 15:04:05"),
+			fmt.Sprint(entry.TransactionID),
+			entry.Type,
+			entry.Description,
+			formatAmount(entry.Debit),
+			formatAmount(entry.Credit),
+			formatAmount(entry.Balance),
+		})
+	}
+	records = append(records, []string{
+		statement.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"), "", "", "Closing balance",
+		formatAmount(statement.TotalDebits), formatAmount(statement.TotalCredits), formatAmount(statement.ClosingBalance),
+	})
+	if err := w.WriteAll(records); err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+func writeStatementPDF(out io.Writer, statement domain.Statement) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Wallet Statement")
+	pdf.Ln(12)
+
+	pdf.SetFont("Arial", "", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("Wallet: %d", statement.WalletID))
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Period: %s to %s", statement.PeriodStart.Format("2006-01-02"), statement.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02")))
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Opening balance: %s", formatAmount(statement.OpeningBalance)))
+	pdf.Ln(10)
+
+	widths := []float64{38, 62, 30, 30, 30}
+	pdf.SetFont("Arial", "B", 10)
+	for i, heading := range []string{"Date", "Description", "Debit", "Credit", "Balance"} {
+		pdf.CellFormat(widths[i], 7, heading, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 9)
+	for _, entry := range statement.Entries {
+		pdf.CellFormat(widths[0], 6, entry.Date.Format("2006-01-02 15:04"), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, entry.Description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, formatAmount(entry.Debit), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 6, formatAmount(entry.Credit), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, formatAmount(entry.Balance), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(4)
+	pdf.SetFont("Arial", "B", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("Total debits: %s   Total credits: %s   Closing balance: %s",
+		formatAmount(statement.TotalDebits), formatAmount(statement.TotalCredits), formatAmount(statement.ClosingBalance)))
+
+	return pdf.Output(out)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"nickPay/wallet/internal/db/mocks"
+	"nickPay/wallet/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func (suite *ServiceTestSuite) TestWalletService_GenerateStatement() {
+	t := suite.T()
+	month := time.Date(2023, time.May, 17, 10, 0, 0, 0, time.UTC)
+	start := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	type test struct {
+		name    string
+		want    domain.Statement
+		wantErr bool
+		prepare func(*mocks.Storer)
+	}
+	tests := []test{
+		{
+			name: "Statement with running balance",
+			want: domain.Statement{
+				WalletID:       7,
+				PeriodStart:    start,
+				PeriodEnd:      end,
+				OpeningBalance: 100,
+				TotalCredits:   500,
+				TotalDebits:    150,
+				ClosingBalance: 450,
+				Entries: []domain.StatementEntry{
+					{TransactionID: 1, Date: start.Add(time.Hour), Type: domain.TransactionCredit, Description: "Wallet credit", Credit: 500, Balance: 600},
+					{TransactionID: 2, Date: start.Add(2 * time.Hour), Type: domain.TransactionDebit, Description: "Wallet debit", Debit: 150, Balance: 450},
+				},
+			},
+			wantErr: false,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 7, UserID: 1}, nil).Once()
+				s.On("GetBalanceAt", mock.Anything, int64(1), start).Return(100.0, nil).Once()
+				s.On("ListTransactions", mock.Anything, int64(1), start, end).Return([]domain.Transaction{
+					{ID: 1, WalletID: 7, UserID: 1, Type: domain.TransactionCredit, Amount: 500, BalanceAfter: 600, Description: "Wallet credit", CreatedAt: start.Add(time.Hour)},
+					{ID: 2, WalletID: 7, UserID: 1, Type: domain.TransactionDebit, Amount: -150, BalanceAfter: 450, Description: "Wallet debit", CreatedAt: start.Add(2 * time.Hour)},
+				}, nil).Once()
+			},
+		},
+		{
+			name:    "Error while fetching transactions",
+			want:    domain.Statement{},
+			wantErr: true,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 7, UserID: 1}, nil).Once()
+				s.On("GetBalanceAt", mock.Anything, int64(1), start).Return(100.0, nil).Once()
+				s.On("ListTransactions", mock.Anything, int64(1), start, end).Return([]domain.Transaction{}, errors.New("mocked error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare(suite.repository)
+			got, err := suite.service.GenerateStatement(context.Background(), 1, month)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWriteStatement(t *testing.T) {
+	start := time.Date(2023, time.May, 1, 0, 0, 0, 0, time.UTC)
+	statement := domain.Statement{
+		WalletID:       7,
+		PeriodStart:    start,
+		PeriodEnd:      start.AddDate(0, 1, 0),
+		OpeningBalance: 100,
+		TotalCredits:   500,
+		ClosingBalance: 600,
+		Entries: []domain.StatementEntry{
+			{TransactionID: 1, Date: start.Add(time.Hour), Type: domain.TransactionCredit, Description: "Wallet credit", Credit: 500, Balance: 600},
+		},
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, WriteStatement(&out, statement, StatementFormatCSV))
+		want := strings.Join([]string{
+			"date,transaction_id,type,description,debit,credit,balance",
+			"2023-05-01,,,Opening balance,,,100.00",
+			"2023-05-01 01:00:00,1,credit,Wallet credit,0.00,500.00,600.00",
+			"2023-05-31,,,Closing balance,0.00,500.00,600.00",
+			"",
+		}, "\n")
+		require.Equal(t, want, out.String())
+	})
+
+	t.Run("PDF", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, WriteStatement(&out, statement, StatementFormatPDF))
+		require.True(t, bytes.HasPrefix(out.Bytes(), []byte("%PDF-")))
+	})
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		var out bytes.Buffer
+		require.Error(t, WriteStatement(&out, statement, "xml"))
+	})
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"math"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/gateway"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/metrics"
+	"nickPay/wallet/internal/tracing"
+	"strconv"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// CreditWallet starts a top-up of amount through the payment gateway and
+// returns it pending, with the checkout URL where the user pays. The wallet
+// is only credited once the gateway confirms the payment, through
+// HandleTopUpCallback or GetTopUp.
+func (w *walletService) CreditWallet(ctx context.Context, userID int64, amount float64) (topUp domain.TopUp, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.CreditWallet")
+	defer tracing.End(span, &err)
+	defer func() {
+		// Successful credits are counted when their top-up settles
+		if err != nil {
+			metrics.ObserveOperation(metrics.OperationCredit, operationResult(err), amount)
+		}
+	}()
+	if w.gateway == nil {
+		return domain.TopUp{}, errors.ErrNoPaymentGateway
+	}
+	err = ValidateAmount(amount, w.maxAmount)
+	if err != nil {
+		return domain.TopUp{}, err
+	}
+	err = w.requireVerified(ctx, userID)
+	if err != nil {
+		return domain.TopUp{}, err
+	}
+	wallet, err := w.store.GetWallet(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
+		return domain.TopUp{}, errors.ErrFetchingWallet
+	}
+	if wallet.ID == 0 {
+		return domain.TopUp{}, errors.ErrNoWallet
+	}
+	if wallet.Status == domain.WalletStatusFrozen {
+		return domain.TopUp{}, errors.ErrWalletFrozen
+	}
+
+	topUpID, err := w.store.CreateTopUp(ctx, domain.TopUp{UserID: userID, Amount: amount, Gateway: w.gateway.Name()})
+	if err != nil {
+		return domain.TopUp{}, err
+	}
+	intent, err := w.gateway.CreateIntent(ctx, gateway.IntentRequest{Reference: strconv.FormatInt(topUpID, 10), Amount: amount})
+	if err != nil {
+		logging.FromContext(ctx).WithFields(logger.Fields{"top_up_id": topUpID, "err": err.Error()}).Error("Gateway refused the top-up")
+		if _, failErr := w.store.CompleteTopUp(ctx, topUpID, domain.TopUpStatusFailed); failErr != nil {
+			logging.FromContext(ctx).WithField("top_up_id", topUpID).Warn("Top-up left pending without a payment")
+		}
+		return domain.TopUp{}, errors.ErrCreatingTopUp
+	}
+	err = w.store.SetTopUpPayment(ctx, topUpID, intent.PaymentID, intent.CheckoutURL)
+	if err != nil {
+		return domain.TopUp{}, err
+	}
+	return w.store.GetTopUp(ctx, topUpID)
+}
+
+// HandleTopUpCallback applies a payment update sent by the gateway. The
+// callback is only trusted once its signature checks out, and delivering it
+// again changes nothing.
+func (w *walletService) HandleTopUpCallback(ctx context.Context, payload []byte, signature string) (topUp domain.TopUp, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.HandleTopUpCallback")
+	defer tracing.End(span, &err)
+	if w.gateway == nil {
+		return domain.TopUp{}, errors.ErrNoPaymentGateway
+	}
+	payment, err := w.gateway.VerifyCallback(payload, signature)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Warn("Gateway callback rejected")
+		return domain.TopUp{}, err
+	}
+	topUpID, err := strconv.ParseInt(payment.Reference, 10, 64)
+	if err != nil {
+		return domain.TopUp{}, errors.ErrTopUpNotFound
+	}
+	topUp, err = w.store.GetTopUp(ctx, topUpID)
+	if err != nil {
+		return domain.TopUp{}, err
+	}
+	return w.settleTopUp(ctx, topUp, payment)
+}
+
+// GetTopUp returns one of the user's top-ups. A pending top-up is checked
+// with the gateway first, so a missed callback does not leave it pending.
+func (w *walletService) GetTopUp(ctx context.Context, userID int64, topUpID int64) (topUp domain.TopUp, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetTopUp")
+	defer tracing.End(span, &err)
+	topUp, err = w.store.GetTopUp(ctx, topUpID)
+	if err != nil {
+		return domain.TopUp{}, err
+	}
+	if topUp.UserID != userID {
+		return domain.TopUp{}, errors.ErrTopUpNotFound
+	}
+	if topUp.Status != domain.TopUpStatusPending || topUp.PaymentID == "" || w.gateway == nil || topUp.Gateway != w.gateway.Name() {
+		return topUp, nil
+	}
+	payment, err := w.gateway.QueryStatus(ctx, topUp.PaymentID)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(logger.Fields{"top_up_id": topUpID, "err": err.Error()}).Warn("Cannot query top-up payment status")
+		return topUp, nil
+	}
+	settled, err := w.settleTopUp(ctx, topUp, payment)
+	if err == errors.ErrWalletFrozen || err == errors.ErrWalletClosed {
+		// Paid but held, the top-up stays pending until the wallet is active
+		return settled, nil
+	}
+	return settled, err
+}
+
+// settleTopUp applies what the gateway reports about the payment of topUp,
+// crediting the wallet when it succeeded. A payment for a frozen or closed
+// wallet leaves topUp pending and returns the wallet's error.
+func (w *walletService) settleTopUp(ctx context.Context, topUp domain.TopUp, payment gateway.Payment) (domain.TopUp, error) {
+	log := logging.FromContext(ctx).WithFields(logger.Fields{"top_up_id": topUp.ID, "payment_id": payment.ID})
+	if topUp.Gateway != w.gateway.Name() || payment.ID != topUp.PaymentID || math.Round(payment.Amount*100) != math.Round(topUp.Amount*100) {
+		log.WithField("amount", payment.Amount).Error(errors.ErrTopUpMismatch.Error())
+		return domain.TopUp{}, errors.ErrTopUpMismatch
+	}
+	var status, result string
+	switch payment.Status {
+	case gateway.StatusSucceeded:
+		status, result = domain.TopUpStatusSucceeded, metrics.ResultSuccess
+	case gateway.StatusFailed:
+		status, result = domain.TopUpStatusFailed, metrics.ResultDeclined
+	default:
+		return topUp, nil
+	}
+
+	completed, err := w.store.CompleteTopUp(ctx, topUp.ID, status)
+	if err == errors.ErrWalletFrozen || err == errors.ErrWalletClosed {
+		log.WithField("user_id", topUp.UserID).Warn("Top-up held until the wallet is active")
+		return topUp, err
+	}
+	if err != nil {
+		return domain.TopUp{}, err
+	}
+	if completed {
+		metrics.ObserveOperation(metrics.OperationCredit, result, topUp.Amount)
+		log.WithFields(logger.Fields{"user_id": topUp.UserID, "status": status, "amount": topUp.Amount}).Info("Top-up settled")
+	}
+	return w.store.GetTopUp(ctx, topUp.ID)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/gateway"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// startTopUp runs CreditWallet for user 1 against the fake gateway and
+// returns the pending top-up the store would hold afterwards.
+func (suite *ServiceTestSuite) startTopUp(service WalletService, amount float64) domain.TopUp {
+	t := suite.T()
+	topUp := domain.TopUp{ID: 7, UserID: 1, Amount: amount, Status: domain.TopUpStatusPending, Gateway: "fake"}
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Once()
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 3, UserID: 1, Status: domain.WalletStatusActive}, nil).Once()
+	suite.repository.On("CreateTopUp", mock.Anything, domain.TopUp{UserID: 1, Amount: amount, Gateway: "fake"}).Return(int64(7), nil).Once()
+	suite.repository.On("SetTopUpPayment", mock.Anything, int64(7), mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		topUp.PaymentID, topUp.CheckoutURL = args.String(2), args.String(3)
+	}).Return(nil).Once()
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(func(context.Context, int64) domain.TopUp { return topUp }, nil).Once()
+
+	got, err := service.CreditWallet(context.Background(), 1, amount)
+	require.NoError(t, err)
+	require.Equal(t, domain.TopUpStatusPending, got.Status)
+	require.NotEmpty(t, got.PaymentID)
+	require.Equal(t, "https://checkout.fake.invalid/pay/"+got.PaymentID, got.CheckoutURL)
+	return got
+}
+
+func (suite *ServiceTestSuite) TestWalletService_CreditWalletRejections() {
+	t := suite.T()
+	_, err := suite.service.CreditWallet(context.Background(), 1, 50)
+	require.Equal(t, errors.ErrNoPaymentGateway, err)
+
+	service := NewWalletService(suite.repository, WithPaymentGateway(gateway.NewFakeGateway("secret")))
+	_, err = service.CreditWallet(context.Background(), 1, 0)
+	require.Error(t, err)
+
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Twice()
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 3, UserID: 1, Status: domain.WalletStatusFrozen}, nil).Once()
+	_, err = service.CreditWallet(context.Background(), 1, 50)
+	require.Equal(t, errors.ErrWalletFrozen, err)
+
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{}, nil).Once()
+	_, err = service.CreditWallet(context.Background(), 1, 50)
+	require.Equal(t, errors.ErrNoWallet, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_HandleTopUpCallback() {
+	t := suite.T()
+	fake := gateway.NewFakeGateway("secret")
+	service := NewWalletService(suite.repository, WithPaymentGateway(fake))
+	topUp := suite.startTopUp(service, 50)
+
+	payload, signature, err := fake.Complete(topUp.PaymentID, gateway.StatusSucceeded)
+	require.NoError(t, err)
+
+	_, err = service.HandleTopUpCallback(context.Background(), payload, "00"+signature)
+	require.Equal(t, errors.ErrInvalidGatewaySignature, err)
+
+	settled := topUp
+	settled.Status = domain.TopUpStatusSucceeded
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(topUp, nil).Once()
+	suite.repository.On("CompleteTopUp", mock.Anything, int64(7), domain.TopUpStatusSucceeded).Return(true, nil).Once()
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(settled, nil).Once()
+	got, err := service.HandleTopUpCallback(context.Background(), payload, signature)
+	require.NoError(t, err)
+	require.Equal(t, settled, got)
+
+	// A replayed callback finds the top-up already settled
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(settled, nil).Once()
+	suite.repository.On("CompleteTopUp", mock.Anything, int64(7), domain.TopUpStatusSucceeded).Return(false, nil).Once()
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(settled, nil).Once()
+	got, err = service.HandleTopUpCallback(context.Background(), payload, signature)
+	require.NoError(t, err)
+	require.Equal(t, settled, got)
+
+	// A payment for a frozen wallet is held rather than credited
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(topUp, nil).Once()
+	suite.repository.On("CompleteTopUp", mock.Anything, int64(7), domain.TopUpStatusSucceeded).Return(false, errors.ErrWalletFrozen).Once()
+	_, err = service.HandleTopUpCallback(context.Background(), payload, signature)
+	require.Equal(t, errors.ErrWalletFrozen, err)
+
+	// A correctly signed callback must still match the top-up it names
+	forged := []byte(`{"id":"` + topUp.PaymentID + `","reference":"7","amount":5000,"status":"succeeded"}`)
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(topUp, nil).Once()
+	_, err = service.HandleTopUpCallback(context.Background(), forged, fake.Sign(forged))
+	require.Equal(t, errors.ErrTopUpMismatch, err)
+
+	unknown := []byte(`{"id":"fake_1","reference":"abc","amount":50,"status":"succeeded"}`)
+	_, err = service.HandleTopUpCallback(context.Background(), unknown, fake.Sign(unknown))
+	require.Equal(t, errors.ErrTopUpNotFound, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetTopUp() {
+	t := suite.T()
+	fake := gateway.NewFakeGateway("secret")
+	service := NewWalletService(suite.repository, WithPaymentGateway(fake))
+	topUp := suite.startTopUp(service, 50)
+
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(topUp, nil).Once()
+	_, err := service.GetTopUp(context.Background(), 2, 7)
+	require.Equal(t, errors.ErrTopUpNotFound, err)
+
+	// Still pending at the gateway
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(topUp, nil).Once()
+	got, err := service.GetTopUp(context.Background(), 1, 7)
+	require.NoError(t, err)
+	require.Equal(t, topUp, got)
+
+	// The callback was missed, polling settles the top-up
+	_, _, err = fake.Complete(topUp.PaymentID, gateway.StatusFailed)
+	require.NoError(t, err)
+	failed := topUp
+	failed.Status = domain.TopUpStatusFailed
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(topUp, nil).Once()
+	suite.repository.On("CompleteTopUp", mock.Anything, int64(7), domain.TopUpStatusFailed).Return(true, nil).Once()
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(failed, nil).Once()
+	got, err = service.GetTopUp(context.Background(), 1, 7)
+	require.NoError(t, err)
+	require.Equal(t, failed, got)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetTopUpHeld() {
+	t := suite.T()
+	fake := gateway.NewFakeGateway("secret")
+	service := NewWalletService(suite.repository, WithPaymentGateway(fake))
+	topUp := suite.startTopUp(service, 50)
+
+	// Paid, but the wallet was frozen meanwhile, so the top-up stays pending
+	_, _, err := fake.Complete(topUp.PaymentID, gateway.StatusSucceeded)
+	require.NoError(t, err)
+	suite.repository.On("GetTopUp", mock.Anything, int64(7)).Return(topUp, nil).Once()
+	suite.repository.On("CompleteTopUp", mock.Anything, int64(7), domain.TopUpStatusSucceeded).Return(false, errors.ErrWalletFrozen).Once()
+	got, err := service.GetTopUp(context.Background(), 1, 7)
+	require.NoError(t, err)
+	require.Equal(t, topUp, got)
+}
@@ -2,46 +2,184 @@ package service
 
 import (
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"math"
 	"nickPay/wallet/internal/domain"
 	errors "nickPay/wallet/internal/errors"
-	"regexp"
+	"nickPay/wallet/internal/phone"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/net/idna"
 )
 
-func HashPassword(password string) string {
-	
-	hash := sha256.New()
+// maxPasswordLength is the most bcrypt will hash, anything past it would be
+// silently ignored.
+const maxPasswordLength = 72
 
-	hash.Write([]byte(password))
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
 
-	hashBytes := hash.Sum(nil)
+// CheckPassword reports whether password matches hash. Accounts registered
+// before passwords were hashed with bcrypt hold a hex SHA-256 digest, which is
+// accepted so LoginUser can replace it with a bcrypt hash.
+func CheckPassword(hash string, password string) bool {
+	if !isLegacyHash(hash) {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	}
+	sum := sha256.Sum256([]byte(password))
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hex.EncodeToString(sum[:]))) == 1
+}
 
-	hashString := hex.EncodeToString(hashBytes)
-	return hashString
+// isLegacyHash reports whether hash is an unsalted SHA-256 digest rather
+// than a bcrypt hash.
+func isLegacyHash(hash string) bool {
+	return !strings.HasPrefix(hash, "$2")
 }
 
-func Validate(user domain.User) error {
-	if !ValidateEmail(user.Email) {
-		return errors.ErrInvalidEmail
+func ValidatePassword(password string) error {
+	if password == "" {
+		return errors.ErrInvalidPassword
 	}
-	if !ValidatePhoneNumber(user.PhoneNumber) {
-		return errors.ErrInvalidPhoneNumber
+	if len(password) > maxPasswordLength {
+		return errors.ErrPasswordTooLong
 	}
+	return nil
+}
+
+// Validate checks every field of a new user and returns the user with its
+// email and phone number in canonical form. Phone numbers without a country
+// code are read as numbers of phoneRegion. All invalid fields are reported
+// together as errors.ValidationErrors.
+func Validate(user domain.User, phoneRegion string) (domain.User, error) {
+	var errs errors.ValidationErrors
+	var err error
+	user.Name = strings.TrimSpace(user.Name)
 	if user.Name == "" {
-		return errors.ErrInvalidName
+		errs = append(errs, errors.FieldError{Field: "name", Code: errors.CodeRequired, Err: errors.ErrInvalidName})
 	}
-	if user.Password == "" {
-		return errors.ErrInvalidPassword
+	email, number := user.Email, user.PhoneNumber
+	user.Email, err = NormalizeEmail(email)
+	if err != nil {
+		errs = append(errs, errors.FieldError{Field: "email", Code: requiredOrInvalid(email), Err: err})
 	}
-	return nil
+	user.PhoneNumber, err = NormalizePhoneNumber(number, phoneRegion)
+	if err != nil {
+		errs = append(errs, errors.FieldError{Field: "phone_number", Code: requiredOrInvalid(number), Err: err})
+	}
+	errs = append(errs, passwordFieldErrors("password", user.Password)...)
+	return user, errs.OrNil()
+}
+
+// ValidateLogin checks the shape of a login request before any credentials
+// are looked up.
+func ValidateLogin(request domain.LoginUserRequest) error {
+	var errs errors.ValidationErrors
+	if _, err := NormalizeEmail(request.Email); err != nil {
+		errs = append(errs, errors.FieldError{Field: "email", Code: requiredOrInvalid(request.Email), Err: err})
+	}
+	if request.Password == "" {
+		errs = append(errs, errors.FieldError{Field: "password", Code: errors.CodeRequired, Err: errors.ErrInvalidPassword})
+	}
+	return errs.OrNil()
+}
+
+// ValidateAmount checks that amount can be moved in a single credit or
+// debit: a positive, finite number of at most two decimal places, no more
+// than max.
+func ValidateAmount(amount float64, max float64) error {
+	var fieldErr errors.FieldError
+	switch {
+	case math.IsNaN(amount) || math.IsInf(amount, 0):
+		fieldErr = errors.FieldError{Code: errors.CodeNotFinite, Err: errors.ErrAmountNotFinite}
+	case amount <= 0:
+		fieldErr = errors.FieldError{Code: errors.CodeNotPositive, Err: errors.ErrAmountNotPositive}
+	case max > 0 && amount > max:
+		fieldErr = errors.FieldError{Code: errors.CodeTooLarge, Err: errors.ErrAmountTooLarge}
+	case math.Abs(amount*100-math.Round(amount*100)) > 1e-6:
+		fieldErr = errors.FieldError{Code: errors.CodeTooPrecise, Err: errors.ErrAmountTooPrecise}
+	default:
+		return nil
+	}
+	fieldErr.Field = "amount"
+	return errors.ValidationErrors{fieldErr}
+}
+
+func passwordFieldErrors(field string, password string) errors.ValidationErrors {
+	err := ValidatePassword(password)
+	switch err {
+	case nil:
+		return nil
+	case errors.ErrPasswordTooLong:
+		return errors.ValidationErrors{{Field: field, Code: errors.CodeTooLong, Err: err}}
+	}
+	return errors.ValidationErrors{{Field: field, Code: errors.CodeRequired, Err: err}}
+}
+
+func requiredOrInvalid(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return errors.CodeRequired
+	}
+	return errors.CodeInvalid
+}
+
+// emailLocalSpecials are the characters besides letters and digits allowed
+// in the unquoted local part of an address.
+const emailLocalSpecials = "!#$%&'*+-/=?^_`{|}~."
+
+// NormalizeEmail returns email in the form it is stored and compared in:
+// trimmed, lowercased and with an internationalised domain in its ASCII
+// form. Non-ASCII letters are allowed in the local part.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 || len(email) > 254 {
+		return "", errors.ErrInvalidEmail
+	}
+	local, host := email[:at], email[at+1:]
+	if len(local) > 64 || strings.HasPrefix(local, ".") || strings.HasSuffix(local, ".") || strings.Contains(local, "..") {
+		return "", errors.ErrInvalidEmail
+	}
+	for _, r := range local {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(emailLocalSpecials, r) {
+			return "", errors.ErrInvalidEmail
+		}
+	}
+
+	host, err := idna.Lookup.ToASCII(host)
+	if err != nil || !strings.Contains(host, ".") {
+		return "", errors.ErrInvalidEmail
+	}
+	tld := host[strings.LastIndex(host, ".")+1:]
+	if len(tld) < 2 || !(strings.HasPrefix(tld, "xn--") || strings.Trim(tld, "abcdefghijklmnopqrstuvwxyz") == "") {
+		return "", errors.ErrInvalidEmail
+	}
+	return strings.ToLower(local) + "@" + host, nil
 }
 
-func ValidateEmail(email string) bool {
-	re := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	return re.MatchString(email)
+// NormalizePhoneNumber returns number in E.164 form, reading numbers
+// without a country code as numbers of region.
+func NormalizePhoneNumber(number string, region string) (string, error) {
+	normalized, err := phone.Normalize(number, region)
+	if err != nil {
+		return "", errors.ErrInvalidPhoneNumber
+	}
+	return normalized, nil
 }
 
-func ValidatePhoneNumber(phoneNumber string) bool {
-	re := regexp.MustCompile(`^[0-9]{10}$`)
-	return re.MatchString(phoneNumber)
+// canonicalEmail is NormalizeEmail for lookups, where an invalid email
+// should simply find nothing.
+func canonicalEmail(email string) string {
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(email))
+	}
+	return normalized
 }
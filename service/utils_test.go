@@ -0,0 +1,75 @@
+package service
+
+import (
+	stderrors "errors"
+	"math"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	valid := map[string]string{
+		"john@mail.com":         "john@mail.com",
+		" John.Doe@Mail.COM ":   "john.doe@mail.com",
+		"o'neil+tag@mail.co.uk": "o'neil+tag@mail.co.uk",
+		"user@Bücher.de":        "user@xn--bcher-kva.de",
+		"josé@correo.es":        "josé@correo.es",
+		"user@例え.テスト":           "user@xn--r8jz45g.xn--zckzah",
+	}
+	for email, want := range valid {
+		got, err := NormalizeEmail(email)
+		require.NoError(t, err, email)
+		require.Equal(t, want, got, email)
+	}
+
+	for _, email := range []string{"john1mail.com", "@mail.com", "john@", "john@mail", "john..doe@mail.com", "john doe@mail.com", "john@mail.c", "john@-mail.com"} {
+		_, err := NormalizeEmail(email)
+		require.Equal(t, errors.ErrInvalidEmail, err, email)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	user, err := Validate(domain.User{Name: " John Doe ", Email: "John@Mail.com", PhoneNumber: "+44 7700 900123", Password: "12345678"}, "IN")
+	require.NoError(t, err)
+	require.Equal(t, domain.User{Name: "John Doe", Email: "john@mail.com", PhoneNumber: "+447700900123", Password: "12345678"}, user)
+
+	_, err = Validate(domain.User{Name: "", Email: "john1mail.com", PhoneNumber: "812346789", Password: "12345678"}, "IN")
+	var errs errors.ValidationErrors
+	require.True(t, stderrors.As(err, &errs))
+	require.Equal(t, errors.ValidationErrors{
+		{Field: "name", Code: errors.CodeRequired, Err: errors.ErrInvalidName},
+		{Field: "email", Code: errors.CodeInvalid, Err: errors.ErrInvalidEmail},
+		{Field: "phone_number", Code: errors.CodeInvalid, Err: errors.ErrInvalidPhoneNumber},
+	}, errs)
+}
+
+func TestValidateLogin(t *testing.T) {
+	require.NoError(t, ValidateLogin(domain.LoginUserRequest{Email: "john@mail.com", Password: "12345678"}))
+	require.Equal(t, errors.ValidationErrors{
+		{Field: "email", Code: errors.CodeRequired, Err: errors.ErrInvalidEmail},
+		{Field: "password", Code: errors.CodeRequired, Err: errors.ErrInvalidPassword},
+	}, ValidateLogin(domain.LoginUserRequest{}))
+}
+
+func TestValidateAmount(t *testing.T) {
+	for _, amount := range []float64{0.01, 100, 999.99, 1000} {
+		require.NoError(t, ValidateAmount(amount, 1000), amount)
+	}
+	codes := map[float64]string{
+		0:           errors.CodeNotPositive,
+		-1000:       errors.CodeNotPositive,
+		math.Inf(1): errors.CodeNotFinite,
+		math.NaN():  errors.CodeNotFinite,
+		1000.01:     errors.CodeTooLarge,
+		10.005:      errors.CodeTooPrecise,
+	}
+	for amount, code := range codes {
+		var errs errors.ValidationErrors
+		require.True(t, stderrors.As(ValidateAmount(amount, 1000), &errs), amount)
+		require.Equal(t, "amount", errs[0].Field)
+		require.Equal(t, code, errs[0].Code, amount)
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/notify"
+	"nickPay/wallet/internal/tracing"
+	"strconv"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+const (
+	verificationCodeTTL = 15 * time.Minute
+	// maxVerificationAttempts wrong guesses void a code, so a six digit code
+	// can not be brute forced before it expires.
+	maxVerificationAttempts = 5
+)
+
+// VerifyContact marks the user's email or phone as verified if code matches
+// the one last sent to it.
+func (w *walletService) VerifyContact(ctx context.Context, userID int64, channel string, code string) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.VerifyContact")
+	defer tracing.End(span, &err)
+	if !validVerificationChannel(channel) {
+		return errors.ErrInvalidVerificationChannel
+	}
+	err = w.checkVerificationCode(ctx, userID, channel, code)
+	if err != nil {
+		return err
+	}
+
+	err = w.store.MarkVerified(ctx, userID, channel)
+	if err != nil {
+		return err
+	}
+	logging.FromContext(ctx).WithFields(logger.Fields{"user_id": userID, "channel": channel}).Info("Contact verified")
+	return nil
+}
+
+// ResendVerification sends a fresh code to the user's email or phone,
+// replacing the previous one. The new code inherits the wrong attempts at
+// the previous one until it expires, and none is sent while they are used
+// up.
+func (w *walletService) ResendVerification(ctx context.Context, userID int64, channel string) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ResendVerification")
+	defer tracing.End(span, &err)
+	if !validVerificationChannel(channel) {
+		return errors.ErrInvalidVerificationChannel
+	}
+	user, err := w.store.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if (channel == domain.VerificationEmail && user.EmailVerified) || (channel == domain.VerificationPhone && user.PhoneVerified) {
+		return errors.ErrAlreadyVerified
+	}
+	pending, err := w.store.GetVerificationCode(ctx, userID, channel)
+	if err != nil && err != errors.ErrNoVerificationCode {
+		return err
+	}
+	if err == nil && pending.Attempts >= maxVerificationAttempts && time.Now().Before(pending.ExpiresAt) {
+		return errors.ErrTooManyVerificationAttempts
+	}
+	return w.sendVerificationCode(ctx, user, channel)
+}
+
+// checkVerificationCode returns ErrInvalidVerificationCode unless code
+// matches the pending code for the user and channel. Wrong codes count
+// towards maxVerificationAttempts.
+func (w *walletService) checkVerificationCode(ctx context.Context, userID int64, channel string, code string) error {
+	pending, err := w.store.GetVerificationCode(ctx, userID, channel)
+	if err == errors.ErrNoVerificationCode {
+		return errors.ErrInvalidVerificationCode
+	}
+	if err != nil {
+		return err
+	}
+	if time.Now().After(pending.ExpiresAt) || pending.Attempts >= maxVerificationAttempts {
+		return errors.ErrInvalidVerificationCode
+	}
+	if subtle.ConstantTimeCompare([]byte(pending.CodeHash), []byte(hashVerificationCode(userID, channel, code))) != 1 {
+		err = w.store.IncrementVerificationAttempts(ctx, userID, channel)
+		if err != nil {
+			return err
+		}
+		return errors.ErrInvalidVerificationCode
+	}
+	return nil
+}
+
+// requireVerified blocks wallet operations until the user has verified both
+// their email address and phone number.
+func (w *walletService) requireVerified(ctx context.Context, userID int64) error {
+	user, err := w.store.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.EmailVerified || !user.PhoneVerified {
+		return errors.ErrUnverifiedUser
+	}
+	return nil
+}
+
+func (w *walletService) sendVerificationCode(ctx context.Context, user domain.User, channel string) error {
+	code, err := verificationCode()
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrSavingVerificationCode.Error())
+		return errors.ErrSavingVerificationCode
+	}
+	err = w.store.SaveVerificationCode(ctx, domain.VerificationCode{
+		UserID:    user.ID,
+		Channel:   channel,
+		CodeHash:  hashVerificationCode(user.ID, channel, code),
+		ExpiresAt: time.Now().Add(verificationCodeTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Your %s verification code is %s. It expires in %d minutes.", w.totpIssuer, code, int(verificationCodeTTL.Minutes()))
+	msg := notify.Message{Channel: notify.ChannelSMS, To: user.PhoneNumber, Body: body}
+	switch channel {
+	case domain.VerificationEmail:
+		msg = notify.Message{Channel: notify.ChannelEmail, To: user.Email, Subject: "Verify your email address", Body: body}
+	case domain.VerificationEmailChange:
+		msg = notify.Message{Channel: notify.ChannelEmail, To: user.PendingEmail, Subject: "Confirm your new email address", Body: body}
+	}
+	err = w.notifier.Notify(ctx, msg)
+	if err != nil {
+		logging.FromContext(ctx).WithFields(logger.Fields{"err": err.Error(), "channel": channel}).Error(errors.ErrSendingVerificationCode.Error())
+		return errors.ErrSendingVerificationCode
+	}
+	return nil
+}
+
+func validVerificationChannel(channel string) bool {
+	return channel == domain.VerificationEmail || channel == domain.VerificationPhone
+}
+
+// verificationCode returns a random six digit code.
+func verificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashVerificationCode binds the code to its user and channel, so a stored
+// hash is useless for any other pending code.
+func hashVerificationCode(userID int64, channel string, code string) string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(userID, 10) + ":" + channel + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
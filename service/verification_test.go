@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/db/mocks"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/gateway"
+	"nickPay/wallet/internal/notify"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingNotifier keeps the messages it is asked to send.
+type recordingNotifier struct {
+	sent []notify.Message
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, msg notify.Message) error {
+	r.sent = append(r.sent, msg)
+	return nil
+}
+
+func (suite *ServiceTestSuite) TestWalletService_RegisterUserSendsCodes() {
+	t := suite.T()
+	notifier := &recordingNotifier{}
+	service := NewWalletService(suite.repository, WithNotifier(notifier))
+	user := domain.User{Name: "John Doe", Email: "john@mail.com", PhoneNumber: "8123467890", Password: "12345678"}
+
+	suite.repository.On("RegisterUser", mock.Anything, mock.AnythingOfType("domain.User")).Return(int64(7), nil).Once()
+	suite.repository.On("SaveVerificationCode", mock.Anything, mock.MatchedBy(func(code domain.VerificationCode) bool {
+		return code.UserID == 7 && code.Channel == domain.VerificationEmail
+	})).Return(nil).Once()
+	suite.repository.On("SaveVerificationCode", mock.Anything, mock.MatchedBy(func(code domain.VerificationCode) bool {
+		return code.UserID == 7 && code.Channel == domain.VerificationPhone
+	})).Return(nil).Once()
+
+	err := service.RegisterUser(context.Background(), user)
+	require.NoError(t, err)
+	require.Len(t, notifier.sent, 2)
+	require.Equal(t, notify.Message{Channel: notify.ChannelEmail, To: "john@mail.com", Subject: "Verify your email address", Body: notifier.sent[0].Body}, notifier.sent[0])
+	require.Equal(t, notify.ChannelSMS, notifier.sent[1].Channel)
+	require.Equal(t, "+918123467890", notifier.sent[1].To)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_VerifyContact() {
+	t := suite.T()
+	pending := domain.VerificationCode{
+		UserID:    1,
+		Channel:   domain.VerificationEmail,
+		CodeHash:  hashVerificationCode(1, domain.VerificationEmail, "123456"),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	type test struct {
+		name    string
+		channel string
+		code    string
+		wantErr error
+		prepare func(*mocks.Storer)
+	}
+	tests := []test{
+		{
+			name:    "Correct code",
+			channel: domain.VerificationEmail,
+			code:    "123456",
+			wantErr: nil,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetVerificationCode", mock.Anything, int64(1), domain.VerificationEmail).Return(pending, nil).Once()
+				s.On("MarkVerified", mock.Anything, int64(1), domain.VerificationEmail).Return(nil).Once()
+			},
+		},
+		{
+			name:    "Wrong code counts an attempt",
+			channel: domain.VerificationEmail,
+			code:    "654321",
+			wantErr: errors.ErrInvalidVerificationCode,
+			prepare: func(s *mocks.Storer) {
+				s.On("GetVerificationCode", mock.Anything, int64(1), domain.VerificationEmail).Return(pending, nil).Once()
+				s.On("IncrementVerificationAttempts", mock.Anything, int64(1), domain.VerificationEmail).Return(nil).Once()
+			},
+		},
+		{
+			name:    "Too many attempts voids the code",
+			channel: domain.VerificationEmail,
+			code:    "123456",
+			wantErr: errors.ErrInvalidVerificationCode,
+			prepare: func(s *mocks.Storer) {
+				exhausted := pending
+				exhausted.Attempts = maxVerificationAttempts
+				s.On("GetVerificationCode", mock.Anything, int64(1), domain.VerificationEmail).Return(exhausted, nil).Once()
+			},
+		},
+		{
+			name:    "Expired code",
+			channel: domain.VerificationEmail,
+			code:    "123456",
+			wantErr: errors.ErrInvalidVerificationCode,
+			prepare: func(s *mocks.Storer) {
+				expired := pending
+				expired.ExpiresAt = time.Now().Add(-time.Minute)
+				s.On("GetVerificationCode", mock.Anything, int64(1), domain.VerificationEmail).Return(expired, nil).Once()
+			},
+		},
+		{
+			name:    "Unknown channel",
+			channel: "fax",
+			code:    "123456",
+			wantErr: errors.ErrInvalidVerificationChannel,
+			prepare: func(s *mocks.Storer) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare(suite.repository)
+			err := suite.service.VerifyContact(context.Background(), 1, tt.channel, tt.code)
+			require.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func (suite *ServiceTestSuite) TestWalletService_ResendVerification() {
+	t := suite.T()
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, PhoneNumber: "8123467890", EmailVerified: true}, nil).Times(4)
+	suite.repository.On("GetVerificationCode", mock.Anything, int64(1), domain.VerificationPhone).Return(domain.VerificationCode{}, errors.ErrNoVerificationCode).Once()
+	suite.repository.On("SaveVerificationCode", mock.Anything, mock.AnythingOfType("domain.VerificationCode")).Return(nil).Twice()
+
+	require.NoError(t, suite.service.ResendVerification(context.Background(), 1, domain.VerificationPhone))
+	require.Equal(t, errors.ErrAlreadyVerified, suite.service.ResendVerification(context.Background(), 1, domain.VerificationEmail))
+
+	// Resending does not grant fresh guesses at a code
+	locked := domain.VerificationCode{UserID: 1, Channel: domain.VerificationPhone, Attempts: maxVerificationAttempts, ExpiresAt: time.Now().Add(time.Minute)}
+	suite.repository.On("GetVerificationCode", mock.Anything, int64(1), domain.VerificationPhone).Return(locked, nil).Once()
+	require.Equal(t, errors.ErrTooManyVerificationAttempts, suite.service.ResendVerification(context.Background(), 1, domain.VerificationPhone))
+
+	locked.ExpiresAt = time.Now().Add(-time.Minute)
+	suite.repository.On("GetVerificationCode", mock.Anything, int64(1), domain.VerificationPhone).Return(locked, nil).Once()
+	require.NoError(t, suite.service.ResendVerification(context.Background(), 1, domain.VerificationPhone))
+}
+
+func (suite *ServiceTestSuite) TestWalletService_CreditWalletUnverified() {
+	t := suite.T()
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true}, nil).Once()
+
+	service := NewWalletService(suite.repository, WithPaymentGateway(gateway.NewFakeGateway("secret")))
+	_, err := service.CreditWallet(context.Background(), 1, 100)
+	require.Equal(t, errors.ErrUnverifiedUser, err)
+}
@@ -2,31 +2,190 @@ package service
 
 import (
 	"context"
+	"nickPay/wallet/internal/bank"
 	"nickPay/wallet/internal/db"
 	"nickPay/wallet/internal/domain"
 	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/gateway"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/metrics"
+	"nickPay/wallet/internal/notify"
+	"nickPay/wallet/internal/tracing"
+	"time"
 
 	logger "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/bcrypt"
+	"go.opentelemetry.io/otel"
 )
 
 type WalletService interface {
+	Ping(context.Context) error
 	RegisterUser(context.Context, domain.User) error
 	LoginUser(context.Context, domain.LoginUserRequest) (string, error)
 	GetWallet(context.Context, int64) (domain.Wallet, error)
-	CreditWallet(context.Context, int64, float64) error
-	DebitWallet(context.Context, int64, float64) error
+	CreditWallet(context.Context, int64, float64) (domain.TopUp, error)
+	DebitWallet(context.Context, int64, float64) (domain.FeeQuote, error)
+	GenerateStatement(context.Context, int64, time.Time) (domain.Statement, error)
+	Reconcile(context.Context, bool) (domain.ReconciliationReport, error)
+	UnlockLogin(context.Context, domain.UnlockLoginRequest) error
+	EnrollTOTP(context.Context, int64) (domain.TOTPEnrollment, error)
+	ConfirmTOTP(context.Context, int64, string) ([]string, error)
+	VerifyLoginMFA(context.Context, domain.MFALoginRequest) (string, error)
+	VerifyContact(context.Context, int64, string, string) error
+	ResendVerification(context.Context, int64, string) error
+	ForgotPassword(context.Context, string) error
+	ResetPassword(context.Context, domain.ResetPasswordRequest) error
+	ChangePassword(context.Context, int64, domain.ChangePasswordRequest) error
+	ValidateSession(context.Context, int64, int64) error
+	GetProfile(context.Context, int64) (domain.UserProfile, error)
+	UpdateProfile(context.Context, int64, domain.UpdateProfileRequest) (domain.UserProfile, error)
+	ConfirmEmailChange(context.Context, int64, string) error
+	DeleteAccount(context.Context, int64) error
+	ListActivity(context.Context, int64, int, string) (domain.ActivityPage, error)
+	GetWalletsByID(context.Context, int64, []int64) ([]domain.Wallet, error)
+	ListTransactionsSince(context.Context, int64, int64, int) ([]domain.Transaction, error)
+	LookupUser(context.Context, string) (domain.UserProfile, error)
+	AdjustWallet(context.Context, domain.WalletAdjustment) error
+	ChangeWalletStatus(context.Context, domain.WalletStatusChange) error
+	ExportUserData(context.Context, int64) (domain.UserExport, error)
+	CreatePayoutBatch(context.Context, domain.PayoutRequest) (domain.PayoutBatch, error)
+	ExecutePayoutBatch(context.Context, int64) (domain.PayoutBatch, error)
+	GetPayoutBatch(context.Context, int64) (domain.PayoutBatch, error)
+	GetPayoutReport(context.Context, int64) ([]domain.PayoutRow, error)
+	GetTopUp(context.Context, int64, int64) (domain.TopUp, error)
+	HandleTopUpCallback(context.Context, []byte, string) (domain.TopUp, error)
+	LinkBankAccount(context.Context, int64, domain.BankAccount) (domain.BankAccount, error)
+	ListBankAccounts(context.Context, int64) ([]domain.BankAccount, error)
+	Withdraw(context.Context, int64, domain.WithdrawalRequest) (domain.Withdrawal, error)
+	GetWithdrawal(context.Context, int64, int64) (domain.Withdrawal, error)
+	SettleWithdrawals(context.Context) (int, error)
+	QuoteFee(context.Context, domain.QuoteRequest) (domain.FeeQuote, error)
+	CreateCampaign(context.Context, domain.Campaign) (domain.Campaign, error)
+	ListCampaigns(context.Context) ([]domain.Campaign, error)
+	GetPromoBalance(context.Context, int64) (domain.PromoBalance, error)
+	RegisterMerchant(context.Context, int64, domain.Merchant) (domain.Merchant, error)
+	GetMerchant(context.Context, int64) (domain.Merchant, error)
+	GetMerchantWallet(context.Context, int64) (domain.Wallet, error)
+	GetStaticQR(context.Context, int64) (domain.PaymentQR, error)
+	CreatePaymentQR(context.Context, int64, domain.PaymentQRRequest) (domain.PaymentQR, error)
+	GetPaymentQR(context.Context, int64, int64) (domain.PaymentQR, error)
+	PayQR(context.Context, int64, domain.QRPaymentRequest) (domain.MerchantPayment, error)
+	GetSettlementReport(context.Context, int64, time.Time, time.Time) (domain.SettlementReport, error)
 }
 
+var tracer = otel.Tracer("nickPay/wallet/internal/service")
+
 type walletService struct {
-	store db.Storer
+	store           db.Storer
+	lockout         LockoutPolicy
+	totpIssuer      string
+	stepUpThreshold float64
+	notifier        notify.Notifier
+	phoneRegion     string
+	maxAmount       float64
+	gateway         gateway.PaymentGateway
+	payoutProvider  bank.PayoutProvider
+	fees            FeeSchedule
+	feeAccount      int64
+}
+
+// Option customises a WalletService built by NewWalletService.
+type Option func(*walletService)
+
+// WithLockoutPolicy replaces DefaultLockoutPolicy for failed logins.
+func WithLockoutPolicy(policy LockoutPolicy) Option {
+	return func(w *walletService) {
+		w.lockout = policy
+	}
 }
 
-func NewWalletService(storer db.Storer) WalletService {
-	return &walletService{store: storer}
+// WithTOTPIssuer sets the issuer authenticator apps show next to codes.
+func WithTOTPIssuer(issuer string) Option {
+	return func(w *walletService) {
+		w.totpIssuer = issuer
+	}
 }
 
+// WithStepUpThreshold requires a TOTP code on debits above amount. Zero, the
+// default, never asks for one.
+func WithStepUpThreshold(amount float64) Option {
+	return func(w *walletService) {
+		w.stepUpThreshold = amount
+	}
+}
+
+// WithNotifier sets how verification codes reach users. The default only
+// writes them to the log.
+func WithNotifier(notifier notify.Notifier) Option {
+	return func(w *walletService) {
+		w.notifier = notifier
+	}
+}
+
+// DefaultPhoneRegion is the country of phone numbers entered without a
+// country code. Numbers stored before E.164 were all Indian.
+const DefaultPhoneRegion = "IN"
+
+// WithPhoneRegion sets the country, as an ISO 3166-1 alpha-2 code, of phone
+// numbers entered without a country code.
+func WithPhoneRegion(region string) Option {
+	return func(w *walletService) {
+		w.phoneRegion = region
+	}
+}
+
+// DefaultMaxAmount is the largest amount a single credit or debit may move.
+const DefaultMaxAmount = 1000000
+
+// WithMaxAmount sets the largest amount a single credit or debit may move.
+// Zero removes the limit.
+func WithMaxAmount(amount float64) Option {
+	return func(w *walletService) {
+		w.maxAmount = amount
+	}
+}
+
+// WithPaymentGateway sets the gateway wallets are topped up through. Without
+// one, credits are refused.
+func WithPaymentGateway(g gateway.PaymentGateway) Option {
+	return func(w *walletService) {
+		w.gateway = g
+	}
+}
+
+// WithPayoutProvider sets the provider withdrawals are sent to bank accounts
+// through. Without one, withdrawals are refused.
+func WithPayoutProvider(p bank.PayoutProvider) Option {
+	return func(w *walletService) {
+		w.payoutProvider = p
+	}
+}
+
+func NewWalletService(storer db.Storer, opts ...Option) WalletService {
+	w := &walletService{
+		store:       storer,
+		lockout:     DefaultLockoutPolicy,
+		totpIssuer:  DefaultTOTPIssuer,
+		notifier:    notify.LogNotifier{},
+		phoneRegion: DefaultPhoneRegion,
+		maxAmount:   DefaultMaxAmount,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Ping reports whether the backing store is reachable.
+func (w *walletService) Ping(ctx context.Context) error {
+	return w.store.Ping(ctx)
+}
+
+// RegisterUser creates the user unverified and sends codes to their email
+// address and phone number. Failing to send a code does not fail the
+// registration, the user can ask for another one.
 func (w *walletService) RegisterUser(ctx context.Context, user domain.User) (err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.RegisterUser")
+	defer tracing.End(span, &err)
 	user = domain.User{
 		ID:          0,
 		Name:        user.Name,
@@ -34,68 +193,158 @@ func (w *walletService) RegisterUser(ctx context.Context, user domain.User) (err
 		PhoneNumber: user.PhoneNumber,
 		Password:    user.Password,
 	}
-	err = Validate(user)
-	if err == nil {
-		user.Password = HashPassword(user.Password)
-		err = w.store.RegisterUser(ctx, user)
-		if err != nil {
-			return
-		}
+	user, err = Validate(user, w.phoneRegion)
+	if err != nil {
 		return
 	}
-	return
+	user.Password, err = HashPassword(user.Password)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrRegisteringUser.Error())
+		return errors.ErrRegisteringUser
+	}
+	user.ID, err = w.store.RegisterUser(ctx, user)
+	if err != nil {
+		return
+	}
+	for _, channel := range []string{domain.VerificationEmail, domain.VerificationPhone} {
+		if sendErr := w.sendVerificationCode(ctx, user, channel); sendErr != nil {
+			logging.FromContext(ctx).WithFields(logger.Fields{"user_id": user.ID, "channel": channel}).Warn("Verification code not sent at registration")
+		}
+	}
+	return nil
 }
 
+// LoginUser returns an access token for valid credentials. Users with
+// two-factor authentication instead get a short-lived MFA challenge token
+// along with ErrMFARequired, to be exchanged through VerifyLoginMFA.
 func (w *walletService) LoginUser(ctx context.Context, loginRequest domain.LoginUserRequest) (token string, err error) {
-	loginResponse, err := w.store.LoginUser(ctx, loginRequest.Email)
-	if bcrypt.CompareHashAndPassword([]byte(loginResponse.Password), []byte(loginRequest.Password)) != nil {
-		return "", errors.ErrInvalidPassword
+	ctx, span := tracer.Start(ctx, "WalletService.LoginUser")
+	defer tracing.End(span, &err)
+	err = ValidateLogin(loginRequest)
+	if err != nil {
+		return "", err
 	}
-
+	email := canonicalEmail(loginRequest.Email)
+	keys := w.loginKeys(accountLoginKey(email), loginRequest.ClientIP)
+	err = w.checkLoginLocks(ctx, keys)
 	if err != nil {
-		logger.WithField("err", err).Error("Error while logging in user")
 		return "", err
 	}
+
+	loginResponse, err := w.store.LoginUser(ctx, email)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err).Error("Error while logging in user")
+		return "", errors.ErrLoggingIn
+	}
+	// Unknown emails and wrong passwords fail the same way, in the same time
+	passwordHash := loginResponse.Password
+	if loginResponse.ID == 0 {
+		passwordHash = string(dummyPasswordHash)
+	}
+	if !CheckPassword(passwordHash, loginRequest.Password) || loginResponse.ID == 0 {
+		w.recordLoginFailure(ctx, keys)
+		return "", errors.ErrInvalidCredentials
+	}
+	if isLegacyHash(passwordHash) {
+		w.upgradePasswordHash(ctx, loginResponse.ID, passwordHash, loginRequest.Password)
+	}
+
+	// Only the account is cleared, a valid login must not reset the count of
+	// an IP that is guessing passwords for other accounts
+	if w.lockout.MaxAccountFailures > 0 {
+		w.store.ClearLoginAttempts(ctx, accountLoginKey(email))
+	}
+
+	mfa, err := w.store.GetUserMFA(ctx, loginResponse.ID)
+	if err != nil {
+		return "", errors.ErrLoggingIn
+	}
+	if mfa.Enabled {
+		token, err = GenerateMFAToken(loginResponse.ID)
+		if err != nil {
+			logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrGenJWTToken.Error())
+			return "", errors.ErrGenJWTToken
+		}
+		return token, errors.ErrMFARequired
+	}
 	token, err = GenerateToken(loginResponse)
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(errors.ErrGenJWTToken.Error())
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrGenJWTToken.Error())
 		return "", errors.ErrGenJWTToken
 	}
 	return token, nil
 }
 
 func (w *walletService) GetWallet(ctx context.Context, userID int64) (wallet domain.Wallet, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetWallet")
+	defer tracing.End(span, &err)
 	wallet, err = w.store.GetWallet(ctx, userID)
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingWallet.Error())
 		return domain.Wallet{}, errors.ErrFetchingWallet
 	}
 	return wallet, nil
 }
 
-func (w *walletService) CreditWallet(ctx context.Context, userID int64, amount float64) (err error) {
-	err = w.store.CreditWallet(ctx, userID, amount)
+// DebitWallet takes amount from the user's wallet along with the fee for it,
+// which goes to the house revenue wallet, and returns what was charged. The
+// debit then earns cashback from the campaigns running.
+func (w *walletService) DebitWallet(ctx context.Context, userID int64, amount float64) (quote domain.FeeQuote, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.DebitWallet")
+	defer tracing.End(span, &err)
+	defer func() {
+		metrics.ObserveOperation(metrics.OperationDebit, operationResult(err), amount)
+	}()
+	err = ValidateAmount(amount, w.maxAmount)
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(errors.ErrCreditingWallet.Error())
-		return errors.ErrCreditingWallet
+		return domain.FeeQuote{}, err
+	}
+	err = w.requireVerified(ctx, userID)
+	if err != nil {
+		return domain.FeeQuote{}, err
 	}
-	return nil
-}
-
-func (w *walletService) DebitWallet(ctx context.Context, userID int64, amount float64) (err error) {
 	wallet, err := w.store.GetWallet(ctx, userID)
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(errors.ErrFetchingBalance.Error())
-		return errors.ErrFetchingBalance
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingBalance.Error())
+		return domain.FeeQuote{}, errors.ErrFetchingBalance
 	}
-	if wallet.Balance < amount {
-		logger.WithField("err", err.Error()).Error(errors.ErrInsufficientBalance.Error())
-		return errors.ErrInsufficientBalance
+	if wallet.Status == domain.WalletStatusFrozen {
+		return domain.FeeQuote{}, errors.ErrWalletFrozen
 	}
-	err = w.store.DebitWallet(ctx, userID, amount)
+	err = w.verifyStepUp(ctx, userID, amount)
 	if err != nil {
-		logger.WithField("err", err.Error()).Error(errors.ErrDebitingWallet.Error())
-		return errors.ErrDebitingWallet
+		return domain.FeeQuote{}, err
 	}
-	return nil
+	quote, fee := w.quoteFee(domain.FeeOperationDebit, amount)
+	// The store checks the balance again as it debits, this only saves the
+	// round trip
+	if wallet.Balance < quote.Total {
+		logging.FromContext(ctx).WithField("user_id", userID).Error(errors.ErrInsufficientBalance.Error())
+		return domain.FeeQuote{}, errors.ErrInsufficientBalance
+	}
+	err = w.store.DebitWallet(ctx, userID, amount, fee)
+	if err == errors.ErrInsufficientBalance {
+		logging.FromContext(ctx).WithField("user_id", userID).Error(errors.ErrInsufficientBalance.Error())
+		return domain.FeeQuote{}, err
+	}
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrDebitingWallet.Error())
+		return domain.FeeQuote{}, errors.ErrDebitingWallet
+	}
+	w.grantCashback(ctx, userID, domain.CampaignOperationDebit, amount)
+	return quote, nil
+}
+
+// operationResult maps the error returned by a wallet operation to its
+// metrics result label.
+func operationResult(err error) string {
+	switch err {
+	case nil:
+		return metrics.ResultSuccess
+	case errors.ErrInsufficientBalance:
+		return metrics.ResultInsufficientBalance
+	case errors.ErrWalletFrozen:
+		return metrics.ResultFrozen
+	}
+	return metrics.ResultError
 }
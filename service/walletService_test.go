@@ -5,10 +5,13 @@ import (
 	"errors"
 	"nickPay/wallet/internal/db/mocks"
 	"nickPay/wallet/internal/domain"
+	"nickPay/wallet/internal/gateway"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type ServiceTestSuite struct {
@@ -55,9 +58,11 @@ func (suite *ServiceTestSuite) TestWalletService_RegisterUser() {
 				},
 			},
 			wantErr: false,
-			prepare: func(args args, mock *mocks.Storer) {
-				args.user.Password = HashPassword(args.user.Password)
-				mock.On("RegisterUser", args.ctx, args.user).Return(nil).Once()
+			prepare: func(args args, s *mocks.Storer) {
+				s.On("RegisterUser", mock.Anything, mock.MatchedBy(func(user domain.User) bool {
+					return user.Email == args.user.Email && CheckPassword(user.Password, args.user.Password)
+				})).Return(int64(1), nil).Once()
+				s.On("SaveVerificationCode", mock.Anything, mock.AnythingOfType("domain.VerificationCode")).Return(nil).Twice()
 			},
 		},
 		{
@@ -72,10 +77,8 @@ func (suite *ServiceTestSuite) TestWalletService_RegisterUser() {
 				},
 			},
 			wantErr: true,
-			prepare: func(args args, s *mocks.Storer) {
-				args.user.Password = HashPassword(args.user.Password)
-				s.On("RegisterUser", args.ctx, args.user).Return(nil).Once()
-			},
+			// Invalid users are turned away before anything is stored
+			prepare: func(args args, s *mocks.Storer) {},
 		},
 		{
 			name: "Register User with Invalid Phone Number",
@@ -89,16 +92,13 @@ func (suite *ServiceTestSuite) TestWalletService_RegisterUser() {
 				},
 			},
 			wantErr: true,
-			prepare: func(args args, s *mocks.Storer) {
-				args.user.Password = HashPassword(args.user.Password)
-				s.On("RegisterUser", args.ctx, args.user).Return(nil).Once()
-			},
+			// Invalid users are turned away before anything is stored
+			prepare: func(args args, s *mocks.Storer) {},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.args.user.Password = HashPassword(tt.args.user.Password)
 			tt.prepare(tt.args, suite.repository)
 			err := suite.service.RegisterUser(tt.args.ctx, tt.args.user)
 			if tt.wantErr {
@@ -133,8 +133,12 @@ func (suite *ServiceTestSuite) TestWalletService_LoginUser() {
 				},
 			},
 			wantErr: false,
-			prepare: func(args args, mock *mocks.Storer) {
-				mock.On("LoginUser", args.ctx, args.loginRequest.Email).Return(domain.LoginDbResponse{}, nil).Once()
+			prepare: func(args args, s *mocks.Storer) {
+				hash, _ := bcrypt.GenerateFromPassword([]byte(args.loginRequest.Password), bcrypt.MinCost)
+				s.On("GetLoginAttempt", mock.Anything, mock.Anything).Return(domain.LoginAttempt{}, nil).Once()
+				s.On("LoginUser", mock.Anything, args.loginRequest.Email).Return(domain.LoginDbResponse{ID: 1, Password: string(hash)}, nil).Once()
+				s.On("ClearLoginAttempts", mock.Anything, mock.Anything).Return(nil).Once()
+				s.On("GetUserMFA", mock.Anything, int64(1)).Return(domain.UserMFA{UserID: 1}, nil).Once()
 			},
 		},
 		{
@@ -147,9 +151,7 @@ func (suite *ServiceTestSuite) TestWalletService_LoginUser() {
 				},
 			},
 			wantErr: true,
-			prepare: func(args args, s *mocks.Storer) {
-				s.On("LoginUser", args.ctx, args.loginRequest.Email).Return(domain.LoginDbResponse{}, errors.New("mocked error")).Once()
-			},
+			prepare: func(args args, s *mocks.Storer) {},
 		},
 	}
 
@@ -188,7 +190,7 @@ func (suite *ServiceTestSuite) TestWalletService_GetWallet() {
 			wantErr: false,
 			prepare: func(args args, s *mocks.Storer) {
 
-				s.On("GetWallet", args.ctx, args.userID).Return(domain.Wallet{}, nil).Once()
+				s.On("GetWallet", mock.Anything, args.userID).Return(domain.Wallet{}, nil).Once()
 			},
 		},
 		{
@@ -199,7 +201,7 @@ func (suite *ServiceTestSuite) TestWalletService_GetWallet() {
 			},
 			wantErr: true,
 			prepare: func(args args, s *mocks.Storer) {
-				s.On("GetWallet", args.ctx, args.userID).Return(domain.Wallet{}, errors.New("mocked error")).Once()
+				s.On("GetWallet", mock.Anything, args.userID).Return(domain.Wallet{}, errors.New("mocked error")).Once()
 			},
 		},
 	}
@@ -241,7 +243,11 @@ func (suite *ServiceTestSuite) TestWallet_CreditWallet() {
 			},
 			wantErr: false,
 			prepare: func(args args, s *mocks.Storer) {
-				s.On("CreditWallet", args.ctx, args.amount, args.userID).Return(nil).Once()
+				s.On("GetUser", mock.Anything, args.userID).Return(domain.User{ID: args.userID, EmailVerified: true, PhoneVerified: true}, nil).Once()
+				s.On("GetWallet", mock.Anything, args.userID).Return(domain.Wallet{ID: 1, UserID: args.userID}, nil).Once()
+				s.On("CreateTopUp", mock.Anything, mock.AnythingOfType("domain.TopUp")).Return(int64(1), nil).Once()
+				s.On("SetTopUpPayment", mock.Anything, int64(1), mock.Anything, mock.Anything).Return(nil).Once()
+				s.On("GetTopUp", mock.Anything, int64(1)).Return(domain.TopUp{ID: 1, UserID: args.userID, Amount: args.amount, Status: domain.TopUpStatusPending}, nil).Once()
 			},
 		},
 		{
@@ -252,15 +258,14 @@ func (suite *ServiceTestSuite) TestWallet_CreditWallet() {
 				userID: 1,
 			},
 			wantErr: true,
-			prepare: func(args args, s *mocks.Storer) {
-				s.On("CreditWallet", args.ctx, args.amount, args.userID).Return("mocked error").Once()
-			},
+			prepare: func(args args, s *mocks.Storer) {},
 		},
 	}
 
+	service := NewWalletService(suite.repository, WithPaymentGateway(gateway.NewFakeGateway("secret")))
 	for _, tt := range tests {
 		tt.prepare(tt.args, suite.repository)
-		err := suite.service.CreditWallet(tt.args.ctx, tt.args.userID, tt.args.amount)
+		_, err := service.CreditWallet(tt.args.ctx, tt.args.userID, tt.args.amount)
 		if tt.wantErr {
 			require.Error(suite.T(), err, "mocked error")
 		} else {
@@ -293,7 +298,10 @@ func (suite *ServiceTestSuite) TestWallet_DebitWallet() {
 			},
 			wantErr: false,
 			prepare: func(args args, s *mocks.Storer) {
-				s.On("DebitWallet", args.ctx, args.amount, args.userID).Return(nil).Once()
+				s.On("GetUser", mock.Anything, args.userID).Return(domain.User{ID: args.userID, EmailVerified: true, PhoneVerified: true}, nil).Once()
+				s.On("GetWallet", mock.Anything, args.userID).Return(domain.Wallet{ID: 1, UserID: args.userID, Balance: 5000, Status: domain.WalletStatusActive}, nil).Once()
+				s.On("DebitWallet", mock.Anything, args.userID, args.amount, domain.FeeCharge{}).Return(nil).Once()
+				s.On("ListActiveCampaigns", mock.Anything, domain.CampaignOperationDebit, mock.Anything).Return([]domain.Campaign{}, nil).Once()
 			},
 		},
 		{
@@ -304,9 +312,7 @@ func (suite *ServiceTestSuite) TestWallet_DebitWallet() {
 				userID: 1,
 			},
 			wantErr: true,
-			prepare: func(args args, s *mocks.Storer) {
-				s.On("DebitWallet", args.ctx, args.amount, args.userID).Return("mocked error").Once()
-			},
+			prepare: func(args args, s *mocks.Storer) {},
 		},
 		{
 			name: "Insufficient balance in wallet",
@@ -317,21 +323,15 @@ func (suite *ServiceTestSuite) TestWallet_DebitWallet() {
 			},
 			wantErr: true,
 			prepare: func(args args, s *mocks.Storer) {
-				s.On("DebitWallet", args.ctx, args.amount, args.userID).Return("mocked error").Once()
+				s.On("GetUser", mock.Anything, args.userID).Return(domain.User{ID: args.userID, EmailVerified: true, PhoneVerified: true}, nil).Once()
+				s.On("GetWallet", mock.Anything, args.userID).Return(domain.Wallet{ID: 1, UserID: args.userID, Balance: 500, Status: domain.WalletStatusActive}, nil).Once()
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		tt.prepare(tt.args, suite.repository)
-		wallet, err := suite.service.GetWallet(tt.args.ctx, tt.args.userID)
-		if err != nil {
-			require.Error(suite.T(), err, "mocked error")
-		}
-		if wallet.Balance < tt.args.amount {
-			require.Error(suite.T(), err, "mocked error")
-		}
-		err = suite.service.DebitWallet(tt.args.ctx, tt.args.userID, tt.args.amount)
+		_, err := suite.service.DebitWallet(tt.args.ctx, tt.args.userID, tt.args.amount)
 		if tt.wantErr {
 			require.Error(suite.T(), err, "mocked error")
 		} else {
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"math"
+	"nickPay/wallet/internal/bank"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"nickPay/wallet/internal/logging"
+	"nickPay/wallet/internal/metrics"
+	"nickPay/wallet/internal/tracing"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/sirupsen/logrus"
+)
+
+// settlementBatchSize caps how many pending withdrawals one
+// SettleWithdrawals run checks with the provider.
+const settlementBatchSize = 100
+
+// unsubmittedWithdrawalAge is how long a withdrawal can stay pending without
+// a recorded transfer before SettleWithdrawals looks it up with the provider
+// by its reference.
+const unsubmittedWithdrawalAge = 15 * time.Minute
+
+// LinkBankAccount validates and stores a bank account the user can withdraw
+// to. Accounts are identified either by an IFSC and account number or by an
+// IBAN.
+func (w *walletService) LinkBankAccount(ctx context.Context, userID int64, account domain.BankAccount) (linked domain.BankAccount, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.LinkBankAccount")
+	defer tracing.End(span, &err)
+	account, err = normalizeBankAccount(account)
+	if err != nil {
+		return domain.BankAccount{}, err
+	}
+	err = w.requireVerified(ctx, userID)
+	if err != nil {
+		return domain.BankAccount{}, err
+	}
+	account.UserID = userID
+	account.ID, err = w.store.CreateBankAccount(ctx, account)
+	if err != nil {
+		return domain.BankAccount{}, err
+	}
+	return w.store.GetBankAccount(ctx, account.ID)
+}
+
+func (w *walletService) ListBankAccounts(ctx context.Context, userID int64) (accounts []domain.BankAccount, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.ListBankAccounts")
+	defer tracing.End(span, &err)
+	return w.store.ListBankAccounts(ctx, userID)
+}
+
+// normalizeBankAccount trims the fields of account and reports every invalid
+// one.
+func normalizeBankAccount(account domain.BankAccount) (domain.BankAccount, error) {
+	var errs errors.ValidationErrors
+	account.HolderName = strings.TrimSpace(account.HolderName)
+	if account.HolderName == "" {
+		errs = append(errs, errors.FieldError{Field: "holder_name", Code: errors.CodeRequired, Err: errors.ErrHolderNameRequired})
+	}
+
+	var ok bool
+	switch {
+	case strings.TrimSpace(account.IBAN) != "":
+		if account.IFSC != "" || account.AccountNumber != "" {
+			errs = append(errs, errors.FieldError{Field: "iban", Code: errors.CodeInvalid, Err: errors.ErrBankAccountRequired})
+			break
+		}
+		if account.IBAN, ok = bank.NormalizeIBAN(account.IBAN); !ok {
+			errs = append(errs, errors.FieldError{Field: "iban", Code: errors.CodeInvalid, Err: errors.ErrInvalidIBAN})
+		}
+	case strings.TrimSpace(account.IFSC) != "" || strings.TrimSpace(account.AccountNumber) != "":
+		if account.IFSC, ok = bank.NormalizeIFSC(account.IFSC); !ok {
+			errs = append(errs, errors.FieldError{Field: "ifsc", Code: requiredOrInvalid(account.IFSC), Err: errors.ErrInvalidIFSC})
+		}
+		if account.AccountNumber, ok = bank.NormalizeAccountNumber(account.AccountNumber); !ok {
+			errs = append(errs, errors.FieldError{Field: "account_number", Code: requiredOrInvalid(account.AccountNumber), Err: errors.ErrInvalidAccountNumber})
+		}
+	default:
+		errs = append(errs, errors.FieldError{Field: "iban", Code: errors.CodeRequired, Err: errors.ErrBankAccountRequired})
+	}
+	return account, errs.OrNil()
+}
+
+// Withdraw sends amount from the wallet to one of the user's bank accounts.
+// The amount is debited and held right away and the withdrawal returned
+// pending; SettleWithdrawals later settles it, or returns the funds to the
+// wallet when the transfer fails. The fee is charged along with the amount
+// and refunded with it.
+func (w *walletService) Withdraw(ctx context.Context, userID int64, request domain.WithdrawalRequest) (withdrawal domain.Withdrawal, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.Withdraw")
+	defer tracing.End(span, &err)
+	defer func() {
+		// Successful withdrawals are counted when they settle
+		if err != nil {
+			metrics.ObserveOperation(metrics.OperationWithdrawal, operationResult(err), request.Amount)
+		}
+	}()
+	if w.payoutProvider == nil {
+		return domain.Withdrawal{}, errors.ErrNoPayoutProvider
+	}
+	err = ValidateAmount(request.Amount, w.maxAmount)
+	if err != nil {
+		return domain.Withdrawal{}, err
+	}
+	err = w.requireVerified(ctx, userID)
+	if err != nil {
+		return domain.Withdrawal{}, err
+	}
+	account, err := w.store.GetBankAccount(ctx, request.BankAccountID)
+	if err == errors.ErrBankAccountNotFound || (err == nil && account.UserID != userID) {
+		return domain.Withdrawal{}, errors.ValidationErrors{{Field: "bank_account_id", Code: errors.CodeNotFound, Err: errors.ErrBankAccountNotFound}}
+	}
+	if err != nil {
+		return domain.Withdrawal{}, err
+	}
+	wallet, err := w.store.GetWallet(ctx, userID)
+	if err != nil {
+		logging.FromContext(ctx).WithField("err", err.Error()).Error(errors.ErrFetchingBalance.Error())
+		return domain.Withdrawal{}, errors.ErrFetchingBalance
+	}
+	if wallet.ID == 0 {
+		return domain.Withdrawal{}, errors.ErrNoWallet
+	}
+	if wallet.Status == domain.WalletStatusFrozen {
+		return domain.Withdrawal{}, errors.ErrWalletFrozen
+	}
+	err = w.verifyStepUp(ctx, userID, request.Amount)
+	if err != nil {
+		return domain.Withdrawal{}, err
+	}
+	quote, fee := w.quoteFee(domain.FeeOperationWithdrawal, request.Amount)
+	// CreateWithdrawal checks the balance again as it holds the funds
+	if wallet.Balance < quote.Total {
+		logging.FromContext(ctx).WithField("user_id", userID).Error(errors.ErrInsufficientBalance.Error())
+		return domain.Withdrawal{}, errors.ErrInsufficientBalance
+	}
+
+	withdrawalID, err := w.store.CreateWithdrawal(ctx, domain.Withdrawal{UserID: userID, BankAccountID: account.ID, Amount: request.Amount, Provider: w.payoutProvider.Name()}, fee)
+	if err != nil {
+		return domain.Withdrawal{}, err
+	}
+	// The withdrawal ID is the reference, so SettleWithdrawals can find the
+	// transfer should it not get recorded below
+	log := logging.FromContext(ctx).WithField("withdrawal_id", withdrawalID)
+	transfer, err := w.payoutProvider.Submit(ctx, bank.TransferRequest{
+		Reference: strconv.FormatInt(withdrawalID, 10),
+		Amount:    request.Amount,
+		Destination: bank.Destination{
+			HolderName:    account.HolderName,
+			AccountNumber: account.AccountNumber,
+			IFSC:          account.IFSC,
+			IBAN:          account.IBAN,
+		},
+	})
+	switch {
+	case err == errors.ErrTransferRejected:
+		log.Error("Payout provider refused the withdrawal")
+		if _, failErr := w.store.CompleteWithdrawal(ctx, withdrawalID, domain.WithdrawalStatusFailed, "refused by the payout provider"); failErr != nil {
+			log.Warn("Withdrawal left pending without a transfer, settlement reverses it")
+			return domain.Withdrawal{}, errors.ErrCreatingWithdrawal
+		}
+		return domain.Withdrawal{}, errors.ErrTransferRefused
+	case err != nil:
+		// The provider may have taken the transfer all the same, so the
+		// funds stay held until settlement finds out
+		log.WithField("err", err.Error()).Warn("Cannot submit withdrawal transfer, settlement looks it up by reference")
+	default:
+		if err := w.store.SetWithdrawalTransfer(ctx, withdrawalID, transfer.ID); err != nil {
+			log.WithField("transfer_id", transfer.ID).Warn("Withdrawal transfer not recorded, settlement looks it up by reference")
+		}
+	}
+	withdrawal, err = w.store.GetWithdrawal(ctx, withdrawalID)
+	if err != nil {
+		return domain.Withdrawal{}, err
+	}
+	withdrawal.FeeBreakdown = quote.Breakdown
+	return withdrawal, nil
+}
+
+// GetWithdrawal returns one of the user's withdrawals.
+func (w *walletService) GetWithdrawal(ctx context.Context, userID int64, withdrawalID int64) (withdrawal domain.Withdrawal, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.GetWithdrawal")
+	defer tracing.End(span, &err)
+	withdrawal, err = w.store.GetWithdrawal(ctx, withdrawalID)
+	if err != nil {
+		return domain.Withdrawal{}, err
+	}
+	if withdrawal.UserID != userID {
+		return domain.Withdrawal{}, errors.ErrWithdrawalNotFound
+	}
+	return withdrawal, nil
+}
+
+// SettleWithdrawals checks pending withdrawals with the payout provider and
+// settles those whose transfer finished, reversing the failed ones. A
+// withdrawal whose transfer was never recorded is looked up by its reference
+// once it is unsubmittedWithdrawalAge old, and reversed if the provider never
+// took it. It returns how many it settled; a withdrawal that can not be
+// checked is left for the next run.
+func (w *walletService) SettleWithdrawals(ctx context.Context) (settled int, err error) {
+	ctx, span := tracer.Start(ctx, "WalletService.SettleWithdrawals")
+	defer tracing.End(span, &err)
+	if w.payoutProvider == nil {
+		return 0, errors.ErrNoPayoutProvider
+	}
+	pending, err := w.store.ListPendingWithdrawals(ctx, settlementBatchSize, unsubmittedWithdrawalAge)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, withdrawal := range pending {
+		log := logging.FromContext(ctx).WithFields(logger.Fields{"withdrawal_id": withdrawal.ID, "transfer_id": withdrawal.TransferID})
+		if withdrawal.Provider != w.payoutProvider.Name() {
+			continue
+		}
+		transfer, err := w.withdrawalTransfer(ctx, withdrawal)
+		if err != nil {
+			log.WithField("err", err.Error()).Warn("Cannot query withdrawal transfer status")
+			continue
+		}
+		if withdrawal.TransferID == "" && transfer.ID != "" {
+			err = w.store.SetWithdrawalTransfer(ctx, withdrawal.ID, transfer.ID)
+			if err != nil {
+				continue
+			}
+			withdrawal.TransferID = transfer.ID
+		}
+		if transfer.ID != withdrawal.TransferID || math.Round(transfer.Amount*100) != math.Round(withdrawal.Amount*100) {
+			log.WithField("amount", transfer.Amount).Error(errors.ErrWithdrawalMismatch.Error())
+			continue
+		}
+		var status, result string
+		switch transfer.Status {
+		case bank.StatusSettled:
+			status, result = domain.WithdrawalStatusSettled, metrics.ResultSuccess
+		case bank.StatusFailed:
+			status, result = domain.WithdrawalStatusFailed, metrics.ResultDeclined
+		default:
+			continue
+		}
+
+		completed, err := w.store.CompleteWithdrawal(ctx, withdrawal.ID, status, transfer.FailureReason)
+		if err != nil {
+			continue
+		}
+		if completed {
+			settled++
+			metrics.ObserveOperation(metrics.OperationWithdrawal, result, withdrawal.Amount)
+			log.WithFields(logger.Fields{"user_id": withdrawal.UserID, "status": status, "amount": withdrawal.Amount}).Info("Withdrawal settled")
+		}
+	}
+	return settled, nil
+}
+
+// withdrawalTransfer returns the provider transfer paying withdrawal. When
+// none was recorded it looks the transfer up by the withdrawal's reference,
+// and reports one the provider never took as failed, as nothing was paid out.
+func (w *walletService) withdrawalTransfer(ctx context.Context, withdrawal domain.Withdrawal) (bank.Transfer, error) {
+	if withdrawal.TransferID != "" {
+		return w.payoutProvider.QueryStatus(ctx, withdrawal.TransferID)
+	}
+	transfer, err := w.payoutProvider.FindTransfer(ctx, strconv.FormatInt(withdrawal.ID, 10))
+	if err == errors.ErrTransferNotFound {
+		return bank.Transfer{Amount: withdrawal.Amount, Status: bank.StatusFailed, FailureReason: "never reached the payout provider"}, nil
+	}
+	return transfer, err
+}
+
+// RunWithdrawalSettlementJob settles pending withdrawals every interval until
+// ctx is cancelled. It blocks, so callers usually start it in its own
+// goroutine.
+func RunWithdrawalSettlementJob(ctx context.Context, NikPay WalletService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			settled, err := NikPay.SettleWithdrawals(ctx)
+			if err != nil || settled == 0 {
+				continue
+			}
+			logging.FromContext(ctx).WithField("settled", settled).Info("Withdrawal settlement finished")
+		}
+	}
+}
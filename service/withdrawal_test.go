@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"nickPay/wallet/internal/bank"
+	"nickPay/wallet/internal/domain"
+	errors "nickPay/wallet/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeBankAccount(t *testing.T) {
+	got, err := normalizeBankAccount(domain.BankAccount{HolderName: " John Doe ", IFSC: "sbin0001234", AccountNumber: "1234 5678 9012"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.BankAccount{HolderName: "John Doe", IFSC: "SBIN0001234", AccountNumber: "123456789012"}, got)
+
+	got, err = normalizeBankAccount(domain.BankAccount{HolderName: "John Doe", IBAN: "gb82 west 1234 5698 7654 32"})
+	require.NoError(t, err)
+	assert.Equal(t, "GB82WEST12345698765432", got.IBAN)
+
+	_, err = normalizeBankAccount(domain.BankAccount{IFSC: "SBIN1001234"})
+	assert.Equal(t, errors.ValidationErrors{
+		{Field: "holder_name", Code: errors.CodeRequired, Err: errors.ErrHolderNameRequired},
+		{Field: "ifsc", Code: errors.CodeInvalid, Err: errors.ErrInvalidIFSC},
+		{Field: "account_number", Code: errors.CodeRequired, Err: errors.ErrInvalidAccountNumber},
+	}, err)
+
+	_, err = normalizeBankAccount(domain.BankAccount{HolderName: "John Doe", IBAN: "GB82WEST12345698765433"})
+	assert.Equal(t, errors.ValidationErrors{{Field: "iban", Code: errors.CodeInvalid, Err: errors.ErrInvalidIBAN}}, err)
+	_, err = normalizeBankAccount(domain.BankAccount{HolderName: "John Doe"})
+	assert.Equal(t, errors.ValidationErrors{{Field: "iban", Code: errors.CodeRequired, Err: errors.ErrBankAccountRequired}}, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_LinkBankAccount() {
+	t := suite.T()
+	account := domain.BankAccount{ID: 5, UserID: 1, HolderName: "John Doe", IBAN: "GB82WEST12345698765432"}
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Once()
+	suite.repository.On("CreateBankAccount", mock.Anything, domain.BankAccount{UserID: 1, HolderName: "John Doe", IBAN: "GB82WEST12345698765432"}).Return(int64(5), nil).Once()
+	suite.repository.On("GetBankAccount", mock.Anything, int64(5)).Return(account, nil).Once()
+
+	got, err := suite.service.LinkBankAccount(context.Background(), 1, domain.BankAccount{HolderName: "John Doe", IBAN: "GB82 WEST 1234 5698 7654 32"})
+	require.NoError(t, err)
+	require.Equal(t, account, got)
+}
+
+// failingProvider is a fake payout provider whose transfers fail to submit
+// with err.
+type failingProvider struct {
+	*bank.FakeProvider
+	err error
+}
+
+func (p failingProvider) Submit(ctx context.Context, request bank.TransferRequest) (bank.Transfer, error) {
+	return bank.Transfer{}, p.err
+}
+
+// withdrawalFixture expects the checks Withdraw makes before holding funds
+// for user 1, who has 100 in their wallet and bank account 5.
+func (suite *ServiceTestSuite) withdrawalFixture(account domain.BankAccount) {
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Once()
+	suite.repository.On("GetBankAccount", mock.Anything, int64(5)).Return(account, nil).Once()
+	suite.repository.On("GetWallet", mock.Anything, int64(1)).Return(domain.Wallet{ID: 3, UserID: 1, Balance: 100, Status: domain.WalletStatusActive}, nil).Once()
+}
+
+func (suite *ServiceTestSuite) TestWalletService_Withdraw() {
+	t := suite.T()
+	_, err := suite.service.Withdraw(context.Background(), 1, domain.WithdrawalRequest{BankAccountID: 5, Amount: 50})
+	require.Equal(t, errors.ErrNoPayoutProvider, err)
+
+	provider := bank.NewFakeProvider()
+	fees := FeeSchedule{domain.FeeOperationWithdrawal: {Flat: 2}}
+	service := NewWalletService(suite.repository, WithPayoutProvider(provider), WithFees(fees, 7))
+	account := domain.BankAccount{ID: 5, UserID: 1, HolderName: "John Doe", IFSC: "SBIN0001234", AccountNumber: "123456789012"}
+
+	// Accounts of other users are reported as unknown
+	suite.repository.On("GetUser", mock.Anything, int64(1)).Return(domain.User{ID: 1, EmailVerified: true, PhoneVerified: true}, nil).Once()
+	suite.repository.On("GetBankAccount", mock.Anything, int64(5)).Return(domain.BankAccount{ID: 5, UserID: 2}, nil).Once()
+	_, err = service.Withdraw(context.Background(), 1, domain.WithdrawalRequest{BankAccountID: 5, Amount: 50})
+	require.Equal(t, errors.ValidationErrors{{Field: "bank_account_id", Code: errors.CodeNotFound, Err: errors.ErrBankAccountNotFound}}, err)
+
+	// The fee has to fit in the balance too
+	suite.withdrawalFixture(account)
+	_, err = service.Withdraw(context.Background(), 1, domain.WithdrawalRequest{BankAccountID: 5, Amount: 99})
+	require.Equal(t, errors.ErrInsufficientBalance, err)
+
+	suite.withdrawalFixture(account)
+	var transferID string
+	suite.repository.On("CreateWithdrawal", mock.Anything, domain.Withdrawal{UserID: 1, BankAccountID: 5, Amount: 50, Provider: "fake"}, domain.FeeCharge{Amount: 2, AccountID: 7}).Return(int64(9), nil).Once()
+	suite.repository.On("SetWithdrawalTransfer", mock.Anything, int64(9), mock.Anything).Run(func(args mock.Arguments) {
+		transferID = args.String(2)
+	}).Return(nil).Once()
+	pending := domain.Withdrawal{ID: 9, UserID: 1, BankAccountID: 5, Amount: 50, Fee: 2, Status: domain.WithdrawalStatusPending, Provider: "fake"}
+	suite.repository.On("GetWithdrawal", mock.Anything, int64(9)).Return(pending, nil).Once()
+	got, err := service.Withdraw(context.Background(), 1, domain.WithdrawalRequest{BankAccountID: 5, Amount: 50})
+	require.NoError(t, err)
+	pending.FeeBreakdown = []domain.FeeComponent{{Kind: domain.FeeFlat, Amount: 2}}
+	require.Equal(t, pending, got)
+
+	transfer, err := provider.QueryStatus(context.Background(), transferID)
+	require.NoError(t, err)
+	require.Equal(t, "9", transfer.Reference)
+	require.Equal(t, 50.0, transfer.Amount)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_WithdrawSubmitFailures() {
+	t := suite.T()
+	account := domain.BankAccount{ID: 5, UserID: 1, HolderName: "John Doe", IBAN: "GB82WEST12345698765432"}
+	held := domain.Withdrawal{UserID: 1, BankAccountID: 5, Amount: 50, Provider: "fake"}
+
+	// Only a rejection returns the funds at once
+	rejecting := NewWalletService(suite.repository, WithPayoutProvider(failingProvider{bank.NewFakeProvider(), errors.ErrTransferRejected}))
+	suite.withdrawalFixture(account)
+	suite.repository.On("CreateWithdrawal", mock.Anything, held, domain.FeeCharge{}).Return(int64(9), nil).Once()
+	suite.repository.On("CompleteWithdrawal", mock.Anything, int64(9), domain.WithdrawalStatusFailed, "refused by the payout provider").Return(true, nil).Once()
+	_, err := rejecting.Withdraw(context.Background(), 1, domain.WithdrawalRequest{BankAccountID: 5, Amount: 50})
+	require.Equal(t, errors.ErrTransferRefused, err)
+
+	// The provider may have taken a transfer that timed out, so its funds
+	// stay held for settlement to look it up
+	timingOut := NewWalletService(suite.repository, WithPayoutProvider(failingProvider{bank.NewFakeProvider(), context.DeadlineExceeded}))
+	suite.withdrawalFixture(account)
+	suite.repository.On("CreateWithdrawal", mock.Anything, held, domain.FeeCharge{}).Return(int64(10), nil).Once()
+	pending := domain.Withdrawal{ID: 10, UserID: 1, BankAccountID: 5, Amount: 50, Status: domain.WithdrawalStatusPending, Provider: "fake"}
+	suite.repository.On("GetWithdrawal", mock.Anything, int64(10)).Return(pending, nil).Once()
+	got, err := timingOut.Withdraw(context.Background(), 1, domain.WithdrawalRequest{BankAccountID: 5, Amount: 50})
+	require.NoError(t, err)
+	require.Equal(t, domain.WithdrawalStatusPending, got.Status)
+	suite.repository.AssertNotCalled(t, "CompleteWithdrawal", mock.Anything, int64(10), mock.Anything, mock.Anything)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_GetWithdrawal() {
+	t := suite.T()
+	withdrawal := domain.Withdrawal{ID: 9, UserID: 1, Status: domain.WithdrawalStatusSettled}
+	suite.repository.On("GetWithdrawal", mock.Anything, int64(9)).Return(withdrawal, nil).Twice()
+
+	got, err := suite.service.GetWithdrawal(context.Background(), 1, 9)
+	require.NoError(t, err)
+	require.Equal(t, withdrawal, got)
+	_, err = suite.service.GetWithdrawal(context.Background(), 2, 9)
+	require.Equal(t, errors.ErrWithdrawalNotFound, err)
+}
+
+func (suite *ServiceTestSuite) TestWalletService_SettleWithdrawals() {
+	t := suite.T()
+	provider := bank.NewFakeProvider()
+	service := NewWalletService(suite.repository, WithPayoutProvider(provider))
+	submit := func(reference string, destination bank.Destination) string {
+		transfer, err := provider.Submit(context.Background(), bank.TransferRequest{Reference: reference, Amount: 50, Destination: destination})
+		require.NoError(t, err)
+		return transfer.ID
+	}
+	settles := submit("9", bank.Destination{IFSC: "SBIN0001234", AccountNumber: "123456789012"})
+	fails := submit("10", bank.Destination{IBAN: "DE89370400440532010000"})
+	unrecorded := submit("13", bank.Destination{IFSC: "SBIN0001234", AccountNumber: "123456789012"})
+
+	suite.repository.On("ListPendingWithdrawals", mock.Anything, settlementBatchSize, unsubmittedWithdrawalAge).Return([]domain.Withdrawal{
+		{ID: 9, UserID: 1, Amount: 50, Status: domain.WithdrawalStatusPending, Provider: "fake", TransferID: settles},
+		{ID: 10, UserID: 2, Amount: 50, Status: domain.WithdrawalStatusPending, Provider: "fake", TransferID: fails},
+		{ID: 11, UserID: 3, Amount: 50, Status: domain.WithdrawalStatusPending, Provider: "fake", TransferID: "fake_unknown"},
+		{ID: 12, UserID: 4, Amount: 50, Status: domain.WithdrawalStatusPending, Provider: "retired", TransferID: "retired_1"},
+		{ID: 13, UserID: 5, Amount: 50, Status: domain.WithdrawalStatusPending, Provider: "fake"},
+		{ID: 14, UserID: 6, Amount: 50, Status: domain.WithdrawalStatusPending, Provider: "fake"},
+	}, nil).Once()
+	suite.repository.On("CompleteWithdrawal", mock.Anything, int64(9), domain.WithdrawalStatusSettled, "").Return(true, nil).Once()
+	// The funds of a failed transfer go back to the wallet
+	suite.repository.On("CompleteWithdrawal", mock.Anything, int64(10), domain.WithdrawalStatusFailed, "account closed").Return(true, nil).Once()
+	// A transfer the withdrawal lost track of is found by its reference
+	suite.repository.On("SetWithdrawalTransfer", mock.Anything, int64(13), unrecorded).Return(nil).Once()
+	suite.repository.On("CompleteWithdrawal", mock.Anything, int64(13), domain.WithdrawalStatusSettled, "").Return(true, nil).Once()
+	// and one the provider never took is reversed
+	suite.repository.On("CompleteWithdrawal", mock.Anything, int64(14), domain.WithdrawalStatusFailed, "never reached the payout provider").Return(true, nil).Once()
+
+	settled, err := service.SettleWithdrawals(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 4, settled)
+
+	_, err = suite.service.SettleWithdrawals(context.Background())
+	require.Equal(t, errors.ErrNoPayoutProvider, err)
+}
@@ -0,0 +1,60 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the RFC 6238 SHA-1 test key "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tt.want, got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := Validate(rfcSecret, "081804", now)
+	require.True(t, ok)
+	require.Equal(t, Step(now), step)
+
+	_, ok = Validate(rfcSecret, "081804", now.Add(Period))
+	require.True(t, ok, "previous step is accepted for clock drift")
+
+	_, ok = Validate(rfcSecret, "081804", now.Add(3*Period))
+	require.False(t, ok)
+
+	_, ok = Validate(rfcSecret, "123", now)
+	require.False(t, ok)
+}
+
+func TestGenerateSecretAndURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+
+	parsed, err := url.Parse(URI("NikPay", "john@mail.com", secret))
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", parsed.Scheme)
+	require.Equal(t, "totp", parsed.Host)
+	require.Equal(t, "/NikPay:john@mail.com", parsed.Path)
+	require.Equal(t, secret, parsed.Query().Get("secret"))
+	require.Equal(t, "NikPay", parsed.Query().Get("issuer"))
+}
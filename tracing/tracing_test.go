@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	run := func(fail bool) (err error) {
+		_, span := tracer.Start(context.Background(), "operation")
+		defer End(span, &err)
+		if fail {
+			return errors.New("mocked error")
+		}
+		return nil
+	}
+
+	require.NoError(t, run(false))
+	require.Error(t, run(true))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+	require.Equal(t, codes.Error, spans[1].Status().Code)
+	require.Equal(t, "mocked error", spans[1].Status().Description)
+}
+
+func TestInit(t *testing.T) {
+	shutdown, err := Init(context.Background(), ExporterNone, "", "walletd")
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = Init(context.Background(), "zipkin", "", "walletd")
+	require.Error(t, err)
+}
@@ -0,0 +1,6 @@
+// Package walletpb holds the gRPC API generated from wallet.proto. Run
+// go generate after editing the .proto, with protoc, protoc-gen-go and
+// protoc-gen-go-grpc on the PATH.
+package walletpb
+
+//go:generate protoc -I .. --go_out=.. --go_opt=paths=source_relative --go-grpc_out=.. --go-grpc_opt=paths=source_relative walletpb/wallet.proto
@@ -0,0 +1,668 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: walletpb/wallet.proto
+
+package walletpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RegisterUserRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	// E.164, or a national number of the server's phone region.
+	PhoneNumber   string `protobuf:"bytes,3,opt,name=phone_number,json=phoneNumber,proto3" json:"phone_number,omitempty"`
+	Password      string `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterUserRequest) Reset() {
+	*x = RegisterUserRequest{}
+	mi := &file_walletpb_wallet_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterUserRequest) ProtoMessage() {}
+
+func (x *RegisterUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterUserRequest.ProtoReflect.Descriptor instead.
+func (*RegisterUserRequest) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RegisterUserRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RegisterUserRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *RegisterUserRequest) GetPhoneNumber() string {
+	if x != nil {
+		return x.PhoneNumber
+	}
+	return ""
+}
+
+func (x *RegisterUserRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type RegisterUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterUserResponse) Reset() {
+	*x = RegisterUserResponse{}
+	mi := &file_walletpb_wallet_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterUserResponse) ProtoMessage() {}
+
+func (x *RegisterUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterUserResponse.ProtoReflect.Descriptor instead.
+func (*RegisterUserResponse) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{1}
+}
+
+type LoginUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginUserRequest) Reset() {
+	*x = LoginUserRequest{}
+	mi := &file_walletpb_wallet_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginUserRequest) ProtoMessage() {}
+
+func (x *LoginUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginUserRequest.ProtoReflect.Descriptor instead.
+func (*LoginUserRequest) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LoginUserRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *LoginUserRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type LoginUserResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Access token to send as "Bearer <token>" in the authorization metadata.
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// Two-factor challenge, completed through the REST API at /v1/login/mfa.
+	MfaToken      string `protobuf:"bytes,2,opt,name=mfa_token,json=mfaToken,proto3" json:"mfa_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginUserResponse) Reset() {
+	*x = LoginUserResponse{}
+	mi := &file_walletpb_wallet_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginUserResponse) ProtoMessage() {}
+
+func (x *LoginUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginUserResponse.ProtoReflect.Descriptor instead.
+func (*LoginUserResponse) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LoginUserResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *LoginUserResponse) GetMfaToken() string {
+	if x != nil {
+		return x.MfaToken
+	}
+	return ""
+}
+
+type GetWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWalletRequest) Reset() {
+	*x = GetWalletRequest{}
+	mi := &file_walletpb_wallet_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWalletRequest) ProtoMessage() {}
+
+func (x *GetWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWalletRequest.ProtoReflect.Descriptor instead.
+func (*GetWalletRequest) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{4}
+}
+
+type Wallet struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Id           int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Balance      float64                `protobuf:"fixed64,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	CreationDate string                 `protobuf:"bytes,3,opt,name=creation_date,json=creationDate,proto3" json:"creation_date,omitempty"`
+	LastUpdated  string                 `protobuf:"bytes,4,opt,name=last_updated,json=lastUpdated,proto3" json:"last_updated,omitempty"`
+	// One of active, frozen or closed.
+	Status        string `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Wallet) Reset() {
+	*x = Wallet{}
+	mi := &file_walletpb_wallet_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Wallet) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Wallet) ProtoMessage() {}
+
+func (x *Wallet) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Wallet.ProtoReflect.Descriptor instead.
+func (*Wallet) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Wallet) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Wallet) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *Wallet) GetCreationDate() string {
+	if x != nil {
+		return x.CreationDate
+	}
+	return ""
+}
+
+func (x *Wallet) GetLastUpdated() string {
+	if x != nil {
+		return x.LastUpdated
+	}
+	return ""
+}
+
+func (x *Wallet) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type CreditWalletRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Amount        float64                `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreditWalletRequest) Reset() {
+	*x = CreditWalletRequest{}
+	mi := &file_walletpb_wallet_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreditWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreditWalletRequest) ProtoMessage() {}
+
+func (x *CreditWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreditWalletRequest.ProtoReflect.Descriptor instead.
+func (*CreditWalletRequest) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreditWalletRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+type CreditWalletResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	TopUpId int64                  `protobuf:"varint,1,opt,name=top_up_id,json=topUpId,proto3" json:"top_up_id,omitempty"`
+	// pending until the payment gateway confirms the payment.
+	Status        string `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	CheckoutUrl   string `protobuf:"bytes,3,opt,name=checkout_url,json=checkoutUrl,proto3" json:"checkout_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreditWalletResponse) Reset() {
+	*x = CreditWalletResponse{}
+	mi := &file_walletpb_wallet_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreditWalletResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreditWalletResponse) ProtoMessage() {}
+
+func (x *CreditWalletResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreditWalletResponse.ProtoReflect.Descriptor instead.
+func (*CreditWalletResponse) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreditWalletResponse) GetTopUpId() int64 {
+	if x != nil {
+		return x.TopUpId
+	}
+	return 0
+}
+
+func (x *CreditWalletResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CreditWalletResponse) GetCheckoutUrl() string {
+	if x != nil {
+		return x.CheckoutUrl
+	}
+	return ""
+}
+
+type DebitWalletRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Amount float64                `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	// TOTP code, required for debits above the step-up threshold.
+	MfaCode       string `protobuf:"bytes,2,opt,name=mfa_code,json=mfaCode,proto3" json:"mfa_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DebitWalletRequest) Reset() {
+	*x = DebitWalletRequest{}
+	mi := &file_walletpb_wallet_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DebitWalletRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DebitWalletRequest) ProtoMessage() {}
+
+func (x *DebitWalletRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DebitWalletRequest.ProtoReflect.Descriptor instead.
+func (*DebitWalletRequest) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DebitWalletRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *DebitWalletRequest) GetMfaCode() string {
+	if x != nil {
+		return x.MfaCode
+	}
+	return ""
+}
+
+type DebitWalletResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Fee charged on top of the amount, credited to the house revenue wallet.
+	Fee float64 `protobuf:"fixed64,1,opt,name=fee,proto3" json:"fee,omitempty"`
+	// Amount plus fee, what left the wallet.
+	Total         float64 `protobuf:"fixed64,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DebitWalletResponse) Reset() {
+	*x = DebitWalletResponse{}
+	mi := &file_walletpb_wallet_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DebitWalletResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DebitWalletResponse) ProtoMessage() {}
+
+func (x *DebitWalletResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_walletpb_wallet_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DebitWalletResponse.ProtoReflect.Descriptor instead.
+func (*DebitWalletResponse) Descriptor() ([]byte, []int) {
+	return file_walletpb_wallet_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DebitWalletResponse) GetFee() float64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *DebitWalletResponse) GetTotal() float64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+var File_walletpb_wallet_proto protoreflect.FileDescriptor
+
+const file_walletpb_wallet_proto_rawDesc = "" +
+	"\n" +
+	"\x15walletpb/wallet.proto\x12\x10nikpay.wallet.v1\"~\n" +
+	"\x13RegisterUserRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12!\n" +
+	"\fphone_number\x18\x03 \x01(\tR\vphoneNumber\x12\x1a\n" +
+	"\bpassword\x18\x04 \x01(\tR\bpassword\"\x16\n" +
+	"\x14RegisterUserResponse\"D\n" +
+	"\x10LoginUserRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"F\n" +
+	"\x11LoginUserResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\x12\x1b\n" +
+	"\tmfa_token\x18\x02 \x01(\tR\bmfaToken\"\x12\n" +
+	"\x10GetWalletRequest\"\x92\x01\n" +
+	"\x06Wallet\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x01R\abalance\x12#\n" +
+	"\rcreation_date\x18\x03 \x01(\tR\fcreationDate\x12!\n" +
+	"\flast_updated\x18\x04 \x01(\tR\vlastUpdated\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\tR\x06status\"-\n" +
+	"\x13CreditWalletRequest\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\"m\n" +
+	"\x14CreditWalletResponse\x12\x1a\n" +
+	"\ttop_up_id\x18\x01 \x01(\x03R\atopUpId\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12!\n" +
+	"\fcheckout_url\x18\x03 \x01(\tR\vcheckoutUrl\"G\n" +
+	"\x12DebitWalletRequest\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12\x19\n" +
+	"\bmfa_code\x18\x02 \x01(\tR\amfaCode\"=\n" +
+	"\x13DebitWalletResponse\x12\x10\n" +
+	"\x03fee\x18\x01 \x01(\x01R\x03fee\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x01R\x05total2\xca\x03\n" +
+	"\rWalletService\x12]\n" +
+	"\fRegisterUser\x12%.nikpay.wallet.v1.RegisterUserRequest\x1a&.nikpay.wallet.v1.RegisterUserResponse\x12T\n" +
+	"\tLoginUser\x12\".nikpay.wallet.v1.LoginUserRequest\x1a#.nikpay.wallet.v1.LoginUserResponse\x12I\n" +
+	"\tGetWallet\x12\".nikpay.wallet.v1.GetWalletRequest\x1a\x18.nikpay.wallet.v1.Wallet\x12]\n" +
+	"\fCreditWallet\x12%.nikpay.wallet.v1.CreditWalletRequest\x1a&.nikpay.wallet.v1.CreditWalletResponse\x12Z\n" +
+	"\vDebitWallet\x12$.nikpay.wallet.v1.DebitWalletRequest\x1a%.nikpay.wallet.v1.DebitWalletResponseB\"Z nickPay/wallet/internal/walletpbb\x06proto3"
+
+var (
+	file_walletpb_wallet_proto_rawDescOnce sync.Once
+	file_walletpb_wallet_proto_rawDescData []byte
+)
+
+func file_walletpb_wallet_proto_rawDescGZIP() []byte {
+	file_walletpb_wallet_proto_rawDescOnce.Do(func() {
+		file_walletpb_wallet_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_walletpb_wallet_proto_rawDesc), len(file_walletpb_wallet_proto_rawDesc)))
+	})
+	return file_walletpb_wallet_proto_rawDescData
+}
+
+var file_walletpb_wallet_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_walletpb_wallet_proto_goTypes = []any{
+	(*RegisterUserRequest)(nil),  // 0: nikpay.wallet.v1.RegisterUserRequest
+	(*RegisterUserResponse)(nil), // 1: nikpay.wallet.v1.RegisterUserResponse
+	(*LoginUserRequest)(nil),     // 2: nikpay.wallet.v1.LoginUserRequest
+	(*LoginUserResponse)(nil),    // 3: nikpay.wallet.v1.LoginUserResponse
+	(*GetWalletRequest)(nil),     // 4: nikpay.wallet.v1.GetWalletRequest
+	(*Wallet)(nil),               // 5: nikpay.wallet.v1.Wallet
+	(*CreditWalletRequest)(nil),  // 6: nikpay.wallet.v1.CreditWalletRequest
+	(*CreditWalletResponse)(nil), // 7: nikpay.wallet.v1.CreditWalletResponse
+	(*DebitWalletRequest)(nil),   // 8: nikpay.wallet.v1.DebitWalletRequest
+	(*DebitWalletResponse)(nil),  // 9: nikpay.wallet.v1.DebitWalletResponse
+}
+var file_walletpb_wallet_proto_depIdxs = []int32{
+	0, // 0: nikpay.wallet.v1.WalletService.RegisterUser:input_type -> nikpay.wallet.v1.RegisterUserRequest
+	2, // 1: nikpay.wallet.v1.WalletService.LoginUser:input_type -> nikpay.wallet.v1.LoginUserRequest
+	4, // 2: nikpay.wallet.v1.WalletService.GetWallet:input_type -> nikpay.wallet.v1.GetWalletRequest
+	6, // 3: nikpay.wallet.v1.WalletService.CreditWallet:input_type -> nikpay.wallet.v1.CreditWalletRequest
+	8, // 4: nikpay.wallet.v1.WalletService.DebitWallet:input_type -> nikpay.wallet.v1.DebitWalletRequest
+	1, // 5: nikpay.wallet.v1.WalletService.RegisterUser:output_type -> nikpay.wallet.v1.RegisterUserResponse
+	3, // 6: nikpay.wallet.v1.WalletService.LoginUser:output_type -> nikpay.wallet.v1.LoginUserResponse
+	5, // 7: nikpay.wallet.v1.WalletService.GetWallet:output_type -> nikpay.wallet.v1.Wallet
+	7, // 8: nikpay.wallet.v1.WalletService.CreditWallet:output_type -> nikpay.wallet.v1.CreditWalletResponse
+	9, // 9: nikpay.wallet.v1.WalletService.DebitWallet:output_type -> nikpay.wallet.v1.DebitWalletResponse
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_walletpb_wallet_proto_init() }
+func file_walletpb_wallet_proto_init() {
+	if File_walletpb_wallet_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_walletpb_wallet_proto_rawDesc), len(file_walletpb_wallet_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_walletpb_wallet_proto_goTypes,
+		DependencyIndexes: file_walletpb_wallet_proto_depIdxs,
+		MessageInfos:      file_walletpb_wallet_proto_msgTypes,
+	}.Build()
+	File_walletpb_wallet_proto = out.File
+	file_walletpb_wallet_proto_goTypes = nil
+	file_walletpb_wallet_proto_depIdxs = nil
+}